@@ -26,7 +26,7 @@ func main() {
 	db.Init(config.Conf.Data.Database)
 	db.MakeMigrate()
 
-	userSvc := service.NewUserService(repository.NewUserRepo(db.DB))
+	userSvc := service.NewUserService(repository.NewUserRepo(db.DB), nil)
 	if err := userSvc.PromoteToAdmin(context.Background(), *username); err != nil {
 		slog.Error("提权失败", slog.String("username", *username), slog.Any("err", err))
 		os.Exit(1)
@@ -23,6 +23,7 @@ import (
 	"SneakerFlash/internal/db"
 	"SneakerFlash/internal/infra/kafka"
 	"SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/infra/storage"
 	"SneakerFlash/internal/pkg/logger"
 	"SneakerFlash/internal/pkg/utils"
 	"SneakerFlash/internal/server"
@@ -36,6 +37,7 @@ func main() {
 	db.Init(config.Conf.Data.Database)
 	redis.Init(config.Conf.Data.Redis)
 	kafka.InitProducer(config.Conf.Data.Kafka)
+	storage.Init(config.Conf.Server)
 
 	db.MakeMigrate()
 
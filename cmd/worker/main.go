@@ -12,6 +12,7 @@ import (
 	"SneakerFlash/internal/db"
 	"SneakerFlash/internal/infra/kafka"
 	"SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/infra/storage"
 	"SneakerFlash/internal/pkg/logger"
 	"SneakerFlash/internal/pkg/utils"
 	"SneakerFlash/internal/repository"
@@ -25,6 +26,7 @@ func main() {
 	db.Init(config.Conf.Data.Database)
 	redis.Init(config.Conf.Data.Redis)
 	kafka.InitProducer(config.Conf.Data.Kafka) // 初始化 Kafka 生产者（用于 Outbox 补偿和 DLQ）
+	storage.Init(config.Conf.Server)           // 清理未引用的上传文件
 
 	if err := utils.InitSnowflake(int64(config.Conf.Server.MachineID)); err != nil {
 		slog.Error("初始化雪花算法失败", slog.Any("err", err))
@@ -49,6 +51,59 @@ func main() {
 	orderCancelCron.Start()
 	defer orderCancelCron.Stop()
 
+	// 启动批量发券任务
+	couponIssueCron := cron.NewCouponIssueCron(db.DB)
+	couponIssueCron.Start()
+	defer couponIssueCron.Stop()
+
+	// 启动积分过期任务
+	pointsExpireCron := cron.NewPointsExpireCron(db.DB)
+	pointsExpireCron.Start()
+	defer pointsExpireCron.Stop()
+
+	// 启动成长等级复核任务
+	growthReviewCron := cron.NewGrowthReviewCron(db.DB)
+	growthReviewCron.Start()
+	defer growthReviewCron.Stop()
+
+	// 启动通知投递与优惠券过期提醒任务
+	notificationCron := cron.NewNotificationDispatchCron(db.DB)
+	notificationCron.Start()
+	defer notificationCron.Stop()
+	couponRemindCron := cron.NewCouponRemindCron(db.DB)
+	couponRemindCron.Start()
+	defer couponRemindCron.Stop()
+
+	// 启动开售提醒任务
+	dropReminderCron := cron.NewDropReminderCron(db.DB)
+	dropReminderCron.Start()
+	defer dropReminderCron.Stop()
+
+	// 启动候补名额超时任务
+	waitlistCron := cron.NewWaitlistExpireCron(db.DB)
+	waitlistCron.Start()
+	defer waitlistCron.Stop()
+
+	// 启动卖家结算任务
+	settlementCron := cron.NewSettlementCron(db.DB)
+	settlementCron.Start()
+	defer settlementCron.Stop()
+
+	// 启动卖家看板刷新任务
+	sellerStatsCron := cron.NewSellerStatsCron(db.DB)
+	sellerStatsCron.Start()
+	defer sellerStatsCron.Stop()
+
+	// 启动调价计划任务
+	priceScheduleCron := cron.NewPriceScheduleCron(db.DB)
+	priceScheduleCron.Start()
+	defer priceScheduleCron.Stop()
+
+	// 启动上传文件清理任务
+	uploadCleanupCron := cron.NewUploadCleanupCron(db.DB)
+	uploadCleanupCron.Start()
+	defer uploadCleanupCron.Stop()
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
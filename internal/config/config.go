@@ -9,17 +9,45 @@ import (
 )
 
 type Config struct {
-	Server ServerConfig `mapstructure:"server"`
-	Data   DataConfig   `mapstructure:"data"`
-	JWT    JWTConfig    `mapstructure:"jwt"`
-	Risk   RiskConfig   `mapstructure:"risk"`
-	Logger LoggerConfig `mapstructure:"log"`
+	Server       ServerConfig       `mapstructure:"server"`
+	Data         DataConfig         `mapstructure:"data"`
+	JWT          JWTConfig          `mapstructure:"jwt"`
+	Risk         RiskConfig         `mapstructure:"risk"`
+	Logger       LoggerConfig       `mapstructure:"log"`
+	Points       PointsConfig       `mapstructure:"points"`
+	Growth       GrowthConfig       `mapstructure:"growth"`
+	Referral     ReferralConfig     `mapstructure:"referral"`
+	Notification NotificationConfig `mapstructure:"notification"`
+	Waitlist     WaitlistConfig     `mapstructure:"waitlist"`
+	Penalty      PenaltyConfig      `mapstructure:"penalty"`
+	Settlement   SettlementConfig   `mapstructure:"settlement"`
+	Webhook      WebhookConfig      `mapstructure:"webhook"`
+	Upload       UploadConfig       `mapstructure:"upload"`
 }
 
 type ServerConfig struct {
-	Port      string `mapstructure:"port"`
-	MachineID int    `mapstructure:"machineid"`
-	UploadDir string `mapstructure:"upload_dir"`
+	Port      string        `mapstructure:"port"`
+	MachineID int           `mapstructure:"machineid"`
+	UploadDir string        `mapstructure:"upload_dir"`
+	Storage   StorageConfig `mapstructure:"storage"`
+}
+
+type StorageConfig struct {
+	Driver           string   `mapstructure:"driver"`             // local/s3，默认 local；多实例部署需使用 s3
+	PublicBaseURL    string   `mapstructure:"public_base_url"`    // 公开文件访问前缀，local 默认 /uploads，s3 默认桶地址，可配置为 CDN 域名
+	SignSecret       string   `mapstructure:"sign_secret"`        // local 私有文件签名密钥，默认使用 jwt.secret
+	OrphanGraceHours int      `mapstructure:"orphan_grace_hours"` // 未被引用的上传文件保留时长(小时)，默认 24
+	S3               S3Config `mapstructure:"s3"`
+}
+
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`   // 如 https://s3.ap-east-1.amazonaws.com、http://127.0.0.1:9000
+	Region    string `mapstructure:"region"`     // 默认 us-east-1
+	Bucket    string `mapstructure:"bucket"`     // 需配置桶策略：除 private/ 前缀外允许匿名读取
+	AccessKey string `mapstructure:"access_key"` // 访问密钥 ID
+	SecretKey string `mapstructure:"secret_key"` // 访问密钥
+	PathStyle bool   `mapstructure:"path_style"` // 路径风格访问（桶名在路径中），MinIO 通常需开启
+	Timeout   int    `mapstructure:"timeout"`    // 请求超时(秒)，默认 10
 }
 
 type DataConfig struct {
@@ -88,6 +116,71 @@ type RiskConfig struct {
 	HotspotBurst int             `mapstructure:"hotspot_burst"` // 默认热点 burst
 }
 
+type PointsConfig struct {
+	ExpireDays       int   `mapstructure:"expire_days"`        // 积分有效期(天)，默认 365
+	EarnPerYuan      int64 `mapstructure:"earn_per_yuan"`      // 每实付 1 元获得积分，默认 1
+	LevelMultipliers []int `mapstructure:"level_multipliers"`  // 按 VIP 等级的积分倍率(百分比)，下标为等级-1，默认 100,120,150,200
+	FirstOrderBonus  int64 `mapstructure:"first_order_bonus"`  // 首单奖励积分，默认 100
+	ReferralBonus    int64 `mapstructure:"referral_bonus"`     // 邀请奖励积分，默认 200
+	PointValueCents  int64 `mapstructure:"point_value_cents"`  // 抵扣时 1 积分价值(分)，默认 1
+	MaxDeductPercent int   `mapstructure:"max_deduct_percent"` // 单笔订单最多抵扣比例(%)，默认 50
+}
+
+type GrowthConfig struct {
+	WindowMonths int `mapstructure:"window_months"` // 成长值统计窗口(月)，按窗口内实付计算等级，默认 12
+	GraceDays    int `mapstructure:"grace_days"`    // 低于门槛后的降级宽限期(天)，默认 30
+}
+
+type ReferralConfig struct {
+	InviterPoints   int64 `mapstructure:"inviter_points"`    // 邀请人奖励积分，未配置时使用 points.referral_bonus
+	InviteePoints   int64 `mapstructure:"invitee_points"`    // 被邀请人奖励积分，0 不发
+	InviterCouponID uint  `mapstructure:"inviter_coupon_id"` // 邀请人奖励券模板，0 不发
+	InviteeCouponID uint  `mapstructure:"invitee_coupon_id"` // 被邀请人奖励券模板，0 不发
+	MaxPerSource    int   `mapstructure:"max_per_source"`    // 同一邀请人名下同 IP/设备最多计奖人数，默认 3
+	FootprintDays   int   `mapstructure:"footprint_days"`    // 登录 IP/设备足迹保留天数，默认 90
+}
+
+type NotificationConfig struct {
+	EmailOutbox         string `mapstructure:"email_outbox"`          // 邮件桩输出文件（JSON 行），为空时只写日志
+	SMSOutbox           string `mapstructure:"sms_outbox"`            // 短信桩输出文件（JSON 行），为空时只写日志
+	MaxRetries          int    `mapstructure:"max_retries"`           // 单条投递最大重试次数，默认 5
+	CouponExpiringDays  int    `mapstructure:"coupon_expiring_days"`  // 优惠券到期提前提醒天数，默认 3
+	DropReminderOffsets []int  `mapstructure:"drop_reminder_offsets"` // 预约商品开售前提醒的提前量(分钟)，默认 1440,15
+}
+
+type WaitlistConfig struct {
+	ClaimMinutes int `mapstructure:"claim_minutes"` // 回流库存分配给候补用户后的认领时限(分钟)，默认 5
+}
+
+type PenaltyConfig struct {
+	WindowDays      int `mapstructure:"window_days"`      // 超时未支付计数窗口(天)，默认 30
+	DemoteThreshold int `mapstructure:"demote_threshold"` // 窗口内超时次数达到后候补排队降级，默认 2
+	BanThreshold    int `mapstructure:"ban_threshold"`    // 窗口内超时次数达到后暂停抢购，默认 3
+	BanDays         int `mapstructure:"ban_days"`         // 暂停抢购天数，默认 7
+	GrayThreshold   int `mapstructure:"gray_threshold"`   // 窗口内超时次数达到后自动加入灰名单，默认 5
+}
+
+type SettlementConfig struct {
+	CommissionBps    int `mapstructure:"commission_bps"`     // 平台佣金(万分比)，默认 500 即 5%
+	RefundWindowDays int `mapstructure:"refund_window_days"` // 支付后的退款期(天)，期满的订单才计入结算，默认 7
+}
+
+type WebhookConfig struct {
+	MaxAttempts    int  `mapstructure:"max_attempts"`    // 单次投递最多尝试次数，默认 8
+	TimeoutSeconds int  `mapstructure:"timeout_seconds"` // 单次请求超时(秒)，默认 5
+	ScanInterval   int  `mapstructure:"scan_interval"`   // 投递扫描间隔(秒)，默认 5
+	MaxEndpoints   int  `mapstructure:"max_endpoints"`   // 每个卖家最多注册的地址数，默认 5
+	AllowPrivate   bool `mapstructure:"allow_private"`   // 允许回调内网与本机地址，仅用于开发测试
+}
+
+type UploadConfig struct {
+	MaxSizeMB    int `mapstructure:"max_size_mb"`   // 单个文件大小上限(MB)，默认 10
+	DailyCount   int `mapstructure:"daily_count"`   // 每个用户每天最多上传次数，默认 100
+	DailySizeMB  int `mapstructure:"daily_size_mb"` // 每个用户每天最多上传总大小(MB)，默认 200
+	MaxDimension int `mapstructure:"max_dimension"` // 图片最长边上限(像素)，默认 8000
+	MaxPixels    int `mapstructure:"max_pixels"`    // 图片像素数(宽×高)上限，解码前校验以拒绝解压炸弹，默认 40000000
+}
+
 type RateLimitConfig struct {
 	Rate  int `mapstructure:"rate"`  // 每秒令牌
 	Burst int `mapstructure:"burst"` // 桶大小
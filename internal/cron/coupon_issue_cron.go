@@ -0,0 +1,54 @@
+package cron
+
+import (
+	"SneakerFlash/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultCouponIssueInterval = 10 * time.Second
+
+// CouponIssueCron 定时认领并执行管理员创建的批量发券任务。
+type CouponIssueCron struct {
+	jobSvc *service.CouponJobService
+	stopCh chan struct{}
+}
+
+func NewCouponIssueCron(db *gorm.DB) *CouponIssueCron {
+	return &CouponIssueCron{
+		jobSvc: service.NewCouponJobService(db),
+		stopCh: make(chan struct{}),
+	}
+}
+
+func (c *CouponIssueCron) Start() {
+	ticker := time.NewTicker(defaultCouponIssueInterval)
+	slog.Info("批量发券任务已启动", slog.Duration("interval", defaultCouponIssueInterval))
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				processed, err := c.jobSvc.RunPendingJobs(context.Background(), 5)
+				if err != nil {
+					slog.Error("批量发券执行失败", slog.Any("err", err))
+					continue
+				}
+				if processed > 0 {
+					slog.Info("批量发券执行完成", slog.Int("jobs", processed))
+				}
+			case <-c.stopCh:
+				ticker.Stop()
+				slog.Info("批量发券任务停止")
+				return
+			}
+		}
+	}()
+}
+
+func (c *CouponIssueCron) Stop() {
+	close(c.stopCh)
+}
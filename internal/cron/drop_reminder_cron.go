@@ -0,0 +1,57 @@
+package cron
+
+import (
+	"SneakerFlash/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultDropReminderInterval = time.Minute
+	defaultDropReminderBatch    = 500
+)
+
+// DropReminderCron 每分钟扫描即将开售的商品，按提前量提醒预约用户。
+type DropReminderCron struct {
+	dropSvc *service.DropService
+	stopCh  chan struct{}
+}
+
+func NewDropReminderCron(db *gorm.DB) *DropReminderCron {
+	return &DropReminderCron{
+		dropSvc: service.NewDropService(db),
+		stopCh:  make(chan struct{}),
+	}
+}
+
+func (c *DropReminderCron) Start() {
+	ticker := time.NewTicker(defaultDropReminderInterval)
+	slog.Info("开售提醒任务已启动", slog.Duration("interval", defaultDropReminderInterval))
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				sent, err := c.dropSvc.SendReminders(context.Background(), time.Now(), defaultDropReminderBatch)
+				if err != nil {
+					slog.Error("开售提醒发送失败", slog.Any("err", err))
+					continue
+				}
+				if sent > 0 {
+					slog.Info("开售提醒发送完成", slog.Int("users", sent))
+				}
+			case <-c.stopCh:
+				ticker.Stop()
+				slog.Info("开售提醒任务停止")
+				return
+			}
+		}
+	}()
+}
+
+func (c *DropReminderCron) Stop() {
+	close(c.stopCh)
+}
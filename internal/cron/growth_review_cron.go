@@ -0,0 +1,62 @@
+package cron
+
+import (
+	"SneakerFlash/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+const defaultGrowthReviewBatch = 200
+
+// GrowthReviewCron 每日复核成长等级：按滚动窗口重算，低于门槛预警，宽限期满降级。
+type GrowthReviewCron struct {
+	cron      *cron.Cron
+	growthSvc *service.GrowthService
+}
+
+func NewGrowthReviewCron(db *gorm.DB) *GrowthReviewCron {
+	return &GrowthReviewCron{
+		cron:      cron.New(),
+		growthSvc: service.NewGrowthService(db),
+	}
+}
+
+// Start 每天 03:10 执行，避开月初 00:01 的发券任务
+func (c *GrowthReviewCron) Start() {
+	if _, err := c.cron.AddFunc("10 3 * * *", c.review); err != nil {
+		slog.Error("注册成长等级复核任务失败", slog.Any("err", err))
+		return
+	}
+	c.cron.Start()
+	slog.Info("成长等级复核任务已启动", slog.String("schedule", "每天 03:10"))
+}
+
+func (c *GrowthReviewCron) Stop() {
+	c.cron.Stop()
+}
+
+func (c *GrowthReviewCron) review() {
+	start := time.Now()
+	result, err := c.growthSvc.Review(context.Background(), defaultGrowthReviewBatch)
+	if err != nil {
+		slog.Error("成长等级复核失败", slog.Any("err", err))
+		return
+	}
+	slog.Info("成长等级复核完成",
+		slog.Int("checked", result.Checked),
+		slog.Int("upgraded", result.Upgraded),
+		slog.Int("warned", result.Warned),
+		slog.Int("restored", result.Restored),
+		slog.Int("downgraded", result.Downgraded),
+		slog.Duration("duration", time.Since(start)),
+	)
+}
+
+// RunOnce 手动执行一次复核（用于测试或补跑）
+func (c *GrowthReviewCron) RunOnce() {
+	c.review()
+}
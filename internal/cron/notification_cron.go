@@ -0,0 +1,109 @@
+package cron
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultNotificationDispatchInterval = 10 * time.Second
+	defaultNotificationDispatchBatch    = 200
+	defaultCouponExpiringDays           = 3
+	defaultCouponRemindBatch            = 500
+)
+
+// NotificationDispatchCron 定时投递待发送的通知，失败按次数重试。
+type NotificationDispatchCron struct {
+	notificationSvc *service.NotificationService
+	stopCh          chan struct{}
+}
+
+func NewNotificationDispatchCron(db *gorm.DB) *NotificationDispatchCron {
+	return &NotificationDispatchCron{
+		notificationSvc: service.NewNotificationService(db),
+		stopCh:          make(chan struct{}),
+	}
+}
+
+func (c *NotificationDispatchCron) Start() {
+	ticker := time.NewTicker(defaultNotificationDispatchInterval)
+	slog.Info("通知投递任务已启动", slog.Duration("interval", defaultNotificationDispatchInterval))
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				sent, err := c.notificationSvc.Dispatch(context.Background(), defaultNotificationDispatchBatch)
+				if err != nil {
+					slog.Error("通知投递失败", slog.Any("err", err))
+					continue
+				}
+				if sent > 0 {
+					slog.Info("通知投递完成", slog.Int("sent", sent))
+				}
+			case <-c.stopCh:
+				ticker.Stop()
+				slog.Info("通知投递任务停止")
+				return
+			}
+		}
+	}()
+}
+
+func (c *NotificationDispatchCron) Stop() {
+	close(c.stopCh)
+}
+
+// CouponRemindCron 每日提醒即将过期的优惠券。
+type CouponRemindCron struct {
+	cron      *cron.Cron
+	couponSvc *service.CouponService
+}
+
+func NewCouponRemindCron(db *gorm.DB) *CouponRemindCron {
+	return &CouponRemindCron{
+		cron:      cron.New(),
+		couponSvc: service.NewCouponService(db),
+	}
+}
+
+// Start 每天 10:00 执行，避免夜间打扰
+func (c *CouponRemindCron) Start() {
+	if _, err := c.cron.AddFunc("0 10 * * *", c.remind); err != nil {
+		slog.Error("注册优惠券过期提醒任务失败", slog.Any("err", err))
+		return
+	}
+	c.cron.Start()
+	slog.Info("优惠券过期提醒任务已启动", slog.String("schedule", "每天 10:00"))
+}
+
+func (c *CouponRemindCron) Stop() {
+	c.cron.Stop()
+}
+
+func (c *CouponRemindCron) remind() {
+	days := config.Conf.Notification.CouponExpiringDays
+	if days <= 0 {
+		days = defaultCouponExpiringDays
+	}
+	within := time.Duration(days) * 24 * time.Hour
+	total := 0
+	for {
+		reminded, err := c.couponSvc.RemindExpiring(context.Background(), within, defaultCouponRemindBatch)
+		if err != nil {
+			slog.Error("优惠券过期提醒失败", slog.Any("err", err))
+			return
+		}
+		total += reminded
+		if reminded < defaultCouponRemindBatch {
+			break
+		}
+	}
+	slog.Info("优惠券过期提醒完成", slog.Int("coupons", total))
+}
@@ -6,6 +6,7 @@ import (
 	"SneakerFlash/internal/model"
 	"SneakerFlash/internal/pkg/breaker"
 	"SneakerFlash/internal/repository"
+	"SneakerFlash/internal/service"
 	"context"
 	"log/slog"
 	"time"
@@ -13,9 +14,10 @@ import (
 	"gorm.io/gorm"
 )
 
-// OutboxCron 本地消息表补偿定时任务
+// OutboxCron 本地消息表补偿定时任务，同时负责商家 Webhook 的投递与重试
 type OutboxCron struct {
 	outboxRepo *repository.OutboxRepo
+	webhookSvc *service.WebhookService
 	maxRetries int
 	cfg        config.KafkaConfig
 	stopCh     chan struct{}
@@ -29,6 +31,7 @@ func NewOutboxCron(db *gorm.DB, cfg config.KafkaConfig) *OutboxCron {
 	}
 	return &OutboxCron{
 		outboxRepo: repository.NewOutboxRepo(db),
+		webhookSvc: service.NewWebhookService(db),
 		maxRetries: maxRetries,
 		cfg:        cfg,
 		stopCh:     make(chan struct{}),
@@ -42,8 +45,14 @@ func (c *OutboxCron) Start() {
 		scanInterval = 30
 	}
 
+	webhookInterval := config.Conf.Webhook.ScanInterval
+	if webhookInterval <= 0 {
+		webhookInterval = 5
+	}
+
 	ticker := time.NewTicker(time.Duration(scanInterval) * time.Second)
-	slog.Info("Outbox 补偿任务启动", slog.Int("scan_interval_sec", scanInterval))
+	webhookTicker := time.NewTicker(time.Duration(webhookInterval) * time.Second)
+	slog.Info("Outbox 补偿任务启动", slog.Int("scan_interval_sec", scanInterval), slog.Int("webhook_interval_sec", webhookInterval))
 
 	go func() {
 		for {
@@ -51,8 +60,11 @@ func (c *OutboxCron) Start() {
 			case <-ticker.C:
 				c.compensate()
 				c.cleanupOldMessages()
+			case <-webhookTicker.C:
+				c.dispatchWebhooks()
 			case <-c.stopCh:
 				ticker.Stop()
+				webhookTicker.Stop()
 				slog.Info("Outbox 补偿任务停止")
 				return
 			}
@@ -151,6 +163,18 @@ func (c *OutboxCron) processMessage(ctx context.Context, msg *model.OutboxMessag
 	slog.Info("消息补偿发送成功", slog.Uint64("msg_id", uint64(msg.ID)))
 }
 
+// dispatchWebhooks 投递到期的商家 Webhook，失败的按指数退避重新排期
+func (c *OutboxCron) dispatchWebhooks() {
+	handled, err := c.webhookSvc.Dispatch(context.Background(), time.Now(), 100)
+	if err != nil {
+		slog.Error("Webhook 投递失败", slog.Any("error", err))
+		return
+	}
+	if handled > 0 {
+		slog.Info("Webhook 投递完成", slog.Int("count", handled))
+	}
+}
+
 // cleanupOldMessages 清理已发送成功的旧消息
 func (c *OutboxCron) cleanupOldMessages() {
 	ctx := context.Background()
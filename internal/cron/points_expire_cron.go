@@ -0,0 +1,57 @@
+package cron
+
+import (
+	"SneakerFlash/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPointsExpireInterval = 10 * time.Minute
+	defaultPointsExpireBatch    = 500
+)
+
+// PointsExpireCron 定时清理过期积分，扣减用户余额并记录流水。
+type PointsExpireCron struct {
+	pointsSvc *service.PointsService
+	stopCh    chan struct{}
+}
+
+func NewPointsExpireCron(db *gorm.DB) *PointsExpireCron {
+	return &PointsExpireCron{
+		pointsSvc: service.NewPointsService(db),
+		stopCh:    make(chan struct{}),
+	}
+}
+
+func (c *PointsExpireCron) Start() {
+	ticker := time.NewTicker(defaultPointsExpireInterval)
+	slog.Info("积分过期任务已启动", slog.Duration("interval", defaultPointsExpireInterval))
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				expired, err := c.pointsSvc.ExpirePoints(context.Background(), defaultPointsExpireBatch)
+				if err != nil {
+					slog.Error("积分过期处理失败", slog.Any("err", err))
+					continue
+				}
+				if expired > 0 {
+					slog.Info("积分过期处理完成", slog.Int("entries", expired))
+				}
+			case <-c.stopCh:
+				ticker.Stop()
+				slog.Info("积分过期任务停止")
+				return
+			}
+		}
+	}()
+}
+
+func (c *PointsExpireCron) Stop() {
+	close(c.stopCh)
+}
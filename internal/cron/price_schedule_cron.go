@@ -0,0 +1,55 @@
+package cron
+
+import (
+	"SneakerFlash/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 发售价、降价等按分钟预设，扫描间隔需明显小于一分钟
+const defaultPriceScheduleInterval = 10 * time.Second
+
+// PriceScheduleCron 定时执行到期的调价计划。
+type PriceScheduleCron struct {
+	priceSvc *service.PriceService
+	stopCh   chan struct{}
+}
+
+func NewPriceScheduleCron(db *gorm.DB) *PriceScheduleCron {
+	return &PriceScheduleCron{
+		priceSvc: service.NewPriceService(db),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+func (c *PriceScheduleCron) Start() {
+	ticker := time.NewTicker(defaultPriceScheduleInterval)
+	slog.Info("调价计划任务已启动", slog.Duration("interval", defaultPriceScheduleInterval))
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				changed, err := c.priceSvc.ApplyDueSchedules(context.Background())
+				if err != nil {
+					slog.Error("执行调价计划失败", slog.Any("err", err))
+					continue
+				}
+				if changed > 0 {
+					slog.Info("调价计划已生效", slog.Int("products", changed))
+				}
+			case <-c.stopCh:
+				ticker.Stop()
+				slog.Info("调价计划任务停止")
+				return
+			}
+		}
+	}()
+}
+
+func (c *PriceScheduleCron) Stop() {
+	close(c.stopCh)
+}
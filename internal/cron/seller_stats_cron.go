@@ -0,0 +1,53 @@
+package cron
+
+import (
+	"SneakerFlash/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultSellerStatsInterval = time.Minute
+
+// SellerStatsCron 定时重算有变动的卖家销售看板并写入 Redis，多实例部署时只有持有租约的实例执行。
+type SellerStatsCron struct {
+	statsSvc *service.SellerStatsService
+	stopCh   chan struct{}
+}
+
+func NewSellerStatsCron(db *gorm.DB) *SellerStatsCron {
+	return &SellerStatsCron{
+		statsSvc: service.NewSellerStatsService(db),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+func (c *SellerStatsCron) Start() {
+	ticker := time.NewTicker(defaultSellerStatsInterval)
+	slog.Info("卖家看板刷新任务已启动", slog.Duration("interval", defaultSellerStatsInterval))
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				start := time.Now()
+				refreshed, err := c.statsSvc.RefreshChanged(context.Background(), defaultSellerStatsInterval)
+				if err != nil {
+					slog.Error("卖家看板刷新失败", slog.Any("err", err))
+					continue
+				}
+				slog.Debug("卖家看板刷新完成", slog.Int("sellers", refreshed), slog.Duration("duration", time.Since(start)))
+			case <-c.stopCh:
+				ticker.Stop()
+				slog.Info("卖家看板刷新任务停止")
+				return
+			}
+		}
+	}()
+}
+
+func (c *SellerStatsCron) Stop() {
+	close(c.stopCh)
+}
@@ -0,0 +1,55 @@
+package cron
+
+import (
+	"SneakerFlash/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// SettlementCron 每周为卖家生成上一自然周的结算单。
+type SettlementCron struct {
+	cron          *cron.Cron
+	settlementSvc *service.SettlementService
+}
+
+func NewSettlementCron(db *gorm.DB) *SettlementCron {
+	return &SettlementCron{
+		cron:          cron.New(),
+		settlementSvc: service.NewSettlementService(db),
+	}
+}
+
+// Start 每周一 03:30 执行，避开每天 03:10 的成长等级复核
+func (c *SettlementCron) Start() {
+	if _, err := c.cron.AddFunc("30 3 * * 1", c.generate); err != nil {
+		slog.Error("注册卖家结算任务失败", slog.Any("err", err))
+		return
+	}
+	c.cron.Start()
+	slog.Info("卖家结算任务已启动", slog.String("schedule", "每周一 03:30"))
+}
+
+func (c *SettlementCron) Stop() {
+	c.cron.Stop()
+}
+
+func (c *SettlementCron) generate() {
+	start := time.Now()
+	result, err := c.settlementSvc.Generate(context.Background(), start)
+	if err != nil {
+		slog.Error("卖家结算失败", slog.Any("err", err))
+		return
+	}
+	slog.Info("卖家结算完成",
+		slog.Time("period_start", result.PeriodStart),
+		slog.Int("statements", result.Statements),
+		slog.Int("orders", result.Orders),
+		slog.Int("reversals", result.Reversals),
+		slog.Int("skipped", result.Skipped),
+		slog.Duration("duration", time.Since(start)),
+	)
+}
@@ -0,0 +1,65 @@
+package cron
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/infra/storage"
+	"SneakerFlash/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultUploadCleanupInterval = time.Hour
+	defaultUploadOrphanGrace     = 24 * time.Hour
+)
+
+// UploadCleanupCron 定时删除超过保留期仍未被引用的上传文件。
+type UploadCleanupCron struct {
+	uploadSvc *service.UploadService
+	grace     time.Duration
+	stopCh    chan struct{}
+}
+
+func NewUploadCleanupCron(db *gorm.DB) *UploadCleanupCron {
+	grace := defaultUploadOrphanGrace
+	if hours := config.Conf.Server.Storage.OrphanGraceHours; hours > 0 {
+		grace = time.Duration(hours) * time.Hour
+	}
+	return &UploadCleanupCron{
+		uploadSvc: service.NewUploadService(db, storage.Store),
+		grace:     grace,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+func (c *UploadCleanupCron) Start() {
+	ticker := time.NewTicker(defaultUploadCleanupInterval)
+	slog.Info("上传文件清理任务已启动", slog.Duration("interval", defaultUploadCleanupInterval), slog.Duration("grace", c.grace))
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				deleted, err := c.uploadSvc.CleanupOrphans(context.Background(), c.grace)
+				if err != nil {
+					slog.Error("上传文件清理失败", slog.Any("err", err))
+					continue
+				}
+				if deleted > 0 {
+					slog.Info("已清理未引用的上传文件", slog.Int("count", deleted))
+				}
+			case <-c.stopCh:
+				ticker.Stop()
+				slog.Info("上传文件清理任务停止")
+				return
+			}
+		}
+	}()
+}
+
+func (c *UploadCleanupCron) Stop() {
+	close(c.stopCh)
+}
@@ -0,0 +1,58 @@
+package cron
+
+import (
+	"SneakerFlash/internal/repository"
+	"SneakerFlash/internal/service"
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultWaitlistExpireInterval = 15 * time.Second
+	defaultWaitlistExpireBatch    = 100
+)
+
+// WaitlistExpireCron 定时收回认领超时的候补名额，顺延给下一位或放回库存。
+type WaitlistExpireCron struct {
+	waitlistSvc *service.WaitlistService
+	stopCh      chan struct{}
+}
+
+func NewWaitlistExpireCron(db *gorm.DB) *WaitlistExpireCron {
+	return &WaitlistExpireCron{
+		waitlistSvc: service.NewWaitlistService(db, repository.NewProductRepo(db)),
+		stopCh:      make(chan struct{}),
+	}
+}
+
+func (c *WaitlistExpireCron) Start() {
+	ticker := time.NewTicker(defaultWaitlistExpireInterval)
+	slog.Info("候补名额超时任务已启动", slog.Duration("interval", defaultWaitlistExpireInterval))
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				expired, err := c.waitlistSvc.ExpireOffers(context.Background(), time.Now(), defaultWaitlistExpireBatch)
+				if err != nil {
+					slog.Error("候补名额超时处理失败", slog.Any("err", err))
+					continue
+				}
+				if expired > 0 {
+					slog.Info("候补名额超时处理完成", slog.Int("expired", expired))
+				}
+			case <-c.stopCh:
+				ticker.Stop()
+				slog.Info("候补名额超时任务停止")
+				return
+			}
+		}
+	}()
+}
+
+func (c *WaitlistExpireCron) Stop() {
+	close(c.stopCh)
+}
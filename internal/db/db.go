@@ -10,6 +10,7 @@ import (
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	gormlogger "gorm.io/gorm/logger"
 )
 
@@ -94,6 +95,14 @@ func MakeMigrate() {
 
 	slog.Info("正在迁移数据库")
 
+	// 旧版 idx_user_product 标签错误，实际生成了 product_id 单列唯一索引，迁移前移除
+	if DB.Migrator().HasIndex(&model.Order{}, "idx_user_product") {
+		if err := DB.Migrator().DropIndex(&model.Order{}, "idx_user_product"); err != nil {
+			slog.Error("删除旧订单索引失败", slog.Any("err", err))
+			panic(err)
+		}
+	}
+
 	err := DB.AutoMigrate(
 		&model.Order{},
 		&model.User{},
@@ -102,8 +111,35 @@ func MakeMigrate() {
 		&model.Coupon{},
 		&model.UserCoupon{},
 		&model.PaidVIP{},
+		&model.PaidVIPGrant{},
 		&model.OutboxMessage{},
 		&model.AuditLog{},
+		&model.CouponIssueJob{},
+		&model.VIPPlan{},
+		&model.VIPLevelConfig{},
+		&model.PointEntry{},
+		&model.PointAllocation{},
+		&model.GrowthLevelChange{},
+		&model.InviteCode{},
+		&model.Referral{},
+		&model.Notification{},
+		&model.NotificationDelivery{},
+		&model.NotificationPreference{},
+		&model.DropSubscription{},
+		&model.DropReminder{},
+		&model.WaitlistEntry{},
+		&model.UnpaidTimeout{},
+		&model.UserPenalty{},
+		&model.SellerProfile{},
+		&model.SettlementStatement{},
+		&model.SettlementItem{},
+		&model.WebhookEndpoint{},
+		&model.WebhookDelivery{},
+		&model.Brand{},
+		&model.Category{},
+		&model.Upload{},
+		&model.PriceSchedule{},
+		&model.PriceHistory{},
 	)
 
 	if err != nil {
@@ -111,9 +147,104 @@ func MakeMigrate() {
 		panic(err)
 	}
 
+	// 首次部署写入默认 VIP 套餐与等级配置
+	if err := SeedVIPConfig(DB); err != nil {
+		slog.Error("写入默认 VIP 配置失败", slog.Any("err", err))
+		panic(err)
+	}
+
+	// 成长值改为按支付时间的滚动窗口统计，为历史已支付订单补齐支付时间
+	if err := DB.Model(&model.Order{}).
+		Where("status = ? AND paid_at IS NULL", model.OrderStatusPaid).
+		Update("paid_at", gorm.Expr("updated_at")).Error; err != nil {
+		slog.Error("补齐订单支付时间失败", slog.Any("err", err))
+		panic(err)
+	}
+
+	// 商品由单图改为图集，历史封面图作为单图图集，旧图没有变体，各尺寸均指向原图
+	if err := migrateLegacyProductImages(); err != nil {
+		slog.Error("迁移商品图集失败", slog.Any("err", err))
+		panic(err)
+	}
+
+	// 卖家入驻上线前已发布商品的用户补建入驻记录，保留商品写权限
+	if err := BackfillSellerProfiles(DB); err != nil {
+		slog.Error("补建卖家入驻记录失败", slog.Any("err", err))
+		panic(err)
+	}
+
 	slog.Info("数据库迁移成功")
 }
 
+// SeedVIPConfig 套餐或等级配置表为空时写入默认配置，已存在的版本忽略。
+func SeedVIPConfig(gdb *gorm.DB) error {
+	var plans, levels int64
+	if err := gdb.Model(&model.VIPPlan{}).Count(&plans).Error; err != nil {
+		return err
+	}
+	if plans == 0 {
+		if err := gdb.Clauses(clause.OnConflict{DoNothing: true}).Create(model.DefaultVIPPlans()).Error; err != nil {
+			return err
+		}
+	}
+	if err := gdb.Model(&model.VIPLevelConfig{}).Count(&levels).Error; err != nil {
+		return err
+	}
+	if levels == 0 {
+		return gdb.Clauses(clause.OnConflict{DoNothing: true}).Create(model.DefaultVIPLevels()).Error
+	}
+	return nil
+}
+
+// BackfillSellerProfiles 为已有商品但没有入驻记录的用户补建审核通过的入驻记录，店铺名按用户 ID 生成，可由卖家后续修改。
+func BackfillSellerProfiles(gdb *gorm.DB) error {
+	var owners []struct {
+		UserID   uint
+		Username string
+	}
+	if err := gdb.Model(&model.Product{}).
+		Select("DISTINCT products.user_id, users.username").
+		Joins("JOIN users ON users.id = products.user_id").
+		Joins("LEFT JOIN seller_profiles ON seller_profiles.user_id = products.user_id").
+		Where("seller_profiles.user_id IS NULL").
+		Scan(&owners).Error; err != nil {
+		return err
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+	now := time.Now()
+	profiles := make([]model.SellerProfile, 0, len(owners))
+	for _, o := range owners {
+		profiles = append(profiles, model.SellerProfile{
+			UserID:      o.UserID,
+			StoreName:   fmt.Sprintf("店铺%d", o.UserID),
+			ContactName: o.Username,
+			Status:      model.SellerStatusApproved,
+			ReviewedAt:  &now,
+			ApprovedAt:  &now,
+		})
+	}
+	return gdb.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(profiles, 200).Error
+}
+
+func migrateLegacyProductImages() error {
+	var products []model.Product
+	return DB.Unscoped().Select("id", "image").Where("images IS NULL").
+		FindInBatches(&products, 500, func(tx *gorm.DB, _ int) error {
+			for _, p := range products {
+				images := model.ProductImages{}
+				if p.Image != "" {
+					images = append(images, model.ProductImage{URL: p.Image, Thumbnail: p.Image, Medium: p.Image})
+				}
+				if err := DB.Unscoped().Model(&model.Product{}).Where("id = ?", p.ID).UpdateColumn("images", images).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
 func Close() error {
 	if DB == nil {
 		return nil
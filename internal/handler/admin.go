@@ -20,10 +20,18 @@ import (
 )
 
 type AdminHandler struct {
-	adminSvc  *service.AdminService
-	riskSvc   *service.RiskService
-	couponSvc *service.CouponService
-	auditSvc  *service.AuditService
+	adminSvc      *service.AdminService
+	riskSvc       *service.RiskService
+	couponSvc     *service.CouponService
+	couponJobSvc  *service.CouponJobService
+	vipConfigSvc  *service.VIPConfigService
+	auditSvc      *service.AuditService
+	referralSvc   *service.ReferralService
+	dropSvc       *service.DropService
+	penaltySvc    *service.PenaltyService
+	sellerSvc     *service.SellerService
+	settlementSvc *service.SettlementService
+	catalogSvc    *service.CatalogService
 }
 
 type riskEntryReq struct {
@@ -42,6 +50,7 @@ type adminCouponCreateReq struct {
 	ValidTo       string `json:"valid_to" binding:"required"`
 	Purchasable   bool   `json:"purchasable"`
 	PriceCents    int64  `json:"price_cents"`
+	PointsCost    int64  `json:"points_cost"` // 积分兑换所需积分，0 不可兑换
 	Status        string `json:"status"`
 }
 
@@ -56,15 +65,40 @@ type adminCouponUpdateReq struct {
 	ValidTo       *string `json:"valid_to"`
 	Purchasable   *bool   `json:"purchasable"`
 	PriceCents    *int64  `json:"price_cents"`
+	PointsCost    *int64  `json:"points_cost"`
 	Status        *string `json:"status"`
 }
 
-func NewAdminHandler(adminSvc *service.AdminService, riskSvc *service.RiskService, couponSvc *service.CouponService, auditSvc *service.AuditService) *AdminHandler {
+// AdminDeps 管理后台依赖的服务，按模块命名，新增模块只需补充字段。
+type AdminDeps struct {
+	Admin      *service.AdminService
+	Risk       *service.RiskService
+	Coupon     *service.CouponService
+	CouponJob  *service.CouponJobService
+	VIPConfig  *service.VIPConfigService
+	Audit      *service.AuditService
+	Referral   *service.ReferralService
+	Drop       *service.DropService
+	Penalty    *service.PenaltyService
+	Seller     *service.SellerService
+	Settlement *service.SettlementService
+	Catalog    *service.CatalogService
+}
+
+func NewAdminHandler(deps AdminDeps) *AdminHandler {
 	return &AdminHandler{
-		adminSvc:  adminSvc,
-		riskSvc:   riskSvc,
-		couponSvc: couponSvc,
-		auditSvc:  auditSvc,
+		adminSvc:      deps.Admin,
+		riskSvc:       deps.Risk,
+		couponSvc:     deps.Coupon,
+		couponJobSvc:  deps.CouponJob,
+		vipConfigSvc:  deps.VIPConfig,
+		auditSvc:      deps.Audit,
+		referralSvc:   deps.Referral,
+		dropSvc:       deps.Drop,
+		penaltySvc:    deps.Penalty,
+		sellerSvc:     deps.Seller,
+		settlementSvc: deps.Settlement,
+		catalogSvc:    deps.Catalog,
 	}
 }
 
@@ -230,6 +264,7 @@ func (h *AdminHandler) CreateCoupon(c *gin.Context) {
 		ValidTo:       validTo,
 		Purchasable:   req.Purchasable,
 		PriceCents:    req.PriceCents,
+		PointsCost:    req.PointsCost,
 		Status:        req.Status,
 	})
 	if err != nil {
@@ -280,6 +315,7 @@ func (h *AdminHandler) UpdateCoupon(c *gin.Context) {
 		MinSpendCents: req.MinSpendCents,
 		Purchasable:   req.Purchasable,
 		PriceCents:    req.PriceCents,
+		PointsCost:    req.PointsCost,
 		Status:        req.Status,
 	}
 	if req.Type != nil {
@@ -358,11 +394,13 @@ func (h *AdminHandler) DeleteCoupon(c *gin.Context) {
 
 // ListProducts 管理台商品列表
 // @Summary 管理台商品列表
+// @Description status=pending_review 为审核队列，按提交时间先后排序
 // @Tags 管理后台
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页条数" default(20)
+// @Param status query string false "draft/pending_review/approved/rejected/offline"
 // @Success 200 {object} app.Response{data=app.PageData}
 // @Failure 400 {object} app.Response "参数错误"
 // @Failure 401 {object} app.Response "未登录"
@@ -376,14 +414,49 @@ func (h *AdminHandler) ListProducts(c *gin.Context) {
 		return
 	}
 
-	products, total, err := h.adminSvc.ListAllProducts(c.Request.Context(), page, pageSize)
+	products, total, err := h.adminSvc.ListAllProducts(c.Request.Context(), model.ProductStatus(c.Query("status")), page, pageSize)
 	if err != nil {
+		if errors.Is(err, service.ErrProductStatusInvalid) {
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+			return
+		}
 		appG.Error(http.StatusInternalServerError, e.ERROR)
 		return
 	}
 	appG.SuccessWithPage(products, total, page, pageSize)
 }
 
+// ProductSubscribers 商品预约人数
+// @Summary 商品开售提醒预约人数
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=service.DropSubscribers}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /admin/products/{id}/subscribers [get]
+func (h *AdminHandler) ProductSubscribers(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	stats, err := h.dropSvc.Subscribers(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
+			return
+		}
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(stats)
+}
+
 // ListBlacklist 管理台黑名单
 // @Summary 查询黑名单
 // @Tags 管理后台
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type adminBrandReq struct {
+	Name        string `json:"name" binding:"required,max=50"`
+	Logo        string `json:"logo" binding:"max=255"`
+	Description string `json:"description" binding:"max=500"`
+	Sort        int    `json:"sort"`
+}
+
+type adminBrandUpdateReq struct {
+	Name        *string `json:"name" binding:"omitempty,max=50"`
+	Logo        *string `json:"logo" binding:"omitempty,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+	Sort        *int    `json:"sort"`
+}
+
+type adminCategoryReq struct {
+	ParentID uint   `json:"parent_id"` // 0 为一级分类
+	Name     string `json:"name" binding:"required,max=50"`
+	Sort     int    `json:"sort"`
+}
+
+type adminCategoryUpdateReq struct {
+	Name *string `json:"name" binding:"omitempty,max=50"`
+	Sort *int    `json:"sort"`
+}
+
+// CreateBrand 新建品牌
+// @Summary 新建品牌
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body adminBrandReq true "品牌"
+// @Success 200 {object} app.Response{data=model.Brand}
+// @Failure 400 {object} app.Response "参数错误或名称重复"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/brands [post]
+func (h *AdminHandler) CreateBrand(c *gin.Context) {
+	appG := app.Gin{C: c}
+	var req adminBrandReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	brand, err := h.catalogSvc.CreateBrand(c.Request.Context(), service.BrandInput{
+		Name:        req.Name,
+		Logo:        req.Logo,
+		Description: req.Description,
+		Sort:        req.Sort,
+	})
+	if err != nil {
+		catalogError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "brand_create", strconv.Itoa(int(brand.ID)), req, "")
+	appG.Success(brand)
+}
+
+// UpdateBrand 修改品牌
+// @Summary 修改品牌
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "品牌ID"
+// @Param payload body adminBrandUpdateReq true "需要修改的字段"
+// @Success 200 {object} app.Response{data=model.Brand}
+// @Failure 400 {object} app.Response "参数错误或名称重复"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "品牌不存在"
+// @Router /admin/brands/{id} [put]
+func (h *AdminHandler) UpdateBrand(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var req adminBrandUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	brand, err := h.catalogSvc.UpdateBrand(c.Request.Context(), uint(id), service.BrandUpdate{
+		Name:        req.Name,
+		Logo:        req.Logo,
+		Description: req.Description,
+		Sort:        req.Sort,
+	})
+	if err != nil {
+		catalogError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "brand_update", strconv.Itoa(id), req, "")
+	appG.Success(brand)
+}
+
+// DeleteBrand 删除品牌
+// @Summary 删除品牌
+// @Description 仍有商品引用时不可删除
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "品牌ID"
+// @Success 200 {object} app.Response
+// @Failure 400 {object} app.Response "品牌下仍有商品"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "品牌不存在"
+// @Router /admin/brands/{id} [delete]
+func (h *AdminHandler) DeleteBrand(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	if err := h.catalogSvc.DeleteBrand(c.Request.Context(), uint(id)); err != nil {
+		catalogError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "brand_delete", strconv.Itoa(id), nil, "")
+	appG.Success(gin.H{"id": id})
+}
+
+// CreateCategory 新建分类
+// @Summary 新建商品分类
+// @Description 最多三级，同级名称唯一
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body adminCategoryReq true "分类"
+// @Success 200 {object} app.Response{data=model.Category}
+// @Failure 400 {object} app.Response "参数错误、名称重复或层级过深"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "父分类不存在"
+// @Router /admin/categories [post]
+func (h *AdminHandler) CreateCategory(c *gin.Context) {
+	appG := app.Gin{C: c}
+	var req adminCategoryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	category, err := h.catalogSvc.CreateCategory(c.Request.Context(), service.CategoryInput{
+		ParentID: req.ParentID,
+		Name:     req.Name,
+		Sort:     req.Sort,
+	})
+	if err != nil {
+		catalogError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "category_create", strconv.Itoa(int(category.ID)), req, "")
+	appG.Success(category)
+}
+
+// UpdateCategory 修改分类
+// @Summary 修改商品分类名称或排序
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分类ID"
+// @Param payload body adminCategoryUpdateReq true "需要修改的字段"
+// @Success 200 {object} app.Response{data=model.Category}
+// @Failure 400 {object} app.Response "参数错误或名称重复"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "分类不存在"
+// @Router /admin/categories/{id} [put]
+func (h *AdminHandler) UpdateCategory(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var req adminCategoryUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	category, err := h.catalogSvc.UpdateCategory(c.Request.Context(), uint(id), service.CategoryUpdate{
+		Name: req.Name,
+		Sort: req.Sort,
+	})
+	if err != nil {
+		catalogError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "category_update", strconv.Itoa(id), req, "")
+	appG.Success(category)
+}
+
+// DeleteCategory 删除分类
+// @Summary 删除商品分类
+// @Description 仍有子分类或商品引用时不可删除
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "分类ID"
+// @Success 200 {object} app.Response
+// @Failure 400 {object} app.Response "分类下仍有子分类或商品"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "分类不存在"
+// @Router /admin/categories/{id} [delete]
+func (h *AdminHandler) DeleteCategory(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	if err := h.catalogSvc.DeleteCategory(c.Request.Context(), uint(id)); err != nil {
+		catalogError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "category_delete", strconv.Itoa(id), nil, "")
+	appG.Success(gin.H{"id": id})
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type adminCouponJobCreateReq struct {
+	CouponID  uint   `json:"coupon_id" binding:"required"`
+	Segment   string `json:"segment" binding:"required"` // growth_level / paid_vip / product_buyers / user_ids
+	MinLevel  int    `json:"min_level"`
+	MaxLevel  int    `json:"max_level"`
+	PaidLevel int    `json:"paid_level"`
+	ProductID uint   `json:"product_id"`
+	UserIDs   []uint `json:"user_ids"`
+}
+
+// ListCouponJobs 批量发券任务列表
+// @Summary 批量发券任务列表
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "任务状态 pending/running/completed/failed/cancelled"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页条数" default(20)
+// @Success 200 {object} app.Response{data=app.PageData}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/coupon-jobs [get]
+func (h *AdminHandler) ListCouponJobs(c *gin.Context) {
+	appG := app.Gin{C: c}
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	jobs, total, err := h.couponJobSvc.ListJobs(c.Request.Context(), c.Query("status"), page, pageSize)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.SuccessWithPage(jobs, total, page, pageSize)
+}
+
+// CreateCouponJob 创建批量发券任务
+// @Summary 创建批量发券任务
+// @Description 按人群（成长等级区间/付费 VIP/商品购买者/用户 ID 列表）异步发放优惠券，由 worker 分批执行
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body adminCouponJobCreateReq true "发券任务"
+// @Success 200 {object} app.Response{data=model.CouponIssueJob}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "资源不存在"
+// @Router /admin/coupon-jobs [post]
+func (h *AdminHandler) CreateCouponJob(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	var req adminCouponJobCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	job, err := h.couponJobSvc.CreateJob(c.Request.Context(), req.CouponID, service.CouponSegment{
+		Type:      req.Segment,
+		MinLevel:  req.MinLevel,
+		MaxLevel:  req.MaxLevel,
+		PaidLevel: req.PaidLevel,
+		ProductID: req.ProductID,
+		UserIDs:   req.UserIDs,
+	}, adminOperatorID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCouponNotFound):
+			appG.Error(http.StatusNotFound, e.INVALID_PARAMS)
+		case errors.Is(err, service.ErrCouponJobSegment), errors.Is(err, service.ErrCouponJobTemplate):
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+		default:
+			appG.Error(http.StatusInternalServerError, e.ERROR)
+		}
+		return
+	}
+	// 用户 ID 列表最多 10 万条，审计日志只记录数量
+	h.recordAudit(c, model.AdminResourceCoupons, "create_job", strconv.Itoa(int(job.ID)), gin.H{
+		"job_id":        job.ID,
+		"coupon_id":     req.CouponID,
+		"segment":       job.SegmentType,
+		"min_level":     req.MinLevel,
+		"max_level":     req.MaxLevel,
+		"paid_level":    req.PaidLevel,
+		"product_id":    req.ProductID,
+		"user_id_count": len(req.UserIDs),
+	}, "")
+	appG.Success(job)
+}
+
+// GetCouponJob 查询批量发券任务进度
+// @Summary 批量发券任务详情
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Success 200 {object} app.Response{data=model.CouponIssueJob}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "资源不存在"
+// @Router /admin/coupon-jobs/{id} [get]
+func (h *AdminHandler) GetCouponJob(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	job, err := h.couponJobSvc.GetJob(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrCouponJobNotFound) {
+			appG.Error(http.StatusNotFound, e.INVALID_PARAMS)
+			return
+		}
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(job)
+}
+
+// CancelCouponJob 取消批量发券任务
+// @Summary 取消批量发券任务
+// @Description 取消待执行或执行中的任务，已发放的券不回收
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "任务ID"
+// @Success 200 {object} app.Response{data=model.CouponIssueJob}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "资源不存在"
+// @Router /admin/coupon-jobs/{id}/cancel [post]
+func (h *AdminHandler) CancelCouponJob(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	job, err := h.couponJobSvc.CancelJob(c.Request.Context(), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCouponJobNotFound):
+			appG.Error(http.StatusNotFound, e.INVALID_PARAMS)
+		case errors.Is(err, service.ErrCouponJobNotCancelable):
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+		default:
+			appG.Error(http.StatusInternalServerError, e.ERROR)
+		}
+		return
+	}
+	h.recordAudit(c, model.AdminResourceCoupons, "cancel_job", strconv.Itoa(id), nil, "")
+	appG.Success(job)
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type adminPenaltyResetReq struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+type adminPenaltyAppealReq struct {
+	Approve bool   `json:"approve"`
+	Reply   string `json:"reply" binding:"max=255"`
+}
+
+// ListPenalties 未支付处罚列表
+// @Summary 未支付处罚列表
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页条数" default(20)
+// @Param appeal_status query string false "pending/approved/rejected"
+// @Param active query bool false "仅返回暂停期内或灰名单中的用户"
+// @Success 200 {object} app.Response{data=app.PageData}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/penalties [get]
+func (h *AdminHandler) ListPenalties(c *gin.Context) {
+	appG := app.Gin{C: c}
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	activeOnly, err := strconv.ParseBool(c.DefaultQuery("active", "false"))
+	if err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	list, total, err := h.penaltySvc.List(c.Request.Context(), c.Query("appeal_status"), activeOnly, page, pageSize)
+	if err != nil {
+		if errors.Is(err, service.ErrPenaltyAppealStatusInvalid) {
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+			return
+		}
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// GetPenalty 用户处罚详情
+// @Summary 用户未支付处罚详情
+// @Description 当前处罚状态、申诉内容与最近 20 笔超时订单
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "用户ID"
+// @Success 200 {object} app.Response{data=service.PenaltyView}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/penalties/{user_id} [get]
+func (h *AdminHandler) GetPenalty(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := pathUserID(appG)
+	if !ok {
+		return
+	}
+	view, err := h.penaltySvc.Detail(c.Request.Context(), userID)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(view)
+}
+
+// ResetPenalty 解除处罚
+// @Summary 解除未支付处罚
+// @Description 清零超时计数并解除暂停抢购、候补降级与自动加入的灰名单；待处理申诉视为通过
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "用户ID"
+// @Param payload body adminPenaltyResetReq false "解除原因"
+// @Success 200 {object} app.Response{data=service.PenaltyView}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "无处罚记录"
+// @Router /admin/penalties/{user_id}/reset [post]
+func (h *AdminHandler) ResetPenalty(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := pathUserID(appG)
+	if !ok {
+		return
+	}
+	var req adminPenaltyResetReq
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+			return
+		}
+	}
+
+	view, err := h.penaltySvc.Reset(c.Request.Context(), userID, req.Reason)
+	if err != nil {
+		if errors.Is(err, service.ErrPenaltyNotFound) {
+			appG.ErrorMsg(http.StatusNotFound, e.INVALID_PARAMS, err.Error())
+			return
+		}
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceRisk, "reset_penalty", c.Param("user_id"), req, "")
+	appG.Success(view)
+}
+
+// ResolvePenaltyAppeal 处理申诉
+// @Summary 处理未支付处罚申诉
+// @Description approve=true 时解除全部处罚并清零计数，否则驳回并通知用户
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "用户ID"
+// @Param payload body adminPenaltyAppealReq true "处理结果"
+// @Success 200 {object} app.Response{data=service.PenaltyView}
+// @Failure 400 {object} app.Response "参数错误或无待处理申诉"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/penalties/{user_id}/appeal [post]
+func (h *AdminHandler) ResolvePenaltyAppeal(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := pathUserID(appG)
+	if !ok {
+		return
+	}
+	var req adminPenaltyAppealReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	view, err := h.penaltySvc.ResolveAppeal(c.Request.Context(), userID, req.Approve, req.Reply)
+	if err != nil {
+		if errors.Is(err, service.ErrPenaltyAppealNotPending) {
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+			return
+		}
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	action := "reject_appeal"
+	if req.Approve {
+		action = "approve_appeal"
+	}
+	h.recordAudit(c, model.AdminResourceRisk, action, c.Param("user_id"), req, "")
+	appG.Success(view)
+}
+
+func pathUserID(appG app.Gin) (uint, bool) {
+	id, err := strconv.Atoi(appG.C.Param("user_id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type adminProductReasonReq struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// ApproveProduct 审核通过商品
+// @Summary 审核通过商品
+// @Description 待审核商品审核通过后立即上架，并通知卖家
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=model.Product}
+// @Failure 400 {object} app.Response "不是待审核状态"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /admin/products/{id}/approve [post]
+func (h *AdminHandler) ApproveProduct(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	product, err := h.adminSvc.ApproveProduct(c.Request.Context(), uint(id))
+	if err != nil {
+		productReviewError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "approve", strconv.Itoa(id), nil, "")
+	appG.Success(product)
+}
+
+// RejectProduct 驳回商品
+// @Summary 驳回待审核商品
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param payload body adminProductReasonReq true "驳回原因"
+// @Success 200 {object} app.Response{data=model.Product}
+// @Failure 400 {object} app.Response "参数错误或不是待审核状态"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /admin/products/{id}/reject [post]
+func (h *AdminHandler) RejectProduct(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var req adminProductReasonReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	product, err := h.adminSvc.RejectProduct(c.Request.Context(), uint(id), req.Reason)
+	if err != nil {
+		productReviewError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "reject", strconv.Itoa(id), req, "")
+	appG.Success(product)
+}
+
+// OfflineProduct 强制下架商品
+// @Summary 强制下架商品
+// @Description 下架已上架或待审核的商品并通知卖家，卖家可修改后重新提交审核
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param payload body adminProductReasonReq true "下架原因"
+// @Success 200 {object} app.Response{data=model.Product}
+// @Failure 400 {object} app.Response "参数错误或当前状态不可下架"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /admin/products/{id}/offline [post]
+func (h *AdminHandler) OfflineProduct(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var req adminProductReasonReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	product, err := h.adminSvc.OfflineProduct(c.Request.Context(), uint(id), req.Reason)
+	if err != nil {
+		productReviewError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "offline", strconv.Itoa(id), req, "")
+	appG.Success(product)
+}
+
+func productReviewError(appG app.Gin, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
+	case errors.Is(err, service.ErrProductStatusConflict), errors.Is(err, service.ErrProductReasonEmpty):
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+	default:
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListReferrals 邀请记录
+// @Summary 邀请记录
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页条数" default(20)
+// @Param inviter_id query int false "邀请人 ID"
+// @Param status query string false "pending/rewarded/rejected/revoked"
+// @Success 200 {object} app.Response{data=app.PageData}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/referrals [get]
+func (h *AdminHandler) ListReferrals(c *gin.Context) {
+	appG := app.Gin{C: c}
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var inviterID uint
+	if raw := c.Query("inviter_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+			return
+		}
+		inviterID = uint(id)
+	}
+
+	list, total, err := h.referralSvc.List(c.Request.Context(), inviterID, c.Query("status"), page, pageSize)
+	if err != nil {
+		if errors.Is(err, service.ErrReferralStatusInvalid) {
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+			return
+		}
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// ReferralReport 邀请转化报表
+// @Summary 邀请转化报表
+// @Description 各状态邀请数、首单转化率与邀请人转化排行
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param top query int false "排行条数" default(10)
+// @Success 200 {object} app.Response{data=service.ReferralReport}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/referrals/report [get]
+func (h *AdminHandler) ReferralReport(c *gin.Context) {
+	appG := app.Gin{C: c}
+	top, _ := strconv.Atoi(c.DefaultQuery("top", "10"))
+
+	report, err := h.referralSvc.Report(c.Request.Context(), top)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(report)
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type adminSellerRejectReq struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// ListSellers 卖家入驻申请列表
+// @Summary 卖家入驻申请列表
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页条数" default(20)
+// @Param status query string false "pending/approved/rejected"
+// @Success 200 {object} app.Response{data=app.PageData}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/sellers [get]
+func (h *AdminHandler) ListSellers(c *gin.Context) {
+	appG := app.Gin{C: c}
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	list, total, err := h.sellerSvc.List(c.Request.Context(), c.Query("status"), page, pageSize)
+	if err != nil {
+		sellerError(appG, err)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// ApproveSeller 通过入驻申请
+// @Summary 通过卖家入驻申请
+// @Description 普通用户角色升级为 seller，重新登录或刷新 token 后生效；商品权限以入驻记录为准立即生效
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "申请人用户ID"
+// @Success 200 {object} app.Response{data=model.SellerProfile}
+// @Failure 400 {object} app.Response "不是待审核状态"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "申请不存在"
+// @Router /admin/sellers/{user_id}/approve [post]
+func (h *AdminHandler) ApproveSeller(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := pathUserID(appG)
+	if !ok {
+		return
+	}
+	profile, err := h.sellerSvc.Approve(c.Request.Context(), adminOperatorID(c), userID)
+	if err != nil {
+		sellerError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "approve_seller", c.Param("user_id"), nil, "")
+	appG.Success(profile)
+}
+
+// RejectSeller 驳回入驻申请
+// @Summary 驳回卖家入驻申请
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path int true "申请人用户ID"
+// @Param payload body adminSellerRejectReq true "驳回原因"
+// @Success 200 {object} app.Response{data=model.SellerProfile}
+// @Failure 400 {object} app.Response "参数错误或不是待审核状态"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "申请不存在"
+// @Router /admin/sellers/{user_id}/reject [post]
+func (h *AdminHandler) RejectSeller(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := pathUserID(appG)
+	if !ok {
+		return
+	}
+	var req adminSellerRejectReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	profile, err := h.sellerSvc.Reject(c.Request.Context(), adminOperatorID(c), userID, req.Reason)
+	if err != nil {
+		sellerError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceProducts, "reject_seller", c.Param("user_id"), req, "")
+	appG.Success(profile)
+}
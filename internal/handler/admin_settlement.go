@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type adminSettlementPayReq struct {
+	PayoutRef string `json:"payout_ref" binding:"required,max=64"`
+}
+
+// ListSettlements 结算单列表
+// @Summary 卖家结算单列表
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页条数" default(20)
+// @Param seller_id query int false "卖家用户ID"
+// @Param status query string false "pending/paid"
+// @Success 200 {object} app.Response{data=app.PageData}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/settlements [get]
+func (h *AdminHandler) ListSettlements(c *gin.Context) {
+	appG := app.Gin{C: c}
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var sellerID uint
+	if raw := c.Query("seller_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+			return
+		}
+		sellerID = uint(id)
+	}
+	list, total, err := h.settlementSvc.List(c.Request.Context(), sellerID, c.Query("status"), page, pageSize)
+	if err != nil {
+		settlementError(appG, err)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// GetSettlement 结算单明细
+// @Summary 卖家结算单明细
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "结算单ID"
+// @Success 200 {object} app.Response{data=service.SettlementDetail}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "结算单不存在"
+// @Router /admin/settlements/{id} [get]
+func (h *AdminHandler) GetSettlement(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	detail, err := h.settlementSvc.Detail(c.Request.Context(), 0, uint(id))
+	if err != nil {
+		settlementError(appG, err)
+		return
+	}
+	appG.Success(detail)
+}
+
+// RunSettlement 手动生成结算单
+// @Summary 手动生成上一自然周结算单
+// @Description 与每周定时任务相同，可重复执行，已结算订单不会重复计入
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=service.SettlementRunResult}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/settlements/run [post]
+func (h *AdminHandler) RunSettlement(c *gin.Context) {
+	appG := app.Gin{C: c}
+	result, err := h.settlementSvc.Generate(c.Request.Context(), time.Now())
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceSettlement, "run", result.PeriodStart.Format(time.DateOnly), nil, "")
+	appG.Success(result)
+}
+
+// PaySettlement 登记打款
+// @Summary 结算单登记打款
+// @Description 财务线下打款后登记流水号，结算单置为已打款并通知卖家
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "结算单ID"
+// @Param payload body adminSettlementPayReq true "打款流水号"
+// @Success 200 {object} app.Response{data=model.SettlementStatement}
+// @Failure 400 {object} app.Response "参数错误或不是待打款状态"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "结算单不存在"
+// @Router /admin/settlements/{id}/pay [post]
+func (h *AdminHandler) PaySettlement(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var req adminSettlementPayReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	statement, err := h.settlementSvc.MarkPaid(c.Request.Context(), adminOperatorID(c), uint(id), req.PayoutRef)
+	if err != nil {
+		settlementError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceSettlement, "pay", strconv.Itoa(id), req, "")
+	appG.Success(statement)
+}
+
+// ExportSettlements 导出结算单
+// @Summary 导出结算单 CSV
+// @Description 供财务批量打款，包含店铺名与联系人
+// @Tags 管理后台
+// @Produce text/csv
+// @Security BearerAuth
+// @Param status query string false "pending/paid"
+// @Param period_start query string false "结算周期开始日期，如 2026-10-05"
+// @Success 200 {file} file "CSV 文件"
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/settlements/export [get]
+func (h *AdminHandler) ExportSettlements(c *gin.Context) {
+	appG := app.Gin{C: c}
+	var periodStart *time.Time
+	if raw := c.Query("period_start"); raw != "" {
+		parsed, err := time.ParseInLocation(time.DateOnly, raw, time.Local)
+		if err != nil {
+			appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+			return
+		}
+		periodStart = &parsed
+	}
+	var buf bytes.Buffer
+	if err := h.settlementSvc.ExportStatements(c.Request.Context(), c.Query("status"), periodStart, &buf); err != nil {
+		settlementError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceSettlement, "export", c.Query("period_start"), nil, "")
+	sendCSV(c, fmt.Sprintf("settlements-%s.csv", time.Now().Format("20060102")), buf.Bytes())
+}
+
+// ExportSettlementItems 导出结算单明细
+// @Summary 导出结算单明细 CSV
+// @Tags 管理后台
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path int true "结算单ID"
+// @Success 200 {file} file "CSV 文件"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "结算单不存在"
+// @Router /admin/settlements/{id}/export [get]
+func (h *AdminHandler) ExportSettlementItems(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var buf bytes.Buffer
+	if err := h.settlementSvc.ExportItems(c.Request.Context(), 0, uint(id), &buf); err != nil {
+		settlementError(appG, err)
+		return
+	}
+	sendCSV(c, fmt.Sprintf("settlement-%d.csv", id), buf.Bytes())
+}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type adminVIPPlanReq struct {
+	Name         string `json:"name" binding:"required"`
+	Level        int    `json:"level" binding:"required"`
+	DurationDays int    `json:"duration_days" binding:"required"`
+	PriceCents   int64  `json:"price_cents"`
+	Status       string `json:"status"` // active / inactive
+}
+
+type adminVIPLevelReq struct {
+	MinSpentCents       int64  `json:"min_spent_cents"`
+	MonthlyCouponQuota  int    `json:"monthly_coupon_quota"`
+	CouponTitle         string `json:"coupon_title"`
+	CouponType          string `json:"coupon_type"` // full_cut / discount
+	CouponAmountCents   int64  `json:"coupon_amount_cents"`
+	CouponDiscountRate  int    `json:"coupon_discount_rate"`
+	CouponMinSpendCents int64  `json:"coupon_min_spend_cents"`
+	EarlyAccessMinutes  int    `json:"early_access_minutes"` // 秒杀提前入场分钟数
+}
+
+// GetVIPConfig 当前生效的 VIP 配置
+// @Summary VIP 配置
+// @Description 返回各套餐与成长等级的当前版本（含已下架套餐）
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=service.VIPConfig}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/vip/config [get]
+func (h *AdminHandler) GetVIPConfig(c *gin.Context) {
+	appG := app.Gin{C: c}
+	cfg, err := h.vipConfigSvc.Current(c.Request.Context())
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(cfg)
+}
+
+// CreateVIPPlan 新建付费套餐
+// @Summary 新建 VIP 套餐
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body adminVIPPlanReq true "套餐"
+// @Success 200 {object} app.Response{data=model.VIPPlan}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/vip/plans [post]
+func (h *AdminHandler) CreateVIPPlan(c *gin.Context) {
+	appG := app.Gin{C: c}
+	var req adminVIPPlanReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	plan, err := h.vipConfigSvc.CreatePlan(c.Request.Context(), req.toInput(), adminOperatorID(c))
+	if err != nil {
+		h.respondVIPConfigError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceVIP, "create_plan", strconv.Itoa(plan.PlanID), req, "")
+	appG.Success(plan)
+}
+
+// UpdateVIPPlan 修改付费套餐（发布新版本）
+// @Summary 修改 VIP 套餐
+// @Description 以新版本生效，已购用户保留购买时版本的条款
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id path int true "套餐ID"
+// @Param payload body adminVIPPlanReq true "套餐"
+// @Success 200 {object} app.Response{data=model.VIPPlan}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "资源不存在"
+// @Router /admin/vip/plans/{plan_id} [put]
+func (h *AdminHandler) UpdateVIPPlan(c *gin.Context) {
+	appG := app.Gin{C: c}
+	planID, err := strconv.Atoi(c.Param("plan_id"))
+	if err != nil || planID <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var req adminVIPPlanReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	plan, err := h.vipConfigSvc.UpdatePlan(c.Request.Context(), planID, req.toInput(), adminOperatorID(c))
+	if err != nil {
+		h.respondVIPConfigError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceVIP, "update_plan", strconv.Itoa(planID), req, "")
+	appG.Success(plan)
+}
+
+// ListVIPPlanVersions 套餐历史版本
+// @Summary VIP 套餐历史版本
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param plan_id path int true "套餐ID"
+// @Success 200 {object} app.Response{data=[]model.VIPPlan}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "资源不存在"
+// @Router /admin/vip/plans/{plan_id}/versions [get]
+func (h *AdminHandler) ListVIPPlanVersions(c *gin.Context) {
+	appG := app.Gin{C: c}
+	planID, err := strconv.Atoi(c.Param("plan_id"))
+	if err != nil || planID <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	plans, err := h.vipConfigSvc.ListPlanVersions(c.Request.Context(), planID)
+	if err != nil {
+		h.respondVIPConfigError(appG, err)
+		return
+	}
+	appG.Success(plans)
+}
+
+// SaveVIPLevel 新增或修改成长等级配置（发布新版本）
+// @Summary 保存 VIP 等级配置
+// @Description 配置成长门槛、月度发券配额与月度券规格
+// @Tags 管理后台
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param level path int true "等级"
+// @Param payload body adminVIPLevelReq true "等级配置"
+// @Success 200 {object} app.Response{data=model.VIPLevelConfig}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Router /admin/vip/levels/{level} [put]
+func (h *AdminHandler) SaveVIPLevel(c *gin.Context) {
+	appG := app.Gin{C: c}
+	level, err := strconv.Atoi(c.Param("level"))
+	if err != nil || level <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var req adminVIPLevelReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	cfg, err := h.vipConfigSvc.SaveLevel(c.Request.Context(), level, service.VIPLevelInput{
+		MinSpentCents:       req.MinSpentCents,
+		MonthlyCouponQuota:  req.MonthlyCouponQuota,
+		CouponTitle:         req.CouponTitle,
+		CouponType:          model.CouponType(strings.TrimSpace(req.CouponType)),
+		CouponAmountCents:   req.CouponAmountCents,
+		CouponDiscountRate:  req.CouponDiscountRate,
+		CouponMinSpendCents: req.CouponMinSpendCents,
+		EarlyAccessMinutes:  req.EarlyAccessMinutes,
+	}, adminOperatorID(c))
+	if err != nil {
+		h.respondVIPConfigError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceVIP, "save_level", strconv.Itoa(level), req, "")
+	appG.Success(cfg)
+}
+
+// DeleteVIPLevel 下线成长等级
+// @Summary 删除 VIP 等级配置
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param level path int true "等级"
+// @Success 200 {object} app.Response{data=MessageResponse}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "资源不存在"
+// @Router /admin/vip/levels/{level} [delete]
+func (h *AdminHandler) DeleteVIPLevel(c *gin.Context) {
+	appG := app.Gin{C: c}
+	level, err := strconv.Atoi(c.Param("level"))
+	if err != nil || level <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	if err := h.vipConfigSvc.DeleteLevel(c.Request.Context(), level); err != nil {
+		h.respondVIPConfigError(appG, err)
+		return
+	}
+	h.recordAudit(c, model.AdminResourceVIP, "delete_level", strconv.Itoa(level), nil, "")
+	appG.Success(gin.H{"message": "ok"})
+}
+
+// ListVIPLevelVersions 等级配置历史版本
+// @Summary VIP 等级配置历史版本
+// @Tags 管理后台
+// @Produce json
+// @Security BearerAuth
+// @Param level path int true "等级"
+// @Success 200 {object} app.Response{data=[]model.VIPLevelConfig}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "需要管理员权限"
+// @Failure 404 {object} app.Response "资源不存在"
+// @Router /admin/vip/levels/{level}/versions [get]
+func (h *AdminHandler) ListVIPLevelVersions(c *gin.Context) {
+	appG := app.Gin{C: c}
+	level, err := strconv.Atoi(c.Param("level"))
+	if err != nil || level <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	levels, err := h.vipConfigSvc.ListLevelVersions(c.Request.Context(), level)
+	if err != nil {
+		h.respondVIPConfigError(appG, err)
+		return
+	}
+	appG.Success(levels)
+}
+
+func (h *AdminHandler) respondVIPConfigError(appG app.Gin, err error) {
+	switch {
+	case errors.Is(err, service.ErrVIPPlanNotFound), errors.Is(err, service.ErrVIPLevelNotFound):
+		appG.Error(http.StatusNotFound, e.INVALID_PARAMS)
+	case errors.Is(err, service.ErrVIPPlanInvalid),
+		errors.Is(err, service.ErrVIPLevelInvalid),
+		errors.Is(err, service.ErrVIPLevelThreshold),
+		errors.Is(err, service.ErrVIPLevelBaseDelete):
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+	default:
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+	}
+}
+
+func (r adminVIPPlanReq) toInput() service.VIPPlanInput {
+	return service.VIPPlanInput{
+		Name:         r.Name,
+		Level:        r.Level,
+		DurationDays: r.DurationDays,
+		PriceCents:   r.PriceCents,
+		Status:       strings.TrimSpace(r.Status),
+	}
+}
+
+// adminOperatorID 读取当前操作管理员 ID。
+func adminOperatorID(c *gin.Context) uint {
+	uid, _ := c.Get("userID")
+	userID, _ := uid.(uint)
+	return userID
+}
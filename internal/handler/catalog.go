@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogHandler struct {
+	svc *service.CatalogService
+}
+
+func NewCatalogHandler(svc *service.CatalogService) *CatalogHandler {
+	return &CatalogHandler{svc: svc}
+}
+
+// Brands 品牌列表
+// @Summary 品牌列表
+// @Tags 商品
+// @Produce json
+// @Success 200 {object} app.Response{data=[]model.Brand}
+// @Router /brands [get]
+func (h *CatalogHandler) Brands(c *gin.Context) {
+	appG := app.Gin{C: c}
+	brands, err := h.svc.ListBrands(c.Request.Context())
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(brands)
+}
+
+// Categories 分类树
+// @Summary 商品分类树
+// @Tags 商品
+// @Produce json
+// @Success 200 {object} app.Response{data=[]model.Category}
+// @Router /categories [get]
+func (h *CatalogHandler) Categories(c *gin.Context) {
+	appG := app.Gin{C: c}
+	tree, err := h.svc.CategoryTree(c.Request.Context())
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(tree)
+}
+
+func catalogError(appG app.Gin, err error) {
+	switch {
+	case errors.Is(err, service.ErrBrandNotFound),
+		errors.Is(err, service.ErrCategoryNotFound):
+		appG.ErrorMsg(http.StatusNotFound, e.INVALID_PARAMS, err.Error())
+	case errors.Is(err, service.ErrCatalogNameInvalid),
+		errors.Is(err, service.ErrBrandDuplicate),
+		errors.Is(err, service.ErrBrandInUse),
+		errors.Is(err, service.ErrCategoryDuplicate),
+		errors.Is(err, service.ErrCategoryInUse),
+		errors.Is(err, service.ErrCategoryTooDeep):
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+	default:
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+	}
+}
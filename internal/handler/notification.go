@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	svc *service.NotificationService
+}
+
+func NewNotificationHandler(svc *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{svc: svc}
+}
+
+type NotificationPreferenceReq struct {
+	InApp     bool   `json:"in_app"`
+	Email     bool   `json:"email"`
+	SMS       bool   `json:"sms"`
+	EmailAddr string `json:"email_addr" binding:"omitempty,email,max=128"`
+	Phone     string `json:"phone" binding:"omitempty,max=32"`
+}
+
+// List 站内信列表
+// @Summary 站内信列表
+// @Description 按时间倒序返回站内信，unread=true 时只返回未读
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "仅未读"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} app.Response{data=app.PageData}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /notifications [get]
+func (h *NotificationHandler) List(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	unreadOnly, err := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+	if err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	list, total, err := h.svc.List(ctx, userID, unreadOnly, page, pageSize)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// UnreadCount 未读数量
+// @Summary 未读站内信数量
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=map[string]int64}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /notifications/unread-count [get]
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	count, err := h.svc.UnreadCount(ctx, userID)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(gin.H{"unread": count})
+}
+
+// MarkRead 标记已读
+// @Summary 标记单条站内信已读
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "通知ID"
+// @Success 200 {object} app.Response
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "通知不存在"
+// @Router /notifications/{id}/read [post]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	if err := h.svc.MarkRead(ctx, userID, uint(id)); err != nil {
+		if errors.Is(err, service.ErrNotificationNotFound) {
+			appG.ErrorMsg(http.StatusNotFound, e.INVALID_PARAMS, err.Error())
+			return
+		}
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(nil)
+}
+
+// MarkAllRead 全部已读
+// @Summary 全部标记已读
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=map[string]int64}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /notifications/read-all [post]
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	updated, err := h.svc.MarkAllRead(ctx, userID)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(gin.H{"updated": updated})
+}
+
+// GetPreference 通知偏好
+// @Summary 查询通知渠道偏好
+// @Description 未设置时默认仅开启站内推送；站内信始终写入收件箱
+// @Tags 通知
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=service.NotificationPreferenceView}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /notifications/preferences [get]
+func (h *NotificationHandler) GetPreference(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	pref, err := h.svc.GetPreference(ctx, userID)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(pref)
+}
+
+// UpdatePreference 更新通知偏好
+// @Summary 更新通知渠道偏好
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body NotificationPreferenceReq true "渠道开关与联系方式"
+// @Success 200 {object} app.Response{data=service.NotificationPreferenceView}
+// @Failure 400 {object} app.Response "参数错误或缺少联系方式"
+// @Failure 401 {object} app.Response "未登录"
+// @Router /notifications/preferences [put]
+func (h *NotificationHandler) UpdatePreference(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	var req NotificationPreferenceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	pref, err := h.svc.UpdatePreference(ctx, userID, service.NotificationPreferenceView{
+		InApp:     req.InApp,
+		Email:     req.Email,
+		SMS:       req.SMS,
+		EmailAddr: req.EmailAddr,
+		Phone:     req.Phone,
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrNotificationRecipientMiss) {
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+			return
+		}
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(pref)
+}
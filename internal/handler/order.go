@@ -27,6 +27,10 @@ type ApplyCouponReq struct {
 	CouponID *uint `json:"coupon_id" binding:"omitempty"`
 }
 
+type ApplyPointsReq struct {
+	Points int64 `json:"points" binding:"min=0"`
+}
+
 type PollOrderResponse struct {
 	Status    string                    `json:"status"`
 	OrderNum  string                    `json:"order_num"`
@@ -248,6 +252,115 @@ func (h *OrderHandler) ApplyCoupon(c *gin.Context) {
 	appG.Success(result)
 }
 
+// ApplyPoints 在订单支付前使用积分抵扣
+// @Summary 订单积分抵扣
+// @Description points 为 0 表示取消抵扣；抵扣金额不超过订单金额的配置比例
+// @Tags 订单
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订单ID"
+// @Param payload body ApplyPointsReq true "抵扣积分数"
+// @Success 200 {object} app.Response{data=OrderWithPaymentResponse}
+// @Failure 400 {object} app.Response "参数错误、积分不足或状态不允许"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "订单不存在"
+// @Router /orders/{id}/apply-points [post]
+func (h *OrderHandler) ApplyPoints(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	var req ApplyPointsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	result, err := h.orderSvc.ApplyPoints(ctx, userID, uint(id), req.Points)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			appG.Error(http.StatusNotFound, e.INVALID_PARAMS)
+		case errors.Is(err, service.ErrOrderNotPayable):
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, "订单状态不可支付")
+		default:
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+		}
+		return
+	}
+
+	appG.Success(result)
+}
+
+// CouponOptions 订单可用优惠券试算
+// @Summary 订单可用优惠券
+// @Description 按实际核销规则试算用户所有可用券，返回优惠后金额或不可用原因，最优在前
+// @Tags 订单
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订单ID"
+// @Success 200 {object} app.Response{data=service.OrderCouponOptions}
+// @Failure 400 {object} app.Response "参数错误或状态不允许"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "订单不存在"
+// @Router /orders/{id}/coupon-options [get]
+func (h *OrderHandler) CouponOptions(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	result, err := h.orderSvc.CouponOptions(ctx, userID, uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			appG.Error(http.StatusNotFound, e.INVALID_PARAMS)
+		case errors.Is(err, service.ErrOrderNotPayable):
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, "订单状态不可支付")
+		case errors.Is(err, service.ErrVIPOrderNoCoupon):
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+		default:
+			appG.Error(http.StatusInternalServerError, e.ERROR)
+		}
+		return
+	}
+
+	appG.Success(result)
+}
+
 // PaymentCallback 支付回调（模拟验签已完成）
 // @Summary 支付回调
 // @Tags 订单
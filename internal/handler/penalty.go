@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PenaltyHandler struct {
+	svc *service.PenaltyService
+}
+
+func NewPenaltyHandler(svc *service.PenaltyService) *PenaltyHandler {
+	return &PenaltyHandler{svc: svc}
+}
+
+type PenaltyAppealReq struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// Status 我的抢购限制
+// @Summary 未支付处罚状态
+// @Description 窗口内超时未支付次数、候补降级、暂停抢购、灰名单与申诉进度
+// @Tags 风控
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=service.PenaltyView}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /penalty [get]
+func (h *PenaltyHandler) Status(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	view, err := h.svc.Status(c.Request.Context(), userID)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(view)
+}
+
+// Appeal 提交申诉
+// @Summary 申诉未支付处罚
+// @Description 存在生效中的处罚时提交申诉，同一时间只能有一条待处理申诉；灰名单用户也可访问
+// @Tags 风控
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body PenaltyAppealReq true "申诉理由"
+// @Success 200 {object} app.Response{data=service.PenaltyView}
+// @Failure 400 {object} app.Response "无生效处罚或申诉处理中"
+// @Failure 401 {object} app.Response "未登录"
+// @Router /penalty/appeal [post]
+func (h *PenaltyHandler) Appeal(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	var req PenaltyAppealReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	view, err := h.svc.Appeal(c.Request.Context(), userID, req.Reason)
+	if err != nil {
+		if errors.Is(err, service.ErrPenaltyNotFound) || errors.Is(err, service.ErrPenaltyAppealPending) {
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+			return
+		}
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(view)
+}
+
+func currentUserID(appG app.Gin) (uint, bool) {
+	userIDAny, exists := appG.C.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return 0, false
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return 0, false
+	}
+	return userID, true
+}
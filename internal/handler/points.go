@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PointsHandler struct {
+	svc *service.PointsService
+}
+
+func NewPointsHandler(svc *service.PointsService) *PointsHandler {
+	return &PointsHandler{svc: svc}
+}
+
+type RedeemCouponReq struct {
+	CouponID uint `json:"coupon_id" binding:"required"`
+}
+
+// GetSummary 我的积分
+// @Summary 我的积分
+// @Description 返回可用积分、30 天内即将过期积分及抵扣规则
+// @Tags 积分
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=service.PointsSummary}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /points [get]
+func (h *PointsHandler) GetSummary(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	summary, err := h.svc.Summary(ctx, userID)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(summary)
+}
+
+// ListHistory 积分流水
+// @Summary 积分流水
+// @Tags 积分
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} app.Response{data=app.PageData}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /points/history [get]
+func (h *PointsHandler) ListHistory(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	list, total, err := h.svc.History(ctx, userID, page, pageSize)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// RedeemCoupon 积分兑换优惠券
+// @Summary 积分兑换优惠券
+// @Tags 积分
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body RedeemCouponReq true "coupon_id"
+// @Success 200 {object} app.Response{data=service.MyCoupon}
+// @Failure 400 {object} app.Response "积分不足或券不可兑换"
+// @Failure 401 {object} app.Response "未登录"
+// @Router /points/redeem-coupon [post]
+func (h *PointsHandler) RedeemCoupon(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	var req RedeemCouponReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	uc, err := h.svc.RedeemCoupon(ctx, userID, req.CouponID)
+	if err != nil {
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+		return
+	}
+	appG.Success(uc)
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PriceHandler struct {
+	svc *service.PriceService
+}
+
+func NewPriceHandler(svc *service.PriceService) *PriceHandler {
+	return &PriceHandler{svc: svc}
+}
+
+type PriceScheduleCreateReq struct {
+	Price       float64 `json:"price" binding:"required,gt=0" example:"1299.00"`
+	EffectiveAt string  `json:"effective_at" binding:"required" example:"2025-12-11 10:00:00"`
+}
+
+// History 价格历史
+// @Summary 商品价格变动记录
+// @Description 包含创建时的初始价格、卖家改价与调价计划生效，最新的在前
+// @Tags 商品
+// @Produce json
+// @Param id path int true "商品ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页条数" default(20)
+// @Success 200 {object} app.Response{data=[]model.PriceHistory}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 404 {object} app.Response "商品不存在"
+// @Router /products/{id}/price-history [get]
+func (h *PriceHandler) History(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	list, total, err := h.svc.PriceHistory(c.Request.Context(), uint(id), page, pageSize)
+	if err != nil {
+		priceError(appG, err)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// ListSchedules 调价计划列表
+// @Summary 商品的调价计划
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页条数" default(20)
+// @Success 200 {object} app.Response{data=[]model.PriceSchedule}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "商品不存在"
+// @Router /products/{id}/price-schedules [get]
+func (h *PriceHandler) ListSchedules(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	list, total, err := h.svc.ListSchedules(c.Request.Context(), userID, productID, page, pageSize)
+	if err != nil {
+		priceError(appG, err)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// CreateSchedule 新增调价计划
+// @Summary 预设调价
+// @Description 到达生效时间后自动改价；创建时校验新价格，商品当前状态与价格不变
+// @Tags 卖家
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param payload body PriceScheduleCreateReq true "新价格与生效时间"
+// @Success 200 {object} app.Response{data=model.PriceSchedule}
+// @Failure 400 {object} app.Response "价格或时间无效、计划数量已达上限"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "不是审核通过的卖家"
+// @Failure 404 {object} app.Response "商品不存在"
+// @Router /products/{id}/price-schedules [post]
+func (h *PriceHandler) CreateSchedule(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	var req PriceScheduleCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	effectiveAt, err := parseStartTime(req.EffectiveAt)
+	if err != nil {
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, "生效时间格式错误")
+		return
+	}
+	schedule, err := h.svc.CreateSchedule(c.Request.Context(), userID, productID, req.Price, effectiveAt)
+	if err != nil {
+		priceError(appG, err)
+		return
+	}
+	appG.Success(schedule)
+}
+
+// CancelSchedule 取消调价计划
+// @Summary 取消尚未执行的调价计划
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param schedule_id path int true "调价计划ID"
+// @Success 200 {object} app.Response
+// @Failure 400 {object} app.Response "计划已执行或已取消"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "商品或计划不存在"
+// @Router /products/{id}/price-schedules/{schedule_id} [delete]
+func (h *PriceHandler) CancelSchedule(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	scheduleID, err := strconv.Atoi(c.Param("schedule_id"))
+	if err != nil || scheduleID <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	if err := h.svc.CancelSchedule(c.Request.Context(), userID, productID, uint(scheduleID)); err != nil {
+		priceError(appG, err)
+		return
+	}
+	appG.Success(gin.H{"id": scheduleID})
+}
+
+func priceError(appG app.Gin, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
+	case errors.Is(err, service.ErrPriceScheduleNotFound):
+		appG.ErrorMsg(http.StatusNotFound, e.INVALID_PARAMS, err.Error())
+	case errors.Is(err, service.ErrSellerNotApproved):
+		appG.ErrorMsg(http.StatusForbidden, e.UNAUTHORIZED, err.Error())
+	case errors.Is(err, service.ErrPriceInvalid),
+		errors.Is(err, service.ErrPriceScheduleTime),
+		errors.Is(err, service.ErrPriceScheduleLimit),
+		errors.Is(err, service.ErrPriceScheduleConflict),
+		errors.Is(err, service.ErrPriceScheduleFinished),
+		errors.Is(err, service.ErrMemberPriceInvalid):
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+	default:
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+	}
+}
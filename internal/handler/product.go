@@ -4,11 +4,13 @@ import (
 	"SneakerFlash/internal/model"
 	"SneakerFlash/internal/pkg/app"
 	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/repository"
 	"SneakerFlash/internal/service"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,30 +18,59 @@ import (
 )
 
 type ProductHandler struct {
-	svc *service.ProductService
+	svc     *service.ProductService
+	vipSvc  *service.VIPService
+	dropSvc *service.DropService
 }
 
 type CreateProductReq struct {
-	Name      string  `json:"name" binding:"required" example:"限量球鞋"`
-	Price     float64 `json:"price" binding:"required,gt=0" example:"999.00"`
-	Stock     int     `json:"stock" binding:"required,gt=0" example:"100"`
-	StartTime string  `json:"start_time" binding:"required" example:"2025-12-10 10:00:00"`
-	EndTime   string  `json:"end_time" example:"2025-12-10 12:00:00"` // 可选，结束时间，不设置则永不过期
-	Image     string  `json:"image" example:"https://example.com/shoe.jpg"`
+	Name        string   `json:"name" binding:"required" example:"限量球鞋"`
+	Price       float64  `json:"price" binding:"required,gt=0" example:"999.00"`
+	Stock       int      `json:"stock" binding:"required,gt=0" example:"100"`
+	StartTime   string   `json:"start_time" binding:"required" example:"2025-12-10 10:00:00"`
+	EndTime     string   `json:"end_time" example:"2025-12-10 12:00:00"`       // 可选，结束时间，不设置则永不过期
+	Image       string   `json:"image" example:"https://example.com/shoe.jpg"` // 可选，封面图；传 images 时以其第一张为准
+	Images      []string `json:"images" binding:"omitempty,max=9"`             // 可选，按展示顺序排列的图片地址
+	Description string   `json:"description" binding:"max=5000"`
+	BrandID     uint     `json:"brand_id" example:"1"`    // 可选，品牌ID
+	CategoryID  uint     `json:"category_id" example:"3"` // 可选，分类ID
+	Tags        []string `json:"tags" example:"复刻,联名"`
+	SKU         string   `json:"sku" binding:"max=64" example:"DD1391-100"`           // 可选，货号
+	ReleaseDate string   `json:"release_date" example:"2021-03-10"`                   // 可选，官方发售日期
+	RetailPrice float64  `json:"retail_price" binding:"gte=0" example:"799.00"`       // 可选，官方发售价
+	MinVIPLevel int      `json:"min_vip_level" binding:"omitempty,gte=0" example:"0"` // 可选，最低可购 VIP 等级，0 不限
+	// 可选，会员价规则与是否可叠加优惠券
+	MemberPrices          model.MemberPrices `json:"member_prices"`
+	MemberCouponStackable bool               `json:"member_coupon_stackable"`
+	Draft                 bool               `json:"draft"` // 可选，true 保存为草稿，默认直接提交审核
 }
 
 type UpdateProductReq struct {
-	Name      *string  `json:"name" binding:"omitempty" example:"限量球鞋"`
-	Price     *float64 `json:"price" binding:"omitempty,gt=0" example:"999.00"`
-	Stock     *int     `json:"stock" binding:"omitempty,gt=0" example:"100"`
-	StartTime *string  `json:"start_time" binding:"omitempty" example:"2025-12-10 10:00:00"`
-	EndTime   *string  `json:"end_time" binding:"omitempty" example:"2025-12-10 12:00:00"` // 可选，结束时间，空字符串清除
-	Image     *string  `json:"image" example:"https://example.com/shoe.jpg"`
+	Name        *string   `json:"name" binding:"omitempty" example:"限量球鞋"`
+	Price       *float64  `json:"price" binding:"omitempty,gt=0" example:"999.00"`
+	Stock       *int      `json:"stock" binding:"omitempty,gt=0" example:"100"`
+	StartTime   *string   `json:"start_time" binding:"omitempty" example:"2025-12-10 10:00:00"`
+	EndTime     *string   `json:"end_time" binding:"omitempty" example:"2025-12-10 12:00:00"` // 可选，结束时间，空字符串清除
+	Image       *string   `json:"image" example:"https://example.com/shoe.jpg"`               // 可选，仅替换封面，空字符串移除封面
+	Images      *[]string `json:"images" binding:"omitempty,max=9"`                           // 可选，传入即整体替换图集
+	Description *string   `json:"description" binding:"omitempty,max=5000"`
+	BrandID     *uint     `json:"brand_id" example:"1"`    // 可选，0 清除品牌
+	CategoryID  *uint     `json:"category_id" example:"3"` // 可选，0 清除分类
+	Tags        *[]string `json:"tags"`                    // 可选，传入即整体替换，空数组表示清除
+	SKU         *string   `json:"sku" binding:"omitempty,max=64" example:"DD1391-100"`
+	ReleaseDate *string   `json:"release_date" example:"2021-03-10"` // 可选，空字符串清除
+	RetailPrice *float64  `json:"retail_price" binding:"omitempty,gte=0" example:"799.00"`
+	MinVIPLevel *int      `json:"min_vip_level" binding:"omitempty,gte=0" example:"0"` // 可选，最低可购 VIP 等级，0 不限
+	// 可选，传入即整体替换会员价，空数组表示清除
+	MemberPrices          *model.MemberPrices `json:"member_prices"`
+	MemberCouponStackable *bool               `json:"member_coupon_stackable"`
 }
 
-func NewProductHandler(svc *service.ProductService) *ProductHandler {
+func NewProductHandler(svc *service.ProductService, vipSvc *service.VIPService, dropSvc *service.DropService) *ProductHandler {
 	return &ProductHandler{
-		svc: svc,
+		svc:     svc,
+		vipSvc:  vipSvc,
+		dropSvc: dropSvc,
 	}
 }
 
@@ -63,6 +94,7 @@ func parseStartTime(raw string) (time.Time, error) {
 
 // Create 发布商品
 // @Summary 发布商品
+// @Description 商品创建后进入待审核（draft=true 时为草稿），审核通过后才对外展示与抢购
 // @Tags 商品
 // @Accept json
 // @Produce json
@@ -71,6 +103,7 @@ func parseStartTime(raw string) (time.Time, error) {
 // @Success 200 {object} app.Response{data=model.Product}
 // @Failure 400 {object} app.Response "参数错误"
 // @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "不是审核通过的卖家"
 // @Router /products [post]
 func (h *ProductHandler) Create(c *gin.Context) {
 	appG := app.Gin{C: c}
@@ -109,20 +142,52 @@ func (h *ProductHandler) Create(c *gin.Context) {
 		endTime = &et
 	}
 
+	var releaseDate *time.Time
+	if req.ReleaseDate != "" {
+		rd, err := time.Parse(time.DateOnly, req.ReleaseDate)
+		if err != nil {
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, "发售日期格式不正确（格式示例：2021-03-10）")
+			return
+		}
+		releaseDate = &rd
+	}
+
 	p := &model.Product{
-		UserID:    userID,
-		Name:      req.Name,
-		Price:     req.Price,
-		Stock:     req.Stock,
-		StartTime: startTime,
-		EndTime:   endTime,
-		Image:     req.Image,
+		UserID:                userID,
+		Name:                  req.Name,
+		Price:                 req.Price,
+		Stock:                 req.Stock,
+		StartTime:             startTime,
+		EndTime:               endTime,
+		Image:                 req.Image,
+		Images:                productImagesFromURLs(req.Images),
+		Description:           strings.TrimSpace(req.Description),
+		BrandID:               req.BrandID,
+		CategoryID:            req.CategoryID,
+		Tags:                  req.Tags,
+		SKU:                   strings.TrimSpace(req.SKU),
+		ReleaseDate:           releaseDate,
+		RetailPrice:           req.RetailPrice,
+		MinVIPLevel:           req.MinVIPLevel,
+		MemberPrices:          req.MemberPrices,
+		MemberCouponStackable: req.MemberCouponStackable,
+	}
+	if req.Draft {
+		p.Status = model.ProductStatusDraft
 	}
 
 	if err := h.svc.CreateProduct(ctx, p); err != nil {
 		switch {
+		case errors.Is(err, service.ErrSellerNotApproved):
+			appG.ErrorMsg(http.StatusForbidden, e.UNAUTHORIZED, err.Error())
 		case errors.Is(err, service.ErrProductDuplicate):
 			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, "商品已存在，请勿重复提交")
+		case errors.Is(err, service.ErrMemberPriceInvalid),
+			errors.Is(err, service.ErrBrandNotFound),
+			errors.Is(err, service.ErrCategoryNotFound),
+			errors.Is(err, service.ErrProductTagsInvalid),
+			errors.Is(err, service.ErrProductImagesInvalid):
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
 		default:
 			appG.Error(http.StatusInternalServerError, e.ERROR)
 		}
@@ -165,10 +230,67 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 	appG.Success(p)
 }
 
+// GetAccess 查询当前用户对商品的抢购资格
+// @Summary 我的抢购资格
+// @Description 按用户生效 VIP 等级返回是否满足商品等级门槛、提前入场后的个人开抢时间，以及原价与该用户可享的会员价
+// @Tags 商品
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=service.DropAccess}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /products/{id}/access [get]
+func (h *ProductHandler) GetAccess(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+	uidAny, ok := c.Get("userID")
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID := uidAny.(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	p, err := h.svc.GetProductByID(ctx, uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrProductNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+			appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
+		default:
+			appG.Error(http.StatusInternalServerError, e.ERROR)
+		}
+		return
+	}
+
+	access, err := h.vipSvc.DropAccess(ctx, userID, p)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(access)
+}
+
 // ListProducts 获取商品列表
-// @Summary 获取商品列表
+// @Summary 检索商品列表
+// @Description 仅返回已上架商品；前 3 页结果缓存 30 秒，商品变更时失效
 // @Tags 商品
 // @Produce json
+// @Param keyword query string false "名称、描述或货号关键字"
+// @Param brand_id query int false "品牌ID"
+// @Param category_id query int false "分类ID，包含下级分类"
+// @Param tag query string false "标签"
+// @Param min_price query number false "最低价格"
+// @Param max_price query number false "最高价格"
+// @Param sale_status query string false "upcoming/live/sold_out/ended"
+// @Param seller_id query int false "卖家用户ID"
+// @Param sort query string false "newest/start_time_asc/start_time_desc/price_asc/price_desc/popular" default(newest)
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页条数" default(10)
 // @Success 200 {object} app.Response{data=ProductListResponse}
@@ -177,28 +299,103 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 func (h *ProductHandler) ListProducts(c *gin.Context) {
 	appG := app.Gin{C: c}
 	ctx := c.Request.Context()
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil {
-		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
-		return
-	}
-	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-	if err != nil {
+	filter, ok := parseProductFilter(c)
+	if !ok {
 		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
 		return
 	}
 
-	list, total, err := h.svc.ListProducts(ctx, page, pageSize)
+	list, total, err := h.svc.SearchProducts(ctx, filter)
 	if err != nil {
 		appG.Error(http.StatusInternalServerError, e.ERROR)
 		return
 	}
 
-	appG.SuccessWithPage(list, total, page, pageSize)
+	appG.SuccessWithPage(list, total, filter.Page, filter.PageSize)
+}
+
+// productImagesFromURLs 由图片地址构造图集，变体地址由服务层补全。
+func productImagesFromURLs(urls []string) model.ProductImages {
+	images := make(model.ProductImages, 0, len(urls))
+	for _, url := range urls {
+		images = append(images, model.ProductImage{URL: url})
+	}
+	return images
+}
+
+// parseProductFilter 解析商品目录查询参数，未传的条件不过滤。
+func parseProductFilter(c *gin.Context) (repository.ProductFilter, bool) {
+	filter := repository.ProductFilter{
+		Keyword:    c.Query("keyword"),
+		Tag:        c.Query("tag"),
+		SaleStatus: model.ProductSaleStatus(c.Query("sale_status")),
+		Sort:       repository.ProductSort(c.Query("sort")),
+	}
+	var err error
+	var ok bool
+	if filter.Page, err = strconv.Atoi(c.DefaultQuery("page", "1")); err != nil || filter.Page <= 0 {
+		return filter, false
+	}
+	if filter.PageSize, err = strconv.Atoi(c.DefaultQuery("page_size", "10")); err != nil || filter.PageSize <= 0 || filter.PageSize > 100 {
+		return filter, false
+	}
+	if len([]rune(filter.Keyword)) > 50 {
+		return filter, false
+	}
+	if filter.SaleStatus != "" && !model.ValidProductSaleStatus(filter.SaleStatus) {
+		return filter, false
+	}
+	if !repository.ValidProductSort(filter.Sort) {
+		return filter, false
+	}
+	if filter.MinPrice, ok = parsePriceQuery(c.Query("min_price")); !ok {
+		return filter, false
+	}
+	if filter.MaxPrice, ok = parsePriceQuery(c.Query("max_price")); !ok {
+		return filter, false
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, false
+	}
+	if filter.SellerID, ok = parseIDQuery(c.Query("seller_id")); !ok {
+		return filter, false
+	}
+	if filter.BrandID, ok = parseIDQuery(c.Query("brand_id")); !ok {
+		return filter, false
+	}
+	if filter.CategoryID, ok = parseIDQuery(c.Query("category_id")); !ok {
+		return filter, false
+	}
+	return filter, true
 }
 
-// UpdateProduct 更新商品（仅创建者）
+// parseIDQuery 解析可选的 ID 参数，空串表示不限。
+func parseIDQuery(raw string) (uint, bool) {
+	if raw == "" {
+		return 0, true
+	}
+	id, err := strconv.Atoi(raw)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parsePriceQuery 解析可选的价格参数，空串表示不限。
+func parsePriceQuery(raw string) (*float64, bool) {
+	if raw == "" {
+		return nil, true
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		return nil, false
+	}
+	return &v, true
+}
+
+// UpdateProduct 更新商品（仅创建者，需为审核通过的卖家）
 // @Summary 更新商品
+// @Description 已上架商品修改名称、价格、图片、会员价、描述、品牌或分类后需重新审核
 // @Tags 商品
 // @Accept json
 // @Produce json
@@ -208,6 +405,7 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 // @Success 200 {object} app.Response{data=IDResponse}
 // @Failure 400 {object} app.Response "参数错误"
 // @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "不是审核通过的卖家"
 // @Failure 404 {object} app.Response "未找到"
 // @Router /products/{id} [put]
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
@@ -253,6 +451,46 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	if req.Image != nil {
 		updates["image"] = *req.Image
 	}
+	if req.Images != nil {
+		updates["images"] = productImagesFromURLs(*req.Images)
+	}
+	if req.Description != nil {
+		updates["description"] = strings.TrimSpace(*req.Description)
+	}
+	if req.BrandID != nil {
+		updates["brand_id"] = *req.BrandID
+	}
+	if req.CategoryID != nil {
+		updates["category_id"] = *req.CategoryID
+	}
+	if req.Tags != nil {
+		updates["tags"] = model.ProductTags(*req.Tags)
+	}
+	if req.SKU != nil {
+		updates["sku"] = strings.TrimSpace(*req.SKU)
+	}
+	if req.ReleaseDate != nil {
+		if *req.ReleaseDate == "" {
+			updates["release_date"] = nil
+		} else if t, parseErr := time.Parse(time.DateOnly, *req.ReleaseDate); parseErr == nil {
+			updates["release_date"] = t
+		} else {
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, "发售日期格式不正确（格式示例：2021-03-10）")
+			return
+		}
+	}
+	if req.RetailPrice != nil {
+		updates["retail_price"] = *req.RetailPrice
+	}
+	if req.MinVIPLevel != nil {
+		updates["min_vip_level"] = *req.MinVIPLevel
+	}
+	if req.MemberPrices != nil {
+		updates["member_prices"] = *req.MemberPrices
+	}
+	if req.MemberCouponStackable != nil {
+		updates["member_coupon_stackable"] = *req.MemberCouponStackable
+	}
 	if req.EndTime != nil {
 		if *req.EndTime == "" {
 			// 允许清空结束时间
@@ -266,8 +504,14 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	}
 
 	if err := h.svc.UpdateProduct(ctx, userID, uint(id), updates); err != nil {
-		if errors.Is(err, service.ErrProductNotFound) {
+		if errors.Is(err, service.ErrSellerNotApproved) {
+			appG.ErrorMsg(http.StatusForbidden, e.UNAUTHORIZED, err.Error())
+		} else if errors.Is(err, service.ErrProductNotFound) {
 			appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
+		} else if errors.Is(err, service.ErrMemberPriceInvalid) || errors.Is(err, service.ErrBrandNotFound) ||
+			errors.Is(err, service.ErrCategoryNotFound) || errors.Is(err, service.ErrProductTagsInvalid) ||
+			errors.Is(err, service.ErrProductImagesInvalid) {
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
 		} else {
 			appG.Error(http.StatusInternalServerError, e.ERROR)
 		}
@@ -276,7 +520,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	appG.Success(gin.H{"id": id})
 }
 
-// DeleteProduct 删除商品（仅创建者）
+// DeleteProduct 删除商品（仅创建者，需为审核通过的卖家）
 // @Summary 删除商品
 // @Tags 商品
 // @Produce json
@@ -285,6 +529,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 // @Success 200 {object} app.Response{data=IDResponse}
 // @Failure 400 {object} app.Response "参数错误"
 // @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "不是审核通过的卖家"
 // @Failure 404 {object} app.Response "未找到"
 // @Router /products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
@@ -304,7 +549,9 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	}
 
 	if err := h.svc.DeleteProduct(ctx, userID, uint(id)); err != nil {
-		if errors.Is(err, service.ErrProductNotFound) {
+		if errors.Is(err, service.ErrSellerNotApproved) {
+			appG.ErrorMsg(http.StatusForbidden, e.UNAUTHORIZED, err.Error())
+		} else if errors.Is(err, service.ErrProductNotFound) {
 			appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
 		} else {
 			appG.Error(http.StatusInternalServerError, e.ERROR)
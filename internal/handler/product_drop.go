@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSubscription 查询开售提醒预约状态
+// @Summary 我的开售提醒
+// @Tags 商品
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=service.DropSubscriptionView}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /products/{id}/subscription [get]
+func (h *ProductHandler) GetSubscription(c *gin.Context) {
+	h.handleSubscription(c, h.dropSvc.Status)
+}
+
+// Subscribe 预约开售提醒
+// @Summary 预约开售提醒
+// @Description 开售前按配置的提前量通过用户开启的通知渠道提醒，同一档提前量只提醒一次
+// @Tags 商品
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=service.DropSubscriptionView}
+// @Failure 400 {object} app.Response "商品已开售"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /products/{id}/subscription [post]
+func (h *ProductHandler) Subscribe(c *gin.Context) {
+	h.handleSubscription(c, h.dropSvc.Subscribe)
+}
+
+// Unsubscribe 取消开售提醒
+// @Summary 取消开售提醒
+// @Tags 商品
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response
+// @Failure 401 {object} app.Response "未登录"
+// @Router /products/{id}/subscription [delete]
+func (h *ProductHandler) Unsubscribe(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	if err := h.dropSvc.Unsubscribe(c.Request.Context(), userID, productID); err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(nil)
+}
+
+// GetSubscribers 商家查看预约人数
+// @Summary 我的商品预约人数
+// @Description 仅商品创建者可查看，用于评估开售热度
+// @Tags 商品
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=service.DropSubscribers}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /products/{id}/subscribers [get]
+func (h *ProductHandler) GetSubscribers(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	stats, err := h.dropSvc.SellerSubscribers(c.Request.Context(), userID, productID)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
+			return
+		}
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(stats)
+}
+
+func (h *ProductHandler) handleSubscription(c *gin.Context, fn func(ctx context.Context, userID, productID uint) (*service.DropSubscriptionView, error)) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	view, err := fn(c.Request.Context(), userID, productID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrProductNotFound):
+			appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
+		case errors.Is(err, service.ErrDropStarted):
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+		default:
+			appG.Error(http.StatusInternalServerError, e.ERROR)
+		}
+		return
+	}
+	appG.Success(view)
+}
+
+func userProductParams(appG app.Gin) (uint, uint, bool) {
+	userIDAny, exists := appG.C.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return 0, 0, false
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(appG.C.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return 0, 0, false
+	}
+	return userID, uint(id), true
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubmitProduct 提交商品审核
+// @Summary 提交商品审核
+// @Description 草稿、被驳回或已下架的商品提交审核，审核通过后上架
+// @Tags 商品
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=IDResponse}
+// @Failure 400 {object} app.Response "当前状态不可提交"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "不是审核通过的卖家"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /products/{id}/submit [post]
+func (h *ProductHandler) SubmitProduct(c *gin.Context) {
+	h.handleStatus(c, h.svc.SubmitProduct)
+}
+
+// OfflineProduct 下架商品
+// @Summary 下架自己的商品
+// @Description 下架后不再展示与抢购，重新上架需再次提交审核
+// @Tags 商品
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=IDResponse}
+// @Failure 400 {object} app.Response "商品未上架"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "不是审核通过的卖家"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /products/{id}/offline [post]
+func (h *ProductHandler) OfflineProduct(c *gin.Context) {
+	h.handleStatus(c, h.svc.TakeOffline)
+}
+
+func (h *ProductHandler) handleStatus(c *gin.Context, fn func(ctx context.Context, userID, id uint) error) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	if err := fn(c.Request.Context(), userID, productID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrSellerNotApproved):
+			appG.ErrorMsg(http.StatusForbidden, e.UNAUTHORIZED, err.Error())
+		case errors.Is(err, service.ErrProductNotFound):
+			appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
+		case errors.Is(err, service.ErrProductStatusConflict):
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+		default:
+			appG.Error(http.StatusInternalServerError, e.ERROR)
+		}
+		return
+	}
+	appG.Success(gin.H{"id": productID})
+}
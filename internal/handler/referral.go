@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReferralHandler struct {
+	svc *service.ReferralService
+}
+
+func NewReferralHandler(svc *service.ReferralService) *ReferralHandler {
+	return &ReferralHandler{svc: svc}
+}
+
+// GetMine 我的邀请
+// @Summary 我的邀请码与邀请统计
+// @Description 首次查询时生成邀请码；被邀请人完成首单后双方获得奖励
+// @Tags 邀请
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=service.MyReferral}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /referral [get]
+func (h *ReferralHandler) GetMine(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	mine, err := h.svc.Mine(ctx, userID)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(mine)
+}
@@ -22,7 +22,8 @@ func NewSeckillHandler(svc *service.SeckillService) *SeckillHandler {
 }
 
 type SeckillReq struct {
-	ProductID uint `json:"product_id" binding:"required"`
+	ProductID  uint `json:"product_id" binding:"required"`
+	AutoCoupon bool `json:"auto_coupon"` // 下单后自动使用最优优惠券
 }
 
 type SeckillResponse struct {
@@ -66,7 +67,7 @@ func (h *SeckillHandler) Seckill(c *gin.Context) {
 	}
 
 	// 3. 调用秒杀服务
-	result, err := h.svc.Seckill(c.Request.Context(), userID, req.ProductID)
+	result, err := h.svc.Seckill(c.Request.Context(), userID, req.ProductID, req.AutoCoupon)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrSeckillRepeat):
@@ -81,6 +82,9 @@ func (h *SeckillHandler) Seckill(c *gin.Context) {
 		case errors.Is(err, service.ErrSeckillEnded):
 			metrics.IncSeckillResult("ended")
 			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+		case errors.Is(err, service.ErrSeckillVIPOnly):
+			metrics.IncSeckillResult("vip_only")
+			appG.ErrorMsg(http.StatusForbidden, e.INVALID_PARAMS, err.Error())
 		case errors.Is(err, service.ErrProductNotFound):
 			appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
 		case errors.Is(err, service.ErrSeckillBusy):
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SellerHandler struct {
+	svc *service.SellerService
+}
+
+func NewSellerHandler(svc *service.SellerService) *SellerHandler {
+	return &SellerHandler{svc: svc}
+}
+
+type SellerApplyReq struct {
+	StoreName    string `json:"store_name" binding:"required,max=50"`
+	Description  string `json:"description" binding:"max=500"`
+	Logo         string `json:"logo" binding:"max=255"`
+	ContactName  string `json:"contact_name" binding:"required,max=50"`
+	ContactPhone string `json:"contact_phone" binding:"required,max=32"`
+	LicenseNo    string `json:"license_no" binding:"max=64"` // 营业执照号或身份证明编号
+}
+
+type SellerStoreReq struct {
+	Description *string `json:"description" binding:"omitempty,max=500"`
+	Logo        *string `json:"logo" binding:"omitempty,max=255"`
+}
+
+// Apply 提交卖家入驻申请
+// @Summary 提交卖家入驻申请
+// @Description 提交店铺资料等待管理员审核；被驳回后可修改资料重新提交
+// @Tags 卖家
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body SellerApplyReq true "入驻资料"
+// @Success 200 {object} app.Response{data=model.SellerProfile}
+// @Failure 400 {object} app.Response "参数错误、重复申请或店铺名已占用"
+// @Failure 401 {object} app.Response "未登录"
+// @Router /seller/application [post]
+func (h *SellerHandler) Apply(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	var req SellerApplyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	profile, err := h.svc.Apply(c.Request.Context(), userID, service.SellerApplication{
+		StoreName:    req.StoreName,
+		Description:  req.Description,
+		Logo:         req.Logo,
+		ContactName:  req.ContactName,
+		ContactPhone: req.ContactPhone,
+		LicenseNo:    req.LicenseNo,
+	})
+	if err != nil {
+		sellerError(appG, err)
+		return
+	}
+	appG.Success(profile)
+}
+
+// Mine 我的入驻资料
+// @Summary 我的卖家入驻资料与审核状态
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=model.SellerProfile}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "未申请"
+// @Router /seller/profile [get]
+func (h *SellerHandler) Mine(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	profile, err := h.svc.Mine(c.Request.Context(), userID)
+	if err != nil {
+		sellerError(appG, err)
+		return
+	}
+	appG.Success(profile)
+}
+
+// UpdateStore 修改店铺展示资料
+// @Summary 修改店铺简介与 Logo
+// @Description 仅审核通过的卖家可修改，无需重新审核
+// @Tags 卖家
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body SellerStoreReq true "店铺资料"
+// @Success 200 {object} app.Response{data=model.SellerProfile}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "不是审核通过的卖家"
+// @Router /seller/profile [put]
+func (h *SellerHandler) UpdateStore(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	var req SellerStoreReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	profile, err := h.svc.UpdateStore(c.Request.Context(), userID, service.SellerStoreUpdate{
+		Description: req.Description,
+		Logo:        req.Logo,
+	})
+	if err != nil {
+		sellerError(appG, err)
+		return
+	}
+	appG.Success(profile)
+}
+
+// Profile 卖家主页
+// @Summary 卖家主页资料
+// @Tags 卖家
+// @Produce json
+// @Param id path int true "卖家用户ID"
+// @Success 200 {object} app.Response{data=service.SellerPublicProfile}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 404 {object} app.Response "卖家不存在"
+// @Router /sellers/{id} [get]
+func (h *SellerHandler) Profile(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	profile, err := h.svc.Profile(c.Request.Context(), uint(id))
+	if err != nil {
+		sellerError(appG, err)
+		return
+	}
+	appG.Success(profile)
+}
+
+// Drops 卖家商品列表
+// @Summary 卖家主页商品列表
+// @Tags 卖家
+// @Produce json
+// @Param id path int true "卖家用户ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页条数" default(10)
+// @Success 200 {object} app.Response{data=ProductListResponse}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 404 {object} app.Response "卖家不存在"
+// @Router /sellers/{id}/products [get]
+func (h *SellerHandler) Drops(c *gin.Context) {
+	appG := app.Gin{C: c}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	if err != nil || pageSize <= 0 || pageSize > 100 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	list, total, err := h.svc.ListDrops(c.Request.Context(), uint(id), page, pageSize)
+	if err != nil {
+		sellerError(appG, err)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+func sellerError(appG app.Gin, err error) {
+	switch {
+	case errors.Is(err, service.ErrSellerNotFound):
+		appG.ErrorMsg(http.StatusNotFound, e.INVALID_PARAMS, err.Error())
+	case errors.Is(err, service.ErrSellerNotApproved):
+		appG.ErrorMsg(http.StatusForbidden, e.UNAUTHORIZED, err.Error())
+	case errors.Is(err, service.ErrSellerApplied),
+		errors.Is(err, service.ErrSellerStoreNameTaken),
+		errors.Is(err, service.ErrSellerApplicationEmpty),
+		errors.Is(err, service.ErrSellerNotPending),
+		errors.Is(err, service.ErrSellerStatusInvalid),
+		errors.Is(err, service.ErrSellerRejectReasonEmpty):
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+	default:
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+	}
+}
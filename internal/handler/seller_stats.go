@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SellerStatsHandler struct {
+	svc *service.SellerStatsService
+}
+
+func NewSellerStatsHandler(svc *service.SellerStatsService) *SellerStatsHandler {
+	return &SellerStatsHandler{svc: svc}
+}
+
+// Overview 卖家销售看板
+// @Summary 卖家销售看板
+// @Description 全部商品的销量、库存、转化率与营收汇总，由 worker 每分钟刷新
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=service.SellerStats}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /seller/stats [get]
+func (h *SellerStatsHandler) Overview(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	stats, err := h.svc.Overview(c.Request.Context(), userID)
+	if err != nil {
+		sellerStatsError(appG, err)
+		return
+	}
+	appG.Success(stats)
+}
+
+// Revenue 营收趋势
+// @Summary 卖家每日营收趋势
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "最近天数，1-90" default(30)
+// @Success 200 {object} app.Response{data=[]service.DailyRevenue}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Router /seller/stats/revenue [get]
+func (h *SellerStatsHandler) Revenue(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	series, err := h.svc.Revenue(c.Request.Context(), userID, days)
+	if err != nil {
+		sellerStatsError(appG, err)
+		return
+	}
+	appG.Success(series)
+}
+
+// Live 商品实时售卖进度
+// @Summary 商品实时售卖进度
+// @Description 开售期间读取秒杀库存与请求计数，不走缓存
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=service.ProductLiveStats}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "商品不存在"
+// @Router /seller/stats/products/{id}/live [get]
+func (h *SellerStatsHandler) Live(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	live, err := h.svc.Live(c.Request.Context(), userID, uint(id))
+	if err != nil {
+		sellerStatsError(appG, err)
+		return
+	}
+	appG.Success(live)
+}
+
+func sellerStatsError(appG app.Gin, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		appG.ErrorMsg(http.StatusNotFound, e.INVALID_PARAMS, err.Error())
+	case errors.Is(err, service.ErrSellerStatsDaysInvalid):
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+	default:
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+	}
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SettlementHandler struct {
+	svc *service.SettlementService
+}
+
+func NewSettlementHandler(svc *service.SettlementService) *SettlementHandler {
+	return &SettlementHandler{svc: svc}
+}
+
+// List 我的结算单
+// @Summary 卖家结算单列表
+// @Description 按周汇总的结算单，新周期在前
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页条数" default(20)
+// @Success 200 {object} app.Response{data=app.PageData}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Router /seller/settlements [get]
+func (h *SettlementHandler) List(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	page, pageSize, ok := parsePage(c)
+	if !ok || pageSize > 100 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	list, total, err := h.svc.ListForSeller(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// Detail 结算单明细
+// @Summary 卖家结算单明细
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "结算单ID"
+// @Success 200 {object} app.Response{data=service.SettlementDetail}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "结算单不存在"
+// @Router /seller/settlements/{id} [get]
+func (h *SettlementHandler) Detail(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	detail, err := h.svc.Detail(c.Request.Context(), userID, uint(id))
+	if err != nil {
+		settlementError(appG, err)
+		return
+	}
+	appG.Success(detail)
+}
+
+// Export 导出结算单明细
+// @Summary 导出卖家结算单明细 CSV
+// @Tags 卖家
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path int true "结算单ID"
+// @Success 200 {file} file "CSV 文件"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "结算单不存在"
+// @Router /seller/settlements/{id}/export [get]
+func (h *SettlementHandler) Export(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	var buf bytes.Buffer
+	if err := h.svc.ExportItems(c.Request.Context(), userID, uint(id), &buf); err != nil {
+		settlementError(appG, err)
+		return
+	}
+	sendCSV(c, fmt.Sprintf("settlement-%d.csv", id), buf.Bytes())
+}
+
+// sendCSV 以附件形式返回 CSV 内容。
+func sendCSV(c *gin.Context, filename string, data []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+func settlementError(appG app.Gin, err error) {
+	switch {
+	case errors.Is(err, service.ErrSettlementNotFound):
+		appG.ErrorMsg(http.StatusNotFound, e.INVALID_PARAMS, err.Error())
+	case errors.Is(err, service.ErrSettlementNotPending),
+		errors.Is(err, service.ErrSettlementStatusInvalid),
+		errors.Is(err, service.ErrSettlementPayoutRef):
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+	default:
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+	}
+}
@@ -84,6 +84,63 @@ func (h *StreamHandler) ProductEvents(c *gin.Context) {
 	streamEvents(c, events)
 }
 
+// WaitlistEvents 订阅候补状态推送
+// @Summary 订阅候补状态推送
+// @Tags 推送
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param access_token query string false "access token"
+// @Success 200 {string} string "SSE stream established"
+// @Failure 401 {object} app.Response "未登录"
+// @Router /stream/waitlist/{id} [get]
+func (h *StreamHandler) WaitlistEvents(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+
+	events, unsubscribe, err := h.streamSvc.SubscribeWaitlist(userID, productID)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	defer unsubscribe()
+	streamEvents(c, events)
+}
+
+// NotificationEvents 订阅站内通知推送
+// @Summary 订阅站内通知推送
+// @Tags 推送
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param access_token query string false "access token"
+// @Success 200 {string} string "SSE stream established"
+// @Failure 401 {object} app.Response "未登录"
+// @Router /stream/notifications [get]
+func (h *StreamHandler) NotificationEvents(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	events, unsubscribe, err := h.streamSvc.SubscribeNotifications(userID)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	defer unsubscribe()
+	streamEvents(c, events)
+}
+
 func streamEvents(c *gin.Context, events <-chan []byte) {
 	c.Writer.Header().Set("Content-Type", "text/event-stream")
 	c.Writer.Header().Set("Cache-Control", "no-cache")
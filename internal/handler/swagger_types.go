@@ -34,11 +34,6 @@ type OrderNumResponse struct {
 	OrderNum string `json:"order_num"`
 }
 
-// UploadURLResponse 返回上传后资源地址。
-type UploadURLResponse struct {
-	URL string `json:"url"`
-}
-
 // HealthStatusResponse 存活检查响应。
 type HealthStatusResponse struct {
 	Status    string    `json:"status"`
@@ -69,16 +64,18 @@ type ReadinessStatusResponse struct {
 
 // UserResponse 用户信息输出。
 type UserResponse struct {
-	ID              uint      `json:"id"`
-	Username        string    `json:"username"`
-	Balance         float64   `json:"balance"`
-	Avatar          string    `json:"avatar"`
-	TotalSpentCents int64     `json:"total_spent_cents"`
-	GrowthLevel     int       `json:"growth_level"`
-	Role            string    `json:"role"`
-	Permissions     []string  `json:"permissions,omitempty"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID               uint      `json:"id"`
+	Username         string    `json:"username"`
+	Balance          float64   `json:"balance"`
+	Avatar           string    `json:"avatar"`
+	TotalSpentCents  int64     `json:"total_spent_cents"`
+	WindowSpentCents int64     `json:"window_spent_cents"`
+	GrowthLevel      int       `json:"growth_level"`
+	Points           int64     `json:"points"`
+	Role             string    `json:"role"`
+	Permissions      []string  `json:"permissions,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 // PaymentResponse 用于描述支付单输出，避免暴露内部 gorm.Model。
@@ -3,12 +3,32 @@ package handler
 import (
 	"SneakerFlash/internal/pkg/app"
 	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/pkg/metrics"
 	"SneakerFlash/internal/service"
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// uploadRejections 上传被拒绝的原因，用于返回码与 upload_rejected_total 指标。
+var uploadRejections = []struct {
+	err    error
+	reason string
+	status int
+	code   int
+}{
+	{service.ErrUploadTooLarge, "too_large", http.StatusBadRequest, e.INVALID_PARAMS},
+	{service.ErrUploadUnsupported, "unsupported_type", http.StatusBadRequest, e.INVALID_PARAMS},
+	{service.ErrUploadTypeMismatch, "type_mismatch", http.StatusBadRequest, e.INVALID_PARAMS},
+	{service.ErrUploadInvalidImage, "invalid_image", http.StatusBadRequest, e.INVALID_PARAMS},
+	{service.ErrUploadDimensions, "dimensions", http.StatusBadRequest, e.INVALID_PARAMS},
+	{service.ErrUploadTooManyPixels, "too_many_pixels", http.StatusBadRequest, e.INVALID_PARAMS},
+	{service.ErrUploadDailyCount, "daily_count", http.StatusTooManyRequests, e.RATE_LIMIT},
+	{service.ErrUploadDailySize, "daily_size", http.StatusTooManyRequests, e.RATE_LIMIT},
+}
+
 type UploadHandler struct {
 	svc *service.UploadService
 }
@@ -19,17 +39,23 @@ func NewUploadHandler(svc *service.UploadService) *UploadHandler {
 
 // UploadImage 上传图片（头像、商品图通用）
 // @Summary 上传图片
+// @Description JPEG/PNG 去除 EXIF 等元数据后重新编码，并生成缩略图（最长边 200）与中图（最长边 800）；内容相同的图片返回已有地址；每个用户每天的上传次数与总大小有限额
 // @Tags 文件
 // @Accept mpfd
 // @Produce json
 // @Security BearerAuth
 // @Param file formData file true "图片文件"
-// @Success 200 {object} app.Response{data=UploadURLResponse}
+// @Success 200 {object} app.Response{data=service.UploadedImage}
 // @Failure 400 {object} app.Response "参数错误"
 // @Failure 401 {object} app.Response "未登录"
+// @Failure 429 {object} app.Response "超出每日上传额度"
 // @Router /upload [post]
 func (h *UploadHandler) UploadImage(c *gin.Context) {
 	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
 
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -37,13 +63,19 @@ func (h *UploadHandler) UploadImage(c *gin.Context) {
 		return
 	}
 
-	path, err := h.svc.SaveImage(file)
+	img, err := h.svc.SaveImage(c.Request.Context(), userID, file)
 	if err != nil {
-		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+		for _, rejection := range uploadRejections {
+			if errors.Is(err, rejection.err) {
+				metrics.IncUploadRejected(rejection.reason)
+				appG.ErrorMsg(rejection.status, rejection.code, err.Error())
+				return
+			}
+		}
+		slog.ErrorContext(c.Request.Context(), "上传图片失败", slog.Uint64("user_id", uint64(userID)), slog.Any("err", err))
+		appG.Error(http.StatusInternalServerError, e.ERROR)
 		return
 	}
 
-	appG.Success(gin.H{
-		"url": path,
-	})
+	appG.Success(img)
 }
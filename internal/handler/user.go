@@ -7,6 +7,7 @@ import (
 	"SneakerFlash/internal/service"
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,8 +23,9 @@ func NewUserHandler(svc *service.UserService) *UserHandler {
 }
 
 type RegisterReq struct {
-	Username string `json:"user_name" binding:"required"`
-	Password string `json:"user_password" binding:"required"`
+	Username   string `json:"user_name" binding:"required"`
+	Password   string `json:"user_password" binding:"required"`
+	InviteCode string `json:"invite_code" binding:"omitempty,max=16"` // 邀请码，仅注册时使用
 }
 
 type RefreshReq struct {
@@ -37,11 +39,12 @@ type UpdateProfileReq struct {
 
 // Register 用户注册
 // @Summary 用户注册
-// @Description 注册后返回成功提示
+// @Description 注册后返回成功提示；可携带邀请码，设备标识通过 X-Device-ID 头传入
 // @Tags 用户
 // @Accept json
 // @Produce json
 // @Param payload body RegisterReq true "注册信息"
+// @Param X-Device-ID header string false "设备标识"
 // @Success 200 {object} app.Response{data=MessageResponse}
 // @Failure 400 {object} app.Response "参数错误"
 // @Router /register [post]
@@ -57,11 +60,15 @@ func (h *UserHandler) Register(c *gin.Context) {
 	}
 
 	// 2. 调用业务逻辑
-	if err := h.svc.Register(ctx, req.Username, req.Password); err != nil {
+	if err := h.svc.Register(ctx, req.Username, req.Password, req.InviteCode, clientMeta(c)); err != nil {
 		if errors.Is(err, service.ErrUserExited) {
 			appG.Error(http.StatusOK, e.ERROR_EXIST_USER)
 			return
 		}
+		if errors.Is(err, service.ErrInviteCodeInvalid) {
+			appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+			return
+		}
 		appG.Error(http.StatusInternalServerError, e.ERROR)
 		return
 	}
@@ -76,6 +83,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param payload body RegisterReq true "登录信息"
+// @Param X-Device-ID header string false "设备标识"
 // @Success 200 {object} app.Response{data=TokenPairResponse}
 // @Failure 400 {object} app.Response "参数错误"
 // @Failure 401 {object} app.Response "认证失败"
@@ -90,7 +98,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	access, refresh, err := h.svc.Login(ctx, req.Username, req.Password)
+	access, refresh, err := h.svc.Login(ctx, req.Username, req.Password, clientMeta(c))
 	switch {
 	case errors.Is(err, service.ErrUserNotFound):
 		appG.Error(http.StatusUnauthorized, e.ERROR_NOT_EXIST_USER)
@@ -217,3 +225,12 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 
 	appG.Success(user)
 }
+
+// clientMeta 提取请求来源 IP 与设备标识，用于邀请反作弊。
+func clientMeta(c *gin.Context) service.ClientMeta {
+	deviceID := strings.TrimSpace(c.GetHeader("X-Device-ID"))
+	if len(deviceID) > 64 {
+		deviceID = deviceID[:64]
+	}
+	return service.ClientMeta{IP: c.ClientIP(), DeviceID: deviceID}
+}
@@ -48,18 +48,74 @@ func (h *VIPHandler) GetProfile(c *gin.Context) {
 	appG.Success(profile)
 }
 
+// GetGrowthHistory 成长等级变更记录
+// @Summary 成长等级变更记录
+// @Description 包含升级、降级预警（进入宽限期）、恢复达标、宽限期满降级与退款回退
+// @Tags VIP
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} app.Response{data=app.PageData}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /vip/growth-history [get]
+func (h *VIPHandler) GetGrowthHistory(c *gin.Context) {
+	appG := app.Gin{C: c}
+	ctx := c.Request.Context()
+
+	userIDAny, exists := c.Get("userID")
+	if !exists {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+	userID, ok := userIDAny.(uint)
+	if !ok {
+		appG.Error(http.StatusUnauthorized, e.UNAUTHORIZED)
+		return
+	}
+
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+
+	list, total, err := h.svc.GrowthHistory(ctx, userID, page, pageSize)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// ListPlans 可购买的付费 VIP 套餐
+// @Summary 付费 VIP 套餐列表
+// @Tags VIP
+// @Produce json
+// @Success 200 {object} app.Response{data=[]model.VIPPlan}
+// @Router /vip/plans [get]
+func (h *VIPHandler) ListPlans(c *gin.Context) {
+	appG := app.Gin{C: c}
+	plans, err := h.svc.ListPlans(c.Request.Context())
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(plans)
+}
+
 type PurchaseVIPReq struct {
 	PlanID int `json:"plan_id" binding:"required"`
 }
 
-// Purchase 购买付费 VIP（当前模拟支付成功，直接生效）。
+// Purchase 购买付费 VIP，创建待支付订单，支付成功后生效。
 // @Summary 购买付费 VIP
 // @Tags VIP
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param payload body PurchaseVIPReq true "付费套餐"
-// @Success 200 {object} app.Response{data=service.VIPProfile}
+// @Success 200 {object} app.Response{data=service.OrderWithPayment}
 // @Failure 400 {object} app.Response "参数错误或套餐不存在"
 // @Failure 401 {object} app.Response "未登录"
 // @Router /vip/purchase [post]
@@ -84,10 +140,10 @@ func (h *VIPHandler) Purchase(c *gin.Context) {
 		return
 	}
 
-	profile, err := h.svc.PurchasePaidVIP(ctx, userID, req.PlanID)
+	order, err := h.svc.PurchasePaidVIP(ctx, userID, req.PlanID)
 	if err != nil {
 		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
 		return
 	}
-	appG.Success(profile)
+	appG.Success(order)
 }
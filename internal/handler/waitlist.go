@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WaitlistHandler struct {
+	svc *service.WaitlistService
+}
+
+func NewWaitlistHandler(svc *service.WaitlistService) *WaitlistHandler {
+	return &WaitlistHandler{svc: svc}
+}
+
+type WaitlistClaimReq struct {
+	AutoCoupon bool `json:"auto_coupon"` // 下单后自动使用最优优惠券
+}
+
+// Join 加入候补
+// @Summary 加入售罄候补
+// @Description 商品售罄后排队，取消订单回流的名额按先后顺序限时分配
+// @Tags 秒杀
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=service.WaitlistView}
+// @Failure 400 {object} app.Response "未售罄或活动未开始/已结束"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "未找到"
+// @Router /products/{id}/waitlist [post]
+func (h *WaitlistHandler) Join(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	view, err := h.svc.Join(c.Request.Context(), userID, productID)
+	if err != nil {
+		waitlistError(appG, err)
+		return
+	}
+	appG.Success(view)
+}
+
+// Status 候补状态
+// @Summary 我的候补状态
+// @Tags 秒杀
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response{data=service.WaitlistView}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "未加入候补"
+// @Router /products/{id}/waitlist [get]
+func (h *WaitlistHandler) Status(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	view, err := h.svc.Status(c.Request.Context(), userID, productID)
+	if err != nil {
+		waitlistError(appG, err)
+		return
+	}
+	appG.Success(view)
+}
+
+// Leave 退出候补
+// @Summary 退出候补
+// @Description 已分配名额未认领时视为放弃，名额顺延给下一位
+// @Tags 秒杀
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Success 200 {object} app.Response
+// @Failure 401 {object} app.Response "未登录"
+// @Router /products/{id}/waitlist [delete]
+func (h *WaitlistHandler) Leave(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	if err := h.svc.Leave(c.Request.Context(), userID, productID); err != nil {
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+		return
+	}
+	appG.Success(nil)
+}
+
+// Claim 认领候补名额
+// @Summary 认领候补名额
+// @Description 在认领时限内下单，订单走秒杀异步落库链路，结果通过 /orders/poll/{order_num} 查询
+// @Tags 秒杀
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "商品ID"
+// @Param payload body WaitlistClaimReq false "认领参数"
+// @Success 200 {object} app.Response{data=SeckillResponse}
+// @Failure 400 {object} app.Response "无可认领名额或已超时"
+// @Failure 401 {object} app.Response "未登录"
+// @Router /products/{id}/waitlist/claim [post]
+func (h *WaitlistHandler) Claim(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, productID, ok := userProductParams(appG)
+	if !ok {
+		return
+	}
+	var req WaitlistClaimReq
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+			return
+		}
+	}
+	result, err := h.svc.Claim(c.Request.Context(), userID, productID, req.AutoCoupon)
+	if err != nil {
+		waitlistError(appG, err)
+		return
+	}
+	appG.Success(SeckillResponse{
+		OrderNum:  result.OrderNum,
+		OrderID:   result.OrderID,
+		PaymentID: result.PaymentID,
+		Status:    result.Status,
+	})
+}
+
+func waitlistError(appG app.Gin, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound):
+		appG.Error(http.StatusNotFound, e.ERROR_NOT_EXIST_PRODUCT)
+	case errors.Is(err, service.ErrWaitlistNotJoined):
+		appG.ErrorMsg(http.StatusNotFound, e.INVALID_PARAMS, err.Error())
+	case errors.Is(err, service.ErrSeckillRepeat):
+		appG.Error(http.StatusOK, e.ERROR_REPEAT_BUY)
+	case errors.Is(err, service.ErrSeckillVIPOnly):
+		appG.ErrorMsg(http.StatusForbidden, e.INVALID_PARAMS, err.Error())
+	case errors.Is(err, service.ErrWaitlistNotSoldOut),
+		errors.Is(err, service.ErrWaitlistNoOffer),
+		errors.Is(err, service.ErrSeckillNotStart),
+		errors.Is(err, service.ErrSeckillEnded):
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+	case errors.Is(err, service.ErrSeckillBusy):
+		appG.ErrorMsg(http.StatusServiceUnavailable, e.ERROR, err.Error())
+	default:
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+	}
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/app"
+	"SneakerFlash/internal/pkg/e"
+	"SneakerFlash/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	svc *service.WebhookService
+}
+
+func NewWebhookHandler(svc *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{svc: svc}
+}
+
+type WebhookCreateReq struct {
+	URL         string               `json:"url" binding:"required,max=512"`
+	Events      []model.WebhookEvent `json:"events"` // 为空时订阅全部事件
+	Description string               `json:"description" binding:"max=255"`
+}
+
+type WebhookUpdateReq struct {
+	URL         *string              `json:"url" binding:"omitempty,max=512"`
+	Events      []model.WebhookEvent `json:"events"`
+	Enabled     *bool                `json:"enabled"`
+	Description *string              `json:"description" binding:"omitempty,max=255"`
+}
+
+// List 我的 Webhook
+// @Summary Webhook 地址列表
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} app.Response{data=[]model.WebhookEndpoint}
+// @Failure 401 {object} app.Response "未登录"
+// @Router /seller/webhooks [get]
+func (h *WebhookHandler) List(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	endpoints, err := h.svc.ListEndpoints(c.Request.Context(), userID)
+	if err != nil {
+		webhookError(appG, err)
+		return
+	}
+	appG.Success(endpoints)
+}
+
+// Create 注册 Webhook
+// @Summary 注册 Webhook 地址
+// @Description 仅审核通过的卖家可用；签名密钥只在创建时返回一次
+// @Tags 卖家
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body WebhookCreateReq true "回调地址与订阅事件"
+// @Success 200 {object} app.Response{data=service.WebhookEndpointWithSecret}
+// @Failure 400 {object} app.Response "地址或事件无效、数量已达上限"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 403 {object} app.Response "不是审核通过的卖家"
+// @Router /seller/webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return
+	}
+	var req WebhookCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	endpoint, err := h.svc.CreateEndpoint(c.Request.Context(), userID, service.WebhookEndpointInput{
+		URL:         req.URL,
+		Events:      req.Events,
+		Description: req.Description,
+	})
+	if err != nil {
+		webhookError(appG, err)
+		return
+	}
+	appG.Success(endpoint)
+}
+
+// Update 修改 Webhook
+// @Summary 修改 Webhook 地址、订阅事件或启停
+// @Tags 卖家
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param payload body WebhookUpdateReq true "需要修改的字段"
+// @Success 200 {object} app.Response{data=model.WebhookEndpoint}
+// @Failure 400 {object} app.Response "参数错误"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "Webhook 不存在"
+// @Router /seller/webhooks/{id} [put]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, id, ok := webhookPathParams(appG)
+	if !ok {
+		return
+	}
+	var req WebhookUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	endpoint, err := h.svc.UpdateEndpoint(c.Request.Context(), userID, id, service.WebhookEndpointUpdate{
+		URL:         req.URL,
+		Events:      req.Events,
+		Enabled:     req.Enabled,
+		Description: req.Description,
+	})
+	if err != nil {
+		webhookError(appG, err)
+		return
+	}
+	appG.Success(endpoint)
+}
+
+// Delete 删除 Webhook
+// @Summary 删除 Webhook 地址
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} app.Response
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "Webhook 不存在"
+// @Router /seller/webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, id, ok := webhookPathParams(appG)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteEndpoint(c.Request.Context(), userID, id); err != nil {
+		webhookError(appG, err)
+		return
+	}
+	appG.Success(gin.H{"id": id})
+}
+
+// RotateSecret 轮换签名密钥
+// @Summary 轮换 Webhook 签名密钥
+// @Description 新密钥立即生效并只返回一次
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} app.Response{data=service.WebhookEndpointWithSecret}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "Webhook 不存在"
+// @Router /seller/webhooks/{id}/rotate-secret [post]
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, id, ok := webhookPathParams(appG)
+	if !ok {
+		return
+	}
+	endpoint, err := h.svc.RotateSecret(c.Request.Context(), userID, id)
+	if err != nil {
+		webhookError(appG, err)
+		return
+	}
+	appG.Success(endpoint)
+}
+
+// Deliveries 投递记录
+// @Summary Webhook 投递记录
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param status query string false "pending/succeeded/failed"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页条数" default(20)
+// @Success 200 {object} app.Response{data=app.PageData}
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "Webhook 不存在"
+// @Router /seller/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, id, ok := webhookPathParams(appG)
+	if !ok {
+		return
+	}
+	page, pageSize, ok := parsePage(c)
+	if !ok {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	status := c.Query("status")
+	switch model.WebhookDeliveryStatus(status) {
+	case "", model.WebhookDeliveryPending, model.WebhookDeliverySucceeded, model.WebhookDeliveryFailed:
+	default:
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	list, total, err := h.svc.ListDeliveries(c.Request.Context(), userID, id, status, page, pageSize)
+	if err != nil {
+		webhookError(appG, err)
+		return
+	}
+	appG.SuccessWithPage(list, total, page, pageSize)
+}
+
+// Redeliver 重新投递
+// @Summary 重新投递一次 Webhook
+// @Description 按原请求体生成新的投递记录，事件 ID 不变
+// @Tags 卖家
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Webhook ID"
+// @Param delivery_id path int true "投递记录ID"
+// @Success 200 {object} app.Response{data=model.WebhookDelivery}
+// @Failure 400 {object} app.Response "Webhook 已停用"
+// @Failure 401 {object} app.Response "未登录"
+// @Failure 404 {object} app.Response "Webhook 或投递记录不存在"
+// @Router /seller/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	appG := app.Gin{C: c}
+	userID, id, ok := webhookPathParams(appG)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.Atoi(c.Param("delivery_id"))
+	if err != nil || deliveryID <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return
+	}
+	delivery, err := h.svc.Redeliver(c.Request.Context(), userID, id, uint(deliveryID))
+	if err != nil {
+		webhookError(appG, err)
+		return
+	}
+	appG.Success(delivery)
+}
+
+// webhookPathParams 解析当前用户与路径中的 Webhook ID，失败时已写入响应。
+func webhookPathParams(appG app.Gin) (uint, uint, bool) {
+	userID, ok := currentUserID(appG)
+	if !ok {
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(appG.C.Param("id"))
+	if err != nil || id <= 0 {
+		appG.Error(http.StatusBadRequest, e.INVALID_PARAMS)
+		return 0, 0, false
+	}
+	return userID, uint(id), true
+}
+
+func webhookError(appG app.Gin, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound),
+		errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		appG.ErrorMsg(http.StatusNotFound, e.INVALID_PARAMS, err.Error())
+	case errors.Is(err, service.ErrSellerNotApproved):
+		appG.ErrorMsg(http.StatusForbidden, e.UNAUTHORIZED, err.Error())
+	case errors.Is(err, service.ErrWebhookURLInvalid),
+		errors.Is(err, service.ErrWebhookURLPrivate),
+		errors.Is(err, service.ErrWebhookEventInvalid),
+		errors.Is(err, service.ErrWebhookLimit),
+		errors.Is(err, service.ErrWebhookDisabled):
+		appG.ErrorMsg(http.StatusBadRequest, e.INVALID_PARAMS, err.Error())
+	default:
+		appG.Error(http.StatusInternalServerError, e.ERROR)
+	}
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalMountPath 本地存储由 API 实例在该路径下提供文件访问。
+const LocalMountPath = "/uploads"
+
+// Local 本地磁盘存储，仅适用于单实例部署；公开文件直接访问，私有文件需校验签名。
+type Local struct {
+	root    string
+	baseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+// NewLocal baseURL 为空时使用 /uploads，可配置为带域名的绝对地址。
+func NewLocal(root, baseURL string, secret []byte) *Local {
+	if baseURL == "" {
+		baseURL = LocalMountPath
+	}
+	return &Local{
+		root:    filepath.Clean(root),
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  secret,
+		now:     time.Now,
+	}
+}
+
+func (l *Local) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	dst := l.path(key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	// 先写临时文件再改名，避免并发读到写了一半的文件
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (l *Local) Get(ctx context.Context, key string) ([]byte, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(l.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(l.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Exists(ctx context.Context, key string) (bool, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(l.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return info.Mode().IsRegular(), nil
+}
+
+func (l *Local) URL(key string) string {
+	return l.baseURL + "/" + escapeKey(key)
+}
+
+// SignedURL 生成带过期时间与 HMAC 签名的地址，由 ServeHTTP 校验。
+func (l *Local) SignedURL(key string, ttl time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if ttl <= 0 {
+		return "", fmt.Errorf("invalid signed url ttl: %s", ttl)
+	}
+	expires := strconv.FormatInt(l.now().Add(ttl).Unix(), 10)
+	return l.URL(key) + "?expires=" + expires + "&signature=" + l.sign(key, expires), nil
+}
+
+// ServeHTTP 提供文件访问，请求路径为去掉挂载前缀后的 key；私有文件须携带未过期的有效签名，目录不可列出。
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, err := cleanKey(strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if IsPrivate(key) && !l.verify(key, r.URL.Query().Get("expires"), r.URL.Query().Get("signature")) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	f, err := os.Open(l.path(key))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+	if IsPrivate(key) {
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+func (l *Local) path(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(key))
+}
+
+func (l *Local) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *Local) verify(key, expires, signature string) bool {
+	ts, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || l.now().Unix() > ts {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(l.sign(key, expires)))
+}
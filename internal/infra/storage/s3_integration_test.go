@@ -0,0 +1,71 @@
+//go:build integration
+
+package storage
+
+import (
+	"SneakerFlash/internal/config"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 需要本地 MinIO，例如：
+// docker run -p 9000:9000 minio/minio server /data && mc mb local/sneakerflash-it
+func TestS3StorageMinIO(t *testing.T) {
+	endpoint := strings.TrimSpace(os.Getenv("SNEAKERFLASH_S3_IT_ENDPOINT"))
+	if endpoint == "" {
+		t.Skip("skip s3 integration test: SNEAKERFLASH_S3_IT_ENDPOINT not set")
+	}
+	cfg := config.S3Config{
+		Endpoint:  endpoint,
+		Bucket:    envOr("SNEAKERFLASH_S3_IT_BUCKET", "sneakerflash-it"),
+		AccessKey: envOr("SNEAKERFLASH_S3_IT_ACCESS_KEY", "minioadmin"),
+		SecretKey: envOr("SNEAKERFLASH_S3_IT_SECRET_KEY", "minioadmin"),
+		PathStyle: true,
+	}
+	store, err := NewS3(cfg, "")
+	if err != nil {
+		t.Fatalf("NewS3() error = %v", err)
+	}
+	ctx := context.Background()
+	key := fmt.Sprintf("private/it/%d.txt", time.Now().UnixNano())
+	if err := store.Put(ctx, key, []byte("hello"), "text/plain"); err != nil {
+		t.Skipf("skip s3 integration test: put failed: %v", err)
+	}
+	defer store.Delete(ctx, key)
+
+	if data, err := store.Get(ctx, key); err != nil || string(data) != "hello" {
+		t.Fatalf("Get() = %q, %v", data, err)
+	}
+	signed, err := store.SignedURL(key, time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	resp, err := http.Get(signed)
+	if err != nil {
+		t.Fatalf("GET signed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+		t.Fatalf("GET signed = %d %q", resp.StatusCode, body)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if ok, err := store.Exists(ctx, key); err != nil || ok {
+		t.Fatalf("Exists(deleted) = %v, %v", ok, err)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,93 @@
+// Package storage 抽象上传文件的存储后端：本地磁盘（单实例）与 S3 兼容对象存储（多实例共享）。
+package storage
+
+import (
+	"SneakerFlash/internal/config"
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("文件不存在")
+	ErrInvalidKey = errors.New("文件路径不合法")
+)
+
+// PrivatePrefix 该前缀下的文件不公开访问，只能通过签名链接读取。
+const PrivatePrefix = "private/"
+
+// Storage 上传文件存储；key 为不带前导斜杠的相对路径，如 images/1.jpg。
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete 删除文件，文件不存在视为成功
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	// URL 公开访问地址，私有文件需改用 SignedURL
+	URL(key string) string
+	// SignedURL 限时访问地址
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+var Store Storage
+
+// Init 按配置创建存储后端，默认使用本地磁盘。
+func Init(cfg config.ServerConfig) {
+	store, err := New(cfg)
+	if err != nil {
+		log.Fatalf("初始化文件存储失败: %s", err)
+	}
+	Store = store
+	log.Printf("文件存储初始化成功: %T", store)
+}
+
+func New(cfg config.ServerConfig) (Storage, error) {
+	switch cfg.Storage.Driver {
+	case "", "local":
+		dir := cfg.UploadDir
+		if dir == "" {
+			dir = "uploads"
+		}
+		secret := cfg.Storage.SignSecret
+		if secret == "" {
+			secret = config.Conf.JWT.Secret
+		}
+		if secret == "" {
+			return nil, errors.New("local storage requires sign_secret")
+		}
+		return NewLocal(dir, cfg.Storage.PublicBaseURL, []byte(secret)), nil
+	case "s3":
+		return NewS3(cfg.Storage.S3, cfg.Storage.PublicBaseURL)
+	default:
+		return nil, errors.New("unknown storage driver: " + cfg.Storage.Driver)
+	}
+}
+
+// IsPrivate 判断 key 是否为私有文件。
+func IsPrivate(key string) bool {
+	return strings.HasPrefix(key, PrivatePrefix)
+}
+
+// cleanKey 校验 key，拒绝绝对路径、反斜杠与 .. 等越界写法。
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || strings.HasSuffix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	if path.Clean(key) != key || key == "." || key == ".." || strings.HasPrefix(key, "../") {
+		return "", ErrInvalidKey
+	}
+	return key, nil
+}
+
+// escapeKey 按段转义 key，保留路径分隔符。
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCleanKey(t *testing.T) {
+	for _, key := range []string{"images/1.jpg", "private/licenses/a b.png"} {
+		if _, err := cleanKey(key); err != nil {
+			t.Fatalf("cleanKey(%q) error = %v", key, err)
+		}
+	}
+	for _, key := range []string{"", "/etc/passwd", "../x", "images/../../x", "a//b", `a\b`, "images/", "."} {
+		if _, err := cleanKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("cleanKey(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocal(t.TempDir(), "", []byte("secret"))
+
+	if err := store.Put(ctx, "images/1.jpg", []byte("jpeg"), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if ok, err := store.Exists(ctx, "images/1.jpg"); err != nil || !ok {
+		t.Fatalf("Exists() = %v, %v", ok, err)
+	}
+	if data, err := store.Get(ctx, "images/1.jpg"); err != nil || string(data) != "jpeg" {
+		t.Fatalf("Get() = %q, %v", data, err)
+	}
+	if got := store.URL("images/1.jpg"); got != "/uploads/images/1.jpg" {
+		t.Fatalf("URL() = %q", got)
+	}
+	if err := store.Put(ctx, "../escape.jpg", nil, ""); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Put(../) error = %v, want ErrInvalidKey", err)
+	}
+	if err := store.Put(ctx, "private/licenses/7.png", []byte("license"), "image/png"); err != nil {
+		t.Fatalf("Put(private) error = %v", err)
+	}
+
+	server := httptest.NewServer(http.StripPrefix(LocalMountPath, store))
+	defer server.Close()
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	if code, body := get("/uploads/images/1.jpg"); code != http.StatusOK || body != "jpeg" {
+		t.Fatalf("GET public = %d %q", code, body)
+	}
+	if code, _ := get("/uploads/images/"); code != http.StatusNotFound {
+		t.Fatalf("GET directory = %d, want 404", code)
+	}
+	if code, _ := get("/uploads/private/licenses/7.png"); code != http.StatusForbidden {
+		t.Fatalf("GET private unsigned = %d, want 403", code)
+	}
+
+	signed, err := store.SignedURL("private/licenses/7.png", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	if code, body := get(signed); code != http.StatusOK || body != "license" {
+		t.Fatalf("GET signed = %d %q", code, body)
+	}
+	u, _ := url.Parse(signed)
+	q := u.Query()
+	q.Set("signature", strings.Repeat("0", 64))
+	if code, _ := get(u.Path + "?" + q.Encode()); code != http.StatusForbidden {
+		t.Fatalf("GET tampered = %d, want 403", code)
+	}
+	store.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if code, _ := get(signed); code != http.StatusForbidden {
+		t.Fatalf("GET expired = %d, want 403", code)
+	}
+
+	if err := store.Delete(ctx, "images/1.jpg"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete(ctx, "images/1.jpg"); err != nil {
+		t.Fatalf("Delete(missing) error = %v", err)
+	}
+	if _, err := store.Get(ctx, "images/1.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(deleted) error = %v, want ErrNotFound", err)
+	}
+}
@@ -34,7 +34,7 @@ func TestAdminFlow_StatsAndResources(t *testing.T) {
 	riskSvc := service.NewRiskService(nil)
 	couponSvc := service.NewCouponService(gdb)
 	auditSvc := service.NewAuditService(gdb)
-	adminHandler := handler.NewAdminHandler(adminSvc, riskSvc, couponSvc, auditSvc)
+	adminHandler := handler.NewAdminHandler(handler.AdminDeps{Admin: adminSvc, Risk: riskSvc, Coupon: couponSvc, Audit: auditSvc})
 
 	router := gin.New()
 	admin := router.Group("/api/v1/admin")
@@ -155,7 +155,7 @@ func TestAdminFlow_CouponsAndRisk(t *testing.T) {
 	riskSvc := service.NewRiskService(redis.RDB)
 	couponSvc := service.NewCouponService(gdb)
 	auditSvc := service.NewAuditService(gdb)
-	adminHandler := handler.NewAdminHandler(adminSvc, riskSvc, couponSvc, auditSvc)
+	adminHandler := handler.NewAdminHandler(handler.AdminDeps{Admin: adminSvc, Risk: riskSvc, Coupon: couponSvc, Audit: auditSvc})
 
 	router := gin.New()
 	admin := router.Group("/api/v1/admin")
@@ -20,7 +20,7 @@ func TestAuthFlow_RegisterLoginProfile(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	gdb := setupIntegrationDB(t)
 
-	userSvc := service.NewUserService(repository.NewUserRepo(gdb))
+	userSvc := service.NewUserService(repository.NewUserRepo(gdb), nil)
 	userHandler := handler.NewUserHandler(userSvc)
 
 	router := gin.New()
@@ -178,9 +178,15 @@ func BlackListMiddleware(rdb *redis.Client) gin.HandlerFunc {
 	}
 }
 
-// GrayListMiddleware 灰名单命中直接返回限流响应，可按需插拔。
-func GrayListMiddleware(rdb *redis.Client) gin.HandlerFunc {
+// GrayListMiddleware 灰名单命中直接返回限流响应，可按需插拔；skipPrefixes 下的路径不拦截（如处罚申诉）。
+func GrayListMiddleware(rdb *redis.Client, skipPrefixes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		for _, prefix := range skipPrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
 		appG := app.Gin{C: c}
 		ctx := c.Request.Context()
 		if ctx == nil {
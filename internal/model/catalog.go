@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// CategoryMaxLevel 分类树最大层级。
+const CategoryMaxLevel = 3
+
+// Brand 品牌，由管理员维护，仍有商品引用时不可删除。
+type Brand struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Name        string    `gorm:"type:varchar(50);not null;uniqueIndex" json:"name"`
+	Logo        string    `gorm:"type:varchar(255);default:''" json:"logo"`
+	Description string    `gorm:"type:varchar(500);default:''" json:"description"`
+	Sort        int       `gorm:"default:0;not null" json:"sort"` // 越小越靠前
+}
+
+func (Brand) TableName() string {
+	return "brands"
+}
+
+// Category 商品分类，ParentID 为 0 表示一级分类；按分类筛选时包含全部下级分类。
+type Category struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	ParentID  uint        `gorm:"default:0;not null;uniqueIndex:idx_category_parent_name" json:"parent_id"`
+	Name      string      `gorm:"type:varchar(50);not null;uniqueIndex:idx_category_parent_name" json:"name"`
+	Level     int         `gorm:"not null" json:"level"`          // 1 起
+	Sort      int         `gorm:"default:0;not null" json:"sort"` // 同级内越小越靠前
+	Children  []*Category `gorm:"-" json:"children,omitempty"`
+}
+
+func (Category) TableName() string {
+	return "categories"
+}
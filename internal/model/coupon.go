@@ -37,6 +37,7 @@ type Coupon struct {
 	ValidTo       time.Time  `json:"valid_to"`
 	Purchasable   bool       `gorm:"default:false" json:"purchasable"`      // 是否可购买
 	PriceCents    int64      `gorm:"default:0;not null" json:"price_cents"` // 购买价格（分）
+	PointsCost    int64      `gorm:"default:0;not null" json:"points_cost"` // 积分兑换所需积分，0 表示不可兑换
 	Status        string     `gorm:"type:varchar(20);default:'active'" json:"status"`
 }
 
@@ -54,6 +55,7 @@ type UserCoupon struct {
 	ValidTo      time.Time    `json:"valid_to"`
 	OrderID      *uint        `gorm:"index" json:"order_id,omitempty"`
 	IssuedAt     time.Time    `json:"issued_at"`
+	RemindedAt   *time.Time   `json:"-"` // 到期提醒发送时间
 }
 
 func (UserCoupon) TableName() string {
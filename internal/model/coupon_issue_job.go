@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// CouponIssueJobStatus 批量发券任务状态
+type CouponIssueJobStatus string
+
+const (
+	CouponIssueJobStatusPending   CouponIssueJobStatus = "pending"   // 待执行
+	CouponIssueJobStatusRunning   CouponIssueJobStatus = "running"   // 执行中
+	CouponIssueJobStatusCompleted CouponIssueJobStatus = "completed" // 已完成
+	CouponIssueJobStatusFailed    CouponIssueJobStatus = "failed"    // 执行失败
+	CouponIssueJobStatusCancelled CouponIssueJobStatus = "cancelled" // 已取消
+)
+
+// 批量发券人群类型
+const (
+	CouponSegmentGrowthLevel   = "growth_level"   // 成长等级区间
+	CouponSegmentPaidVIP       = "paid_vip"       // 有效付费 VIP
+	CouponSegmentProductBuyers = "product_buyers" // 购买过指定商品
+	CouponSegmentUserIDs       = "user_ids"       // 上传的用户 ID 列表
+)
+
+// CouponIssueJob 管理员批量发券任务，由 worker 分批执行，cursor 记录已处理到的用户 ID。
+type CouponIssueJob struct {
+	ID            uint                 `gorm:"primaryKey" json:"id"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+	CouponID      uint                 `gorm:"not null;index" json:"coupon_id"`
+	SegmentType   string               `gorm:"type:varchar(32);not null" json:"segment_type"`
+	SegmentParams string               `gorm:"type:text" json:"segment_params"` // JSON 人群参数
+	Status        CouponIssueJobStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	TotalUsers    int64                `gorm:"default:0;not null" json:"total_users"`
+	IssuedCount   int64                `gorm:"default:0;not null" json:"issued_count"`
+	SkippedCount  int64                `gorm:"default:0;not null" json:"skipped_count"`
+	Cursor        uint                 `gorm:"default:0;not null" json:"cursor"`
+	LeaseToken    string               `gorm:"type:varchar(32);default:''" json:"-"` // 当前执行者的租约，心跳超时被重新认领后旧执行者失效
+	CreatedBy     uint                 `gorm:"not null;index" json:"created_by"`
+	LastError     string               `gorm:"type:varchar(512);default:''" json:"last_error"`
+	StartedAt     *time.Time           `json:"started_at"`
+	FinishedAt    *time.Time           `json:"finished_at"`
+}
+
+func (CouponIssueJob) TableName() string {
+	return "coupon_issue_jobs"
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// DropSubscription 用户预约开售提醒。
+type DropSubscription struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_drop_sub_user_product" json:"user_id"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_drop_sub_user_product;index" json:"product_id"`
+}
+
+func (DropSubscription) TableName() string {
+	return "drop_subscriptions"
+}
+
+// DropReminder 已发送的开售提醒，按商品、用户、提前量去重。
+type DropReminder struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	ProductID     uint      `gorm:"not null;uniqueIndex:idx_drop_reminder" json:"product_id"`
+	UserID        uint      `gorm:"not null;uniqueIndex:idx_drop_reminder" json:"user_id"`
+	OffsetMinutes int       `gorm:"not null;uniqueIndex:idx_drop_reminder" json:"offset_minutes"`
+}
+
+func (DropReminder) TableName() string {
+	return "drop_reminders"
+}
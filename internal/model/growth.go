@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+type GrowthChangeReason string
+
+const (
+	GrowthChangeUpgrade            GrowthChangeReason = "upgrade"             // 消费达到更高门槛
+	GrowthChangeDowngradeWarning   GrowthChangeReason = "downgrade_warning"   // 低于门槛，进入宽限期
+	GrowthChangeDowngradeCancelled GrowthChangeReason = "downgrade_cancelled" // 宽限期内恢复达标
+	GrowthChangeDowngrade          GrowthChangeReason = "downgrade"           // 宽限期满降级
+	GrowthChangeRefund             GrowthChangeReason = "refund"              // 退款后立即回退
+)
+
+// GrowthLevelChange 成长等级变更记录，降级预警也记录在此供用户查看。
+type GrowthLevelChange struct {
+	ID               uint               `gorm:"primaryKey" json:"id"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UserID           uint               `gorm:"not null;index" json:"user_id"`
+	FromLevel        int                `gorm:"not null" json:"from_level"`
+	ToLevel          int                `gorm:"not null" json:"to_level"` // 预警时为将降至的等级
+	Reason           GrowthChangeReason `gorm:"type:varchar(32);not null" json:"reason"`
+	WindowSpentCents int64              `gorm:"not null" json:"window_spent_cents"`
+	DowngradeAt      *time.Time         `json:"downgrade_at,omitempty"` // 预警时的降级截止时间
+}
+
+func (GrowthLevelChange) TableName() string {
+	return "growth_level_changes"
+}
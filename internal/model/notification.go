@@ -0,0 +1,96 @@
+package model
+
+import "time"
+
+type NotificationKind string
+
+const (
+	NotifySeckillSuccess          NotificationKind = "seckill_success"           // 抢购成功，订单待支付
+	NotifySeckillFailed           NotificationKind = "seckill_failed"            // 抢购失败
+	NotifyOrderPaid               NotificationKind = "order_paid"                // 支付成功
+	NotifyOrderCancelled          NotificationKind = "order_cancelled"           // 超时取消
+	NotifyOrderRefunded           NotificationKind = "order_refunded"            // 已退款
+	NotifyCouponIssued            NotificationKind = "coupon_issued"             // 优惠券到账
+	NotifyCouponExpiring          NotificationKind = "coupon_expiring"           // 优惠券即将过期
+	NotifyVIPActivated            NotificationKind = "vip_activated"             // 付费会员开通/续费
+	NotifyGrowthUpgrade           NotificationKind = "growth_upgrade"            // 成长等级提升
+	NotifyGrowthDowngradeWarning  NotificationKind = "growth_downgrade_warning"  // 降级预警
+	NotifyGrowthDowngradeCanceled NotificationKind = "growth_downgrade_canceled" // 恢复达标，取消降级
+	NotifyGrowthDowngrade         NotificationKind = "growth_downgrade"          // 成长等级下降
+	NotifyDropReminder            NotificationKind = "drop_reminder"             // 预约商品即将开售
+	NotifyWaitlistOffer           NotificationKind = "waitlist_offer"            // 候补名额待认领
+	NotifyPenaltyApplied          NotificationKind = "penalty_applied"           // 多次未支付，处罚生效
+	NotifyPenaltyLifted           NotificationKind = "penalty_lifted"            // 处罚已解除
+	NotifyPenaltyAppealRejected   NotificationKind = "penalty_appeal_rejected"   // 处罚申诉被驳回
+	NotifySellerApproved          NotificationKind = "seller_approved"           // 卖家入驻审核通过
+	NotifySellerRejected          NotificationKind = "seller_rejected"           // 卖家入驻审核驳回
+	NotifyProductApproved         NotificationKind = "product_approved"          // 商品审核通过并上架
+	NotifyProductRejected         NotificationKind = "product_rejected"          // 商品审核驳回
+	NotifyProductOffline          NotificationKind = "product_offline"           // 商品被平台下架
+	NotifySettlementPaid          NotificationKind = "settlement_paid"           // 结算单已打款
+)
+
+type NotificationChannel string
+
+const (
+	NotificationChannelInApp NotificationChannel = "in_app" // 站内实时推送
+	NotificationChannelEmail NotificationChannel = "email"
+	NotificationChannelSMS   NotificationChannel = "sms"
+)
+
+type NotificationDeliveryStatus string
+
+const (
+	NotificationDeliveryPending NotificationDeliveryStatus = "pending"
+	NotificationDeliverySent    NotificationDeliveryStatus = "sent"
+	NotificationDeliveryFailed  NotificationDeliveryStatus = "failed" // 达到最大重试
+)
+
+// Notification 站内信，按模板渲染后落库。
+type Notification struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time        `json:"created_at"`
+	UserID    uint             `gorm:"not null;index:idx_notifications_user_read" json:"user_id"`
+	Kind      NotificationKind `gorm:"type:varchar(40);not null" json:"kind"`
+	Title     string           `gorm:"type:varchar(100);not null" json:"title"`
+	Content   string           `gorm:"type:varchar(500);not null" json:"content"`
+	ReadAt    *time.Time       `gorm:"index:idx_notifications_user_read" json:"read_at,omitempty"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationDelivery 通知投递记录，与业务变更同事务写入，由投递任务按渠道发送。
+type NotificationDelivery struct {
+	ID             uint                       `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time                  `json:"created_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+	NotificationID uint                       `gorm:"not null;index" json:"notification_id"`
+	UserID         uint                       `gorm:"not null" json:"user_id"`
+	Channel        NotificationChannel        `gorm:"type:varchar(20);not null" json:"channel"`
+	Recipient      string                     `gorm:"type:varchar(128);default:''" json:"recipient"` // 邮箱或手机号，站内推送为空
+	Status         NotificationDeliveryStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	RetryCount     int                        `gorm:"default:0" json:"retry_count"`
+	LastError      string                     `gorm:"type:varchar(512);default:''" json:"last_error"`
+	SentAt         *time.Time                 `json:"sent_at,omitempty"`
+}
+
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+// NotificationPreference 用户通知渠道偏好；无记录时仅开启站内推送。
+type NotificationPreference struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	InApp     bool      `gorm:"not null" json:"in_app"`
+	Email     bool      `gorm:"not null" json:"email"`
+	SMS       bool      `gorm:"not null" json:"sms"`
+	EmailAddr string    `gorm:"type:varchar(128);default:''" json:"email_addr"`
+	Phone     string    `gorm:"type:varchar(32);default:''" json:"phone"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
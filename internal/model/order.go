@@ -13,6 +13,15 @@ const (
 	OrderStatusPaid      OrderStatus = 1
 	OrderStatusFailed    OrderStatus = 2
 	OrderStatusCancelled OrderStatus = 3
+	OrderStatusRefunded  OrderStatus = 4
+)
+
+// OrderType 订单类型：商品订单 / VIP 套餐订单。
+type OrderType string
+
+const (
+	OrderTypeProduct OrderType = "product"
+	OrderTypeVIP     OrderType = "vip"
 )
 
 type Order struct {
@@ -20,10 +29,26 @@ type Order struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
-	UserID    uint           `gorm:"not null;index;idx_user_product,unique" json:"user_id"`
-	ProductID uint           `gorm:"not null;index:idx_user_product,unique" json:"product_id"`
+	UserID    uint           `gorm:"not null;index:idx_order_user_product" json:"user_id"`
+	ProductID uint           `gorm:"not null;index:idx_order_user_product" json:"product_id"` // VIP 订单为 0
 	OrderNum  string         `gorm:"type:varchar(32);unique;not null" json:"order_num"`
 	Status    OrderStatus    `gorm:"default:0" json:"status"`
+	Type      OrderType      `gorm:"type:varchar(20);default:'product';not null;index" json:"type"`
+	// VIP 订单快照：下单时的套餐与版本，支付成功后按此版本开通
+	VIPPlanID      int `gorm:"default:0;not null" json:"vip_plan_id,omitempty"`
+	VIPPlanVersion int `gorm:"default:0;not null" json:"vip_plan_version,omitempty"`
+	// PointsUsed 积分抵扣数，支付金额已扣除对应金额
+	PointsUsed int64 `gorm:"default:0;not null" json:"points_used"`
+	// 商品订单计价快照：下单时的原价与会员价，优惠券按此计算
+	OriginalPriceCents int64      `gorm:"default:0;not null" json:"original_price_cents"`
+	MemberPriceCents   int64      `gorm:"default:0;not null" json:"member_price_cents,omitempty"` // 0 表示未享受会员价
+	MemberLevel        int        `gorm:"default:0;not null" json:"member_level,omitempty"`       // 命中的会员价等级
+	PaidAt             *time.Time `gorm:"index" json:"paid_at,omitempty"`                         // 支付成功时间，成长值窗口按此统计
+}
+
+// IsVIP 是否为 VIP 套餐订单。
+func (o *Order) IsVIP() bool {
+	return o.Type == OrderTypeVIP
 }
 
 func (Order) TableName() string {
@@ -32,7 +57,7 @@ func (Order) TableName() string {
 
 func ValidOrderStatus(status OrderStatus) bool {
 	switch status {
-	case OrderStatusUnpaid, OrderStatusPaid, OrderStatusFailed, OrderStatusCancelled:
+	case OrderStatusUnpaid, OrderStatusPaid, OrderStatusFailed, OrderStatusCancelled, OrderStatusRefunded:
 		return true
 	default:
 		return false
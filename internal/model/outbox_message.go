@@ -13,6 +13,9 @@ const (
 	OutboxStatusFailed  OutboxStatus = 2 // 发送失败（达到最大重试）
 )
 
+// OutboxTopicWebhook 商家 Webhook 投递，由 OutboxCron 直接发 HTTP 请求而非写入 Kafka。
+const OutboxTopicWebhook = "webhook"
+
 // OutboxMessage 本地消息表模型，用于实现 Transactional Outbox Pattern
 type OutboxMessage struct {
 	ID         uint         `gorm:"primaryKey" json:"id"`
@@ -24,6 +27,8 @@ type OutboxMessage struct {
 	RetryCount int          `gorm:"default:0" json:"retry_count"`
 	LastError  string       `gorm:"type:varchar(512)" json:"last_error"`
 	SentAt     *time.Time   `gorm:"index" json:"sent_at"` // 发送成功时间
+	// NextAttemptAt 下次投递时间，Webhook 按指数退避重试；Kafka 消息不使用
+	NextAttemptAt *time.Time `gorm:"index" json:"next_attempt_at,omitempty"`
 }
 
 func (OutboxMessage) TableName() string {
@@ -4,13 +4,43 @@ import "time"
 
 // PaidVIP 记录付费 VIP 的等级与有效期。
 type PaidVIP struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `gorm:"not null;uniqueIndex" json:"user_id"`
-	Level     int       `gorm:"not null" json:"level"`
-	StartedAt time.Time `gorm:"not null" json:"started_at"`
-	ExpiredAt time.Time `gorm:"not null" json:"expired_at"`
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex" json:"user_id"`
+	Level       int       `gorm:"not null" json:"level"`
+	StartedAt   time.Time `gorm:"not null" json:"started_at"`
+	ExpiredAt   time.Time `gorm:"not null" json:"expired_at"`
+	PlanID      int       `gorm:"default:0;not null" json:"plan_id"`      // 购买的套餐 ID
+	PlanVersion int       `gorm:"default:0;not null" json:"plan_version"` // 购买时的套餐版本
 }
 
 func (PaidVIP) TableName() string {
 	return "paid_vips"
 }
+
+type PaidVIPGrantStatus string
+
+const (
+	PaidVIPGrantActive   PaidVIPGrantStatus = "active"
+	PaidVIPGrantRefunded PaidVIPGrantStatus = "refunded"
+)
+
+// PaidVIPGrant 记录每笔 VIP 订单开通的时段，续费时首尾相接，退款时据此缩短或撤销。
+type PaidVIPGrant struct {
+	ID           uint               `gorm:"primaryKey" json:"id"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+	UserID       uint               `gorm:"not null;index" json:"user_id"`
+	OrderID      uint               `gorm:"not null;uniqueIndex" json:"order_id"`
+	PlanID       int                `gorm:"not null" json:"plan_id"`
+	PlanVersion  int                `gorm:"not null" json:"plan_version"`
+	Level        int                `gorm:"not null" json:"level"`
+	DurationDays int                `gorm:"not null" json:"duration_days"`
+	StartsAt     time.Time          `gorm:"not null" json:"starts_at"`
+	EndsAt       time.Time          `gorm:"not null" json:"ends_at"`
+	Status       PaidVIPGrantStatus `gorm:"type:varchar(20);default:'active';not null" json:"status"`
+	RefundedAt   *time.Time         `json:"refunded_at,omitempty"`
+}
+
+func (PaidVIPGrant) TableName() string {
+	return "paid_vip_grants"
+}
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+type PenaltyAppealStatus string
+
+const (
+	PenaltyAppealNone     PenaltyAppealStatus = ""         // 未申诉
+	PenaltyAppealPending  PenaltyAppealStatus = "pending"  // 待处理
+	PenaltyAppealApproved PenaltyAppealStatus = "approved" // 申诉通过，处罚已解除
+	PenaltyAppealRejected PenaltyAppealStatus = "rejected" // 申诉驳回
+)
+
+// UnpaidTimeout 抢购订单超时未支付记录，每笔订单至多一条，按时间窗口计数。
+type UnpaidTimeout struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index:idx_unpaid_timeout_user_time,priority:2" json:"created_at"`
+	UserID    uint      `gorm:"not null;index:idx_unpaid_timeout_user_time,priority:1" json:"user_id"`
+	OrderID   uint      `gorm:"not null;uniqueIndex" json:"order_id"`
+	OrderNum  string    `gorm:"type:varchar(64);not null" json:"order_num"`
+	ProductID uint      `gorm:"not null" json:"product_id"`
+}
+
+func (UnpaidTimeout) TableName() string {
+	return "unpaid_timeouts"
+}
+
+// UserPenalty 用户未支付处罚状态与申诉记录，首次超时时创建。
+type UserPenalty struct {
+	UserID       uint                `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	TimeoutCount int                 `gorm:"not null;default:0" json:"timeout_count"` // 最近一次超时时窗口内的超时次数
+	BannedUntil  *time.Time          `json:"banned_until,omitempty"`                  // 暂停抢购截止时间
+	Graylisted   bool                `gorm:"not null;default:false" json:"graylisted"`
+	ResetAt      *time.Time          `json:"reset_at,omitempty"` // 重置时间，此前的超时不再计数
+	AppealStatus PenaltyAppealStatus `gorm:"type:varchar(20);default:'';index" json:"appeal_status,omitempty"`
+	AppealReason string              `gorm:"type:varchar(255);default:''" json:"appeal_reason,omitempty"`
+	AppealReply  string              `gorm:"type:varchar(255);default:''" json:"appeal_reply,omitempty"`
+	AppealedAt   *time.Time          `json:"appealed_at,omitempty"`
+}
+
+func (UserPenalty) TableName() string {
+	return "user_penalties"
+}
+
+// Banned 当前是否处于暂停抢购期。
+func (p *UserPenalty) Banned(now time.Time) bool {
+	return p.BannedUntil != nil && p.BannedUntil.After(now)
+}
@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+type PointKind string
+
+const (
+	PointKindOrderEarn      PointKind = "order_earn"      // 订单支付获得
+	PointKindFirstOrder     PointKind = "first_order"     // 首单奖励
+	PointKindReferral       PointKind = "referral"        // 邀请奖励
+	PointKindReferralRevoke PointKind = "referral_revoke" // 邀请首单退款收回奖励
+	PointKindRedeemCoupon   PointKind = "redeem_coupon"   // 兑换优惠券
+	PointKindOrderDeduct    PointKind = "order_deduct"    // 订单抵扣
+	PointKindOrderRelease   PointKind = "order_release"   // 订单取消/失败退回抵扣
+	PointKindOrderReversal  PointKind = "order_reversal"  // 订单退款扣回已获积分
+	PointKindExpire         PointKind = "expire"          // 过期
+)
+
+// PointEntry 积分流水。收入流水记录剩余可用积分与过期时间，支出按过期时间先后扣减。
+type PointEntry struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UserID       uint       `gorm:"not null;index" json:"user_id"`
+	Delta        int64      `gorm:"not null" json:"delta"`
+	Kind         PointKind  `gorm:"type:varchar(30);not null;index" json:"kind"`
+	OrderID      uint       `gorm:"default:0;not null;index" json:"order_id,omitempty"`
+	Remark       string     `gorm:"type:varchar(255);default:''" json:"remark"`
+	Remaining    int64      `gorm:"default:0;not null" json:"-"`
+	ExpiresAt    *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	BalanceAfter int64      `gorm:"not null" json:"balance_after"`
+}
+
+func (PointEntry) TableName() string {
+	return "point_entries"
+}
+
+// PointAllocation 支出流水占用的收入流水明细，订单抵扣退回时按原流水恢复剩余积分。
+type PointAllocation struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	SpendEntryID  uint      `gorm:"not null;index" json:"spend_entry_id"`
+	SourceEntryID uint      `gorm:"not null;index" json:"source_entry_id"`
+	OrderID       uint      `gorm:"default:0;not null;index" json:"order_id"`
+	Amount        int64     `gorm:"not null" json:"amount"`
+	Released      bool      `gorm:"default:false;not null" json:"released"`
+}
+
+func (PointAllocation) TableName() string {
+	return "point_allocations"
+}
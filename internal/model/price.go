@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+type PriceScheduleStatus string
+
+const (
+	PriceSchedulePending   PriceScheduleStatus = "pending"   // 待执行
+	PriceScheduleApplied   PriceScheduleStatus = "applied"   // 已生效
+	PriceScheduleCancelled PriceScheduleStatus = "cancelled" // 卖家取消或商品已删除
+	PriceScheduleFailed    PriceScheduleStatus = "failed"    // 到点校验未通过，如会员价不再低于新价格
+)
+
+// PriceSchedule 卖家预设的调价计划，到达生效时间后由定时任务改价。
+type PriceSchedule struct {
+	ID          uint                `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	ProductID   uint                `gorm:"not null;index" json:"product_id"`
+	SellerID    uint                `gorm:"not null" json:"seller_id"`
+	Price       float64             `gorm:"type:decimal(10,2);not null" json:"price"`
+	EffectiveAt time.Time           `gorm:"not null;index:idx_price_schedule_due,priority:2" json:"effective_at"`
+	Status      PriceScheduleStatus `gorm:"type:varchar(20);not null;index:idx_price_schedule_due,priority:1" json:"status"`
+	AppliedAt   *time.Time          `json:"applied_at,omitempty"`
+	FailReason  string              `gorm:"type:varchar(255);default:''" json:"fail_reason,omitempty"`
+}
+
+func (PriceSchedule) TableName() string {
+	return "price_schedules"
+}
+
+type PriceChangeSource string
+
+const (
+	PriceChangeCreate   PriceChangeSource = "create"   // 商品创建时的初始价格
+	PriceChangeManual   PriceChangeSource = "manual"   // 卖家编辑商品
+	PriceChangeSchedule PriceChangeSource = "schedule" // 调价计划生效
+)
+
+// PriceHistory 商品价格变动记录，每次改价追加一条。
+type PriceHistory struct {
+	ID         uint              `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time         `gorm:"index:idx_price_history_product,priority:2" json:"created_at"`
+	ProductID  uint              `gorm:"not null;index:idx_price_history_product,priority:1" json:"product_id"`
+	OldPrice   float64           `gorm:"type:decimal(10,2);not null" json:"old_price"` // 创建时为 0
+	NewPrice   float64           `gorm:"type:decimal(10,2);not null" json:"new_price"`
+	Source     PriceChangeSource `gorm:"type:varchar(20);not null" json:"source"`
+	ScheduleID uint              `gorm:"default:0;not null" json:"schedule_id,omitempty"`
+	OperatorID uint              `gorm:"default:0;not null" json:"-"` // 改价的卖家，定时任务为 0
+}
+
+func (PriceHistory) TableName() string {
+	return "price_history"
+}
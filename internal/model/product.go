@@ -1,23 +1,204 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type Product struct {
-	ID        uint           `gorm:"primaryKey" json:"id"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"uniqueIndex:idx_user_name_deleted" json:"-"`
-	UserID    uint           `gorm:"not null;uniqueIndex:idx_user_name_deleted" json:"user_id"`
-	Name      string         `gorm:"type:varchar(100);not null;uniqueIndex:idx_user_name_deleted" json:"name"`
-	Price     float64        `gorm:"type:decimal(10,2);not null" json:"price"`
-	Stock     int            `gorm:"not null" json:"stock"`
-	StartTime time.Time      `gorm:"not null" json:"start_time"`
-	EndTime   *time.Time     `json:"end_time"` // 可选，NULL 表示永不过期
-	Image     string         `gorm:"type:varchar(255)" json:"image"`
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"uniqueIndex:idx_user_name_deleted" json:"-"`
+	UserID      uint           `gorm:"not null;uniqueIndex:idx_user_name_deleted" json:"user_id"`
+	Name        string         `gorm:"type:varchar(100);not null;uniqueIndex:idx_user_name_deleted" json:"name"`
+	Price       float64        `gorm:"type:decimal(10,2);not null" json:"price"`
+	Stock       int            `gorm:"not null" json:"stock"`
+	StartTime   time.Time      `gorm:"not null" json:"start_time"`
+	EndTime     *time.Time     `json:"end_time"`                       // 可选，NULL 表示永不过期
+	Image       string         `gorm:"type:varchar(255)" json:"image"` // 封面图，即 images 第一张
+	Images      ProductImages  `gorm:"type:text" json:"images"`        // 按展示顺序排列的图集
+	Description string         `gorm:"type:text" json:"description"`
+	BrandID     uint           `gorm:"default:0;not null;index" json:"brand_id"`    // 0 表示未设置
+	CategoryID  uint           `gorm:"default:0;not null;index" json:"category_id"` // 0 表示未设置
+	Tags        ProductTags    `gorm:"type:text" json:"tags"`
+	MinVIPLevel int            `gorm:"default:0;not null" json:"min_vip_level"` // 最低可购 VIP 等级，0 表示不限
+	// 发售信息：货号、官方发售日期与发售价
+	SKU         string     `gorm:"type:varchar(64);default:'';index" json:"sku"`
+	ReleaseDate *time.Time `json:"release_date,omitempty"`
+	RetailPrice float64    `gorm:"type:decimal(10,2);default:0;not null" json:"retail_price"`
+	// 会员价：按等级配置，用户取不高于其生效等级的最高一档
+	MemberPrices          MemberPrices `gorm:"type:text" json:"member_prices"`
+	MemberCouponStackable bool         `gorm:"default:false;not null" json:"member_coupon_stackable"` // 会员价是否可与优惠券叠加
+	// 审核状态：仅 approved 对外展示并可抢购；存量商品迁移后默认为 approved
+	Status       ProductStatus `gorm:"type:varchar(20);default:'approved';not null;index" json:"status"`
+	ReviewReason string        `gorm:"type:varchar(255)" json:"review_reason,omitempty"` // 驳回或下架原因
+	SubmittedAt  *time.Time    `json:"submitted_at,omitempty"`                           // 最近一次提交审核时间，审核队列按此排序
+	ReviewedAt   *time.Time    `json:"reviewed_at,omitempty"`
+}
+
+// ProductStatus 商品审核与上架状态。
+type ProductStatus string
+
+const (
+	ProductStatusDraft         ProductStatus = "draft"          // 草稿，卖家尚未提交
+	ProductStatusPendingReview ProductStatus = "pending_review" // 待审核
+	ProductStatusApproved      ProductStatus = "approved"       // 审核通过，已上架
+	ProductStatusRejected      ProductStatus = "rejected"       // 审核驳回
+	ProductStatusOffline       ProductStatus = "offline"        // 已下架
+)
+
+func ValidProductStatus(status ProductStatus) bool {
+	switch status {
+	case ProductStatusDraft, ProductStatusPendingReview, ProductStatusApproved, ProductStatusRejected, ProductStatusOffline:
+		return true
+	default:
+		return false
+	}
+}
+
+// Listed 商品是否对外展示且可抢购。
+func (p *Product) Listed() bool {
+	return p.Status == ProductStatusApproved
+}
+
+// MemberPrice 会员价规则，DiscountRate 与 PriceCents 二选一。
+type MemberPrice struct {
+	Level        int   `json:"level"`
+	DiscountRate int   `json:"discount_rate,omitempty"` // 折扣百分比，90 表示九折
+	PriceCents   int64 `json:"price_cents,omitempty"`   // 固定会员价（分）
+}
+
+// MemberPrices 会员价列表，以 JSON 文本存储，支持 map 方式更新。
+type MemberPrices []MemberPrice
+
+func (m MemberPrices) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return "[]", nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (m *MemberPrices) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported member prices type: %T", src)
+	}
+	if len(data) == 0 {
+		*m = nil
+		return nil
+	}
+	return json.Unmarshal(data, m)
+}
+
+// ProductTags 商品标签，以 JSON 文本存储；按标签筛选时匹配带引号的元素，标签中不允许出现引号。
+type ProductTags []string
+
+func (t ProductTags) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return "[]", nil
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (t *ProductTags) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported product tags type: %T", src)
+	}
+	if len(data) == 0 {
+		*t = nil
+		return nil
+	}
+	return json.Unmarshal(data, t)
+}
+
+// ProductImage 商品图片及其缩略图、中图地址。
+type ProductImage struct {
+	URL       string `json:"url"`
+	Thumbnail string `json:"thumbnail"`
+	Medium    string `json:"medium"`
+}
+
+// ProductImages 商品图集，以 JSON 文本存储。
+type ProductImages []ProductImage
+
+func (m ProductImages) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return "[]", nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (m *ProductImages) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported product images type: %T", src)
+	}
+	if len(data) == 0 {
+		*m = nil
+		return nil
+	}
+	return json.Unmarshal(data, m)
+}
+
+// ProductSaleStatus 商品销售状态，由开售/结束时间与库存推导，不落库。
+type ProductSaleStatus string
+
+const (
+	ProductSaleUpcoming ProductSaleStatus = "upcoming" // 未开售
+	ProductSaleLive     ProductSaleStatus = "live"     // 开售中且有库存
+	ProductSaleSoldOut  ProductSaleStatus = "sold_out" // 开售中但已售罄
+	ProductSaleEnded    ProductSaleStatus = "ended"    // 已结束
+)
+
+func ValidProductSaleStatus(status ProductSaleStatus) bool {
+	switch status {
+	case ProductSaleUpcoming, ProductSaleLive, ProductSaleSoldOut, ProductSaleEnded:
+		return true
+	default:
+		return false
+	}
 }
 
 func (Product) TableName() string {
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+type ReferralStatus string
+
+const (
+	ReferralStatusPending  ReferralStatus = "pending"  // 已注册，等待首单
+	ReferralStatusRewarded ReferralStatus = "rewarded" // 首单支付，已发放奖励
+	ReferralStatusRejected ReferralStatus = "rejected" // 命中反作弊规则，不发奖励
+	ReferralStatusRevoked  ReferralStatus = "revoked"  // 首单退款，奖励已收回
+)
+
+// 邀请被拒原因
+const (
+	ReferralRejectSelf        = "self_referral" // 与邀请人同设备
+	ReferralRejectSameIP      = "same_ip"       // 与邀请人同 IP
+	ReferralRejectSourceLimit = "source_limit"  // 同一 IP/设备被邀请人数超限
+	ReferralRejectRiskListed  = "risk_listed"   // 邀请人或被邀请人命中风控名单
+)
+
+// InviteCode 用户邀请码，首次查询时生成。
+type InviteCode struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	Code      string    `gorm:"type:varchar(16);uniqueIndex;not null" json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (InviteCode) TableName() string {
+	return "invite_codes"
+}
+
+// Referral 邀请关系，每个被邀请人至多一条；记录注册来源用于反作弊与奖励追溯。
+type Referral struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	InviterID    uint           `gorm:"not null;index" json:"inviter_id"`
+	InviteeID    uint           `gorm:"not null;uniqueIndex" json:"invitee_id"`
+	Code         string         `gorm:"type:varchar(16);not null" json:"code"`
+	Status       ReferralStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	RejectReason string         `gorm:"type:varchar(30);default:''" json:"reject_reason,omitempty"`
+	RegisterIP   string         `gorm:"type:varchar(64);default:'';index" json:"register_ip"`
+	DeviceID     string         `gorm:"type:varchar(64);default:'';index" json:"device_id"`
+	OrderID      uint           `gorm:"default:0;not null;index" json:"order_id,omitempty"` // 触发奖励的首单
+	RewardedAt   *time.Time     `json:"rewarded_at,omitempty"`
+}
+
+func (Referral) TableName() string {
+	return "referrals"
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type SellerStatus string
+
+const (
+	SellerStatusPending  SellerStatus = "pending"  // 已提交，待审核
+	SellerStatusApproved SellerStatus = "approved" // 审核通过，可发布商品
+	SellerStatusRejected SellerStatus = "rejected" // 审核驳回，可修改后重新提交
+)
+
+// SellerProfile 卖家入驻资料与审核状态，每个用户至多一条。
+type SellerProfile struct {
+	UserID       uint         `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	StoreName    string       `gorm:"type:varchar(50);not null;uniqueIndex" json:"store_name"`
+	Description  string       `gorm:"type:varchar(500);default:''" json:"description"`
+	Logo         string       `gorm:"type:varchar(255);default:''" json:"logo"`
+	ContactName  string       `gorm:"type:varchar(50);not null" json:"contact_name,omitempty"`
+	ContactPhone string       `gorm:"type:varchar(32);not null" json:"contact_phone,omitempty"`
+	LicenseNo    string       `gorm:"type:varchar(64);default:''" json:"license_no,omitempty"` // 营业执照号或身份证明编号
+	Status       SellerStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	RejectReason string       `gorm:"type:varchar(255);default:''" json:"reject_reason,omitempty"`
+	ReviewedBy   uint         `gorm:"default:0;not null" json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time   `json:"reviewed_at,omitempty"`
+	ApprovedAt   *time.Time   `json:"approved_at,omitempty"` // 首次审核通过时间
+}
+
+func (SellerProfile) TableName() string {
+	return "seller_profiles"
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// SettlementStatus 结算单状态。
+type SettlementStatus string
+
+const (
+	SettlementStatusPending SettlementStatus = "pending" // 待打款
+	SettlementStatusPaid    SettlementStatus = "paid"    // 已打款
+)
+
+// SettlementItemKind 结算明细类型。
+type SettlementItemKind string
+
+const (
+	SettlementItemSale   SettlementItemKind = "sale"   // 退款期满的已支付订单
+	SettlementItemRefund SettlementItemKind = "refund" // 已结算订单发生退款后的冲销，金额为负
+)
+
+// SettlementStatement 卖家按周期汇总的结算单，同一卖家每个周期一张。
+type SettlementStatement struct {
+	ID              uint             `gorm:"primaryKey" json:"id"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	SellerID        uint             `gorm:"not null;uniqueIndex:idx_settlement_seller_period" json:"seller_id"`
+	PeriodStart     time.Time        `gorm:"not null;uniqueIndex:idx_settlement_seller_period" json:"period_start"`
+	PeriodEnd       time.Time        `gorm:"not null" json:"period_end"`
+	OrderCount      int              `gorm:"not null;default:0" json:"order_count"`
+	GrossCents      int64            `gorm:"not null;default:0" json:"gross_cents"`      // 结算订单货款合计
+	RefundCents     int64            `gorm:"not null;default:0" json:"refund_cents"`     // 退款冲销货款合计（正数）
+	CommissionCents int64            `gorm:"not null;default:0" json:"commission_cents"` // 平台佣金，已扣除冲销部分
+	NetCents        int64            `gorm:"not null;default:0" json:"net_cents"`        // 应付卖家金额，可能为负
+	Status          SettlementStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	PayoutRef       string           `gorm:"type:varchar(64)" json:"payout_ref,omitempty"` // 打款流水号
+	PaidBy          uint             `gorm:"default:0;not null" json:"paid_by,omitempty"`
+	PaidAt          *time.Time       `json:"paid_at,omitempty"`
+}
+
+// SettlementItem 结算明细，每笔订单的销售与冲销各最多一条。
+type SettlementItem struct {
+	ID              uint               `gorm:"primaryKey" json:"id"`
+	CreatedAt       time.Time          `json:"created_at"`
+	StatementID     uint               `gorm:"not null;index" json:"statement_id"`
+	SellerID        uint               `gorm:"not null;index" json:"seller_id"`
+	OrderID         uint               `gorm:"not null;uniqueIndex:idx_settlement_item_order_kind" json:"order_id"`
+	Kind            SettlementItemKind `gorm:"type:varchar(20);not null;uniqueIndex:idx_settlement_item_order_kind" json:"kind"`
+	OrderNum        string             `gorm:"type:varchar(32);not null" json:"order_num"`
+	ProductID       uint               `gorm:"not null" json:"product_id"`
+	AmountCents     int64              `gorm:"not null" json:"amount_cents"` // 货款：会员价或原价，平台券与积分补贴不由卖家承担
+	CommissionCents int64              `gorm:"not null" json:"commission_cents"`
+	NetCents        int64              `gorm:"not null" json:"net_cents"`
+	PaidAt          time.Time          `json:"paid_at"` // 订单支付时间
+}
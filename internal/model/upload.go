@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Upload 上传文件元数据，按内容哈希去重；引用数由清理任务统计，超过保留期仍未被引用的文件会被删除。
+type Upload struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Hash       string    `gorm:"type:char(64);not null;uniqueIndex" json:"hash"` // 上传内容的 SHA-256
+	Key        string    `gorm:"type:varchar(255);not null" json:"key"`          // 原图存储路径，变体按 <name>_thumb、<name>_medium 命名
+	OwnerID    uint      `gorm:"index;not null" json:"owner_id"`                 // 首次上传的用户
+	Size       int64     `gorm:"not null" json:"size"`                           // 上传内容大小(字节)
+	MimeType   string    `gorm:"type:varchar(50);not null" json:"mime_type"`
+	Width      int       `gorm:"default:0;not null" json:"width"`
+	Height     int       `gorm:"default:0;not null" json:"height"`
+	RefCount   int       `gorm:"default:0;not null" json:"ref_count"` // 最近一次统计的引用数
+	LastUsedAt time.Time `gorm:"index;not null" json:"last_used_at"`  // 最近一次上传或引用变化的时间，保留期从此开始计算
+}
+
+func (Upload) TableName() string {
+	return "uploads"
+}
@@ -1,6 +1,10 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 const (
 	UserRoleUser        = "user"
@@ -13,13 +17,16 @@ const (
 )
 
 const (
-	AdminResourceStats    = "stats"
-	AdminResourceUsers    = "users"
-	AdminResourceOrders   = "orders"
-	AdminResourceProducts = "products"
-	AdminResourceCoupons  = "coupons"
-	AdminResourceRisk     = "risk"
-	AdminResourceAudit    = "audit"
+	AdminResourceStats      = "stats"
+	AdminResourceUsers      = "users"
+	AdminResourceOrders     = "orders"
+	AdminResourceProducts   = "products"
+	AdminResourceCoupons    = "coupons"
+	AdminResourceRisk       = "risk"
+	AdminResourceAudit      = "audit"
+	AdminResourceVIP        = "vip"
+	AdminResourceReferral   = "referral"
+	AdminResourceSettlement = "settlement" // 卖家结算与打款
 )
 
 var adminRolePermissions = map[string][]string{
@@ -31,6 +38,9 @@ var adminRolePermissions = map[string][]string{
 		AdminResourceCoupons,
 		AdminResourceRisk,
 		AdminResourceAudit,
+		AdminResourceVIP,
+		AdminResourceReferral,
+		AdminResourceSettlement,
 	},
 	UserRoleSuperAdmin: {
 		AdminResourceStats,
@@ -40,22 +50,29 @@ var adminRolePermissions = map[string][]string{
 		AdminResourceCoupons,
 		AdminResourceRisk,
 		AdminResourceAudit,
+		AdminResourceVIP,
+		AdminResourceReferral,
+		AdminResourceSettlement,
 	},
 	UserRoleOpsAdmin: {
 		AdminResourceStats,
 		AdminResourceUsers,
 		AdminResourceOrders,
 		AdminResourceProducts,
+		AdminResourceReferral,
+		AdminResourceSettlement,
 	},
 	UserRoleRiskAdmin: {
 		AdminResourceStats,
 		AdminResourceRisk,
 		AdminResourceAudit,
+		AdminResourceReferral,
 	},
 	UserRoleCouponAdmin: {
 		AdminResourceStats,
 		AdminResourceCoupons,
 		AdminResourceAudit,
+		AdminResourceVIP,
 	},
 	UserRoleAuditAdmin: {
 		AdminResourceStats,
@@ -71,7 +88,11 @@ type User struct {
 	Avatar          string  `gorm:"type:varchar(255);default:''" json:"avatar"`
 	TotalSpentCents int64   `gorm:"type:bigint;default:0;not null" json:"total_spent_cents"`
 	GrowthLevel     int     `gorm:"type:int;default:1;not null" json:"growth_level"`
-	Role            string  `gorm:"type:varchar(20);default:'user';not null" json:"role"`
+	// 滚动窗口内实付（分），成长等级按此计算；降级宽限截止时间，为空表示无待降级
+	WindowSpentCents  int64      `gorm:"type:bigint;default:0;not null" json:"window_spent_cents"`
+	GrowthDowngradeAt *time.Time `gorm:"index" json:"growth_downgrade_at,omitempty"`
+	Points            int64      `gorm:"type:bigint;default:0;not null" json:"points"`
+	Role              string     `gorm:"type:varchar(20);default:'user';not null" json:"role"`
 }
 
 func (User) TableName() string {
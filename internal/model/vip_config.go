@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// VIP 套餐状态
+const (
+	VIPPlanStatusActive   = "active"
+	VIPPlanStatusInactive = "inactive"
+)
+
+// VIPPlan 付费 VIP 套餐。每次修改生成新版本，购买记录保存版本号以保留当时的条款。
+type VIPPlan struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	PlanID       int       `gorm:"not null;uniqueIndex:idx_vip_plan_version" json:"plan_id"` // 套餐业务 ID，跨版本不变
+	Version      int       `gorm:"not null;uniqueIndex:idx_vip_plan_version" json:"version"`
+	IsCurrent    bool      `gorm:"not null;default:false;index" json:"is_current"`
+	Name         string    `gorm:"type:varchar(50);not null" json:"name"`
+	Level        int       `gorm:"not null" json:"level"`                 // VIP 等级
+	DurationDays int       `gorm:"not null" json:"duration_days"`         // 有效天数
+	PriceCents   int64     `gorm:"default:0;not null" json:"price_cents"` // 价格（分）
+	Status       string    `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+	CreatedBy    uint      `gorm:"default:0;not null" json:"created_by"`
+}
+
+func (VIPPlan) TableName() string {
+	return "vip_plans"
+}
+
+// VIPLevelConfig 成长等级配置：升级门槛与月度券规格，同样按版本保存。
+type VIPLevelConfig struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	Level               int        `gorm:"not null;uniqueIndex:idx_vip_level_version" json:"level"`
+	Version             int        `gorm:"not null;uniqueIndex:idx_vip_level_version" json:"version"`
+	IsCurrent           bool       `gorm:"not null;default:false;index" json:"is_current"`
+	MinSpentCents       int64      `gorm:"default:0;not null" json:"min_spent_cents"`      // 升级门槛：累计实付（分）
+	MonthlyCouponQuota  int        `gorm:"default:0;not null" json:"monthly_coupon_quota"` // 每月发券张数
+	CouponTitle         string     `gorm:"type:varchar(100);default:''" json:"coupon_title"`
+	CouponType          CouponType `gorm:"type:varchar(20);default:''" json:"coupon_type"`
+	CouponAmountCents   int64      `gorm:"default:0;not null" json:"coupon_amount_cents"`
+	CouponDiscountRate  int        `gorm:"default:0;not null" json:"coupon_discount_rate"`
+	CouponMinSpendCents int64      `gorm:"default:0;not null" json:"coupon_min_spend_cents"`
+	EarlyAccessMinutes  int        `gorm:"default:0;not null" json:"early_access_minutes"` // 秒杀提前入场分钟数
+	CreatedBy           uint       `gorm:"default:0;not null" json:"created_by"`
+}
+
+func (VIPLevelConfig) TableName() string {
+	return "vip_level_configs"
+}
+
+// DefaultVIPPlans 迁移时写入的默认付费套餐。
+func DefaultVIPPlans() []VIPPlan {
+	return []VIPPlan{
+		{PlanID: 1, Version: 1, IsCurrent: true, Name: "L3 月卡", Level: 3, DurationDays: 30, PriceCents: 3000, Status: VIPPlanStatusActive}, // L3 30 天
+		{PlanID: 2, Version: 1, IsCurrent: true, Name: "L4 季卡", Level: 4, DurationDays: 90, PriceCents: 8000, Status: VIPPlanStatusActive}, // L4 90 天
+	}
+}
+
+// DefaultVIPLevels 迁移时写入的默认成长等级：门槛、月度配额、月度券规格与提前入场时间。
+func DefaultVIPLevels() []VIPLevelConfig {
+	return []VIPLevelConfig{
+		{Level: 1, Version: 1, IsCurrent: true, MinSpentCents: 0, MonthlyCouponQuota: 1, CouponTitle: "VIP L1 月度券", CouponType: CouponTypeFullCut, CouponAmountCents: 500, CouponMinSpendCents: 3000},        // 满30减5
+		{Level: 2, Version: 1, IsCurrent: true, MinSpentCents: 100_000, MonthlyCouponQuota: 2, CouponTitle: "VIP L2 月度券", CouponType: CouponTypeFullCut, CouponAmountCents: 1000, CouponMinSpendCents: 5000}, // 满50减10
+		{Level: 3, Version: 1, IsCurrent: true, MinSpentCents: 500_000, MonthlyCouponQuota: 3, CouponTitle: "VIP L3 月度券", CouponType: CouponTypeDiscount, CouponDiscountRate: 90, EarlyAccessMinutes: 5},     // 九折，提前 5 分钟
+		{Level: 4, Version: 1, IsCurrent: true, MinSpentCents: 2_000_000, MonthlyCouponQuota: 4, CouponTitle: "VIP L4 月度券", CouponType: CouponTypeDiscount, CouponDiscountRate: 85, EarlyAccessMinutes: 10},  // 八五折，提前 10 分钟
+	}
+}
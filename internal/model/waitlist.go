@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+type WaitlistStatus string
+
+const (
+	WaitlistStatusWaiting   WaitlistStatus = "waiting"   // 排队中
+	WaitlistStatusOffered   WaitlistStatus = "offered"   // 已分配回流库存，等待认领
+	WaitlistStatusClaimed   WaitlistStatus = "claimed"   // 已认领并下单
+	WaitlistStatusExpired   WaitlistStatus = "expired"   // 认领超时
+	WaitlistStatusCancelled WaitlistStatus = "cancelled" // 用户退出
+)
+
+// WaitlistEntry 售罄商品候补排队，按 ID 先到先得，分配时处于未支付处罚降级中的用户排在其他用户之后；取消订单回流的库存优先分配给队首用户。
+type WaitlistEntry struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	ProductID      uint           `gorm:"not null;uniqueIndex:idx_waitlist_product_user;index:idx_waitlist_product_status" json:"product_id"`
+	UserID         uint           `gorm:"not null;uniqueIndex:idx_waitlist_product_user" json:"user_id"`
+	Status         WaitlistStatus `gorm:"type:varchar(20);not null;index:idx_waitlist_product_status" json:"status"`
+	OfferedAt      *time.Time     `json:"offered_at,omitempty"`
+	OfferExpiresAt *time.Time     `gorm:"index" json:"offer_expires_at,omitempty"`
+	OrderNum       string         `gorm:"type:varchar(64);default:''" json:"order_num,omitempty"` // 认领后生成的订单号
+}
+
+func (WaitlistEntry) TableName() string {
+	return "waitlist_entries"
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEvent 推送给商家的事件类型。
+type WebhookEvent string
+
+const (
+	WebhookOrderPaid      WebhookEvent = "order.paid"
+	WebhookOrderCancelled WebhookEvent = "order.cancelled"
+	WebhookOrderRefunded  WebhookEvent = "order.refunded"
+	WebhookProductSoldOut WebhookEvent = "product.sold_out"
+)
+
+// WebhookEventList 全部可订阅事件。
+var WebhookEventList = []WebhookEvent{WebhookOrderPaid, WebhookOrderCancelled, WebhookOrderRefunded, WebhookProductSoldOut}
+
+func ValidWebhookEvent(event WebhookEvent) bool {
+	switch event {
+	case WebhookOrderPaid, WebhookOrderCancelled, WebhookOrderRefunded, WebhookProductSoldOut:
+		return true
+	default:
+		return false
+	}
+}
+
+// WebhookEvents 订阅的事件列表，以 JSON 文本存储。
+type WebhookEvents []WebhookEvent
+
+func (e WebhookEvents) Value() (driver.Value, error) {
+	if len(e) == 0 {
+		return "[]", nil
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (e *WebhookEvents) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*e = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported webhook events type: %T", src)
+	}
+	if len(data) == 0 {
+		*e = nil
+		return nil
+	}
+	return json.Unmarshal(data, e)
+}
+
+// Has 是否订阅了指定事件。
+func (e WebhookEvents) Has(event WebhookEvent) bool {
+	for _, ev := range e {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEndpoint 卖家注册的回调地址，投递时用 Secret 做 HMAC-SHA256 签名。
+type WebhookEndpoint struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	SellerID    uint           `gorm:"not null;index" json:"seller_id"`
+	URL         string         `gorm:"type:varchar(512);not null" json:"url"`
+	Secret      string         `gorm:"type:varchar(64);not null" json:"-"`
+	Events      WebhookEvents  `gorm:"type:text" json:"events"`
+	Enabled     bool           `gorm:"not null" json:"enabled"`
+	Description string         `gorm:"type:varchar(255);default:''" json:"description"`
+}
+
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed" // 达到最大尝试次数或地址已停用
+)
+
+// WebhookDelivery 一次事件投递及其最近一次请求结果；重新投递会生成新记录。
+type WebhookDelivery struct {
+	ID             uint                  `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	EndpointID     uint                  `gorm:"not null;index:idx_webhook_delivery_endpoint" json:"endpoint_id"`
+	SellerID       uint                  `gorm:"not null;index" json:"seller_id"`
+	EventID        string                `gorm:"type:varchar(32);not null;index" json:"event_id"` // 同一事件重新投递时不变，接收方据此去重
+	Event          WebhookEvent          `gorm:"type:varchar(40);not null" json:"event"`
+	Payload        string                `gorm:"type:text;not null" json:"payload"` // 请求体 JSON
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);not null;index:idx_webhook_delivery_endpoint" json:"status"`
+	Attempts       int                   `gorm:"default:0;not null" json:"attempts"`
+	ResponseStatus int                   `gorm:"default:0;not null" json:"response_status"`
+	ResponseBody   string                `gorm:"type:varchar(1024);default:''" json:"response_body"`
+	LastError      string                `gorm:"type:varchar(512);default:''" json:"last_error"`
+	DurationMs     int64                 `gorm:"default:0;not null" json:"duration_ms"`
+	RedeliveryOf   uint                  `gorm:"default:0;not null" json:"redelivery_of,omitempty"` // 手动重新投递的原记录
+	NextRetryAt    *time.Time            `json:"next_retry_at,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
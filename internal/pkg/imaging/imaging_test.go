@@ -0,0 +1,152 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"testing"
+)
+
+func TestFitKeepsAspectAndAverages(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 400; x++ {
+			if x%2 == 0 {
+				src.Set(x, y, color.RGBA{R: 200, A: 255})
+			} else {
+				src.Set(x, y, color.RGBA{B: 100, A: 255})
+			}
+		}
+	}
+	got := Fit(src, 200)
+	if got.Bounds().Dx() != 200 || got.Bounds().Dy() != 50 {
+		t.Fatalf("Fit() bounds = %v, want 200x50", got.Bounds())
+	}
+	if c := color.RGBAModel.Convert(got.At(10, 10)).(color.RGBA); c != (color.RGBA{R: 100, B: 50, A: 255}) {
+		t.Fatalf("Fit() pixel = %v, want averaged", c)
+	}
+	if small := Fit(src, 1000); small != image.Image(src) {
+		t.Fatalf("Fit() should not upscale")
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// 2x1：左红右蓝
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	cases := []struct {
+		orientation int
+		w, h        int
+		first       color.RGBA
+	}{
+		{1, 2, 1, red},
+		{2, 2, 1, blue},
+		{3, 2, 1, blue},
+		{6, 1, 2, red},
+		{8, 1, 2, blue},
+	}
+	for _, tc := range cases {
+		got := Orient(src, tc.orientation)
+		if got.Bounds().Dx() != tc.w || got.Bounds().Dy() != tc.h {
+			t.Fatalf("Orient(%d) bounds = %v", tc.orientation, got.Bounds())
+		}
+		if c := color.RGBAModel.Convert(got.At(0, 0)).(color.RGBA); c != tc.first {
+			t.Fatalf("Orient(%d) first pixel = %v, want %v", tc.orientation, c, tc.first)
+		}
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 2)), nil); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	plain := buf.Bytes()
+	if got := JPEGOrientation(plain); got != 1 {
+		t.Fatalf("JPEGOrientation(no exif) = %d, want 1", got)
+	}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		withExif := insertTestEXIF(plain, order, 6)
+		if got := JPEGOrientation(withExif); got != 6 {
+			t.Fatalf("JPEGOrientation(%v) = %d, want 6", order, got)
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(withExif)); err != nil {
+			t.Fatalf("decode with exif: %v", err)
+		}
+	}
+	if got := JPEGOrientation([]byte("not a jpeg")); got != 1 {
+		t.Fatalf("JPEGOrientation(garbage) = %d, want 1", got)
+	}
+}
+
+func TestStripGIFMetadata(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	anim := &gif.GIF{LoopCount: 3}
+	for i := 0; i < 2; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+		frame.SetColorIndex(i, i, 1)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("encode gif: %v", err)
+	}
+	clean := buf.Bytes()
+
+	// 在结束符前插入注释与 XMP 应用扩展
+	comment := append([]byte{0x21, 0xFE, 7}, "GPS=1,2"...)
+	comment = append(comment, 0)
+	xmp := append([]byte{0x21, 0xFF, 11}, "XMP DataXMP"...)
+	xmp = append(xmp, 4, '<', 'x', '/', '>', 0)
+	data := append([]byte{}, clean[:len(clean)-1]...)
+	data = append(data, comment...)
+	data = append(data, xmp...)
+	data = append(data, 0x3B)
+
+	got, err := StripGIFMetadata(data)
+	if err != nil {
+		t.Fatalf("StripGIFMetadata() error = %v", err)
+	}
+	if !bytes.Equal(got, clean) {
+		t.Fatalf("StripGIFMetadata() kept metadata: %q", got)
+	}
+	decoded, err := gif.DecodeAll(bytes.NewReader(got))
+	if err != nil || len(decoded.Image) != 2 || decoded.LoopCount != 3 {
+		t.Fatalf("decode stripped gif = %+v, %v", decoded, err)
+	}
+	if _, err := StripGIFMetadata(clean[:len(clean)/2]); !errors.Is(err, ErrInvalidGIF) {
+		t.Fatalf("StripGIFMetadata(truncated) error = %v, want ErrInvalidGIF", err)
+	}
+}
+
+// insertTestEXIF 在 SOI 之后插入只含 Orientation 的 APP1 段。
+func insertTestEXIF(jpegData []byte, order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112) // Orientation
+	order.PutUint16(tiff[12:], 3)      // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
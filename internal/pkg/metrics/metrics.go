@@ -128,6 +128,7 @@ var (
 	seckillResult     = NewCounterVec("seckill_requests_total", "Seckill business result", []string{"result"})
 	breakerTransition = NewCounterVec("circuit_breaker_transitions_total", "Circuit breaker state transitions", []string{"breaker", "from", "to"})
 	breakerReject     = NewCounterVec("circuit_breaker_reject_total", "Circuit breaker rejected calls", []string{"breaker", "state"})
+	uploadRejected    = NewCounterVec("upload_rejected_total", "Rejected uploads by reason", []string{"reason"})
 )
 
 // ObserveHTTP 记录 HTTP 维度请求。
@@ -163,6 +164,11 @@ func IncBreakerReject(name, state string) {
 	})
 }
 
+// IncUploadRejected 记录被拒绝的上传及原因。
+func IncUploadRejected(reason string) {
+	uploadRejected.Inc(map[string]string{"reason": reason})
+}
+
 // Handler 暴露 Prometheus 文本格式。
 func Handler(w http.ResponseWriter, _ *http.Request) {
 	var sb strings.Builder
@@ -171,6 +177,7 @@ func Handler(w http.ResponseWriter, _ *http.Request) {
 	seckillResult.Export(&sb)
 	breakerTransition.Export(&sb)
 	breakerReject.Export(&sb)
+	uploadRejected.Export(&sb)
 	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
 	_, _ = w.Write([]byte(sb.String()))
 }
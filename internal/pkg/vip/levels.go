@@ -1,24 +1,30 @@
 package vip
 
-// GrowthLevel 定义成长等级阈值（单位：分），越往后间隔越大。
+// Threshold 成长等级门槛（单位：分）。
+type Threshold struct {
+	Level int
+	Min   int64
+}
+
+// DefaultThresholds 默认成长等级阈值，越往后间隔越大；后台未配置时使用。
 // L1: 0-99,999 分（0-999元）
 // L2: 100,000-499,999 分（1,000-4,999元）
 // L3: 500,000-1,999,999 分（5,000-19,999元）
 // L4: 2,000,000 分以上（20,000元+）
-var growthThresholds = []struct {
-	Level int
-	Min   int64
-}{
+var DefaultThresholds = []Threshold{
 	{Level: 1, Min: 0},
 	{Level: 2, Min: 100_000},
 	{Level: 3, Min: 500_000},
 	{Level: 4, Min: 2_000_000},
 }
 
-// CalcGrowthLevel 按累计实付金额（分）计算成长等级。
-func CalcGrowthLevel(totalSpentCents int64) int {
+// CalcGrowthLevel 按累计实付金额（分）和阈值配置计算成长等级，thresholds 为空时使用默认阈值。
+func CalcGrowthLevel(totalSpentCents int64, thresholds []Threshold) int {
+	if len(thresholds) == 0 {
+		thresholds = DefaultThresholds
+	}
 	level := 1
-	for _, th := range growthThresholds {
+	for _, th := range thresholds {
 		if totalSpentCents >= th.Min && th.Level > level {
 			level = th.Level
 		}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type CatalogRepo struct {
+	db *gorm.DB
+}
+
+func NewCatalogRepo(db *gorm.DB) *CatalogRepo {
+	return &CatalogRepo{db: db}
+}
+
+// ListBrands 全部品牌，按排序值与名称升序。
+func (r *CatalogRepo) ListBrands(ctx context.Context) ([]model.Brand, error) {
+	var brands []model.Brand
+	err := r.db.WithContext(ctx).Order("sort asc, name asc").Find(&brands).Error
+	return brands, err
+}
+
+func (r *CatalogRepo) GetBrand(ctx context.Context, id uint) (*model.Brand, error) {
+	var brand model.Brand
+	if err := r.db.WithContext(ctx).First(&brand, id).Error; err != nil {
+		return nil, err
+	}
+	return &brand, nil
+}
+
+func (r *CatalogRepo) CreateBrand(ctx context.Context, brand *model.Brand) error {
+	return r.db.WithContext(ctx).Create(brand).Error
+}
+
+func (r *CatalogRepo) UpdateBrand(ctx context.Context, id uint, data map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.Brand{}).Where("id = ?", id).Updates(data).Error
+}
+
+func (r *CatalogRepo) DeleteBrand(ctx context.Context, id uint) (int64, error) {
+	tx := r.db.WithContext(ctx).Delete(&model.Brand{}, id)
+	return tx.RowsAffected, tx.Error
+}
+
+// ListCategories 全部分类，按层级、排序值与 ID 升序。
+func (r *CatalogRepo) ListCategories(ctx context.Context) ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.WithContext(ctx).Order("level asc, sort asc, id asc").Find(&categories).Error
+	return categories, err
+}
+
+func (r *CatalogRepo) GetCategory(ctx context.Context, id uint) (*model.Category, error) {
+	var category model.Category
+	if err := r.db.WithContext(ctx).First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CatalogRepo) CreateCategory(ctx context.Context, category *model.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *CatalogRepo) UpdateCategory(ctx context.Context, id uint, data map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.Category{}).Where("id = ?", id).Updates(data).Error
+}
+
+func (r *CatalogRepo) DeleteCategory(ctx context.Context, id uint) (int64, error) {
+	tx := r.db.WithContext(ctx).Delete(&model.Category{}, id)
+	return tx.RowsAffected, tx.Error
+}
+
+func (r *CatalogRepo) CountChildCategories(ctx context.Context, parentID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.Category{}).Where("parent_id = ?", parentID).Count(&total).Error
+	return total, err
+}
+
+// CountProductsByBrand 统计引用该品牌的商品数（不含已删除商品）。
+func (r *CatalogRepo) CountProductsByBrand(ctx context.Context, brandID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.Product{}).Where("brand_id = ?", brandID).Count(&total).Error
+	return total, err
+}
+
+// CountProductsByCategory 统计直接挂在该分类下的商品数（不含已删除商品）。
+func (r *CatalogRepo) CountProductsByCategory(ctx context.Context, categoryID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.Product{}).Where("category_id = ?", categoryID).Count(&total).Error
+	return total, err
+}
@@ -129,6 +129,22 @@ func (r *UserCouponRepo) ListByUserAndStatus(ctx context.Context, userID uint, s
 	return ucs, total, nil
 }
 
+// ListUsableByUser 查询用户未过期的可用券；orderID 非 0 时一并返回已绑定到该订单的券。
+func (r *UserCouponRepo) ListUsableByUser(ctx context.Context, userID, orderID uint, now time.Time) ([]model.UserCoupon, error) {
+	var ucs []model.UserCoupon
+	q := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if orderID != 0 {
+		q = q.Where("(status = ? AND valid_to >= ?) OR (status = ? AND order_id = ?)",
+			model.CouponStatusAvailable, now, model.CouponStatusUsed, orderID)
+	} else {
+		q = q.Where("status = ? AND valid_to >= ?", model.CouponStatusAvailable, now)
+	}
+	if err := q.Order("id asc").Find(&ucs).Error; err != nil {
+		return nil, err
+	}
+	return ucs, nil
+}
+
 // GetByIDForUpdate 按 ID 查询并锁定用户券。
 func (r *UserCouponRepo) GetByIDForUpdate(ctx context.Context, id uint) (*model.UserCoupon, error) {
 	var uc model.UserCoupon
@@ -221,3 +237,42 @@ func (r *UserCouponRepo) MarkExpiredBatch(ctx context.Context, now time.Time) (i
 		Update("status", model.CouponStatusExpired)
 	return tx.RowsAffected, tx.Error
 }
+
+// ListUserIDsByObtainedFrom 查询指定来源下已领券的用户 ID，用于批量发券幂等过滤。
+func (r *UserCouponRepo) ListUserIDsByObtainedFrom(ctx context.Context, obtainedFrom string, userIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(userIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.WithContext(ctx).Model(&model.UserCoupon{}).
+		Where("obtained_from = ? AND user_id IN ?", obtainedFrom, userIDs).
+		Distinct().
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// ExpireAvailableByObtainedFrom 作废指定来源下尚未使用的券，用于撤销奖励。
+func (r *UserCouponRepo) ExpireAvailableByObtainedFrom(ctx context.Context, obtainedFrom string) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.UserCoupon{}).
+		Where("obtained_from = ? AND status = ?", obtainedFrom, model.CouponStatusAvailable).
+		Update("status", model.CouponStatusExpired)
+	return tx.RowsAffected, tx.Error
+}
+
+// ListExpiring 查询将在 before 前过期且未提醒过的可用券。
+func (r *UserCouponRepo) ListExpiring(ctx context.Context, now, before time.Time, limit int) ([]model.UserCoupon, error) {
+	var ucs []model.UserCoupon
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND reminded_at IS NULL AND valid_to > ? AND valid_to <= ?", model.CouponStatusAvailable, now, before).
+		Order("id asc").
+		Limit(limit).
+		Find(&ucs).Error
+	return ucs, err
+}
+
+func (r *UserCouponRepo) MarkReminded(ctx context.Context, ids []uint, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.UserCoupon{}).Where("id IN ?", ids).Update("reminded_at", now).Error
+}
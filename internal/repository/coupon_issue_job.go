@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CouponIssueJobRepo struct {
+	db *gorm.DB
+}
+
+func NewCouponIssueJobRepo(db *gorm.DB) *CouponIssueJobRepo {
+	return &CouponIssueJobRepo{db: db}
+}
+
+func (r *CouponIssueJobRepo) Create(ctx context.Context, job *model.CouponIssueJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *CouponIssueJobRepo) GetByID(ctx context.Context, id uint) (*model.CouponIssueJob, error) {
+	var job model.CouponIssueJob
+	if err := r.db.WithContext(ctx).First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *CouponIssueJobRepo) List(ctx context.Context, status string, page, pageSize int) ([]model.CouponIssueJob, int64, error) {
+	var (
+		jobs  []model.CouponIssueJob
+		total int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.CouponIssueJob{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id desc").Offset(offset).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// ListRunnable 查询待执行任务，以及心跳超时的执行中任务（worker 崩溃后可被重新认领）。
+func (r *CouponIssueJobRepo) ListRunnable(ctx context.Context, staleBefore time.Time, limit int) ([]model.CouponIssueJob, error) {
+	var jobs []model.CouponIssueJob
+	err := r.db.WithContext(ctx).
+		Where("status = ? OR (status = ? AND updated_at < ?)",
+			model.CouponIssueJobStatusPending, model.CouponIssueJobStatusRunning, staleBefore).
+		Order("id asc").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+// Claim 认领待执行或心跳超时的任务并写入新的租约，返回影响行数判断是否抢到。
+func (r *CouponIssueJobRepo) Claim(ctx context.Context, id uint, staleBefore, now time.Time, token string) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.CouponIssueJob{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))",
+			id, model.CouponIssueJobStatusPending, model.CouponIssueJobStatusRunning, staleBefore).
+		Updates(map[string]any{
+			"status":      model.CouponIssueJobStatusRunning,
+			"started_at":  gorm.Expr("COALESCE(started_at, ?)", now),
+			"updated_at":  now,
+			"lease_token": token,
+		})
+	return tx.RowsAffected, tx.Error
+}
+
+// SetTotal 记录人群总数。
+func (r *CouponIssueJobRepo) SetTotal(ctx context.Context, id uint, total int64) error {
+	return r.db.WithContext(ctx).Model(&model.CouponIssueJob{}).Where("id = ?", id).Update("total_users", total).Error
+}
+
+// HoldLease 锁住任务行并确认租约仍归当前执行者，须在事务内调用；任务被取消或重新认领时返回 false。
+func (r *CouponIssueJobRepo) HoldLease(ctx context.Context, id uint, token string) (bool, error) {
+	var job model.CouponIssueJob
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ? AND lease_token = ?", id, model.CouponIssueJobStatusRunning, token).
+		First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// AdvanceCursor 推进游标并累加发放/跳过数量，仅对持有租约的执行中任务生效。
+func (r *CouponIssueJobRepo) AdvanceCursor(ctx context.Context, id uint, token string, cursor uint, issued, skipped int64) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.CouponIssueJob{}).
+		Where("id = ? AND status = ? AND lease_token = ?", id, model.CouponIssueJobStatusRunning, token).
+		Updates(map[string]any{
+			"cursor":        cursor,
+			"issued_count":  gorm.Expr("issued_count + ?", issued),
+			"skipped_count": gorm.Expr("skipped_count + ?", skipped),
+			"updated_at":    time.Now(),
+		})
+	return tx.RowsAffected, tx.Error
+}
+
+// Finish 将持有租约的执行中任务置为终态。
+func (r *CouponIssueJobRepo) Finish(ctx context.Context, id uint, token string, status model.CouponIssueJobStatus, lastErr string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&model.CouponIssueJob{}).
+		Where("id = ? AND status = ? AND lease_token = ?", id, model.CouponIssueJobStatusRunning, token).
+		Updates(map[string]any{
+			"status":      status,
+			"last_error":  lastErr,
+			"finished_at": now,
+		}).Error
+}
+
+// Cancel 取消未结束的任务，返回影响行数。
+func (r *CouponIssueJobRepo) Cancel(ctx context.Context, id uint) (int64, error) {
+	now := time.Now()
+	tx := r.db.WithContext(ctx).Model(&model.CouponIssueJob{}).
+		Where("id = ? AND status IN ?", id, []model.CouponIssueJobStatus{model.CouponIssueJobStatusPending, model.CouponIssueJobStatusRunning}).
+		Updates(map[string]any{
+			"status":      model.CouponIssueJobStatusCancelled,
+			"finished_at": now,
+		})
+	return tx.RowsAffected, tx.Error
+}
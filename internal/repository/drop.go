@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DropRepo struct {
+	db *gorm.DB
+}
+
+func NewDropRepo(db *gorm.DB) *DropRepo {
+	return &DropRepo{db: db}
+}
+
+// Subscribe 预约开售提醒，重复预约忽略。
+func (r *DropRepo) Subscribe(ctx context.Context, userID, productID uint) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.DropSubscription{UserID: userID, ProductID: productID}).Error
+}
+
+func (r *DropRepo) Unsubscribe(ctx context.Context, userID, productID uint) (int64, error) {
+	tx := r.db.WithContext(ctx).Where("user_id = ? AND product_id = ?", userID, productID).Delete(&model.DropSubscription{})
+	return tx.RowsAffected, tx.Error
+}
+
+func (r *DropRepo) IsSubscribed(ctx context.Context, userID, productID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.DropSubscription{}).
+		Where("user_id = ? AND product_id = ?", userID, productID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CountByProducts 按商品统计预约人数。
+func (r *DropRepo) CountByProducts(ctx context.Context, productIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(productIDs))
+	if len(productIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		ProductID uint
+		Total     int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.DropSubscription{}).
+		Select("product_id, COUNT(*) AS total").
+		Where("product_id IN ?", productIDs).
+		Group("product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.ProductID] = row.Total
+	}
+	return counts, nil
+}
+
+// ListUnreminded 查询尚未按该提前量提醒过的预约用户。
+func (r *DropRepo) ListUnreminded(ctx context.Context, productID uint, offsetMinutes, limit int) ([]uint, error) {
+	var userIDs []uint
+	err := r.db.WithContext(ctx).Model(&model.DropSubscription{}).
+		Where("product_id = ?", productID).
+		Where("NOT EXISTS (SELECT 1 FROM drop_reminders dr WHERE dr.product_id = drop_subscriptions.product_id AND dr.user_id = drop_subscriptions.user_id AND dr.offset_minutes = ?)", offsetMinutes).
+		Order("id asc").
+		Limit(limit).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// CreateReminder 记录已提醒，返回 false 表示已被其他实例记录。
+func (r *DropRepo) CreateReminder(ctx context.Context, reminder *model.DropReminder) (bool, error) {
+	tx := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+	return tx.RowsAffected > 0, tx.Error
+}
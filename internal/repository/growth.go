@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type GrowthRepo struct {
+	db *gorm.DB
+}
+
+func NewGrowthRepo(db *gorm.DB) *GrowthRepo {
+	return &GrowthRepo{db: db}
+}
+
+func (r *GrowthRepo) Create(ctx context.Context, change *model.GrowthLevelChange) error {
+	return r.db.WithContext(ctx).Create(change).Error
+}
+
+// ListByUser 分页查询等级变更记录，新记录在前。
+func (r *GrowthRepo) ListByUser(ctx context.Context, userID uint, page, pageSize int) ([]model.GrowthLevelChange, int64, error) {
+	var (
+		changes []model.GrowthLevelChange
+		total   int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.GrowthLevelChange{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id desc").Offset(offset).Limit(pageSize).Find(&changes).Error; err != nil {
+		return nil, 0, err
+	}
+	return changes, total, nil
+}
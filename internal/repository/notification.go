@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepo struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepo(db *gorm.DB) *NotificationRepo {
+	return &NotificationRepo{db: db}
+}
+
+func (r *NotificationRepo) BatchCreate(ctx context.Context, notifications []*model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(notifications, 200).Error
+}
+
+func (r *NotificationRepo) BatchCreateDeliveries(ctx context.Context, deliveries []*model.NotificationDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(deliveries, 200).Error
+}
+
+// ListByUser 分页查询站内信，新消息在前；unreadOnly 只看未读。
+func (r *NotificationRepo) ListByUser(ctx context.Context, userID uint, unreadOnly bool, page, pageSize int) ([]model.Notification, int64, error) {
+	var (
+		list  []model.Notification
+		total int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id desc").Offset(offset).Limit(pageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *NotificationRepo) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&total).Error
+	return total, err
+}
+
+// MarkRead 将用户的一条站内信标记为已读，已读过的不改动。
+func (r *NotificationRepo) MarkRead(ctx context.Context, userID, id uint, now time.Time) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("id = ? AND user_id = ? AND read_at IS NULL", id, userID).
+		Update("read_at", now)
+	return tx.RowsAffected, tx.Error
+}
+
+func (r *NotificationRepo) MarkAllRead(ctx context.Context, userID uint, now time.Time) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", now)
+	return tx.RowsAffected, tx.Error
+}
+
+func (r *NotificationRepo) Exists(ctx context.Context, userID, id uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *NotificationRepo) ListByIDs(ctx context.Context, ids []uint) ([]model.Notification, error) {
+	var list []model.Notification
+	if len(ids) == 0 {
+		return list, nil
+	}
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&list).Error
+	return list, err
+}
+
+// ListPreferences 批量查询通知偏好，未设置的用户不在结果中。
+func (r *NotificationRepo) ListPreferences(ctx context.Context, userIDs []uint) ([]model.NotificationPreference, error) {
+	var prefs []model.NotificationPreference
+	if len(userIDs) == 0 {
+		return prefs, nil
+	}
+	err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&prefs).Error
+	return prefs, err
+}
+
+// SavePreference 写入或覆盖用户通知偏好。
+func (r *NotificationRepo) SavePreference(ctx context.Context, pref *model.NotificationPreference) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "sms", "email_addr", "phone", "updated_at"}),
+	}).Create(pref).Error
+}
+
+// ListPendingDeliveries 查询待投递记录，先入先发。
+func (r *NotificationRepo) ListPendingDeliveries(ctx context.Context, limit int) ([]model.NotificationDelivery, error) {
+	var list []model.NotificationDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ?", model.NotificationDeliveryPending).
+		Order("id asc").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+func (r *NotificationRepo) MarkDeliverySent(ctx context.Context, id uint, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.NotificationDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"status": model.NotificationDeliverySent, "sent_at": now, "last_error": ""}).Error
+}
+
+// MarkDeliveryRetry 记录投递失败，达到最大重试后置为失败。
+func (r *NotificationRepo) MarkDeliveryRetry(ctx context.Context, id uint, retryCount int, status model.NotificationDeliveryStatus, lastErr string) error {
+	return r.db.WithContext(ctx).Model(&model.NotificationDelivery{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"status": status, "retry_count": retryCount, "last_error": lastErr}).Error
+}
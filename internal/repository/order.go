@@ -67,6 +67,11 @@ func (r *OrderRepo) GetByUserAndProduct(ctx context.Context, userID, productID u
 	return &order, nil
 }
 
+// UpdatePointsUsed 更新订单积分抵扣数。
+func (r *OrderRepo) UpdatePointsUsed(ctx context.Context, orderID uint, points int64) error {
+	return r.db.WithContext(ctx).Model(&model.Order{}).Where("id = ?", orderID).Update("points_used", points).Error
+}
+
 // ListByUserID 获取用户订单列表，可按状态过滤，按创建时间倒序。
 func (r *OrderRepo) ListByUserID(ctx context.Context, uid uint, status *model.OrderStatus, page, pagesize int) ([]model.Order, int64, error) {
 	var orders []model.Order
@@ -135,6 +140,25 @@ func (r *OrderRepo) UpdateStatusIfMatch(ctx context.Context, orderID uint, fromS
 	return tx.RowsAffected, tx.Error
 }
 
+// MarkPaid 待支付订单置为已支付并记录支付时间。
+func (r *OrderRepo) MarkPaid(ctx context.Context, orderID uint, paidAt time.Time) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.Order{}).
+		Where("id = ? AND status = ?", orderID, model.OrderStatusUnpaid).
+		Updates(map[string]any{"status": model.OrderStatusPaid, "paid_at": paidAt})
+	return tx.RowsAffected, tx.Error
+}
+
+// SumPaidSince 统计用户 since 之后支付、当前仍为已支付的商品订单实付金额（分）。
+func (r *OrderRepo) SumPaidSince(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.Payment{}).
+		Joins("JOIN orders ON orders.id = payments.order_id AND orders.deleted_at IS NULL").
+		Where("orders.user_id = ? AND orders.type = ? AND orders.status = ? AND orders.paid_at >= ?", userID, model.OrderTypeProduct, model.OrderStatusPaid, since).
+		Select("COALESCE(SUM(payments.amount_cents), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
 // GetByOrderNums 批量查询订单号对应的订单，用于批量幂等检查。
 func (r *OrderRepo) GetByOrderNums(ctx context.Context, orderNums []string) ([]*model.Order, error) {
 	var orders []*model.Order
@@ -158,3 +182,25 @@ func (r *OrderRepo) ListStaleUnpaid(ctx context.Context, before time.Time, limit
 	}
 	return orders, nil
 }
+
+// ListPaidBuyerIDs 游标分页查询已支付购买过指定商品的用户 ID。
+func (r *OrderRepo) ListPaidBuyerIDs(ctx context.Context, productID, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&model.Order{}).
+		Distinct("user_id").
+		Where("product_id = ? AND status = ? AND user_id > ?", productID, model.OrderStatusPaid, afterID).
+		Order("user_id asc").
+		Limit(limit).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// CountPaidBuyers 统计已支付购买过指定商品的用户数。
+func (r *OrderRepo) CountPaidBuyers(ctx context.Context, productID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.Order{}).
+		Where("product_id = ? AND status = ?", productID, model.OrderStatusPaid).
+		Distinct("user_id").
+		Count(&total).Error
+	return total, err
+}
@@ -45,12 +45,12 @@ func (r *OutboxRepo) MarkFailed(ctx context.Context, id uint, errMsg string) err
 	}).Error
 }
 
-// GetPendingMessages 获取超时未发送的消息（用于补偿）
+// GetPendingMessages 获取超时未发送的 Kafka 消息（用于补偿）
 func (r *OutboxRepo) GetPendingMessages(ctx context.Context, timeout time.Duration, limit int) ([]*model.OutboxMessage, error) {
 	var msgs []*model.OutboxMessage
 	cutoff := time.Now().Add(-timeout)
 	err := r.db.WithContext(ctx).
-		Where("status = ? AND created_at < ?", model.OutboxStatusPending, cutoff).
+		Where("status = ? AND created_at < ? AND topic <> ?", model.OutboxStatusPending, cutoff, model.OutboxTopicWebhook).
 		Order("created_at ASC").
 		Limit(limit).
 		Find(&msgs).Error
@@ -83,3 +83,31 @@ func (r *OutboxRepo) GetByID(ctx context.Context, id uint) (*model.OutboxMessage
 	}
 	return &msg, nil
 }
+
+// GetDueByTopic 获取指定 topic 已到下次投递时间的待发送消息
+func (r *OutboxRepo) GetDueByTopic(ctx context.Context, topic string, now time.Time, limit int) ([]*model.OutboxMessage, error) {
+	var msgs []*model.OutboxMessage
+	err := r.db.WithContext(ctx).
+		Where("topic = ? AND status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", topic, model.OutboxStatusPending, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&msgs).Error
+	return msgs, err
+}
+
+// Claim 将到期消息的下次投递时间推迟到 leaseUntil，避免多个 worker 同时投递；返回 0 表示已被抢占
+func (r *OutboxRepo) Claim(ctx context.Context, id uint, now, leaseUntil time.Time) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.OutboxMessage{}).
+		Where("id = ? AND status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)", id, model.OutboxStatusPending, now).
+		Update("next_attempt_at", leaseUntil)
+	return tx.RowsAffected, tx.Error
+}
+
+// ScheduleRetry 增加重试次数并设置下次投递时间
+func (r *OutboxRepo) ScheduleRetry(ctx context.Context, id uint, lastErr string, next time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"retry_count":     gorm.Expr("retry_count + ?", 1),
+		"last_error":      lastErr,
+		"next_attempt_at": next,
+	}).Error
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaidVIPRepo struct {
@@ -25,18 +26,21 @@ func (r *PaidVIPRepo) GetByUser(ctx context.Context, userID uint) (*model.PaidVI
 	return &pv, nil
 }
 
-// Upsert 覆盖/新增付费 VIP。
-func (r *PaidVIPRepo) Upsert(ctx context.Context, userID uint, level int, start, end time.Time) error {
-	pv := model.PaidVIP{
-		UserID:    userID,
-		Level:     level,
-		StartedAt: start,
-		ExpiredAt: end,
+// GetByUserForUpdate 加行锁查询付费 VIP 记录，用于开通/退款串行化。
+func (r *PaidVIPRepo) GetByUserForUpdate(ctx context.Context, userID uint) (*model.PaidVIP, error) {
+	var pv model.PaidVIP
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&pv).Error; err != nil {
+		return nil, err
 	}
+	return &pv, nil
+}
+
+// Upsert 覆盖/新增付费 VIP，按 user_id 唯一。
+func (r *PaidVIPRepo) Upsert(ctx context.Context, pv *model.PaidVIP) error {
 	return r.db.WithContext(ctx).
-		Where("user_id = ?", userID).
-		Assign(pv).
-		FirstOrCreate(&pv).Error
+		Where("user_id = ?", pv.UserID).
+		Assign(*pv).
+		FirstOrCreate(pv).Error
 }
 
 // ListActivePaidVIPs 查询所有有效（未过期）的付费 VIP 记录。
@@ -47,3 +51,54 @@ func (r *PaidVIPRepo) ListActivePaidVIPs(ctx context.Context, now time.Time) ([]
 	}
 	return pvs, nil
 }
+
+// ListActiveUserIDs 游标分页查询有效付费 VIP 的用户 ID，level 为 0 时不限等级。
+func (r *PaidVIPRepo) ListActiveUserIDs(ctx context.Context, now time.Time, level int, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	query := r.db.WithContext(ctx).Model(&model.PaidVIP{}).Where("expired_at > ? AND user_id > ?", now, afterID)
+	if level > 0 {
+		query = query.Where("level = ?", level)
+	}
+	err := query.Order("user_id asc").Limit(limit).Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// CountActive 统计有效付费 VIP 数量，level 为 0 时不限等级。
+func (r *PaidVIPRepo) CountActive(ctx context.Context, now time.Time, level int) (int64, error) {
+	var total int64
+	query := r.db.WithContext(ctx).Model(&model.PaidVIP{}).Where("expired_at > ?", now)
+	if level > 0 {
+		query = query.Where("level = ?", level)
+	}
+	err := query.Count(&total).Error
+	return total, err
+}
+
+// CreateGrant 写入一笔开通时段。
+func (r *PaidVIPRepo) CreateGrant(ctx context.Context, grant *model.PaidVIPGrant) error {
+	return r.db.WithContext(ctx).Create(grant).Error
+}
+
+// GetGrantByOrderID 按订单查询开通时段。
+func (r *PaidVIPRepo) GetGrantByOrderID(ctx context.Context, orderID uint) (*model.PaidVIPGrant, error) {
+	var grant model.PaidVIPGrant
+	if err := r.db.WithContext(ctx).Where("order_id = ?", orderID).First(&grant).Error; err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// ListActiveGrants 查询用户未退款的开通时段，按开始时间升序。
+func (r *PaidVIPRepo) ListActiveGrants(ctx context.Context, userID uint) ([]model.PaidVIPGrant, error) {
+	var grants []model.PaidVIPGrant
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND status = ?", userID, model.PaidVIPGrantActive).
+		Order("starts_at asc, id asc").
+		Find(&grants).Error
+	return grants, err
+}
+
+// SaveGrant 更新开通时段。
+func (r *PaidVIPRepo) SaveGrant(ctx context.Context, grant *model.PaidVIPGrant) error {
+	return r.db.WithContext(ctx).Save(grant).Error
+}
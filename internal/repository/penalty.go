@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PenaltyRepo struct {
+	db *gorm.DB
+}
+
+func NewPenaltyRepo(db *gorm.DB) *PenaltyRepo {
+	return &PenaltyRepo{db: db}
+}
+
+// RecordTimeout 记录一次超时未支付，同一订单重复记录时忽略，返回是否新写入。
+func (r *PenaltyRepo) RecordTimeout(ctx context.Context, timeout *model.UnpaidTimeout) (bool, error) {
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(timeout)
+	return res.RowsAffected > 0, res.Error
+}
+
+// CountTimeoutsSince 统计用户在指定时间之后的超时次数。
+func (r *PenaltyRepo) CountTimeoutsSince(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.UnpaidTimeout{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// ListTimeouts 用户最近的超时记录，新记录在前。
+func (r *PenaltyRepo) ListTimeouts(ctx context.Context, userID uint, limit int) ([]model.UnpaidTimeout, error) {
+	var timeouts []model.UnpaidTimeout
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("id desc").
+		Limit(limit).
+		Find(&timeouts).Error
+	return timeouts, err
+}
+
+func (r *PenaltyRepo) Get(ctx context.Context, userID uint) (*model.UserPenalty, error) {
+	var penalty model.UserPenalty
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&penalty).Error; err != nil {
+		return nil, err
+	}
+	return &penalty, nil
+}
+
+// GetOrCreateForUpdate 加锁查询处罚状态，不存在时先创建。
+func (r *PenaltyRepo) GetOrCreateForUpdate(ctx context.Context, userID uint) (*model.UserPenalty, error) {
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UserPenalty{UserID: userID}).Error; err != nil {
+		return nil, err
+	}
+	return r.GetForUpdate(ctx, userID)
+}
+
+func (r *PenaltyRepo) GetForUpdate(ctx context.Context, userID uint) (*model.UserPenalty, error) {
+	var penalty model.UserPenalty
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(&penalty).Error
+	if err != nil {
+		return nil, err
+	}
+	return &penalty, nil
+}
+
+func (r *PenaltyRepo) Update(ctx context.Context, userID uint, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.UserPenalty{}).Where("user_id = ?", userID).Updates(updates).Error
+}
+
+// PenaltyFilter 处罚列表筛选条件。
+type PenaltyFilter struct {
+	AppealStatus string
+	ActiveAt     *time.Time // 非空时只返回该时刻仍在暂停期或灰名单中的用户
+	Page         int
+	PageSize     int
+}
+
+// List 分页查询处罚记录，最近更新的在前。
+func (r *PenaltyRepo) List(ctx context.Context, filter PenaltyFilter) ([]model.UserPenalty, int64, error) {
+	var (
+		penalties []model.UserPenalty
+		total     int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.UserPenalty{})
+	if filter.AppealStatus != "" {
+		query = query.Where("appeal_status = ?", filter.AppealStatus)
+	}
+	if filter.ActiveAt != nil {
+		query = query.Where("banned_until > ? OR graylisted = ?", *filter.ActiveAt, true)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (filter.Page - 1) * filter.PageSize
+	if err := query.Order("updated_at desc").Offset(offset).Limit(filter.PageSize).Find(&penalties).Error; err != nil {
+		return nil, 0, err
+	}
+	return penalties, total, nil
+}
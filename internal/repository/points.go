@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PointRepo struct {
+	db *gorm.DB
+}
+
+func NewPointRepo(db *gorm.DB) *PointRepo {
+	return &PointRepo{db: db}
+}
+
+func (r *PointRepo) Create(ctx context.Context, entry *model.PointEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *PointRepo) GetByID(ctx context.Context, id uint) (*model.PointEntry, error) {
+	var entry model.PointEntry
+	if err := r.db.WithContext(ctx).First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListByUser 分页查询积分流水，新记录在前。
+func (r *PointRepo) ListByUser(ctx context.Context, userID uint, page, pageSize int) ([]model.PointEntry, int64, error) {
+	var (
+		entries []model.PointEntry
+		total   int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.PointEntry{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id desc").Offset(offset).Limit(pageSize).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// ListSpendableForUpdate 加锁查询未过期且有剩余的收入流水，先过期的在前。
+func (r *PointRepo) ListSpendableForUpdate(ctx context.Context, userID uint, now time.Time) ([]model.PointEntry, error) {
+	var entries []model.PointEntry
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND remaining > 0 AND expires_at > ?", userID, now).
+		Order("expires_at asc, id asc").
+		Find(&entries).Error
+	return entries, err
+}
+
+// SumSpendable 统计未过期的可用积分；before 非零时只统计在该时间前过期的部分。
+func (r *PointRepo) SumSpendable(ctx context.Context, userID uint, now time.Time, before time.Time) (int64, error) {
+	var total int64
+	query := r.db.WithContext(ctx).Model(&model.PointEntry{}).
+		Where("user_id = ? AND remaining > 0 AND expires_at > ?", userID, now)
+	if !before.IsZero() {
+		query = query.Where("expires_at <= ?", before)
+	}
+	err := query.Select("COALESCE(SUM(remaining), 0)").Scan(&total).Error
+	return total, err
+}
+
+func (r *PointRepo) UpdateRemaining(ctx context.Context, id uint, remaining int64) error {
+	return r.db.WithContext(ctx).Model(&model.PointEntry{}).Where("id = ?", id).Update("remaining", remaining).Error
+}
+
+// ExistsByUserKind 判断用户是否已有某类流水，用于一次性奖励去重。
+func (r *PointRepo) ExistsByUserKind(ctx context.Context, userID uint, kind model.PointKind) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.PointEntry{}).
+		Where("user_id = ? AND kind = ?", userID, kind).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SumByOrder 汇总订单在指定类型下的积分变动。
+func (r *PointRepo) SumByOrder(ctx context.Context, orderID uint, kinds ...model.PointKind) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.PointEntry{}).
+		Where("order_id = ? AND kind IN ?", orderID, kinds).
+		Select("COALESCE(SUM(delta), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// SumByUserOrder 汇总用户在指定订单、指定类型下的积分变动，用于同一订单涉及多个用户的场景。
+func (r *PointRepo) SumByUserOrder(ctx context.Context, userID, orderID uint, kinds ...model.PointKind) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.PointEntry{}).
+		Where("user_id = ? AND order_id = ? AND kind IN ?", userID, orderID, kinds).
+		Select("COALESCE(SUM(delta), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// ListExpired 查询已过期但仍有剩余的收入流水。
+func (r *PointRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]model.PointEntry, error) {
+	var entries []model.PointEntry
+	err := r.db.WithContext(ctx).
+		Where("remaining > 0 AND expires_at <= ?", now).
+		Order("id asc").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+// CreateAllocations 记录支出流水占用的收入流水明细。
+func (r *PointRepo) CreateAllocations(ctx context.Context, allocations []model.PointAllocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&allocations).Error
+}
+
+// ListOpenAllocationsForUpdate 加锁查询订单指定类型支出中尚未退回的占用明细。
+func (r *PointRepo) ListOpenAllocationsForUpdate(ctx context.Context, orderID uint, kind model.PointKind) ([]model.PointAllocation, error) {
+	var allocations []model.PointAllocation
+	spends := r.db.Model(&model.PointEntry{}).Select("id").Where("order_id = ? AND kind = ?", orderID, kind)
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND released = ? AND spend_entry_id IN (?)", orderID, false, spends).
+		Order("id asc").
+		Find(&allocations).Error
+	return allocations, err
+}
+
+// ListByIDsForUpdate 加锁查询指定流水。
+func (r *PointRepo) ListByIDsForUpdate(ctx context.Context, ids []uint) ([]model.PointEntry, error) {
+	var entries []model.PointEntry
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id asc").
+		Find(&entries).Error
+	return entries, err
+}
+
+// MarkAllocationsReleased 将占用明细标记为已退回。
+func (r *PointRepo) MarkAllocationsReleased(ctx context.Context, ids []uint) error {
+	return r.db.WithContext(ctx).Model(&model.PointAllocation{}).Where("id IN ?", ids).Update("released", true).Error
+}
+
+// RestoreRemaining 为收入流水退回剩余积分。
+func (r *PointRepo) RestoreRemaining(ctx context.Context, id uint, amount int64) error {
+	return r.db.WithContext(ctx).Model(&model.PointEntry{}).Where("id = ?", id).
+		Update("remaining", gorm.Expr("remaining + ?", amount)).Error
+}
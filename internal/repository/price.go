@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PriceRepo struct {
+	db *gorm.DB
+}
+
+func NewPriceRepo(db *gorm.DB) *PriceRepo {
+	return &PriceRepo{db: db}
+}
+
+func (r *PriceRepo) CreateSchedule(ctx context.Context, schedule *model.PriceSchedule) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
+}
+
+func (r *PriceRepo) GetSchedule(ctx context.Context, id, productID uint) (*model.PriceSchedule, error) {
+	var schedule model.PriceSchedule
+	if err := r.db.WithContext(ctx).Where("id = ? AND product_id = ?", id, productID).First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ListSchedules 商品的调价计划，按生效时间倒序分页。
+func (r *PriceRepo) ListSchedules(ctx context.Context, productID uint, page, pageSize int) ([]model.PriceSchedule, int64, error) {
+	var (
+		schedules []model.PriceSchedule
+		total     int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.PriceSchedule{}).Where("product_id = ?", productID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("effective_at desc, id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&schedules).Error
+	return schedules, total, err
+}
+
+// PendingSchedules 商品全部待执行的调价计划。
+func (r *PriceRepo) PendingSchedules(ctx context.Context, productID uint) ([]model.PriceSchedule, error) {
+	var schedules []model.PriceSchedule
+	err := r.db.WithContext(ctx).
+		Where("product_id = ? AND status = ?", productID, model.PriceSchedulePending).
+		Order("effective_at asc, id asc").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// ListDue 已到生效时间的待执行计划，按生效时间先后执行。
+func (r *PriceRepo) ListDue(ctx context.Context, now time.Time, limit int) ([]model.PriceSchedule, error) {
+	var schedules []model.PriceSchedule
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND effective_at <= ?", model.PriceSchedulePending, now).
+		Order("effective_at asc, id asc").
+		Limit(limit).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// FinishSchedule 条件流转待执行的计划，返回受影响行数；为 0 说明已被取消或执行。
+func (r *PriceRepo) FinishSchedule(ctx context.Context, id uint, data map[string]any) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.PriceSchedule{}).
+		Where("id = ? AND status = ?", id, model.PriceSchedulePending).
+		Updates(data)
+	return tx.RowsAffected, tx.Error
+}
+
+func (r *PriceRepo) CreateHistory(ctx context.Context, history *model.PriceHistory) error {
+	return r.db.WithContext(ctx).Create(history).Error
+}
+
+// ListHistory 商品价格变动记录，最新的在前。
+func (r *PriceRepo) ListHistory(ctx context.Context, productID uint, page, pageSize int) ([]model.PriceHistory, int64, error) {
+	var (
+		history []model.PriceHistory
+		total   int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.PriceHistory{}).Where("product_id = ?", productID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at desc, id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&history).Error
+	return history, total, err
+}
@@ -3,8 +3,11 @@ package repository
 import (
 	"SneakerFlash/internal/model"
 	"context"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepo struct {
@@ -28,6 +31,15 @@ func (r *ProductRepo) GetByID(ctx context.Context, id uint) (*model.Product, err
 	return &p, nil
 }
 
+// GetByIDForUpdate 在事务内加行锁读取商品，用于串行化改价。
+func (r *ProductRepo) GetByIDForUpdate(ctx context.Context, id uint) (*model.Product, error) {
+	var p model.Product
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
 func (r *ProductRepo) GetByIDAndUser(ctx context.Context, id, userID uint) (*model.Product, error) {
 	var p model.Product
 	if err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&p).Error; err != nil {
@@ -75,13 +87,13 @@ func (r *ProductRepo) UpdateByUser(ctx context.Context, id, userID uint, data ma
 	return tx.RowsAffected, tx.Error
 }
 
-// List 分页查询商品列表，按 id 倒序。
+// List 分页查询已上架商品列表，按 id 倒序。
 func (r *ProductRepo) List(ctx context.Context, page, pageSize int) ([]model.Product, int64, error) {
 	var products []model.Product
 	var total int64
 
-	db := r.db.WithContext(ctx)
-	if err := db.Model(&model.Product{}).Count(&total).Error; err != nil {
+	db := r.db.WithContext(ctx).Model(&model.Product{}).Where("status = ?", model.ProductStatusApproved)
+	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -91,8 +103,138 @@ func (r *ProductRepo) List(ctx context.Context, page, pageSize int) ([]model.Pro
 	return products, total, err
 }
 
-func (r *ProductRepo) ListAll(ctx context.Context, page, pageSize int) ([]model.Product, int64, error) {
-	return r.List(ctx, page, pageSize)
+// ProductSort 商品目录排序方式。
+type ProductSort string
+
+const (
+	ProductSortNewest        ProductSort = "newest" // 默认，按发布先后倒序
+	ProductSortStartTimeAsc  ProductSort = "start_time_asc"
+	ProductSortStartTimeDesc ProductSort = "start_time_desc"
+	ProductSortPriceAsc      ProductSort = "price_asc"
+	ProductSortPriceDesc     ProductSort = "price_desc"
+	ProductSortPopular       ProductSort = "popular" // 按已抢到订单数（待支付与已支付）倒序
+)
+
+func ValidProductSort(sort ProductSort) bool {
+	switch sort {
+	case "", ProductSortNewest, ProductSortStartTimeAsc, ProductSortStartTimeDesc, ProductSortPriceAsc, ProductSortPriceDesc, ProductSortPopular:
+		return true
+	default:
+		return false
+	}
+}
+
+// ProductFilter 商品目录检索条件，零值字段不过滤；只返回已上架商品。
+type ProductFilter struct {
+	Keyword     string // 匹配名称、描述或货号
+	BrandID     uint
+	CategoryID  uint
+	CategoryIDs []uint `json:"-"` // CategoryID 及其全部下级分类，由服务层展开
+	Tag         string
+	MinPrice    *float64
+	MaxPrice    *float64
+	SaleStatus  model.ProductSaleStatus
+	SellerID    uint
+	Sort        ProductSort
+	Page        int
+	PageSize    int
+}
+
+// Search 按条件检索已上架商品，销售状态以 now 为准。
+func (r *ProductRepo) Search(ctx context.Context, filter ProductFilter, now time.Time) ([]model.Product, int64, error) {
+	var products []model.Product
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.Product{}).Where("products.status = ?", model.ProductStatusApproved)
+	if filter.Keyword != "" {
+		pattern := "%" + escapeLike(filter.Keyword) + "%"
+		query = query.Where("(products.name LIKE ? ESCAPE '!' OR products.description LIKE ? ESCAPE '!' OR products.sku LIKE ? ESCAPE '!')", pattern, pattern, pattern)
+	}
+	if filter.BrandID > 0 {
+		query = query.Where("products.brand_id = ?", filter.BrandID)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("products.category_id IN ?", filter.CategoryIDs)
+	} else if filter.CategoryID > 0 {
+		query = query.Where("products.category_id = ?", filter.CategoryID)
+	}
+	if filter.Tag != "" {
+		// 标签以 JSON 数组存储，匹配带引号的完整元素
+		query = query.Where("products.tags LIKE ? ESCAPE '!'", `%"`+escapeLike(filter.Tag)+`"%`)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("products.price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("products.price <= ?", *filter.MaxPrice)
+	}
+	if filter.SellerID > 0 {
+		query = query.Where("products.user_id = ?", filter.SellerID)
+	}
+	switch filter.SaleStatus {
+	case model.ProductSaleUpcoming:
+		query = query.Where("products.start_time > ?", now)
+	case model.ProductSaleLive:
+		query = query.Where("products.start_time <= ? AND (products.end_time IS NULL OR products.end_time > ?) AND products.stock > 0", now, now)
+	case model.ProductSaleSoldOut:
+		query = query.Where("products.start_time <= ? AND (products.end_time IS NULL OR products.end_time > ?) AND products.stock <= 0", now, now)
+	case model.ProductSaleEnded:
+		query = query.Where("products.end_time IS NOT NULL AND products.end_time <= ?", now)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	switch filter.Sort {
+	case ProductSortStartTimeAsc:
+		query = query.Order("products.start_time asc, products.id desc")
+	case ProductSortStartTimeDesc:
+		query = query.Order("products.start_time desc, products.id desc")
+	case ProductSortPriceAsc:
+		query = query.Order("products.price asc, products.id desc")
+	case ProductSortPriceDesc:
+		query = query.Order("products.price desc, products.id desc")
+	case ProductSortPopular:
+		grabbed := r.db.Model(&model.Order{}).
+			Select("product_id, COUNT(*) AS grabbed").
+			Where("status IN ?", []model.OrderStatus{model.OrderStatusUnpaid, model.OrderStatusPaid}).
+			Group("product_id")
+		query = query.Select("products.*").
+			Joins("LEFT JOIN (?) AS popularity ON popularity.product_id = products.id", grabbed).
+			Order("COALESCE(popularity.grabbed, 0) desc, products.id desc")
+	default:
+		query = query.Order("products.id desc")
+	}
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Offset(offset).Limit(filter.PageSize).Find(&products).Error
+	return products, total, err
+}
+
+// escapeLike 转义 LIKE 通配符，配合 ESCAPE '!' 使用。
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// ListAll 管理台商品列表，status 为空时不过滤；待审核队列按提交时间先后排序。
+func (r *ProductRepo) ListAll(ctx context.Context, status model.ProductStatus, page, pageSize int) ([]model.Product, int64, error) {
+	var products []model.Product
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&model.Product{})
+	order := "id desc"
+	if status != "" {
+		db = db.Where("status = ?", status)
+		if status == model.ProductStatusPendingReview {
+			order = "submitted_at asc, id asc"
+		}
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Offset(offset).Limit(pageSize).Order(order).Find(&products).Error
+	return products, total, err
 }
 
 func (r *ProductRepo) CountAll(ctx context.Context) (int64, error) {
@@ -114,6 +256,40 @@ func (r *ProductRepo) ListByUserID(ctx context.Context, userID uint, page, pageS
 	return products, total, err
 }
 
+// ListListedByUserID 查询指定卖家已上架的商品列表。
+func (r *ProductRepo) ListListedByUserID(ctx context.Context, userID uint, page, pageSize int) ([]model.Product, int64, error) {
+	var products []model.Product
+	var total int64
+	query := r.db.WithContext(ctx).Model(&model.Product{}).Where("user_id = ? AND status = ?", userID, model.ProductStatusApproved)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	err := query.Offset(offset).Limit(pageSize).Order("id desc").Find(&products).Error
+	return products, total, err
+}
+
+// ListStartingBetween 查询开售时间落在 (from, to] 的已上架商品，按开售时间升序。
+func (r *ProductRepo) ListStartingBetween(ctx context.Context, from, to time.Time, limit int) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.WithContext(ctx).
+		Where("start_time > ? AND start_time <= ? AND status = ?", from, to, model.ProductStatusApproved).
+		Order("start_time asc").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+// UpdateStatus 仅当商品当前状态属于 from 时更新，userID 非 0 时同时限定创建者；返回受影响行数。
+func (r *ProductRepo) UpdateStatus(ctx context.Context, id, userID uint, from []model.ProductStatus, data map[string]any) (int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.Product{}).Where("id = ? AND status IN ?", id, from)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	tx := query.Updates(data)
+	return tx.RowsAffected, tx.Error
+}
+
 // Delete 软删除商品。
 func (r *ProductRepo) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&model.Product{}, id).Error
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReferralRepo struct {
+	db *gorm.DB
+}
+
+func NewReferralRepo(db *gorm.DB) *ReferralRepo {
+	return &ReferralRepo{db: db}
+}
+
+func (r *ReferralRepo) CreateInviteCode(ctx context.Context, code *model.InviteCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+func (r *ReferralRepo) GetInviteCodeByUser(ctx context.Context, userID uint) (*model.InviteCode, error) {
+	var code model.InviteCode
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *ReferralRepo) GetInviteCode(ctx context.Context, code string) (*model.InviteCode, error) {
+	var ic model.InviteCode
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&ic).Error; err != nil {
+		return nil, err
+	}
+	return &ic, nil
+}
+
+func (r *ReferralRepo) Create(ctx context.Context, referral *model.Referral) error {
+	return r.db.WithContext(ctx).Create(referral).Error
+}
+
+// GetByInviteeForUpdate 加锁查询被邀请人的邀请关系。
+func (r *ReferralRepo) GetByInviteeForUpdate(ctx context.Context, inviteeID uint) (*model.Referral, error) {
+	var referral model.Referral
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("invitee_id = ?", inviteeID).
+		First(&referral).Error
+	if err != nil {
+		return nil, err
+	}
+	return &referral, nil
+}
+
+func (r *ReferralRepo) Update(ctx context.Context, id uint, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.Referral{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// CountBySource 统计邀请人名下来自同一 IP 或设备的被邀请人数。
+func (r *ReferralRepo) CountBySource(ctx context.Context, inviterID uint, ip, deviceID string) (int64, error) {
+	if ip == "" && deviceID == "" {
+		return 0, nil
+	}
+	query := r.db.WithContext(ctx).Model(&model.Referral{}).Where("inviter_id = ?", inviterID)
+	switch {
+	case ip != "" && deviceID != "":
+		query = query.Where("register_ip = ? OR device_id = ?", ip, deviceID)
+	case ip != "":
+		query = query.Where("register_ip = ?", ip)
+	default:
+		query = query.Where("device_id = ?", deviceID)
+	}
+	var total int64
+	err := query.Count(&total).Error
+	return total, err
+}
+
+// ReferralStatusCount 按状态统计的邀请数。
+type ReferralStatusCount struct {
+	Status model.ReferralStatus
+	Total  int64
+}
+
+// CountByStatus 按状态统计邀请数，inviterID 为 0 时统计全部。
+func (r *ReferralRepo) CountByStatus(ctx context.Context, inviterID uint) ([]ReferralStatusCount, error) {
+	query := r.db.WithContext(ctx).Model(&model.Referral{})
+	if inviterID != 0 {
+		query = query.Where("inviter_id = ?", inviterID)
+	}
+	var counts []ReferralStatusCount
+	err := query.Select("status, COUNT(*) AS total").Group("status").Scan(&counts).Error
+	return counts, err
+}
+
+// InviterConversion 邀请人维度的转化统计。
+type InviterConversion struct {
+	InviterID uint  `json:"inviter_id"`
+	Invited   int64 `json:"invited"`
+	Rewarded  int64 `json:"rewarded"`
+}
+
+// TopInviters 按成功转化数排序的邀请人。
+func (r *ReferralRepo) TopInviters(ctx context.Context, limit int) ([]InviterConversion, error) {
+	var rows []InviterConversion
+	err := r.db.WithContext(ctx).Model(&model.Referral{}).
+		Select("inviter_id, COUNT(*) AS invited, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS rewarded", model.ReferralStatusRewarded).
+		Group("inviter_id").
+		Order("rewarded desc, invited desc, inviter_id asc").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// List 分页查询邀请记录，可按邀请人与状态过滤，新记录在前。
+func (r *ReferralRepo) List(ctx context.Context, inviterID uint, status model.ReferralStatus, page, pageSize int) ([]model.Referral, int64, error) {
+	var (
+		referrals []model.Referral
+		total     int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.Referral{})
+	if inviterID != 0 {
+		query = query.Where("inviter_id = ?", inviterID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id desc").Offset(offset).Limit(pageSize).Find(&referrals).Error; err != nil {
+		return nil, 0, err
+	}
+	return referrals, total, nil
+}
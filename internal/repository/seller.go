@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SellerRepo struct {
+	db *gorm.DB
+}
+
+func NewSellerRepo(db *gorm.DB) *SellerRepo {
+	return &SellerRepo{db: db}
+}
+
+func (r *SellerRepo) Create(ctx context.Context, profile *model.SellerProfile) error {
+	return r.db.WithContext(ctx).Create(profile).Error
+}
+
+func (r *SellerRepo) Get(ctx context.Context, userID uint) (*model.SellerProfile, error) {
+	var profile model.SellerProfile
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *SellerRepo) GetForUpdate(ctx context.Context, userID uint) (*model.SellerProfile, error) {
+	var profile model.SellerProfile
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// IsApproved 用户是否为审核通过的卖家。
+func (r *SellerRepo) IsApproved(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.SellerProfile{}).
+		Where("user_id = ? AND status = ?", userID, model.SellerStatusApproved).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *SellerRepo) Update(ctx context.Context, userID uint, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.SellerProfile{}).Where("user_id = ?", userID).Updates(updates).Error
+}
+
+// List 按状态分页查询入驻申请，最近更新的在前。
+func (r *SellerRepo) List(ctx context.Context, status string, page, pageSize int) ([]model.SellerProfile, int64, error) {
+	var (
+		profiles []model.SellerProfile
+		total    int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.SellerProfile{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("updated_at desc").Offset(offset).Limit(pageSize).Find(&profiles).Error; err != nil {
+		return nil, 0, err
+	}
+	return profiles, total, nil
+}
+
+// ListByUserIDs 批量查询入驻资料。
+func (r *SellerRepo) ListByUserIDs(ctx context.Context, userIDs []uint) ([]model.SellerProfile, error) {
+	var profiles []model.SellerProfile
+	if len(userIDs) == 0 {
+		return profiles, nil
+	}
+	err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&profiles).Error
+	return profiles, err
+}
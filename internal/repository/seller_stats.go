@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sellerAmountExpr 订单按卖家定价计的金额：会员价 > 原价 > 实付金额，与结算口径一致。
+const sellerAmountExpr = "COALESCE(NULLIF(orders.member_price_cents, 0), NULLIF(orders.original_price_cents, 0), payments.amount_cents, 0)"
+
+type SellerStatsRepo struct {
+	db *gorm.DB
+}
+
+func NewSellerStatsRepo(db *gorm.DB) *SellerStatsRepo {
+	return &SellerStatsRepo{db: db}
+}
+
+// ProductOrderStat 单个商品按订单状态聚合的结果。
+type ProductOrderStat struct {
+	ProductID    uint
+	Paid         int64
+	Unpaid       int64
+	Cancelled    int64
+	Refunded     int64
+	Failed       int64
+	RevenueCents int64
+}
+
+// PaidOrderAmount 已支付订单的支付时间与金额，用于按天汇总营收。
+type PaidOrderAmount struct {
+	PaidAt      time.Time
+	AmountCents int64
+}
+
+// ListSellerIDs 按用户 ID 游标查询发布过商品的卖家。
+func (r *SellerStatsRepo) ListSellerIDs(ctx context.Context, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&model.Product{}).
+		Where("user_id > ?", afterID).
+		Distinct("user_id").
+		Order("user_id asc").
+		Limit(limit).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// ListChangedSellerIDs 查询 since 之后商品或订单有变动的卖家，含已删除的商品。
+func (r *SellerStatsRepo) ListChangedSellerIDs(ctx context.Context, since time.Time) ([]uint, error) {
+	var fromProducts, fromOrders []uint
+	if err := r.db.WithContext(ctx).Unscoped().Model(&model.Product{}).
+		Where("updated_at > ? OR deleted_at > ?", since, since).
+		Distinct("user_id").
+		Pluck("user_id", &fromProducts).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Table("orders").
+		Joins("JOIN products ON products.id = orders.product_id").
+		Where("orders.type = ? AND orders.updated_at > ?", model.OrderTypeProduct, since).
+		Distinct("products.user_id").
+		Pluck("products.user_id", &fromOrders).Error; err != nil {
+		return nil, err
+	}
+	seen := make(map[uint]struct{}, len(fromProducts)+len(fromOrders))
+	ids := make([]uint, 0, len(fromProducts)+len(fromOrders))
+	for _, id := range append(fromProducts, fromOrders...) {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ProductOrderStats 按商品聚合卖家的订单数与已支付营收；productID 非 0 时只统计该商品。
+func (r *SellerStatsRepo) ProductOrderStats(ctx context.Context, sellerID, productID uint) ([]ProductOrderStat, error) {
+	var rows []ProductOrderStat
+	query := r.db.WithContext(ctx).Table("orders").
+		Select(`orders.product_id,
+			COALESCE(SUM(CASE WHEN orders.status = ? THEN 1 ELSE 0 END), 0) AS paid,
+			COALESCE(SUM(CASE WHEN orders.status = ? THEN 1 ELSE 0 END), 0) AS unpaid,
+			COALESCE(SUM(CASE WHEN orders.status = ? THEN 1 ELSE 0 END), 0) AS cancelled,
+			COALESCE(SUM(CASE WHEN orders.status = ? THEN 1 ELSE 0 END), 0) AS refunded,
+			COALESCE(SUM(CASE WHEN orders.status = ? THEN 1 ELSE 0 END), 0) AS failed,
+			COALESCE(SUM(CASE WHEN orders.status = ? THEN `+sellerAmountExpr+` ELSE 0 END), 0) AS revenue_cents`,
+			model.OrderStatusPaid, model.OrderStatusUnpaid, model.OrderStatusCancelled,
+			model.OrderStatusRefunded, model.OrderStatusFailed, model.OrderStatusPaid).
+		Joins("JOIN products ON products.id = orders.product_id").
+		Joins("LEFT JOIN payments ON payments.order_id = orders.id AND payments.deleted_at IS NULL").
+		Where("orders.deleted_at IS NULL AND orders.type = ? AND products.user_id = ?", model.OrderTypeProduct, sellerID)
+	if productID > 0 {
+		query = query.Where("orders.product_id = ?", productID)
+	}
+	err := query.Group("orders.product_id").Scan(&rows).Error
+	return rows, err
+}
+
+// ListPaidAmounts 查询卖家 since 之后支付且仍为已支付状态的订单金额。
+func (r *SellerStatsRepo) ListPaidAmounts(ctx context.Context, sellerID uint, since time.Time) ([]PaidOrderAmount, error) {
+	var rows []PaidOrderAmount
+	err := r.db.WithContext(ctx).Table("orders").
+		Select("orders.paid_at, "+sellerAmountExpr+" AS amount_cents").
+		Joins("JOIN products ON products.id = orders.product_id").
+		Joins("LEFT JOIN payments ON payments.order_id = orders.id AND payments.deleted_at IS NULL").
+		Where("orders.deleted_at IS NULL AND orders.type = ? AND orders.status = ? AND products.user_id = ?", model.OrderTypeProduct, model.OrderStatusPaid, sellerID).
+		Where("orders.paid_at >= ?", since).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ListProducts 查询卖家全部商品（含未上架）的统计所需字段。
+func (r *SellerStatsRepo) ListProducts(ctx context.Context, sellerID uint) ([]model.Product, error) {
+	var products []model.Product
+	err := r.db.WithContext(ctx).Model(&model.Product{}).
+		Select("id, name, status, stock, start_time, end_time").
+		Where("user_id = ?", sellerID).
+		Order("id desc").
+		Find(&products).Error
+	return products, err
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SettlementRepo struct {
+	db *gorm.DB
+}
+
+func NewSettlementRepo(db *gorm.DB) *SettlementRepo {
+	return &SettlementRepo{db: db}
+}
+
+// SettleableOrder 待结算订单及其卖家。
+type SettleableOrder struct {
+	ID                 uint
+	OrderNum           string
+	ProductID          uint
+	SellerID           uint
+	OriginalPriceCents int64
+	MemberPriceCents   int64
+	PaidCents          int64 // 实付金额，早期订单无价格快照时作为货款
+	PaidAt             time.Time
+}
+
+// ListSettleableOrders 按订单 ID 游标查询支付早于 paidBefore、尚未结算的已支付商品订单。
+func (r *SettlementRepo) ListSettleableOrders(ctx context.Context, paidBefore time.Time, afterID uint, limit int) ([]SettleableOrder, error) {
+	var rows []SettleableOrder
+	err := r.db.WithContext(ctx).Table("orders").
+		Select("orders.id, orders.order_num, orders.product_id, products.user_id AS seller_id, orders.original_price_cents, orders.member_price_cents, COALESCE(payments.amount_cents, 0) AS paid_cents, orders.paid_at").
+		Joins("JOIN products ON products.id = orders.product_id").
+		Joins("LEFT JOIN payments ON payments.order_id = orders.id AND payments.deleted_at IS NULL").
+		Where("orders.deleted_at IS NULL AND orders.type = ? AND orders.status = ?", model.OrderTypeProduct, model.OrderStatusPaid).
+		Where("orders.paid_at IS NOT NULL AND orders.paid_at < ? AND orders.id > ?", paidBefore, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM settlement_items WHERE settlement_items.order_id = orders.id AND settlement_items.kind = ?)", model.SettlementItemSale).
+		Order("orders.id asc").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ListUnreversedRefunds 按 ID 游标查询已结算但随后退款、尚未冲销的销售明细。
+func (r *SettlementRepo) ListUnreversedRefunds(ctx context.Context, afterID uint, limit int) ([]model.SettlementItem, error) {
+	var items []model.SettlementItem
+	err := r.db.WithContext(ctx).Model(&model.SettlementItem{}).
+		Joins("JOIN orders ON orders.id = settlement_items.order_id").
+		Where("settlement_items.kind = ? AND orders.status = ? AND settlement_items.id > ?", model.SettlementItemSale, model.OrderStatusRefunded, afterID).
+		Where("NOT EXISTS (SELECT 1 FROM settlement_items refunds WHERE refunds.order_id = settlement_items.order_id AND refunds.kind = ?)", model.SettlementItemRefund).
+		Order("settlement_items.id asc").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// GetOrCreateStatementForUpdate 获取卖家指定周期的结算单并加锁，不存在时创建为待打款。
+func (r *SettlementRepo) GetOrCreateStatementForUpdate(ctx context.Context, sellerID uint, start, end time.Time) (*model.SettlementStatement, error) {
+	statement := &model.SettlementStatement{SellerID: sellerID, PeriodStart: start, PeriodEnd: end, Status: model.SettlementStatusPending}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(statement).Error; err != nil {
+		return nil, err
+	}
+	var locked model.SettlementStatement
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("seller_id = ? AND period_start = ?", sellerID, start).
+		First(&locked).Error
+	if err != nil {
+		return nil, err
+	}
+	return &locked, nil
+}
+
+// CreateItems 写入结算明细，同一订单同类明细已存在时跳过。
+func (r *SettlementRepo) CreateItems(ctx context.Context, items []model.SettlementItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&items).Error
+}
+
+// RecalcStatement 按明细重新汇总结算单金额。
+func (r *SettlementRepo) RecalcStatement(ctx context.Context, id uint) error {
+	var sum struct {
+		OrderCount      int
+		GrossCents      int64
+		RefundCents     int64
+		CommissionCents int64
+		NetCents        int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.SettlementItem{}).
+		Select(`COALESCE(SUM(CASE WHEN kind = ? THEN 1 ELSE 0 END), 0) AS order_count,
+			COALESCE(SUM(CASE WHEN kind = ? THEN amount_cents ELSE 0 END), 0) AS gross_cents,
+			COALESCE(SUM(CASE WHEN kind = ? THEN -amount_cents ELSE 0 END), 0) AS refund_cents,
+			COALESCE(SUM(commission_cents), 0) AS commission_cents,
+			COALESCE(SUM(net_cents), 0) AS net_cents`,
+			model.SettlementItemSale, model.SettlementItemSale, model.SettlementItemRefund).
+		Where("statement_id = ?", id).
+		Scan(&sum).Error
+	if err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Model(&model.SettlementStatement{}).Where("id = ?", id).Updates(map[string]any{
+		"order_count":      sum.OrderCount,
+		"gross_cents":      sum.GrossCents,
+		"refund_cents":     sum.RefundCents,
+		"commission_cents": sum.CommissionCents,
+		"net_cents":        sum.NetCents,
+	}).Error
+}
+
+func (r *SettlementRepo) GetStatement(ctx context.Context, id uint) (*model.SettlementStatement, error) {
+	var statement model.SettlementStatement
+	if err := r.db.WithContext(ctx).First(&statement, id).Error; err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+func (r *SettlementRepo) GetStatementForUpdate(ctx context.Context, id uint) (*model.SettlementStatement, error) {
+	var statement model.SettlementStatement
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&statement, id).Error; err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// MarkPaid 待打款结算单置为已打款，返回受影响行数。
+func (r *SettlementRepo) MarkPaid(ctx context.Context, id, operatorID uint, payoutRef string, paidAt time.Time) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.SettlementStatement{}).
+		Where("id = ? AND status = ?", id, model.SettlementStatusPending).
+		Updates(map[string]any{
+			"status":     model.SettlementStatusPaid,
+			"payout_ref": payoutRef,
+			"paid_by":    operatorID,
+			"paid_at":    paidAt,
+		})
+	return tx.RowsAffected, tx.Error
+}
+
+// SettlementFilter 结算单查询条件，零值表示不过滤；PageSize 为 0 时不分页。
+type SettlementFilter struct {
+	SellerID    uint
+	Status      string
+	PeriodStart *time.Time
+	Page        int
+	PageSize    int
+}
+
+// ListStatements 按周期倒序查询结算单。
+func (r *SettlementRepo) ListStatements(ctx context.Context, filter SettlementFilter) ([]model.SettlementStatement, int64, error) {
+	var (
+		statements []model.SettlementStatement
+		total      int64
+	)
+	query := r.db.WithContext(ctx).Model(&model.SettlementStatement{})
+	if filter.SellerID != 0 {
+		query = query.Where("seller_id = ?", filter.SellerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.PeriodStart != nil {
+		query = query.Where("period_start = ?", *filter.PeriodStart)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = query.Order("period_start desc, id desc")
+	if filter.PageSize > 0 {
+		query = query.Offset((filter.Page - 1) * filter.PageSize).Limit(filter.PageSize)
+	}
+	if err := query.Find(&statements).Error; err != nil {
+		return nil, 0, err
+	}
+	return statements, total, nil
+}
+
+// ListItems 查询结算单明细，按订单先后排序。
+func (r *SettlementRepo) ListItems(ctx context.Context, statementID uint) ([]model.SettlementItem, error) {
+	var items []model.SettlementItem
+	err := r.db.WithContext(ctx).Where("statement_id = ?", statementID).Order("order_id asc, id asc").Find(&items).Error
+	return items, err
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UploadRepo struct {
+	db *gorm.DB
+}
+
+func NewUploadRepo(db *gorm.DB) *UploadRepo {
+	return &UploadRepo{db: db}
+}
+
+func (r *UploadRepo) GetByHash(ctx context.Context, hash string) (*model.Upload, error) {
+	var upload model.Upload
+	if err := r.db.WithContext(ctx).Where("hash = ?", hash).First(&upload).Error; err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Touch 刷新最近使用时间，返回影响行数；为 0 说明记录不存在或刚被清理。
+func (r *UploadRepo) Touch(ctx context.Context, hash string, now time.Time) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.Upload{}).Where("hash = ?", hash).Update("last_used_at", now)
+	return tx.RowsAffected, tx.Error
+}
+
+// Create 写入上传记录；相同内容并发上传时保留先写入的一条。
+func (r *UploadRepo) Create(ctx context.Context, upload *model.Upload) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(upload).Error
+}
+
+// ListIdle 最近使用时间早于 before 的记录，按 ID 升序分批扫描。
+func (r *UploadRepo) ListIdle(ctx context.Context, before time.Time, afterID uint, limit int) ([]model.Upload, error) {
+	var uploads []model.Upload
+	err := r.db.WithContext(ctx).
+		Where("last_used_at < ? AND id > ?", before, afterID).
+		Order("id asc").
+		Limit(limit).
+		Find(&uploads).Error
+	return uploads, err
+}
+
+// uploadHashPattern 上传文件名即 sha256 内容哈希。
+var uploadHashPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// uploadRefScanBatch 扫描引用表时每批读取的行数。
+const uploadRefScanBatch = 1000
+
+// ReferencedHashes 扫描商品图集、头像、店铺与品牌 logo，返回各文件哈希被引用的记录数；已删除的商品不计入。
+// 每张表按主键分批扫描一次，从地址中提取文件名哈希，可兼容 public_base_url 变更前保存的地址。
+func (r *UploadRepo) ReferencedHashes(ctx context.Context) (map[string]int, error) {
+	refs := make(map[string]int)
+	for _, ref := range []struct {
+		model   any
+		key     string
+		columns []string
+	}{
+		{&model.Product{}, "id", []string{"image", "images"}},
+		{&model.User{}, "id", []string{"avatar"}},
+		{&model.SellerProfile{}, "user_id", []string{"logo"}},
+		{&model.Brand{}, "id", []string{"logo"}},
+	} {
+		// 商品封面与图集第一张同步，封面为空时图集也为空
+		where := ref.columns[0] + " <> ''"
+		var after uint
+		for {
+			var rows []map[string]any
+			err := r.db.WithContext(ctx).Model(ref.model).
+				Select(append([]string{ref.key}, ref.columns...)).
+				Where(where+" AND "+ref.key+" > ?", after).
+				Order(ref.key + " asc").
+				Limit(uploadRefScanBatch).
+				Find(&rows).Error
+			if err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				after = uint(toInt64(row[ref.key]))
+				seen := make(map[string]struct{})
+				for _, col := range ref.columns {
+					for _, hash := range uploadHashPattern.FindAllString(toString(row[col]), -1) {
+						seen[hash] = struct{}{}
+					}
+				}
+				for hash := range seen {
+					refs[hash]++
+				}
+			}
+			if len(rows) < uploadRefScanBatch {
+				break
+			}
+		}
+	}
+	return refs, nil
+}
+
+// MarkReferences 记录引用数并重新开始保留期。
+func (r *UploadRepo) MarkReferences(ctx context.Context, id uint, refs int, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.Upload{}).Where("id = ?", id).
+		Updates(map[string]any{"ref_count": refs, "last_used_at": now}).Error
+}
+
+// DeleteIdle 条件删除仍未被引用且已过保留期的记录，期间被重新上传的不删除。
+func (r *UploadRepo) DeleteIdle(ctx context.Context, id uint, before time.Time) (int64, error) {
+	tx := r.db.WithContext(ctx).Where("id = ? AND ref_count = 0 AND last_used_at < ?", id, before).Delete(&model.Upload{})
+	return tx.RowsAffected, tx.Error
+}
+
+func toString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	default:
+		return ""
+	}
+}
+
+func toInt64(v any) int64 {
+	switch val := v.(type) {
+	case int64:
+		return val
+	case int32:
+		return int64(val)
+	case int:
+		return int64(val)
+	case uint64:
+		return int64(val)
+	case uint32:
+		return int64(val)
+	case uint:
+		return int64(val)
+	case []byte:
+		n, _ := strconv.ParseInt(string(val), 10, 64)
+		return n
+	default:
+		return 0
+	}
+}
@@ -70,13 +70,31 @@ func (r *UserRepo) GetByIDForUpdate(ctx context.Context, id uint) (*model.User,
 	return &user, nil
 }
 
-// UpdateGrowth 同步更新累计实付与成长等级。
-func (r *UserRepo) UpdateGrowth(ctx context.Context, userID uint, totalSpentCents int64, growthLevel int) error {
+// UpdateGrowth 同步更新累计实付、窗口实付、成长等级与待降级时间。
+func (r *UserRepo) UpdateGrowth(ctx context.Context, user *model.User) error {
 	updates := map[string]any{
-		"total_spent_cents": totalSpentCents,
-		"growth_level":      growthLevel,
+		"total_spent_cents":   user.TotalSpentCents,
+		"window_spent_cents":  user.WindowSpentCents,
+		"growth_level":        user.GrowthLevel,
+		"growth_downgrade_at": user.GrowthDowngradeAt,
 	}
-	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", user.ID).Updates(updates).Error
+}
+
+// ListGrowthReviewIDs 游标分页查询需要复核成长等级的用户：等级高于 L1 或处于降级宽限期。
+func (r *UserRepo) ListGrowthReviewIDs(ctx context.Context, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id > ? AND (growth_level > 1 OR growth_downgrade_at IS NOT NULL)", afterID).
+		Order("id asc").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// UpdatePoints 更新积分余额。
+func (r *UserRepo) UpdatePoints(ctx context.Context, userID uint, points int64) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Update("points", points).Error
 }
 
 func (r *UserRepo) ListAll(ctx context.Context, page, pageSize int) ([]model.User, int64, error) {
@@ -109,3 +127,62 @@ func (r *UserRepo) ListAllWithGrowthLevel(ctx context.Context, minLevel int) ([]
 	}
 	return users, nil
 }
+
+// ListIDsByGrowthRange 按成长等级区间游标分页查询用户 ID（id > afterID，升序）。
+func (r *UserRepo) ListIDsByGrowthRange(ctx context.Context, minLevel, maxLevel int, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("growth_level BETWEEN ? AND ? AND id > ?", minLevel, maxLevel, afterID).
+		Order("id asc").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// CountByGrowthRange 统计成长等级区间内的用户数。
+func (r *UserRepo) CountByGrowthRange(ctx context.Context, minLevel, maxLevel int) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("growth_level BETWEEN ? AND ?", minLevel, maxLevel).
+		Count(&total).Error
+	return total, err
+}
+
+// ListExistingIDs 过滤出真实存在的用户 ID（升序）；ids 由调用方分批传入，避免超出占位符上限。
+func (r *UserRepo) ListExistingIDs(ctx context.Context, ids []uint) ([]uint, error) {
+	var existing []uint
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("id IN ?", ids).
+		Order("id asc").
+		Pluck("id", &existing).Error
+	return existing, err
+}
+
+// CountExistingIDs 统计列表中真实存在的用户数；ids 由调用方分批传入。
+func (r *UserRepo) CountExistingIDs(ctx context.Context, ids []uint) (int64, error) {
+	var total int64
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	err := r.db.WithContext(ctx).Model(&model.User{}).Where("id IN ?", ids).Count(&total).Error
+	return total, err
+}
+
+// UsernamesByIDs 批量查询用户名，用于列表展示。
+func (r *UserRepo) UsernamesByIDs(ctx context.Context, ids []uint) (map[uint]string, error) {
+	names := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var users []model.User
+	if err := r.db.WithContext(ctx).Select("id", "username").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+	return names, nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VIPConfigRepo struct {
+	db *gorm.DB
+}
+
+func NewVIPConfigRepo(db *gorm.DB) *VIPConfigRepo {
+	return &VIPConfigRepo{db: db}
+}
+
+// ListCurrentPlans 查询各套餐的当前版本。
+func (r *VIPConfigRepo) ListCurrentPlans(ctx context.Context) ([]model.VIPPlan, error) {
+	var plans []model.VIPPlan
+	err := r.db.WithContext(ctx).Where("is_current = ?", true).Order("plan_id asc").Find(&plans).Error
+	return plans, err
+}
+
+// ListPlanVersions 查询套餐全部历史版本，新版本在前。
+func (r *VIPConfigRepo) ListPlanVersions(ctx context.Context, planID int) ([]model.VIPPlan, error) {
+	var plans []model.VIPPlan
+	err := r.db.WithContext(ctx).Where("plan_id = ?", planID).Order("version desc").Find(&plans).Error
+	return plans, err
+}
+
+// GetPlanVersion 按套餐 ID 和版本号查询。
+func (r *VIPConfigRepo) GetPlanVersion(ctx context.Context, planID, version int) (*model.VIPPlan, error) {
+	var plan model.VIPPlan
+	if err := r.db.WithContext(ctx).Where("plan_id = ? AND version = ?", planID, version).First(&plan).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// GetCurrentPlanForUpdate 锁定套餐当前版本，用于发布新版本。
+func (r *VIPConfigRepo) GetCurrentPlanForUpdate(ctx context.Context, planID int) (*model.VIPPlan, error) {
+	var plan model.VIPPlan
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("plan_id = ? AND is_current = ?", planID, true).
+		First(&plan).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// MaxPlanID 查询已使用的最大套餐 ID。
+func (r *VIPConfigRepo) MaxPlanID(ctx context.Context) (int, error) {
+	var maxID int
+	err := r.db.WithContext(ctx).Model(&model.VIPPlan{}).Select("COALESCE(MAX(plan_id), 0)").Scan(&maxID).Error
+	return maxID, err
+}
+
+// ReplaceCurrentPlan 下线旧的当前版本并写入新版本。
+func (r *VIPConfigRepo) ReplaceCurrentPlan(ctx context.Context, plan *model.VIPPlan) error {
+	if err := r.db.WithContext(ctx).Model(&model.VIPPlan{}).
+		Where("plan_id = ? AND is_current = ?", plan.PlanID, true).
+		Update("is_current", false).Error; err != nil {
+		return err
+	}
+	plan.IsCurrent = true
+	return r.db.WithContext(ctx).Create(plan).Error
+}
+
+// ListCurrentLevels 查询各成长等级的当前版本。
+func (r *VIPConfigRepo) ListCurrentLevels(ctx context.Context) ([]model.VIPLevelConfig, error) {
+	var levels []model.VIPLevelConfig
+	err := r.db.WithContext(ctx).Where("is_current = ?", true).Order("level asc").Find(&levels).Error
+	return levels, err
+}
+
+// ListLevelVersions 查询等级配置全部历史版本，新版本在前。
+func (r *VIPConfigRepo) ListLevelVersions(ctx context.Context, level int) ([]model.VIPLevelConfig, error) {
+	var levels []model.VIPLevelConfig
+	err := r.db.WithContext(ctx).Where("level = ?", level).Order("version desc").Find(&levels).Error
+	return levels, err
+}
+
+// MaxLevelVersion 查询等级配置的最新版本号，不存在时返回 0。
+func (r *VIPConfigRepo) MaxLevelVersion(ctx context.Context, level int) (int, error) {
+	var version int
+	err := r.db.WithContext(ctx).Model(&model.VIPLevelConfig{}).
+		Where("level = ?", level).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error
+	return version, err
+}
+
+// ReplaceCurrentLevel 下线旧的当前版本并写入新版本。
+func (r *VIPConfigRepo) ReplaceCurrentLevel(ctx context.Context, cfg *model.VIPLevelConfig) error {
+	if err := r.ClearCurrentLevel(ctx, cfg.Level); err != nil {
+		return err
+	}
+	cfg.IsCurrent = true
+	return r.db.WithContext(ctx).Create(cfg).Error
+}
+
+// ClearCurrentLevel 下线等级当前版本（历史版本保留）。
+func (r *VIPConfigRepo) ClearCurrentLevel(ctx context.Context, level int) error {
+	return r.db.WithContext(ctx).Model(&model.VIPLevelConfig{}).
+		Where("level = ? AND is_current = ?", level, true).
+		Update("is_current", false).Error
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WaitlistRepo struct {
+	db *gorm.DB
+}
+
+func NewWaitlistRepo(db *gorm.DB) *WaitlistRepo {
+	return &WaitlistRepo{db: db}
+}
+
+func (r *WaitlistRepo) Create(ctx context.Context, entry *model.WaitlistEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *WaitlistRepo) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&model.WaitlistEntry{}, id).Error
+}
+
+func (r *WaitlistRepo) Get(ctx context.Context, productID, userID uint) (*model.WaitlistEntry, error) {
+	var entry model.WaitlistEntry
+	if err := r.db.WithContext(ctx).Where("product_id = ? AND user_id = ?", productID, userID).First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *WaitlistRepo) GetForUpdate(ctx context.Context, productID, userID uint) (*model.WaitlistEntry, error) {
+	var entry model.WaitlistEntry
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND user_id = ?", productID, userID).First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// waitlistDemotedSQL 用户窗口内超时未支付次数达到门槛，计数起点取窗口起点与处罚重置时间的较晚者。
+const waitlistDemotedSQL = "(SELECT COUNT(*) FROM unpaid_timeouts t LEFT JOIN user_penalties p ON p.user_id = t.user_id " +
+	"WHERE t.user_id = waitlist_entries.user_id AND t.created_at > ? AND (p.reset_at IS NULL OR t.created_at > p.reset_at)) >= ?"
+
+// Position 排在该记录之前（含）的等待人数，降级用户排在所有未降级用户之后。
+// demoted 为该用户当前是否降级，since/threshold 为降级计数窗口起点与门槛。
+func (r *WaitlistRepo) Position(ctx context.Context, entry *model.WaitlistEntry, demoted bool, since time.Time, threshold int) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&model.WaitlistEntry{}).
+		Where("product_id = ? AND status = ?", entry.ProductID, model.WaitlistStatusWaiting)
+	if demoted {
+		query = query.Where("NOT "+waitlistDemotedSQL+" OR id <= ?", since, threshold, entry.ID)
+	} else {
+		query = query.Where("NOT "+waitlistDemotedSQL+" AND id <= ?", since, threshold, entry.ID)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
+
+func (r *WaitlistRepo) CountWaiting(ctx context.Context, productID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.WaitlistEntry{}).
+		Where("product_id = ? AND status = ?", productID, model.WaitlistStatusWaiting).
+		Count(&count).Error
+	return count, err
+}
+
+// NextWaiting 队首等待用户，分配时处于降级中的用户排在其他用户之后。
+func (r *WaitlistRepo) NextWaiting(ctx context.Context, productID uint, since time.Time, threshold int) (*model.WaitlistEntry, error) {
+	var entry model.WaitlistEntry
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND status = ?", productID, model.WaitlistStatusWaiting).
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: waitlistDemotedSQL + " ASC, id ASC", Vars: []any{since, threshold}, WithoutParentheses: true}}).
+		Take(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListExpiredOffers 认领超时的分配。
+func (r *WaitlistRepo) ListExpiredOffers(ctx context.Context, now time.Time, limit int) ([]model.WaitlistEntry, error) {
+	var entries []model.WaitlistEntry
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND offer_expires_at <= ?", model.WaitlistStatusOffered, now).
+		Order("offer_expires_at asc").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+// UpdateStatusIfMatch 条件更新状态，返回影响行数用于并发控制。
+func (r *WaitlistRepo) UpdateStatusIfMatch(ctx context.Context, id uint, from model.WaitlistStatus, updates map[string]any) (int64, error) {
+	tx := r.db.WithContext(ctx).Model(&model.WaitlistEntry{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	return tx.RowsAffected, tx.Error
+}
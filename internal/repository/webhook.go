@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepo struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepo(db *gorm.DB) *WebhookRepo {
+	return &WebhookRepo{db: db}
+}
+
+func (r *WebhookRepo) CreateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error {
+	return r.db.WithContext(ctx).Create(endpoint).Error
+}
+
+// GetEndpoint 查询卖家的回调地址；sellerID 为 0 时不限卖家。
+func (r *WebhookRepo) GetEndpoint(ctx context.Context, sellerID, id uint) (*model.WebhookEndpoint, error) {
+	var endpoint model.WebhookEndpoint
+	query := r.db.WithContext(ctx).Where("id = ?", id)
+	if sellerID > 0 {
+		query = query.Where("seller_id = ?", sellerID)
+	}
+	if err := query.First(&endpoint).Error; err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *WebhookRepo) ListEndpoints(ctx context.Context, sellerID uint) ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	err := r.db.WithContext(ctx).Where("seller_id = ?", sellerID).Order("id asc").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// ListEnabledEndpoints 查询卖家启用中的回调地址。
+func (r *WebhookRepo) ListEnabledEndpoints(ctx context.Context, sellerID uint) ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	err := r.db.WithContext(ctx).Where("seller_id = ? AND enabled = ?", sellerID, true).Order("id asc").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *WebhookRepo) CountEndpoints(ctx context.Context, sellerID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&model.WebhookEndpoint{}).Where("seller_id = ?", sellerID).Count(&total).Error
+	return total, err
+}
+
+func (r *WebhookRepo) UpdateEndpoint(ctx context.Context, id uint, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.WebhookEndpoint{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *WebhookRepo) DeleteEndpoint(ctx context.Context, sellerID, id uint) (int64, error) {
+	tx := r.db.WithContext(ctx).Where("id = ? AND seller_id = ?", id, sellerID).Delete(&model.WebhookEndpoint{})
+	return tx.RowsAffected, tx.Error
+}
+
+func (r *WebhookRepo) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// GetDelivery 查询投递记录；endpointID 为 0 时不限回调地址。
+func (r *WebhookRepo) GetDelivery(ctx context.Context, endpointID, id uint) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	query := r.db.WithContext(ctx).Where("id = ?", id)
+	if endpointID > 0 {
+		query = query.Where("endpoint_id = ?", endpointID)
+	}
+	if err := query.First(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookRepo) UpdateDelivery(ctx context.Context, id uint, updates map[string]any) error {
+	return r.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ListDeliveries 分页查询回调地址的投递记录，新记录在前。
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, endpointID uint, status string, page, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	var deliveries []model.WebhookDelivery
+	var total int64
+	query := r.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error
+	return deliveries, total, err
+}
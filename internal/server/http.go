@@ -5,6 +5,7 @@ import (
 	"SneakerFlash/internal/db"
 	"SneakerFlash/internal/handler"
 	"SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/infra/storage"
 	"SneakerFlash/internal/middlerware"
 	"SneakerFlash/internal/model"
 	"SneakerFlash/internal/repository"
@@ -23,51 +24,88 @@ func NewHttpServer() *gin.Engine {
 	productRepo := repository.NewProductRepo(db.DB)
 
 	// service 层
-	userServicer := service.NewUserService(userRepo)
-	productServicer := service.NewProductService(productRepo)
+	riskServicer := service.NewRiskService(redis.RDB)
+	referralServicer := service.NewReferralService(db.DB, riskServicer)
+	userServicer := service.NewUserService(userRepo, referralServicer)
+	productServicer := service.NewProductService(db.DB, productRepo, repository.NewSellerRepo(db.DB), repository.NewCatalogRepo(db.DB))
 	seckillServicer := service.NewSeckillService(db.DB, productRepo)
 	orderServicer := service.NewOrderService(db.DB, productRepo, userRepo)
-	uploadServicer := service.NewUploadService(config.Conf.Server.UploadDir)
+	uploadServicer := service.NewUploadService(db.DB, storage.Store)
 	couponServicer := service.NewCouponService(db.DB)
+	couponJobServicer := service.NewCouponJobService(db.DB)
+	pointsServicer := service.NewPointsService(db.DB)
+	vipConfigServicer := service.NewVIPConfigService(db.DB)
 	vipServicer := service.NewVIPService(db.DB, userRepo, couponServicer)
 	healthServicer := service.NewHealthService()
-	riskServicer := service.NewRiskService(redis.RDB)
 	adminServicer := service.NewAdminService(db.DB, userRepo, productRepo)
 	auditServicer := service.NewAuditService(db.DB)
 	streamServicer := service.NewStreamService()
+	notificationServicer := service.NewNotificationService(db.DB)
+	dropServicer := service.NewDropService(db.DB)
+	waitlistServicer := service.NewWaitlistService(db.DB, productRepo)
+	penaltyServicer := service.NewPenaltyService(db.DB, riskServicer)
+	sellerServicer := service.NewSellerService(db.DB)
+	settlementServicer := service.NewSettlementService(db.DB)
+	catalogServicer := service.NewCatalogService(db.DB)
 
 	// handler 层
 	userHandler := handler.NewUserHandler(userServicer)
-	productHandler := handler.NewProductHandler(productServicer)
+	productHandler := handler.NewProductHandler(productServicer, vipServicer, dropServicer)
+	catalogHandler := handler.NewCatalogHandler(catalogServicer)
 	seckillHandler := handler.NewSeckillHandler(seckillServicer)
 	orderHandler := handler.NewOrderHandler(orderServicer)
 	uploadHandler := handler.NewUploadHandler(uploadServicer)
 	vipHandler := handler.NewVIPHandler(vipServicer)
 	couponHandler := handler.NewCouponHandler(couponServicer)
+	pointsHandler := handler.NewPointsHandler(pointsServicer)
+	referralHandler := handler.NewReferralHandler(referralServicer)
 	healthHandler := handler.NewHealthHandler(healthServicer)
-	adminHandler := handler.NewAdminHandler(adminServicer, riskServicer, couponServicer, auditServicer)
+	adminHandler := handler.NewAdminHandler(handler.AdminDeps{
+		Admin:      adminServicer,
+		Risk:       riskServicer,
+		Coupon:     couponServicer,
+		CouponJob:  couponJobServicer,
+		VIPConfig:  vipConfigServicer,
+		Audit:      auditServicer,
+		Referral:   referralServicer,
+		Drop:       dropServicer,
+		Penalty:    penaltyServicer,
+		Seller:     sellerServicer,
+		Settlement: settlementServicer,
+		Catalog:    catalogServicer,
+	})
 	streamHandler := handler.NewStreamHandler(streamServicer)
+	notificationHandler := handler.NewNotificationHandler(notificationServicer)
+	waitlistHandler := handler.NewWaitlistHandler(waitlistServicer)
+	penaltyHandler := handler.NewPenaltyHandler(penaltyServicer)
+	sellerHandler := handler.NewSellerHandler(sellerServicer)
+	settlementHandler := handler.NewSettlementHandler(settlementServicer)
+	sellerStatsHandler := handler.NewSellerStatsHandler(service.NewSellerStatsService(db.DB))
+	webhookHandler := handler.NewWebhookHandler(service.NewWebhookService(db.DB))
+	priceHandler := handler.NewPriceHandler(service.NewPriceService(db.DB))
 
 	// 注册路由
 	r := gin.New()
 	r.Use(middlerware.SlogMiddlerware(), middlerware.MetricsMiddleware(), middlerware.SlogRecovery())
 	if config.Conf.Risk.Enable {
 		r.Use(middlerware.BlackListMiddleware(redis.RDB))
-		r.Use(middlerware.GrayListMiddleware(redis.RDB))
+		// 灰名单用户仍可查看处罚并提交申诉
+		r.Use(middlerware.GrayListMiddleware(redis.RDB, "/api/v1/penalty"))
 	}
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173", "http://127.0.0.1:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Device-ID"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		AllowWebSockets:  true,
 	}))
-	uploadDir := config.Conf.Server.UploadDir
-	if uploadDir == "" {
-		uploadDir = "uploads"
+	// 本地存储由 API 实例提供文件访问；对象存储直接由桶或 CDN 提供
+	if local, ok := storage.Store.(*storage.Local); ok {
+		files := gin.WrapH(http.StripPrefix(storage.LocalMountPath, local))
+		r.GET(storage.LocalMountPath+"/*filepath", files)
+		r.HEAD(storage.LocalMountPath+"/*filepath", files)
 	}
-	r.Static("/uploads", uploadDir)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	r.GET("/metrics", func(c *gin.Context) {
 		middlerware.MetricsHandler(c)
@@ -92,7 +130,13 @@ func NewHttpServer() *gin.Engine {
 		api.POST("/refresh", userHandler.Refresh)
 
 		api.GET("/products", productHandler.ListProducts)
+		api.GET("/brands", catalogHandler.Brands)
+		api.GET("/categories", catalogHandler.Categories)
 		api.GET("/product/:id", productHandler.GetProduct)
+		api.GET("/products/:id/price-history", priceHandler.History)
+		api.GET("/vip/plans", vipHandler.ListPlans)
+		api.GET("/sellers/:id", sellerHandler.Profile)
+		api.GET("/sellers/:id/products", sellerHandler.Drops)
 
 		// 支付回调（示例）
 		if config.Conf.Risk.Enable {
@@ -110,14 +154,56 @@ func NewHttpServer() *gin.Engine {
 		auth.PUT("/profile", userHandler.UpdateProfile)
 		auth.POST("/upload", uploadHandler.UploadImage)
 		auth.GET("/vip/profile", vipHandler.GetProfile)
+		auth.GET("/vip/growth-history", vipHandler.GetGrowthHistory)
 		auth.POST("/vip/purchase", vipHandler.Purchase)
 		auth.GET("/coupons/mine", couponHandler.ListMyCoupons)
 		auth.POST("/coupons/purchase", couponHandler.PurchaseCoupon)
+		auth.GET("/points", pointsHandler.GetSummary)
+		auth.GET("/points/history", pointsHandler.ListHistory)
+		auth.POST("/points/redeem-coupon", pointsHandler.RedeemCoupon)
+		auth.GET("/referral", referralHandler.GetMine)
+		auth.GET("/notifications", notificationHandler.List)
+		auth.GET("/notifications/unread-count", notificationHandler.UnreadCount)
+		auth.POST("/notifications/:id/read", notificationHandler.MarkRead)
+		auth.POST("/notifications/read-all", notificationHandler.MarkAllRead)
+		auth.GET("/notifications/preferences", notificationHandler.GetPreference)
+		auth.PUT("/notifications/preferences", notificationHandler.UpdatePreference)
+
+		auth.POST("/seller/application", sellerHandler.Apply)
+		auth.GET("/seller/profile", sellerHandler.Mine)
+		auth.PUT("/seller/profile", sellerHandler.UpdateStore)
+		auth.GET("/seller/stats", sellerStatsHandler.Overview)
+		auth.GET("/seller/stats/revenue", sellerStatsHandler.Revenue)
+		auth.GET("/seller/stats/products/:id/live", sellerStatsHandler.Live)
+		auth.GET("/seller/webhooks", webhookHandler.List)
+		auth.POST("/seller/webhooks", webhookHandler.Create)
+		auth.PUT("/seller/webhooks/:id", webhookHandler.Update)
+		auth.DELETE("/seller/webhooks/:id", webhookHandler.Delete)
+		auth.POST("/seller/webhooks/:id/rotate-secret", webhookHandler.RotateSecret)
+		auth.GET("/seller/webhooks/:id/deliveries", webhookHandler.Deliveries)
+		auth.POST("/seller/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+		auth.GET("/seller/settlements", settlementHandler.List)
+		auth.GET("/seller/settlements/:id", settlementHandler.Detail)
+		auth.GET("/seller/settlements/:id/export", settlementHandler.Export)
 
 		auth.POST("/products", productHandler.Create)
 		auth.PUT("/products/:id", productHandler.UpdateProduct)
 		auth.DELETE("/products/:id", productHandler.DeleteProduct)
+		auth.POST("/products/:id/submit", productHandler.SubmitProduct)
+		auth.POST("/products/:id/offline", productHandler.OfflineProduct)
+		auth.GET("/products/:id/price-schedules", priceHandler.ListSchedules)
+		auth.POST("/products/:id/price-schedules", priceHandler.CreateSchedule)
+		auth.DELETE("/products/:id/price-schedules/:schedule_id", priceHandler.CancelSchedule)
 		auth.GET("/products/mine", productHandler.ListMyProducts)
+		auth.GET("/products/:id/access", productHandler.GetAccess)
+		auth.GET("/products/:id/subscription", productHandler.GetSubscription)
+		auth.POST("/products/:id/subscription", productHandler.Subscribe)
+		auth.DELETE("/products/:id/subscription", productHandler.Unsubscribe)
+		auth.GET("/products/:id/subscribers", productHandler.GetSubscribers)
+		auth.GET("/products/:id/waitlist", waitlistHandler.Status)
+		auth.POST("/products/:id/waitlist", waitlistHandler.Join)
+		auth.DELETE("/products/:id/waitlist", waitlistHandler.Leave)
+		auth.POST("/products/:id/waitlist/claim", waitlistHandler.Claim)
 
 		if config.Conf.Risk.Enable {
 			seckillLimit := middlerware.InterfaceLimiter(redis.RDB, middlerware.BuildLimit(config.Conf.Risk.SeckillRate, "rl:seckill", 30), "秒杀过于频繁，请稍后再试")
@@ -127,12 +213,19 @@ func NewHttpServer() *gin.Engine {
 			auth.POST("/seckill", seckillHandler.Seckill)
 		}
 
+		auth.GET("/penalty", penaltyHandler.Status)
+		auth.POST("/penalty/appeal", penaltyHandler.Appeal)
+
 		auth.GET("/orders", orderHandler.ListOrders)
 		auth.GET("/orders/:id", orderHandler.GetOrder)
 		auth.GET("/orders/poll/:order_num", orderHandler.PollOrder)
 		auth.POST("/orders/:id/apply-coupon", orderHandler.ApplyCoupon)
+		auth.POST("/orders/:id/apply-points", orderHandler.ApplyPoints)
+		auth.GET("/orders/:id/coupon-options", orderHandler.CouponOptions)
 		auth.GET("/stream/orders/:id", streamHandler.OrderEvents)
 		auth.GET("/stream/products/:id", streamHandler.ProductEvents)
+		auth.GET("/stream/waitlist/:id", streamHandler.WaitlistEvents)
+		auth.GET("/stream/notifications", streamHandler.NotificationEvents)
 	}
 
 	admin := api.Group("/admin")
@@ -145,13 +238,49 @@ func NewHttpServer() *gin.Engine {
 		admin.POST("/coupons", middlerware.AdminResourceAuth(model.AdminResourceCoupons), adminHandler.CreateCoupon)
 		admin.PUT("/coupons/:id", middlerware.AdminResourceAuth(model.AdminResourceCoupons), adminHandler.UpdateCoupon)
 		admin.DELETE("/coupons/:id", middlerware.AdminResourceAuth(model.AdminResourceCoupons), adminHandler.DeleteCoupon)
+		admin.GET("/coupon-jobs", middlerware.AdminResourceAuth(model.AdminResourceCoupons), adminHandler.ListCouponJobs)
+		admin.POST("/coupon-jobs", middlerware.AdminResourceAuth(model.AdminResourceCoupons), adminHandler.CreateCouponJob)
+		admin.GET("/coupon-jobs/:id", middlerware.AdminResourceAuth(model.AdminResourceCoupons), adminHandler.GetCouponJob)
+		admin.POST("/coupon-jobs/:id/cancel", middlerware.AdminResourceAuth(model.AdminResourceCoupons), adminHandler.CancelCouponJob)
+		admin.GET("/vip/config", middlerware.AdminResourceAuth(model.AdminResourceVIP), adminHandler.GetVIPConfig)
+		admin.POST("/vip/plans", middlerware.AdminResourceAuth(model.AdminResourceVIP), adminHandler.CreateVIPPlan)
+		admin.PUT("/vip/plans/:plan_id", middlerware.AdminResourceAuth(model.AdminResourceVIP), adminHandler.UpdateVIPPlan)
+		admin.GET("/vip/plans/:plan_id/versions", middlerware.AdminResourceAuth(model.AdminResourceVIP), adminHandler.ListVIPPlanVersions)
+		admin.PUT("/vip/levels/:level", middlerware.AdminResourceAuth(model.AdminResourceVIP), adminHandler.SaveVIPLevel)
+		admin.DELETE("/vip/levels/:level", middlerware.AdminResourceAuth(model.AdminResourceVIP), adminHandler.DeleteVIPLevel)
+		admin.GET("/vip/levels/:level/versions", middlerware.AdminResourceAuth(model.AdminResourceVIP), adminHandler.ListVIPLevelVersions)
 		admin.GET("/products", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.ListProducts)
+		admin.GET("/products/:id/subscribers", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.ProductSubscribers)
+		admin.POST("/products/:id/approve", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.ApproveProduct)
+		admin.POST("/products/:id/reject", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.RejectProduct)
+		admin.POST("/products/:id/offline", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.OfflineProduct)
+		admin.GET("/sellers", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.ListSellers)
+		admin.POST("/sellers/:user_id/approve", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.ApproveSeller)
+		admin.POST("/sellers/:user_id/reject", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.RejectSeller)
+		admin.POST("/brands", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.CreateBrand)
+		admin.PUT("/brands/:id", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.UpdateBrand)
+		admin.DELETE("/brands/:id", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.DeleteBrand)
+		admin.POST("/categories", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.CreateCategory)
+		admin.PUT("/categories/:id", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.UpdateCategory)
+		admin.DELETE("/categories/:id", middlerware.AdminResourceAuth(model.AdminResourceProducts), adminHandler.DeleteCategory)
 		admin.GET("/risk/blacklist", middlerware.AdminResourceAuth(model.AdminResourceRisk), adminHandler.ListBlacklist)
 		admin.POST("/risk/blacklist", middlerware.AdminResourceAuth(model.AdminResourceRisk), adminHandler.AddBlacklist)
 		admin.DELETE("/risk/blacklist", middlerware.AdminResourceAuth(model.AdminResourceRisk), adminHandler.RemoveBlacklist)
 		admin.GET("/risk/graylist", middlerware.AdminResourceAuth(model.AdminResourceRisk), adminHandler.ListGraylist)
 		admin.POST("/risk/graylist", middlerware.AdminResourceAuth(model.AdminResourceRisk), adminHandler.AddGraylist)
 		admin.DELETE("/risk/graylist", middlerware.AdminResourceAuth(model.AdminResourceRisk), adminHandler.RemoveGraylist)
+		admin.GET("/penalties", middlerware.AdminResourceAuth(model.AdminResourceRisk), adminHandler.ListPenalties)
+		admin.GET("/penalties/:user_id", middlerware.AdminResourceAuth(model.AdminResourceRisk), adminHandler.GetPenalty)
+		admin.POST("/penalties/:user_id/reset", middlerware.AdminResourceAuth(model.AdminResourceRisk), adminHandler.ResetPenalty)
+		admin.POST("/penalties/:user_id/appeal", middlerware.AdminResourceAuth(model.AdminResourceRisk), adminHandler.ResolvePenaltyAppeal)
+		admin.GET("/referrals", middlerware.AdminResourceAuth(model.AdminResourceReferral), adminHandler.ListReferrals)
+		admin.GET("/referrals/report", middlerware.AdminResourceAuth(model.AdminResourceReferral), adminHandler.ReferralReport)
+		admin.GET("/settlements", middlerware.AdminResourceAuth(model.AdminResourceSettlement), adminHandler.ListSettlements)
+		admin.GET("/settlements/export", middlerware.AdminResourceAuth(model.AdminResourceSettlement), adminHandler.ExportSettlements)
+		admin.POST("/settlements/run", middlerware.AdminResourceAuth(model.AdminResourceSettlement), adminHandler.RunSettlement)
+		admin.GET("/settlements/:id", middlerware.AdminResourceAuth(model.AdminResourceSettlement), adminHandler.GetSettlement)
+		admin.GET("/settlements/:id/export", middlerware.AdminResourceAuth(model.AdminResourceSettlement), adminHandler.ExportSettlementItems)
+		admin.POST("/settlements/:id/pay", middlerware.AdminResourceAuth(model.AdminResourceSettlement), adminHandler.PaySettlement)
 		admin.GET("/audit", middlerware.AdminResourceAuth(model.AdminResourceAudit), adminHandler.ListAuditLogs)
 	}
 	return r
@@ -84,12 +84,16 @@ func (s *AdminService) ListAllOrders(ctx context.Context, status *model.OrderSta
 	return s.orderRepo.ListAll(ctx, status, page, pageSize)
 }
 
-func (s *AdminService) ListAllProducts(ctx context.Context, page, pageSize int) ([]model.Product, int64, error) {
+// ListAllProducts 管理台商品列表，status=pending_review 即审核队列。
+func (s *AdminService) ListAllProducts(ctx context.Context, status model.ProductStatus, page, pageSize int) ([]model.Product, int64, error) {
 	if ctx == nil {
 		return nil, 0, fmt.Errorf("context is nil")
 	}
+	if status != "" && !model.ValidProductStatus(status) {
+		return nil, 0, ErrProductStatusInvalid
+	}
 	page, pageSize = normalizePage(page, pageSize)
-	return s.productRepo.ListAll(ctx, page, pageSize)
+	return s.productRepo.ListAll(ctx, status, page, pageSize)
 }
 
 func normalizePage(page, pageSize int) (int, int) {
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -13,6 +14,9 @@ import (
 // pending 状态缓存 TTL，避免长时间占用内存。
 const pendingOrderTTL = 10 * time.Minute
 
+// productListVersionKey 商品目录缓存版本号，商品上下架、编辑、改价或删除时递增，旧版本缓存自然过期。
+const productListVersionKey = "product:list:version"
+
 // 缓存 worker pool 配置
 const (
 	cacheWorkerCount  = 10    // worker 数量
@@ -171,6 +175,7 @@ func refreshStockCacheAsync(productID uint, stock int) {
 }
 
 // invalidateProductInfoCache 异步失效商品详情缓存，使用 worker pool + 去重。
+// 库存、销售状态变化只走这里，目录缓存依赖 30 秒 TTL 收敛，开售期间不会因每笔订单失效。
 func invalidateProductInfoCache(productID uint) {
 	// 延迟初始化 worker pool
 	cacheInvalidateWorkerOnce.Do(initCacheInvalidateWorkers)
@@ -187,3 +192,11 @@ func invalidateProductInfoCache(productID uint) {
 		pendingInvalidate.Delete(productID) // 没进队列，清除标记
 	}
 }
+
+// invalidateProductCatalogCache 商品上下架、编辑、改价或删除时失效详情缓存并递增目录缓存版本。
+func invalidateProductCatalogCache(productID uint) {
+	invalidateProductInfoCache(productID)
+	if err := redis.RDB.Incr(context.Background(), productListVersionKey).Err(); err != nil {
+		slog.Warn("递增商品目录缓存版本失败", slog.Uint64("product_id", uint64(productID)), slog.Any("err", err))
+	}
+}
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCatalogNameInvalid = errors.New("名称不能为空且不超过 50 个字符")
+	ErrBrandNotFound      = errors.New("品牌不存在")
+	ErrBrandDuplicate     = errors.New("品牌名称已存在")
+	ErrBrandInUse         = errors.New("品牌下仍有商品，不能删除")
+	ErrCategoryNotFound   = errors.New("分类不存在")
+	ErrCategoryDuplicate  = errors.New("同级分类名称已存在")
+	ErrCategoryInUse      = errors.New("分类下仍有子分类或商品，不能删除")
+	ErrCategoryTooDeep    = errors.New("分类最多三级")
+	ErrProductTagsInvalid = errors.New("商品标签最多 10 个，每个不超过 20 个字符且不含引号、尖括号、& 或反斜杠")
+)
+
+const (
+	catalogNameMaxLength = 50
+	productTagMaxCount   = 10
+	productTagMaxLength  = 20
+	// 标签以 JSON 文本存储并按 `"tag"` 子串匹配，这些字符会被转义，不允许出现
+	productTagForbidden = `"\<>&`
+)
+
+// BrandInput 新建品牌。
+type BrandInput struct {
+	Name        string
+	Logo        string
+	Description string
+	Sort        int
+}
+
+// BrandUpdate 修改品牌，nil 字段不修改。
+type BrandUpdate struct {
+	Name        *string
+	Logo        *string
+	Description *string
+	Sort        *int
+}
+
+// CategoryInput 新建分类，ParentID 为 0 时创建一级分类。
+type CategoryInput struct {
+	ParentID uint
+	Name     string
+	Sort     int
+}
+
+// CategoryUpdate 修改分类名称或排序，不支持移动到其他父级。
+type CategoryUpdate struct {
+	Name *string
+	Sort *int
+}
+
+// CatalogService 品牌与分类树管理，供商品发布与目录筛选使用。
+type CatalogService struct {
+	repo *repository.CatalogRepo
+}
+
+func NewCatalogService(db *gorm.DB) *CatalogService {
+	return &CatalogService{repo: repository.NewCatalogRepo(db)}
+}
+
+// ListBrands 全部品牌。
+func (s *CatalogService) ListBrands(ctx context.Context) ([]model.Brand, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	return s.repo.ListBrands(ctx)
+}
+
+// CreateBrand 新建品牌，名称唯一。
+func (s *CatalogService) CreateBrand(ctx context.Context, in BrandInput) (*model.Brand, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	name, err := normalizeCatalogName(in.Name)
+	if err != nil {
+		return nil, err
+	}
+	brand := &model.Brand{
+		Name:        name,
+		Logo:        strings.TrimSpace(in.Logo),
+		Description: strings.TrimSpace(in.Description),
+		Sort:        in.Sort,
+	}
+	if err := s.repo.CreateBrand(ctx, brand); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || isMySQLDuplicate(err) {
+			return nil, ErrBrandDuplicate
+		}
+		return nil, err
+	}
+	return brand, nil
+}
+
+// UpdateBrand 修改品牌信息。
+func (s *CatalogService) UpdateBrand(ctx context.Context, id uint, in BrandUpdate) (*model.Brand, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if _, err := s.brand(ctx, id); err != nil {
+		return nil, err
+	}
+	updates := map[string]any{}
+	if in.Name != nil {
+		name, err := normalizeCatalogName(*in.Name)
+		if err != nil {
+			return nil, err
+		}
+		updates["name"] = name
+	}
+	if in.Logo != nil {
+		updates["logo"] = strings.TrimSpace(*in.Logo)
+	}
+	if in.Description != nil {
+		updates["description"] = strings.TrimSpace(*in.Description)
+	}
+	if in.Sort != nil {
+		updates["sort"] = *in.Sort
+	}
+	if len(updates) > 0 {
+		if err := s.repo.UpdateBrand(ctx, id, updates); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) || isMySQLDuplicate(err) {
+				return nil, ErrBrandDuplicate
+			}
+			return nil, err
+		}
+	}
+	return s.brand(ctx, id)
+}
+
+// DeleteBrand 删除未被商品引用的品牌。
+func (s *CatalogService) DeleteBrand(ctx context.Context, id uint) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	used, err := s.repo.CountProductsByBrand(ctx, id)
+	if err != nil {
+		return err
+	}
+	if used > 0 {
+		return ErrBrandInUse
+	}
+	rows, err := s.repo.DeleteBrand(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrBrandNotFound
+	}
+	return nil
+}
+
+// CategoryTree 分类树，一级分类在最外层，同级按排序值升序。
+func (s *CatalogService) CategoryTree(ctx context.Context) ([]*model.Category, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[uint]*model.Category, len(categories))
+	roots := make([]*model.Category, 0)
+	// 按层级升序遍历，父节点总是先于子节点建好
+	for i := range categories {
+		node := &categories[i]
+		nodes[node.ID] = node
+		if parent, ok := nodes[node.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		} else if node.ParentID == 0 {
+			roots = append(roots, node)
+		}
+	}
+	return roots, nil
+}
+
+// CreateCategory 新建分类，最多三级，同级名称唯一。
+func (s *CatalogService) CreateCategory(ctx context.Context, in CategoryInput) (*model.Category, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	name, err := normalizeCatalogName(in.Name)
+	if err != nil {
+		return nil, err
+	}
+	level := 1
+	if in.ParentID > 0 {
+		parent, err := s.category(ctx, in.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		level = parent.Level + 1
+	}
+	if level > model.CategoryMaxLevel {
+		return nil, ErrCategoryTooDeep
+	}
+	category := &model.Category{ParentID: in.ParentID, Name: name, Level: level, Sort: in.Sort}
+	if err := s.repo.CreateCategory(ctx, category); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || isMySQLDuplicate(err) {
+			return nil, ErrCategoryDuplicate
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+// UpdateCategory 修改分类名称或排序。
+func (s *CatalogService) UpdateCategory(ctx context.Context, id uint, in CategoryUpdate) (*model.Category, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if _, err := s.category(ctx, id); err != nil {
+		return nil, err
+	}
+	updates := map[string]any{}
+	if in.Name != nil {
+		name, err := normalizeCatalogName(*in.Name)
+		if err != nil {
+			return nil, err
+		}
+		updates["name"] = name
+	}
+	if in.Sort != nil {
+		updates["sort"] = *in.Sort
+	}
+	if len(updates) > 0 {
+		if err := s.repo.UpdateCategory(ctx, id, updates); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) || isMySQLDuplicate(err) {
+				return nil, ErrCategoryDuplicate
+			}
+			return nil, err
+		}
+	}
+	return s.category(ctx, id)
+}
+
+// DeleteCategory 删除没有子分类且未被商品引用的分类。
+func (s *CatalogService) DeleteCategory(ctx context.Context, id uint) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	children, err := s.repo.CountChildCategories(ctx, id)
+	if err != nil {
+		return err
+	}
+	used, err := s.repo.CountProductsByCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+	if children > 0 || used > 0 {
+		return ErrCategoryInUse
+	}
+	rows, err := s.repo.DeleteCategory(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+func (s *CatalogService) brand(ctx context.Context, id uint) (*model.Brand, error) {
+	brand, err := s.repo.GetBrand(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBrandNotFound
+		}
+		return nil, err
+	}
+	return brand, nil
+}
+
+func (s *CatalogService) category(ctx context.Context, id uint) (*model.Category, error) {
+	category, err := s.repo.GetCategory(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+func normalizeCatalogName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" || len([]rune(name)) > catalogNameMaxLength {
+		return "", ErrCatalogNameInvalid
+	}
+	return name, nil
+}
+
+// categorySubtreeIDs 分类及其全部下级分类的 ID；分类不存在时只返回自身，筛选结果为空。
+func categorySubtreeIDs(ctx context.Context, repo *repository.CatalogRepo, id uint) ([]uint, error) {
+	categories, err := repo.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[uint][]uint, len(categories))
+	for _, category := range categories {
+		children[category.ParentID] = append(children[category.ParentID], category.ID)
+	}
+	ids := []uint{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+// validateProductCatalog 校验商品引用的品牌与分类存在，0 表示不设置。
+func validateProductCatalog(ctx context.Context, repo *repository.CatalogRepo, brandID, categoryID uint) error {
+	if brandID > 0 {
+		if _, err := repo.GetBrand(ctx, brandID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBrandNotFound
+			}
+			return err
+		}
+	}
+	if categoryID > 0 {
+		if _, err := repo.GetCategory(ctx, categoryID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCategoryNotFound
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeProductTags 去除首尾空白、统一小写并去重，按标签筛选时同样小写匹配。
+func normalizeProductTags(tags []string) (model.ProductTags, error) {
+	normalized := make(model.ProductTags, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, raw := range tags {
+		tag := normalizeProductTag(raw)
+		if tag == "" {
+			continue
+		}
+		if len([]rune(tag)) > productTagMaxLength || strings.ContainsAny(tag, productTagForbidden) ||
+			strings.IndexFunc(tag, unicode.IsControl) >= 0 {
+			return nil, ErrProductTagsInvalid
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > productTagMaxCount {
+		return nil, ErrProductTagsInvalid
+	}
+	return normalized, nil
+}
+
+func normalizeProductTag(raw string) string {
+	return strings.ToLower(strings.TrimSpace(raw))
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCatalogService_BrandsCategoriesAndProductMetadata(t *testing.T) {
+	_, fixtures := newOrderServiceForTest(t)
+	svc := NewCatalogService(db.DB)
+	productSvc := NewProductService(db.DB, repository.NewProductRepo(db.DB), repository.NewSellerRepo(db.DB), repository.NewCatalogRepo(db.DB))
+	ctx := context.Background()
+
+	nike, err := svc.CreateBrand(ctx, BrandInput{Name: " Nike ", Sort: 1})
+	if err != nil || nike.Name != "Nike" {
+		t.Fatalf("CreateBrand() = %+v, %v", nike, err)
+	}
+	if _, err := svc.CreateBrand(ctx, BrandInput{Name: "Nike"}); !errors.Is(err, ErrBrandDuplicate) {
+		t.Fatalf("CreateBrand(duplicate) error = %v, want ErrBrandDuplicate", err)
+	}
+	if _, err := svc.CreateBrand(ctx, BrandInput{Name: "  "}); !errors.Is(err, ErrCatalogNameInvalid) {
+		t.Fatalf("CreateBrand(empty) error = %v, want ErrCatalogNameInvalid", err)
+	}
+
+	shoes, _ := svc.CreateCategory(ctx, CategoryInput{Name: "鞋类"})
+	basketball, _ := svc.CreateCategory(ctx, CategoryInput{ParentID: shoes.ID, Name: "篮球鞋", Sort: 2})
+	running, _ := svc.CreateCategory(ctx, CategoryInput{ParentID: shoes.ID, Name: "跑鞋", Sort: 1})
+	retro, err := svc.CreateCategory(ctx, CategoryInput{ParentID: basketball.ID, Name: "复古篮球"})
+	if err != nil || retro.Level != 3 {
+		t.Fatalf("CreateCategory(level 3) = %+v, %v", retro, err)
+	}
+	if _, err := svc.CreateCategory(ctx, CategoryInput{ParentID: retro.ID, Name: "太深"}); !errors.Is(err, ErrCategoryTooDeep) {
+		t.Fatalf("CreateCategory(level 4) error = %v, want ErrCategoryTooDeep", err)
+	}
+	if _, err := svc.CreateCategory(ctx, CategoryInput{ParentID: shoes.ID, Name: "跑鞋"}); !errors.Is(err, ErrCategoryDuplicate) {
+		t.Fatalf("CreateCategory(duplicate) error = %v, want ErrCategoryDuplicate", err)
+	}
+	if _, err := svc.CreateCategory(ctx, CategoryInput{ParentID: 999, Name: "孤儿"}); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("CreateCategory(missing parent) error = %v, want ErrCategoryNotFound", err)
+	}
+	tree, err := svc.CategoryTree(ctx)
+	if err != nil || len(tree) != 1 || len(tree[0].Children) != 2 || tree[0].Children[0].ID != running.ID ||
+		len(tree[0].Children[1].Children) != 1 || tree[0].Children[1].Children[0].ID != retro.ID {
+		t.Fatalf("CategoryTree() = %+v, %v", tree, err)
+	}
+
+	sellerID := fixtures.user.ID
+	if err := db.DB.Create(&model.SellerProfile{UserID: sellerID, StoreName: "鞋仓", Status: model.SellerStatusApproved}).Error; err != nil {
+		t.Fatalf("create seller: %v", err)
+	}
+	newProduct := func(name string, brandID, categoryID uint, tags ...string) *model.Product {
+		return &model.Product{UserID: sellerID, Name: name, Price: 1299, Stock: 5, StartTime: time.Now().Add(time.Hour),
+			BrandID: brandID, CategoryID: categoryID, Tags: tags}
+	}
+	if err := productSvc.CreateProduct(ctx, newProduct("AJ 1 Chicago", 999, retro.ID)); !errors.Is(err, ErrBrandNotFound) {
+		t.Fatalf("CreateProduct(missing brand) error = %v, want ErrBrandNotFound", err)
+	}
+	if err := productSvc.CreateProduct(ctx, newProduct("AJ 1 Chicago", nike.ID, retro.ID, `a"b`)); !errors.Is(err, ErrProductTagsInvalid) {
+		t.Fatalf("CreateProduct(bad tag) error = %v, want ErrProductTagsInvalid", err)
+	}
+	tooMany := make([]string, 11)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("t%d", i)
+	}
+	if err := productSvc.CreateProduct(ctx, newProduct("AJ 1 Chicago", nike.ID, retro.ID, tooMany...)); !errors.Is(err, ErrProductTagsInvalid) {
+		t.Fatalf("CreateProduct(11 tags) error = %v, want ErrProductTagsInvalid", err)
+	}
+	product := newProduct("AJ 1 Chicago", nike.ID, retro.ID, " OG ", "og", "芝加哥")
+	if err := productSvc.CreateProduct(ctx, product); err != nil {
+		t.Fatalf("CreateProduct() error = %v", err)
+	}
+	if fmt.Sprint(product.Tags) != "[og 芝加哥]" {
+		t.Fatalf("tags = %v, want [og 芝加哥]", product.Tags)
+	}
+	if err := productSvc.UpdateProduct(ctx, sellerID, product.ID, map[string]any{"category_id": uint(999)}); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("UpdateProduct(missing category) error = %v, want ErrCategoryNotFound", err)
+	}
+
+	// 已上架商品修改分类需重新审核
+	if err := db.DB.Model(&model.Product{}).Where("id = ?", product.ID).Update("status", model.ProductStatusApproved).Error; err != nil {
+		t.Fatalf("approve product: %v", err)
+	}
+	if err := productSvc.UpdateProduct(ctx, sellerID, product.ID, map[string]any{"category_id": running.ID, "tags": model.ProductTags{"Retro"}}); err != nil {
+		t.Fatalf("UpdateProduct() error = %v", err)
+	}
+	var updated model.Product
+	if err := db.DB.First(&updated, product.ID).Error; err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+	if updated.CategoryID != running.ID || fmt.Sprint(updated.Tags) != "[retro]" || updated.Status != model.ProductStatusPendingReview {
+		t.Fatalf("updated product = %+v", updated)
+	}
+
+	if err := svc.DeleteBrand(ctx, nike.ID); !errors.Is(err, ErrBrandInUse) {
+		t.Fatalf("DeleteBrand(in use) error = %v, want ErrBrandInUse", err)
+	}
+	if err := svc.DeleteCategory(ctx, shoes.ID); !errors.Is(err, ErrCategoryInUse) {
+		t.Fatalf("DeleteCategory(has children) error = %v, want ErrCategoryInUse", err)
+	}
+	if err := svc.DeleteCategory(ctx, retro.ID); err != nil {
+		t.Fatalf("DeleteCategory(unused) error = %v", err)
+	}
+	if err := svc.DeleteCategory(ctx, retro.ID); !errors.Is(err, ErrCategoryNotFound) {
+		t.Fatalf("DeleteCategory(again) error = %v, want ErrCategoryNotFound", err)
+	}
+}
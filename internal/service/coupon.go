@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -33,6 +34,7 @@ type CouponService struct {
 	db             *gorm.DB
 	couponRepo     *repository.CouponRepo
 	userCouponRepo *repository.UserCouponRepo
+	vipConfigSvc   *VIPConfigService
 }
 
 func NewCouponService(db *gorm.DB) *CouponService {
@@ -40,6 +42,7 @@ func NewCouponService(db *gorm.DB) *CouponService {
 		db:             db,
 		couponRepo:     repository.NewCouponRepo(db),
 		userCouponRepo: repository.NewUserCouponRepo(db),
+		vipConfigSvc:   NewVIPConfigService(db),
 	}
 }
 
@@ -70,6 +73,7 @@ type CouponTemplateInput struct {
 	ValidTo       time.Time
 	Purchasable   bool
 	PriceCents    int64
+	PointsCost    int64
 	Status        string
 }
 
@@ -84,36 +88,12 @@ type CouponTemplatePatch struct {
 	ValidTo       *time.Time
 	Purchasable   *bool
 	PriceCents    *int64
+	PointsCost    *int64
 	Status        *string
 }
 
-// vipMonthlyQuota VIP 等级对应的月度发券配额
-var vipMonthlyQuota = map[int]int{
-	1: 1, // L1 每月 1 张
-	2: 2, // L2 每月 2 张
-	3: 3,
-	4: 4,
-}
-
-// vipCouponTemplate VIP 月度券模板配置
-type vipCouponTemplate struct {
-	Title         string
-	Type          model.CouponType
-	AmountCents   int64
-	DiscountRate  int
-	MinSpendCents int64
-}
-
-// vipTemplates 各等级 VIP 月度券规格
-var vipTemplates = map[int]vipCouponTemplate{
-	1: {Title: "VIP L1 月度券", Type: model.CouponTypeFullCut, AmountCents: 500, MinSpendCents: 3000},  // 满30减5
-	2: {Title: "VIP L2 月度券", Type: model.CouponTypeFullCut, AmountCents: 1000, MinSpendCents: 5000}, // 满50减10
-	3: {Title: "VIP L3 月度券", Type: model.CouponTypeDiscount, DiscountRate: 90, MinSpendCents: 0},    // 九折
-	4: {Title: "VIP L4 月度券", Type: model.CouponTypeDiscount, DiscountRate: 85, MinSpendCents: 0},    // 八五折
-}
-
-// ApplyCoupon 校验并计算优惠后的金额，返回优惠后金额和需要核销的用户券记录。
-func (s *CouponService) ApplyCoupon(ctx context.Context, userID uint, userCouponID uint, originAmount int64) (*model.UserCoupon, *model.Coupon, int64, error) {
+// ApplyCoupon 校验并按会员价叠加规则计算优惠后的金额，返回优惠后金额和需要核销的用户券记录。
+func (s *CouponService) ApplyCoupon(ctx context.Context, userID uint, userCouponID uint, price OrderPrice) (*model.UserCoupon, *model.Coupon, int64, error) {
 	if ctx == nil {
 		return nil, nil, 0, fmt.Errorf("context is nil")
 	}
@@ -129,14 +109,8 @@ func (s *CouponService) ApplyCoupon(ctx context.Context, userID uint, userCoupon
 	}
 
 	// 业务校验：归属、状态、有效期
-	if uc.UserID != userID {
-		return nil, nil, 0, ErrCouponNotFound
-	}
-	if uc.Status != model.CouponStatusAvailable {
-		return nil, nil, 0, ErrCouponNotAvailable
-	}
-	if now.Before(uc.ValidFrom) || now.After(uc.ValidTo) {
-		return nil, nil, 0, ErrCouponExpired
+	if err := checkUserCoupon(uc, userID, 0, now); err != nil {
+		return nil, nil, 0, err
 	}
 
 	// 读取券模板
@@ -145,28 +119,131 @@ func (s *CouponService) ApplyCoupon(ctx context.Context, userID uint, userCoupon
 		return nil, nil, 0, err
 	}
 
-	// 校验门槛
+	newAmount, err := price.applyCoupon(c)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return uc, c, newAmount, nil
+}
+
+// CouponOption 用户券对某笔金额的试算结果。
+type CouponOption struct {
+	Coupon          MyCoupon `json:"coupon"`
+	Usable          bool     `json:"usable"`
+	DiscountedCents int64    `json:"discounted_cents,omitempty"` // 优惠后金额（分）
+	SavedCents      int64    `json:"saved_cents,omitempty"`      // 优惠金额（分）
+	Reason          string   `json:"reason,omitempty"`           // 不可用原因
+}
+
+// EvaluateCoupons 按 ApplyCoupon 相同规则试算用户所有可用券，可用券按优惠金额降序排在前面。
+// orderID 非 0 时，已绑定在该订单上的券视为可用（换券时会先释放）；优惠金额相对不用券时的应付金额计算。
+func (s *CouponService) EvaluateCoupons(ctx context.Context, userID, orderID uint, price OrderPrice) ([]CouponOption, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	now := time.Now()
+
+	ucs, err := s.userCouponRepo.ListUsableByUser(ctx, userID, orderID, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(ucs) == 0 {
+		return []CouponOption{}, nil
+	}
+
+	ids := make([]uint, 0, len(ucs))
+	for _, uc := range ucs {
+		ids = append(ids, uc.CouponID)
+	}
+	cs, err := s.couponRepo.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	cmap := make(map[uint]model.Coupon, len(cs))
+	for _, c := range cs {
+		cmap[c.ID] = c
+	}
+
+	options := make([]CouponOption, 0, len(ucs))
+	for i := range ucs {
+		uc := &ucs[i]
+		c, ok := cmap[uc.CouponID]
+		if !ok {
+			continue
+		}
+		view := toMyCoupon(uc, &c)
+		view.Status = model.CouponStatusAvailable
+		opt := CouponOption{Coupon: *view}
+
+		err := checkUserCoupon(uc, userID, orderID, now)
+		var discounted int64
+		if err == nil {
+			discounted, err = price.applyCoupon(&c)
+		}
+		if err != nil {
+			opt.Reason = err.Error()
+		} else {
+			opt.Usable = true
+			opt.DiscountedCents = discounted
+			opt.SavedCents = price.Payable() - discounted
+		}
+		options = append(options, opt)
+	}
+
+	// 可用优先；优惠多的优先；同等优惠先用快过期的
+	sort.SliceStable(options, func(i, j int) bool {
+		a, b := options[i], options[j]
+		if a.Usable != b.Usable {
+			return a.Usable
+		}
+		if a.SavedCents != b.SavedCents {
+			return a.SavedCents > b.SavedCents
+		}
+		if !a.Coupon.ValidTo.Equal(b.Coupon.ValidTo) {
+			return a.Coupon.ValidTo.Before(b.Coupon.ValidTo)
+		}
+		return a.Coupon.ID < b.Coupon.ID
+	})
+	return options, nil
+}
+
+// checkUserCoupon 校验用户券归属、状态与有效期；orderID 非 0 时允许已绑定到该订单的券。
+func checkUserCoupon(uc *model.UserCoupon, userID, orderID uint, now time.Time) error {
+	if uc.UserID != userID {
+		return ErrCouponNotFound
+	}
+	boundToOrder := orderID != 0 && uc.Status == model.CouponStatusUsed && uc.OrderID != nil && *uc.OrderID == orderID
+	if uc.Status != model.CouponStatusAvailable && !boundToOrder {
+		return ErrCouponNotAvailable
+	}
+	if now.Before(uc.ValidFrom) || now.After(uc.ValidTo) {
+		return ErrCouponExpired
+	}
+	return nil
+}
+
+// calcCouponAmount 校验门槛并计算优惠后金额。
+func calcCouponAmount(c *model.Coupon, originAmount int64) (int64, error) {
 	if originAmount < c.MinSpendCents {
-		return nil, nil, 0, ErrCouponBelowThreshold
+		return 0, ErrCouponBelowThreshold
 	}
 
-	// 计算优惠后金额
 	var newAmount int64
 	switch c.Type {
 	case model.CouponTypeFullCut:
 		newAmount = originAmount - c.AmountCents
 	case model.CouponTypeDiscount:
 		if c.DiscountRate <= 0 || c.DiscountRate >= 100 {
-			return nil, nil, 0, ErrCouponInvalidRate
+			return 0, ErrCouponInvalidRate
 		}
 		newAmount = originAmount * int64(c.DiscountRate) / 100
 	default:
-		return nil, nil, 0, ErrCouponTypeInvalid
+		return 0, ErrCouponTypeInvalid
 	}
 	if newAmount < 0 {
 		newAmount = 0
 	}
-	return uc, c, newAmount, nil
+	return newAmount, nil
 }
 
 // MarkUsed 标记券已使用，绑定订单 ID。
@@ -281,6 +358,7 @@ func (s *CouponService) CreateTemplate(ctx context.Context, input CouponTemplate
 		ValidTo:       input.ValidTo,
 		Purchasable:   input.Purchasable,
 		PriceCents:    input.PriceCents,
+		PointsCost:    input.PointsCost,
 		Status:        status,
 	}
 	if err := validateCouponTemplate(coupon); err != nil {
@@ -346,6 +424,10 @@ func (s *CouponService) UpdateTemplate(ctx context.Context, id uint, patch Coupo
 		coupon.PriceCents = *patch.PriceCents
 		updates["price_cents"] = *patch.PriceCents
 	}
+	if patch.PointsCost != nil {
+		coupon.PointsCost = *patch.PointsCost
+		updates["points_cost"] = *patch.PointsCost
+	}
 	if patch.Status != nil {
 		status, err := parseCouponTemplateStatus(*patch.Status, false)
 		if err != nil {
@@ -445,22 +527,21 @@ func (s *CouponService) PurchaseCoupon(ctx context.Context, userID, couponID uin
 	return result, nil
 }
 
-// IssueVIPMonthly 按月配额为指定等级的用户发券（幂等：当月超配额不再发）。
+// IssueVIPMonthly 按当前等级配置的月度配额为用户发券（幂等：当月超配额不再发）。
 func (s *CouponService) IssueVIPMonthly(ctx context.Context, userID uint, level int) error {
 	if ctx == nil {
 		return fmt.Errorf("context is nil")
 	}
 
-	if level < 1 {
-		level = 1
-	}
-	if level > 4 {
-		level = 4
+	cfg, err := s.vipConfigSvc.Current(ctx)
+	if err != nil {
+		return err
 	}
-	quota := vipMonthlyQuota[level]
-	if quota <= 0 {
+	levelCfg, ok := cfg.Level(cfg.ClampLevel(level))
+	if !ok || levelCfg.MonthlyCouponQuota <= 0 {
 		return nil
 	}
+	quota := levelCfg.MonthlyCouponQuota
 	start, end := monthPeriod(time.Now())
 	existing, err := s.userCouponRepo.CountByPeriod(ctx, userID, "vip_month", start, end)
 	if err != nil {
@@ -469,11 +550,7 @@ func (s *CouponService) IssueVIPMonthly(ctx context.Context, userID uint, level
 	if existing >= int64(quota) {
 		return nil
 	}
-	tpl, ok := vipTemplates[level]
-	if !ok {
-		return nil
-	}
-	coupon, err := s.ensureTemplate(ctx, tpl)
+	coupon, err := s.ensureTemplate(ctx, levelCfg)
 	if err != nil {
 		return err
 	}
@@ -491,23 +568,31 @@ func (s *CouponService) IssueVIPMonthly(ctx context.Context, userID uint, level
 			IssuedAt:     now,
 		})
 	}
-	return s.userCouponRepo.BatchCreate(ctx, ucs)
+	if err := s.userCouponRepo.BatchCreate(ctx, ucs); err != nil {
+		return err
+	}
+	notify(ctx, s.db, Notice{UserID: userID, Kind: model.NotifyCouponIssued, Data: map[string]any{
+		"count": need, "title": coupon.Title, "valid_to": notifyTime(end),
+	}})
+	return nil
 }
 
-// ensureTemplate 确保券模板存在，使用 FirstOrCreate 保证并发安全。
-func (s *CouponService) ensureTemplate(ctx context.Context, tpl vipCouponTemplate) (*model.Coupon, error) {
+// ensureTemplate 确保与等级配置规格一致的券模板存在，规格变更后生成新模板，旧券不受影响。
+func (s *CouponService) ensureTemplate(ctx context.Context, cfg *model.VIPLevelConfig) (*model.Coupon, error) {
 	coupon := &model.Coupon{
-		Title:         tpl.Title,
-		Type:          tpl.Type,
-		AmountCents:   tpl.AmountCents,
-		DiscountRate:  tpl.DiscountRate,
-		MinSpendCents: tpl.MinSpendCents,
+		Title:         cfg.CouponTitle,
+		Type:          cfg.CouponType,
+		AmountCents:   cfg.CouponAmountCents,
+		DiscountRate:  cfg.CouponDiscountRate,
+		MinSpendCents: cfg.CouponMinSpendCents,
 		Purchasable:   false,
 		ValidFrom:     time.Now().AddDate(-1, 0, 0),
 		ValidTo:       time.Now().AddDate(1, 0, 0),
 		Status:        model.CouponTemplateStatusActive,
 	}
-	return s.couponRepo.FirstOrCreate(ctx, coupon, "title = ?", tpl.Title)
+	return s.couponRepo.FirstOrCreate(ctx, coupon,
+		"title = ? AND type = ? AND amount_cents = ? AND discount_rate = ? AND min_spend_cents = ?",
+		cfg.CouponTitle, cfg.CouponType, cfg.CouponAmountCents, cfg.CouponDiscountRate, cfg.CouponMinSpendCents)
 }
 
 // monthPeriod 返回当月起止时间，用于月度配额判断。
@@ -525,6 +610,61 @@ func (s *CouponService) MarkExpiredCoupons(ctx context.Context) (int64, error) {
 	return s.userCouponRepo.MarkExpiredBatch(ctx, time.Now())
 }
 
+// RemindExpiring 为 within 内到期且未提醒过的可用券发送过期提醒，同一用户合并为一条，返回提醒的券数。
+func (s *CouponService) RemindExpiring(ctx context.Context, within time.Duration, limit int) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	if limit <= 0 {
+		limit = 500
+	}
+	now := time.Now()
+	ucs, err := s.userCouponRepo.ListExpiring(ctx, now, now.Add(within), limit)
+	if err != nil || len(ucs) == 0 {
+		return 0, err
+	}
+
+	type expiring struct {
+		count   int
+		validTo time.Time
+	}
+	byUser := make(map[uint]*expiring)
+	order := make([]uint, 0)
+	ids := make([]uint, 0, len(ucs))
+	for _, uc := range ucs {
+		ids = append(ids, uc.ID)
+		e, ok := byUser[uc.UserID]
+		if !ok {
+			e = &expiring{validTo: uc.ValidTo}
+			byUser[uc.UserID] = e
+			order = append(order, uc.UserID)
+		}
+		e.count++
+		if uc.ValidTo.After(e.validTo) {
+			e.validTo = uc.ValidTo
+		}
+	}
+	notices := make([]Notice, 0, len(order))
+	for _, userID := range order {
+		e := byUser[userID]
+		notices = append(notices, Notice{UserID: userID, Kind: model.NotifyCouponExpiring, Data: map[string]any{
+			"count": e.count, "valid_to": notifyTime(e.validTo),
+		}})
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := repository.NewUserCouponRepo(tx).MarkReminded(ctx, ids, now); err != nil {
+			return err
+		}
+		notify(ctx, tx, notices...)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ucs), nil
+}
+
 func validateCouponTemplate(coupon *model.Coupon) error {
 	if coupon == nil {
 		return fmt.Errorf("coupon is nil")
@@ -532,7 +672,7 @@ func validateCouponTemplate(coupon *model.Coupon) error {
 	if strings.TrimSpace(coupon.Title) == "" {
 		return ErrCouponTitleRequired
 	}
-	if coupon.MinSpendCents < 0 || coupon.PriceCents < 0 {
+	if coupon.MinSpendCents < 0 || coupon.PriceCents < 0 || coupon.PointsCost < 0 {
 		return ErrCouponInvalidAmount
 	}
 	if coupon.ValidFrom.IsZero() || coupon.ValidTo.IsZero() || !coupon.ValidTo.After(coupon.ValidFrom) {
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultCouponJobChunkSize = 500
+	couponJobStaleTimeout     = 5 * time.Minute
+	maxCouponJobUserIDs       = 100000
+)
+
+var (
+	ErrCouponJobNotFound      = errors.New("发券任务不存在")
+	ErrCouponJobSegment       = errors.New("发券人群参数无效")
+	ErrCouponJobTemplate      = errors.New("券模板未启用，无法批量发放")
+	ErrCouponJobNotCancelable = errors.New("发券任务已结束，无法取消")
+
+	errCouponJobStopped = errors.New("coupon job stopped")
+)
+
+// CouponSegment 批量发券目标人群。
+type CouponSegment struct {
+	Type      string `json:"type"`
+	MinLevel  int    `json:"min_level,omitempty"`  // growth_level：成长等级下限
+	MaxLevel  int    `json:"max_level,omitempty"`  // growth_level：成长等级上限
+	PaidLevel int    `json:"paid_level,omitempty"` // paid_vip：指定付费等级，0 表示全部
+	ProductID uint   `json:"product_id,omitempty"` // product_buyers：商品 ID
+	UserIDs   []uint `json:"user_ids,omitempty"`   // user_ids：上传的用户 ID 列表
+}
+
+// CouponJobService 批量发券任务：API 侧创建/查询/取消，worker 侧分批执行。
+type CouponJobService struct {
+	db             *gorm.DB
+	jobRepo        *repository.CouponIssueJobRepo
+	couponRepo     *repository.CouponRepo
+	userCouponRepo *repository.UserCouponRepo
+	userRepo       *repository.UserRepo
+	paidVIPRepo    *repository.PaidVIPRepo
+	orderRepo      *repository.OrderRepo
+	chunkSize      int
+}
+
+func NewCouponJobService(db *gorm.DB) *CouponJobService {
+	return &CouponJobService{
+		db:             db,
+		jobRepo:        repository.NewCouponIssueJobRepo(db),
+		couponRepo:     repository.NewCouponRepo(db),
+		userCouponRepo: repository.NewUserCouponRepo(db),
+		userRepo:       repository.NewUserRepo(db),
+		paidVIPRepo:    repository.NewPaidVIPRepo(db),
+		orderRepo:      repository.NewOrderRepo(db),
+		chunkSize:      defaultCouponJobChunkSize,
+	}
+}
+
+// CreateJob 校验模板与人群参数后创建待执行任务。
+func (s *CouponJobService) CreateJob(ctx context.Context, couponID uint, segment CouponSegment, createdBy uint) (*model.CouponIssueJob, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+
+	coupon, err := s.couponRepo.GetByID(ctx, couponID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponNotFound
+		}
+		return nil, err
+	}
+	if coupon.Status != model.CouponTemplateStatusActive || !coupon.ValidTo.After(time.Now()) {
+		return nil, ErrCouponJobTemplate
+	}
+
+	segment, err = normalizeCouponSegment(segment)
+	if err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(segment)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.CouponIssueJob{
+		CouponID:      couponID,
+		SegmentType:   segment.Type,
+		SegmentParams: string(params),
+		Status:        model.CouponIssueJobStatusPending,
+		CreatedBy:     createdBy,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *CouponJobService) GetJob(ctx context.Context, id uint) (*model.CouponIssueJob, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	job, err := s.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponJobNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *CouponJobService) ListJobs(ctx context.Context, status string, page, pageSize int) ([]model.CouponIssueJob, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	page, pageSize = normalizePage(page, pageSize)
+	return s.jobRepo.List(ctx, strings.TrimSpace(status), page, pageSize)
+}
+
+// CancelJob 取消待执行或执行中的任务；已发放的券不回收。
+func (s *CouponJobService) CancelJob(ctx context.Context, id uint) (*model.CouponIssueJob, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if _, err := s.GetJob(ctx, id); err != nil {
+		return nil, err
+	}
+	rows, err := s.jobRepo.Cancel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrCouponJobNotCancelable
+	}
+	return s.GetJob(ctx, id)
+}
+
+// RunPendingJobs 认领并执行待处理任务，供 worker 定时调用；返回本轮执行的任务数。
+func (s *CouponJobService) RunPendingJobs(ctx context.Context, limit int) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+	now := time.Now()
+	staleBefore := now.Add(-couponJobStaleTimeout)
+	jobs, err := s.jobRepo.ListRunnable(ctx, staleBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for _, job := range jobs {
+		token := rand.Text()
+		rows, err := s.jobRepo.Claim(ctx, job.ID, staleBefore, now, token)
+		if err != nil {
+			return processed, err
+		}
+		if rows == 0 {
+			// 已被其他 worker 认领
+			continue
+		}
+		if err := s.runJob(ctx, job.ID, token); err != nil {
+			slog.ErrorContext(ctx, "批量发券任务执行失败", slog.Uint64("job_id", uint64(job.ID)), slog.Any("err", err))
+			if finishErr := s.jobRepo.Finish(ctx, job.ID, token, model.CouponIssueJobStatusFailed, truncateError(err)); finishErr != nil {
+				return processed, finishErr
+			}
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// runJob 从 cursor 处继续分批发券；每批发券与游标推进在同一事务内，重跑不会重复发放。
+// 每批先锁住任务行并校验租约：心跳超时被重新认领后，仍在执行的旧 worker 在下一批放弃，
+// 同一时刻只有一个执行者查询已发放用户并写入。
+func (s *CouponJobService) runJob(ctx context.Context, jobID uint, token string) error {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	var segment CouponSegment
+	if err := json.Unmarshal([]byte(job.SegmentParams), &segment); err != nil {
+		return fmt.Errorf("解析人群参数失败: %w", err)
+	}
+	// 创建时已排序去重，这里兜底历史任务；分批查询依赖有序列表
+	slices.Sort(segment.UserIDs)
+	segment.UserIDs = slices.Compact(segment.UserIDs)
+	coupon, err := s.couponRepo.GetByID(ctx, job.CouponID)
+	if err != nil {
+		return err
+	}
+
+	if job.TotalUsers == 0 {
+		total, err := s.countSegment(ctx, segment)
+		if err != nil {
+			return err
+		}
+		if err := s.jobRepo.SetTotal(ctx, job.ID, total); err != nil {
+			return err
+		}
+	}
+
+	source := couponJobSource(job.ID)
+	cursor := job.Cursor
+	for {
+		userIDs, err := s.nextSegmentChunk(ctx, segment, cursor)
+		if err != nil {
+			return err
+		}
+		if len(userIDs) == 0 {
+			return s.jobRepo.Finish(ctx, job.ID, token, model.CouponIssueJobStatusCompleted, "")
+		}
+
+		lastID := userIDs[len(userIDs)-1]
+		stopped := false
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			txUserCouponRepo := repository.NewUserCouponRepo(tx)
+			txJobRepo := repository.NewCouponIssueJobRepo(tx)
+			held, err := txJobRepo.HoldLease(ctx, job.ID, token)
+			if err != nil {
+				return err
+			}
+			if !held {
+				// 任务已被取消或被其他 worker 重新认领，放弃本批
+				stopped = true
+				return errCouponJobStopped
+			}
+
+			issuedIDs, err := txUserCouponRepo.ListUserIDsByObtainedFrom(ctx, source, userIDs)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			ucs := make([]model.UserCoupon, 0, len(userIDs))
+			for _, uid := range userIDs {
+				if slices.Contains(issuedIDs, uid) {
+					continue
+				}
+				ucs = append(ucs, model.UserCoupon{
+					UserID:       uid,
+					CouponID:     coupon.ID,
+					Status:       model.CouponStatusAvailable,
+					ObtainedFrom: source,
+					ValidFrom:    coupon.ValidFrom,
+					ValidTo:      coupon.ValidTo,
+					IssuedAt:     now,
+				})
+			}
+			if err := txUserCouponRepo.BatchCreate(ctx, ucs); err != nil {
+				return err
+			}
+			rows, err := txJobRepo.AdvanceCursor(ctx, job.ID, token, lastID, int64(len(ucs)), int64(len(userIDs)-len(ucs)))
+			if err != nil {
+				return err
+			}
+			if rows == 0 {
+				// 任务已被取消，放弃本批
+				stopped = true
+				return errCouponJobStopped
+			}
+			return nil
+		})
+		if stopped {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		cursor = lastID
+	}
+}
+
+func (s *CouponJobService) nextSegmentChunk(ctx context.Context, segment CouponSegment, afterID uint) ([]uint, error) {
+	switch segment.Type {
+	case model.CouponSegmentGrowthLevel:
+		return s.userRepo.ListIDsByGrowthRange(ctx, segment.MinLevel, segment.MaxLevel, afterID, s.chunkSize)
+	case model.CouponSegmentPaidVIP:
+		return s.paidVIPRepo.ListActiveUserIDs(ctx, time.Now(), segment.PaidLevel, afterID, s.chunkSize)
+	case model.CouponSegmentProductBuyers:
+		return s.orderRepo.ListPaidBuyerIDs(ctx, segment.ProductID, afterID, s.chunkSize)
+	case model.CouponSegmentUserIDs:
+		return s.nextExistingUserIDs(ctx, segment.UserIDs, afterID)
+	default:
+		return nil, ErrCouponJobSegment
+	}
+}
+
+func (s *CouponJobService) countSegment(ctx context.Context, segment CouponSegment) (int64, error) {
+	switch segment.Type {
+	case model.CouponSegmentGrowthLevel:
+		return s.userRepo.CountByGrowthRange(ctx, segment.MinLevel, segment.MaxLevel)
+	case model.CouponSegmentPaidVIP:
+		return s.paidVIPRepo.CountActive(ctx, time.Now(), segment.PaidLevel)
+	case model.CouponSegmentProductBuyers:
+		return s.orderRepo.CountPaidBuyers(ctx, segment.ProductID)
+	case model.CouponSegmentUserIDs:
+		var total int64
+		for chunk := range slices.Chunk(segment.UserIDs, s.chunkSize) {
+			count, err := s.userRepo.CountExistingIDs(ctx, chunk)
+			if err != nil {
+				return 0, err
+			}
+			total += count
+		}
+		return total, nil
+	default:
+		return 0, ErrCouponJobSegment
+	}
+}
+
+// nextExistingUserIDs 从有序 ID 列表中取 afterID 之后的下一段，每次只查询 chunkSize 个 ID；
+// 整段用户都不存在时继续向后取，返回空表示列表已处理完。
+func (s *CouponJobService) nextExistingUserIDs(ctx context.Context, ids []uint, afterID uint) ([]uint, error) {
+	start, found := slices.BinarySearch(ids, afterID)
+	if found {
+		start++
+	}
+	for start < len(ids) {
+		end := min(start+s.chunkSize, len(ids))
+		existing, err := s.userRepo.ListExistingIDs(ctx, ids[start:end])
+		if err != nil || len(existing) > 0 {
+			return existing, err
+		}
+		start = end
+	}
+	return nil, nil
+}
+
+func normalizeCouponSegment(segment CouponSegment) (CouponSegment, error) {
+	segment.Type = strings.TrimSpace(segment.Type)
+	out := CouponSegment{Type: segment.Type}
+	switch segment.Type {
+	case model.CouponSegmentGrowthLevel:
+		if segment.MinLevel <= 0 {
+			segment.MinLevel = 1
+		}
+		if segment.MaxLevel <= 0 {
+			segment.MaxLevel = segment.MinLevel
+		}
+		if segment.MaxLevel < segment.MinLevel {
+			return out, ErrCouponJobSegment
+		}
+		out.MinLevel, out.MaxLevel = segment.MinLevel, segment.MaxLevel
+	case model.CouponSegmentPaidVIP:
+		if segment.PaidLevel < 0 {
+			return out, ErrCouponJobSegment
+		}
+		out.PaidLevel = segment.PaidLevel
+	case model.CouponSegmentProductBuyers:
+		if segment.ProductID == 0 {
+			return out, ErrCouponJobSegment
+		}
+		out.ProductID = segment.ProductID
+	case model.CouponSegmentUserIDs:
+		ids := make([]uint, 0, len(segment.UserIDs))
+		for _, id := range segment.UserIDs {
+			if id > 0 {
+				ids = append(ids, id)
+			}
+		}
+		slices.Sort(ids)
+		ids = slices.Compact(ids)
+		if len(ids) == 0 || len(ids) > maxCouponJobUserIDs {
+			return out, ErrCouponJobSegment
+		}
+		out.UserIDs = ids
+	default:
+		return out, ErrCouponJobSegment
+	}
+	return out, nil
+}
+
+// couponJobSource 批量发券的 obtained_from 标记，同时作为任务级幂等键。
+func couponJobSource(jobID uint) string {
+	return fmt.Sprintf("admin_job:%d", jobID)
+}
+
+func truncateError(err error) string {
+	msg := err.Error()
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	return msg
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/testutil"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newCouponJobServiceForTest(t *testing.T) (*CouponJobService, context.Context) {
+	t.Helper()
+
+	testutil.SetupTestConfig()
+	db := testutil.NewSQLiteDB(t)
+	return NewCouponJobService(db), context.Background()
+}
+
+func TestCouponJobService_RunPendingJobs(t *testing.T) {
+	svc, ctx := newCouponJobServiceForTest(t)
+	svc.chunkSize = 2
+	now := time.Now()
+
+	for i, level := range []int{1, 3, 3, 4, 5} {
+		user := &model.User{Username: fmt.Sprintf("job_user_%d", i), Password: "x", GrowthLevel: level}
+		if err := svc.db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	coupon := &model.Coupon{
+		Type:        model.CouponTypeFullCut,
+		Title:       "回馈券",
+		AmountCents: 500,
+		ValidFrom:   now.Add(-time.Hour),
+		ValidTo:     now.Add(24 * time.Hour),
+		Status:      model.CouponTemplateStatusActive,
+	}
+	if err := svc.couponRepo.Create(ctx, coupon); err != nil {
+		t.Fatalf("create coupon: %v", err)
+	}
+
+	job, err := svc.CreateJob(ctx, coupon.ID, CouponSegment{Type: model.CouponSegmentGrowthLevel, MinLevel: 3, MaxLevel: 4}, 1)
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	processed, err := svc.RunPendingJobs(ctx, 5)
+	if err != nil {
+		t.Fatalf("RunPendingJobs() error = %v", err)
+	}
+	if processed != 1 {
+		t.Fatalf("expected 1 job processed, got %d", processed)
+	}
+
+	got, err := svc.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if got.Status != model.CouponIssueJobStatusCompleted || got.TotalUsers != 3 || got.IssuedCount != 3 {
+		t.Fatalf("unexpected job state: %+v", got)
+	}
+
+	// 模拟 worker 中途崩溃后从头重跑：已发放用户应被跳过
+	if err := svc.db.Model(&model.CouponIssueJob{}).Where("id = ?", job.ID).
+		Updates(map[string]any{"status": model.CouponIssueJobStatusPending, "cursor": 0}).Error; err != nil {
+		t.Fatalf("reset job: %v", err)
+	}
+	if _, err := svc.RunPendingJobs(ctx, 5); err != nil {
+		t.Fatalf("RunPendingJobs() rerun error = %v", err)
+	}
+
+	var issued int64
+	if err := svc.db.Model(&model.UserCoupon{}).Where("obtained_from = ?", couponJobSource(job.ID)).Count(&issued).Error; err != nil {
+		t.Fatalf("count user coupons: %v", err)
+	}
+	if issued != 3 {
+		t.Fatalf("expected 3 coupons issued once, got %d", issued)
+	}
+	got, _ = svc.GetJob(ctx, job.ID)
+	if got.SkippedCount != 3 {
+		t.Fatalf("expected 3 skipped on rerun, got %d", got.SkippedCount)
+	}
+}
+
+func TestCouponJobService_CreateAndCancel(t *testing.T) {
+	svc, ctx := newCouponJobServiceForTest(t)
+	now := time.Now()
+
+	inactive := &model.Coupon{
+		Type:        model.CouponTypeFullCut,
+		Title:       "停用券",
+		AmountCents: 100,
+		ValidFrom:   now.Add(-time.Hour),
+		ValidTo:     now.Add(time.Hour),
+		Status:      model.CouponTemplateStatusInactive,
+	}
+	if err := svc.couponRepo.Create(ctx, inactive); err != nil {
+		t.Fatalf("create coupon: %v", err)
+	}
+	if _, err := svc.CreateJob(ctx, inactive.ID, CouponSegment{Type: model.CouponSegmentPaidVIP}, 1); !errors.Is(err, ErrCouponJobTemplate) {
+		t.Fatalf("expected ErrCouponJobTemplate, got %v", err)
+	}
+
+	if err := svc.db.Model(inactive).Update("status", model.CouponTemplateStatusActive).Error; err != nil {
+		t.Fatalf("activate coupon: %v", err)
+	}
+	if _, err := svc.CreateJob(ctx, inactive.ID, CouponSegment{Type: model.CouponSegmentUserIDs}, 1); !errors.Is(err, ErrCouponJobSegment) {
+		t.Fatalf("expected ErrCouponJobSegment, got %v", err)
+	}
+
+	job, err := svc.CreateJob(ctx, inactive.ID, CouponSegment{Type: model.CouponSegmentUserIDs, UserIDs: []uint{3, 1, 3}}, 1)
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	if job.SegmentParams != `{"type":"user_ids","user_ids":[1,3]}` {
+		t.Fatalf("unexpected segment params: %s", job.SegmentParams)
+	}
+
+	cancelled, err := svc.CancelJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+	if cancelled.Status != model.CouponIssueJobStatusCancelled {
+		t.Fatalf("expected cancelled, got %s", cancelled.Status)
+	}
+	if _, err := svc.CancelJob(ctx, job.ID); !errors.Is(err, ErrCouponJobNotCancelable) {
+		t.Fatalf("expected ErrCouponJobNotCancelable, got %v", err)
+	}
+}
+
+func TestCouponJobService_UserIDsInChunks(t *testing.T) {
+	svc, ctx := newCouponJobServiceForTest(t)
+	svc.chunkSize = 2
+	now := time.Now()
+
+	for i, id := range []uint{1, 2, 100} {
+		user := &model.User{Username: fmt.Sprintf("list_user_%d", i), Password: "x"}
+		user.ID = id
+		if err := svc.db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	coupon := &model.Coupon{
+		Type:        model.CouponTypeFullCut,
+		Title:       "定向券",
+		AmountCents: 500,
+		ValidFrom:   now.Add(-time.Hour),
+		ValidTo:     now.Add(24 * time.Hour),
+		Status:      model.CouponTemplateStatusActive,
+	}
+	if err := svc.couponRepo.Create(ctx, coupon); err != nil {
+		t.Fatalf("create coupon: %v", err)
+	}
+
+	// 中间整段 ID 都不存在时应继续处理后面的用户
+	list := []uint{100, 51, 1, 50, 2, 1}
+	job, err := svc.CreateJob(ctx, coupon.ID, CouponSegment{Type: model.CouponSegmentUserIDs, UserIDs: list}, 1)
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	if _, err := svc.RunPendingJobs(ctx, 5); err != nil {
+		t.Fatalf("RunPendingJobs() error = %v", err)
+	}
+	got, err := svc.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if got.Status != model.CouponIssueJobStatusCompleted || got.TotalUsers != 3 || got.IssuedCount != 3 {
+		t.Fatalf("unexpected job state: %+v", got)
+	}
+}
+
+func TestCouponJobService_StaleLeaseStopsOldWorker(t *testing.T) {
+	svc, ctx := newCouponJobServiceForTest(t)
+	now := time.Now()
+	user := &model.User{Username: "lease_user", Password: "x", GrowthLevel: 2}
+	if err := svc.db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	coupon := &model.Coupon{
+		Type:        model.CouponTypeFullCut,
+		Title:       "回馈券",
+		AmountCents: 500,
+		ValidFrom:   now.Add(-time.Hour),
+		ValidTo:     now.Add(24 * time.Hour),
+		Status:      model.CouponTemplateStatusActive,
+	}
+	if err := svc.couponRepo.Create(ctx, coupon); err != nil {
+		t.Fatalf("create coupon: %v", err)
+	}
+	job, err := svc.CreateJob(ctx, coupon.ID, CouponSegment{Type: model.CouponSegmentGrowthLevel, MinLevel: 2}, 1)
+	if err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+
+	// 旧 worker 认领后心跳超时，任务被新 worker 重新认领
+	staleBefore := now.Add(-couponJobStaleTimeout)
+	if rows, err := svc.jobRepo.Claim(ctx, job.ID, staleBefore, now, "old"); err != nil || rows != 1 {
+		t.Fatalf("Claim(old) = %d, %v", rows, err)
+	}
+	if err := svc.db.Model(&model.CouponIssueJob{}).Where("id = ?", job.ID).UpdateColumn("updated_at", now.Add(-time.Hour)).Error; err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+	if rows, err := svc.jobRepo.Claim(ctx, job.ID, staleBefore, now, "new"); err != nil || rows != 1 {
+		t.Fatalf("Claim(new) = %d, %v", rows, err)
+	}
+
+	if err := svc.runJob(ctx, job.ID, "old"); err != nil {
+		t.Fatalf("runJob(old) error = %v", err)
+	}
+	var issued int64
+	svc.db.Model(&model.UserCoupon{}).Where("obtained_from = ?", couponJobSource(job.ID)).Count(&issued)
+	if issued != 0 {
+		t.Fatalf("old worker issued %d coupons, want 0", issued)
+	}
+	if err := svc.runJob(ctx, job.ID, "new"); err != nil {
+		t.Fatalf("runJob(new) error = %v", err)
+	}
+	got, _ := svc.GetJob(ctx, job.ID)
+	if got.Status != model.CouponIssueJobStatusCompleted || got.IssuedCount != 1 {
+		t.Fatalf("job after new worker = %+v", got)
+	}
+}
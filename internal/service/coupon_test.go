@@ -82,7 +82,7 @@ func TestCouponService_ApplyCoupon(t *testing.T) {
 	}
 
 	t.Run("full cut success", func(t *testing.T) {
-		uc, coupon, amount, err := svc.ApplyCoupon(ctx, 1, fullCutUC.ID, 5000)
+		uc, coupon, amount, err := svc.ApplyCoupon(ctx, 1, fullCutUC.ID, OrderPrice{OriginalCents: 5000})
 		if err != nil {
 			t.Fatalf("ApplyCoupon() error = %v", err)
 		}
@@ -92,7 +92,7 @@ func TestCouponService_ApplyCoupon(t *testing.T) {
 	})
 
 	t.Run("discount success", func(t *testing.T) {
-		_, _, amount, err := svc.ApplyCoupon(ctx, 1, discountUC.ID, 5000)
+		_, _, amount, err := svc.ApplyCoupon(ctx, 1, discountUC.ID, OrderPrice{OriginalCents: 5000})
 		if err != nil {
 			t.Fatalf("ApplyCoupon() error = %v", err)
 		}
@@ -102,14 +102,14 @@ func TestCouponService_ApplyCoupon(t *testing.T) {
 	})
 
 	t.Run("below threshold", func(t *testing.T) {
-		_, _, _, err := svc.ApplyCoupon(ctx, 1, fullCutUC.ID, 2000)
+		_, _, _, err := svc.ApplyCoupon(ctx, 1, fullCutUC.ID, OrderPrice{OriginalCents: 2000})
 		if !errors.Is(err, ErrCouponBelowThreshold) {
 			t.Fatalf("ApplyCoupon() error = %v, want %v", err, ErrCouponBelowThreshold)
 		}
 	})
 
 	t.Run("expired", func(t *testing.T) {
-		_, _, _, err := svc.ApplyCoupon(ctx, 1, expiredUC.ID, 5000)
+		_, _, _, err := svc.ApplyCoupon(ctx, 1, expiredUC.ID, OrderPrice{OriginalCents: 5000})
 		if !errors.Is(err, ErrCouponExpired) {
 			t.Fatalf("ApplyCoupon() error = %v, want %v", err, ErrCouponExpired)
 		}
@@ -215,3 +215,33 @@ func TestCouponService_CreateTemplateRejectsInvalidStatus(t *testing.T) {
 		t.Fatalf("CreateTemplate() error = %v, want %v", err, ErrCouponTemplateStatus)
 	}
 }
+
+func TestOrderPrice_ApplyCouponWithMemberPrice(t *testing.T) {
+	fullCut := &model.Coupon{Type: model.CouponTypeFullCut, AmountCents: 1500, MinSpendCents: 9500}
+	discount := &model.Coupon{Type: model.CouponTypeDiscount, DiscountRate: 80}
+
+	cases := []struct {
+		name    string
+		price   OrderPrice
+		coupon  *model.Coupon
+		want    int64
+		wantErr error
+	}{
+		{"no member price", OrderPrice{OriginalCents: 10000}, fullCut, 8500, nil},
+		{"stackable uses member price", OrderPrice{OriginalCents: 10000, MemberCents: 9000, Stackable: true}, discount, 7200, nil},
+		{"stackable threshold on member price", OrderPrice{OriginalCents: 10000, MemberCents: 9000, Stackable: true}, fullCut, 0, ErrCouponBelowThreshold},
+		{"exclusive uses original price", OrderPrice{OriginalCents: 10000, MemberCents: 9000}, fullCut, 8500, nil},
+		{"exclusive not better than member", OrderPrice{OriginalCents: 10000, MemberCents: 7000}, discount, 0, ErrCouponMemberPriceExclusive},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.price.applyCoupon(tc.coupon)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("applyCoupon() error = %v, want %v", err, tc.wantErr)
+			}
+			if err == nil && got != tc.want {
+				t.Fatalf("applyCoupon() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
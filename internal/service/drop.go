@@ -0,0 +1,238 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrDropStarted = errors.New("商品已开售，无需预约")
+
+const defaultDropReminderProducts = 100
+
+// loadDropReminderOffsets 开售提醒提前量(分钟)，升序去重。
+func loadDropReminderOffsets() []int {
+	offsets := make([]int, 0, len(config.Conf.Notification.DropReminderOffsets))
+	seen := make(map[int]bool)
+	for _, o := range config.Conf.Notification.DropReminderOffsets {
+		if o > 0 && !seen[o] {
+			seen[o] = true
+			offsets = append(offsets, o)
+		}
+	}
+	if len(offsets) == 0 {
+		offsets = []int{1440, 15}
+	}
+	sort.Ints(offsets)
+	return offsets
+}
+
+// DropSubscriptionView 用户对商品的预约状态。
+type DropSubscriptionView struct {
+	ProductID  uint      `json:"product_id"`
+	StartTime  time.Time `json:"start_time"`
+	Subscribed bool      `json:"subscribed"`
+}
+
+// DropSubscribers 商品预约人数，供商家与运营评估热度。
+type DropSubscribers struct {
+	ProductID   uint      `json:"product_id"`
+	Name        string    `json:"name"`
+	StartTime   time.Time `json:"start_time"`
+	Subscribers int64     `json:"subscribers"`
+}
+
+// DropService 开售提醒：预约、按提前量发送提醒与预约人数统计。
+type DropService struct {
+	db          *gorm.DB
+	repo        *repository.DropRepo
+	productRepo *repository.ProductRepo
+}
+
+func NewDropService(db *gorm.DB) *DropService {
+	return &DropService{
+		db:          db,
+		repo:        repository.NewDropRepo(db),
+		productRepo: repository.NewProductRepo(db),
+	}
+}
+
+// Subscribe 预约开售提醒，重复预约视为成功；未上架或已开售的商品不可预约。
+func (s *DropService) Subscribe(ctx context.Context, userID, productID uint) (*DropSubscriptionView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !product.Listed() {
+		return nil, ErrProductNotFound
+	}
+	if !product.StartTime.After(time.Now()) {
+		return nil, ErrDropStarted
+	}
+	if err := s.repo.Subscribe(ctx, userID, productID); err != nil {
+		return nil, err
+	}
+	return &DropSubscriptionView{ProductID: productID, StartTime: product.StartTime, Subscribed: true}, nil
+}
+
+// Unsubscribe 取消预约，未预约时视为成功。
+func (s *DropService) Unsubscribe(ctx context.Context, userID, productID uint) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	_, err := s.repo.Unsubscribe(ctx, userID, productID)
+	return err
+}
+
+// Status 查询当前用户是否已预约。
+func (s *DropService) Status(ctx context.Context, userID, productID uint) (*DropSubscriptionView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	subscribed, err := s.repo.IsSubscribed(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	return &DropSubscriptionView{ProductID: productID, StartTime: product.StartTime, Subscribed: subscribed}, nil
+}
+
+// SellerSubscribers 商家查看自己商品的预约人数，非本人商品视为不存在。
+func (s *DropService) SellerSubscribers(ctx context.Context, sellerID, productID uint) (*DropSubscribers, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	product, err := s.productRepo.GetByIDAndUser(ctx, productID, sellerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return s.subscribers(ctx, product)
+}
+
+// Subscribers 管理端查看商品预约人数。
+func (s *DropService) Subscribers(ctx context.Context, productID uint) (*DropSubscribers, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.subscribers(ctx, product)
+}
+
+func (s *DropService) subscribers(ctx context.Context, product *model.Product) (*DropSubscribers, error) {
+	counts, err := s.repo.CountByProducts(ctx, []uint{product.ID})
+	if err != nil {
+		return nil, err
+	}
+	return &DropSubscribers{
+		ProductID:   product.ID,
+		Name:        product.Name,
+		StartTime:   product.StartTime,
+		Subscribers: counts[product.ID],
+	}, nil
+}
+
+func (s *DropService) getProduct(ctx context.Context, productID uint) (*model.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return product, nil
+}
+
+// SendReminders 为即将开售商品的预约用户发送提醒，返回本轮提醒人数。
+// 每次只按距开售时间最近的一档提前量发送，晚预约的用户不会一次收到多条；
+// 提醒记录按商品、用户、提前量去重，多实例并发时只有写入成功的一方发送。
+func (s *DropService) SendReminders(ctx context.Context, now time.Time, batchSize int) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	offsets := loadDropReminderOffsets()
+	horizon := now.Add(time.Duration(offsets[len(offsets)-1]) * time.Minute)
+	products, err := s.productRepo.ListStartingBetween(ctx, now, horizon, defaultDropReminderProducts)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range products {
+		product := &products[i]
+		offset := dueOffset(offsets, product.StartTime.Sub(now))
+		for {
+			userIDs, err := s.repo.ListUnreminded(ctx, product.ID, offset, batchSize)
+			if err != nil {
+				return sent, err
+			}
+			if len(userIDs) == 0 {
+				break
+			}
+			reminded, err := s.remind(ctx, product, offset, userIDs)
+			if err != nil {
+				return sent, err
+			}
+			sent += reminded
+			if len(userIDs) < batchSize {
+				break
+			}
+		}
+	}
+	return sent, nil
+}
+
+func (s *DropService) remind(ctx context.Context, product *model.Product, offset int, userIDs []uint) (int, error) {
+	reminded := 0
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewDropRepo(tx)
+		notices := make([]Notice, 0, len(userIDs))
+		for _, userID := range userIDs {
+			created, err := txRepo.CreateReminder(ctx, &model.DropReminder{ProductID: product.ID, UserID: userID, OffsetMinutes: offset})
+			if err != nil {
+				return err
+			}
+			if !created {
+				continue
+			}
+			notices = append(notices, Notice{UserID: userID, Kind: model.NotifyDropReminder, Data: map[string]any{
+				"product": product.Name, "start_time": notifyTime(product.StartTime),
+			}})
+		}
+		reminded = len(notices)
+		notify(ctx, tx, notices...)
+		return nil
+	})
+	return reminded, err
+}
+
+// dueOffset 返回不小于剩余时间的最小提前量。
+func dueOffset(offsets []int, remaining time.Duration) int {
+	for _, o := range offsets {
+		if remaining <= time.Duration(o)*time.Minute {
+			return o
+		}
+	}
+	return offsets[len(offsets)-1]
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDropService_SubscribeAndRemind(t *testing.T) {
+	_, fixtures := newOrderServiceForTest(t)
+	dropSvc := NewDropService(db.DB)
+	ctx := context.Background()
+	seller := fixtures.user.ID
+
+	now := time.Now()
+	product := &model.Product{UserID: seller, Name: "Dunk Low", Price: 899, Stock: 5, StartTime: now.Add(20 * time.Hour)}
+	if err := db.DB.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	bob := &model.User{Username: "bob", Password: "hashed"}
+	if err := db.DB.Create(bob).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	if _, err := dropSvc.Subscribe(ctx, bob.ID, fixtures.product.ID); !errors.Is(err, ErrDropStarted) {
+		t.Fatalf("Subscribe(started) error = %v, want %v", err, ErrDropStarted)
+	}
+	for i := 0; i < 2; i++ {
+		if view, err := dropSvc.Subscribe(ctx, seller, product.ID); err != nil || !view.Subscribed {
+			t.Fatalf("Subscribe() = %+v, %v", view, err)
+		}
+	}
+
+	// 距开售 20 小时：按 24 小时档提醒，重复扫描不再发送
+	if sent, err := dropSvc.SendReminders(ctx, now, 100); err != nil || sent != 1 {
+		t.Fatalf("SendReminders() = %d, %v, want 1", sent, err)
+	}
+	if sent, err := dropSvc.SendReminders(ctx, now.Add(time.Hour), 100); err != nil || sent != 0 {
+		t.Fatalf("SendReminders() again = %d, %v, want 0", sent, err)
+	}
+
+	// 晚预约的用户只收到最近一档提醒
+	if _, err := dropSvc.Subscribe(ctx, bob.ID, product.ID); err != nil {
+		t.Fatalf("Subscribe(bob) error = %v", err)
+	}
+	if sent, err := dropSvc.SendReminders(ctx, product.StartTime.Add(-10*time.Minute), 100); err != nil || sent != 2 {
+		t.Fatalf("SendReminders(15m) = %d, %v, want 2", sent, err)
+	}
+	countReminders := func(userID uint) int64 {
+		t.Helper()
+		var n int64
+		db.DB.Model(&model.Notification{}).Where("user_id = ? AND kind = ?", userID, model.NotifyDropReminder).Count(&n)
+		return n
+	}
+	if got := countReminders(seller); got != 2 {
+		t.Fatalf("seller reminders = %d, want 2", got)
+	}
+	if got := countReminders(bob.ID); got != 1 {
+		t.Fatalf("bob reminders = %d, want 1", got)
+	}
+
+	if _, err := dropSvc.SellerSubscribers(ctx, bob.ID, product.ID); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("SellerSubscribers(not owner) error = %v, want %v", err, ErrProductNotFound)
+	}
+	if stats, err := dropSvc.SellerSubscribers(ctx, seller, product.ID); err != nil || stats.Subscribers != 2 {
+		t.Fatalf("SellerSubscribers() = %+v, %v", stats, err)
+	}
+	if err := dropSvc.Unsubscribe(ctx, bob.ID, product.ID); err != nil {
+		t.Fatalf("Unsubscribe() error = %v", err)
+	}
+	if stats, err := dropSvc.Subscribers(ctx, product.ID); err != nil || stats.Subscribers != 1 {
+		t.Fatalf("Subscribers() = %+v, %v", stats, err)
+	}
+	if view, err := dropSvc.Status(ctx, bob.ID, product.ID); err != nil || view.Subscribed {
+		t.Fatalf("Status(bob) = %+v, %v", view, err)
+	}
+}
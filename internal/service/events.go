@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"strconv"
 	"sync"
+	"time"
 )
 
 type StreamEvent struct {
@@ -108,6 +109,17 @@ func publishProductStockEvent(productID uint, stock int) {
 	})
 }
 
+func publishWaitlistEvent(userID, productID uint, status model.WaitlistStatus, offerExpiresAt *time.Time) {
+	data := map[string]any{
+		"product_id": productID,
+		"status":     status,
+	}
+	if offerExpiresAt != nil {
+		data["offer_expires_at"] = offerExpiresAt
+	}
+	publishStreamEvent(waitlistStreamTopic(userID, productID), StreamEvent{Event: "waitlist_update", Data: data})
+}
+
 func orderStreamTopic(userID, orderID uint) string {
 	return "order:" + itoa(userID) + ":" + itoa(orderID)
 }
@@ -116,6 +128,10 @@ func productStreamTopic(productID uint) string {
 	return "product:" + itoa(productID)
 }
 
+func waitlistStreamTopic(userID, productID uint) string {
+	return "waitlist:" + itoa(userID) + ":" + itoa(productID)
+}
+
 func itoa(v uint) string {
 	return strconv.FormatUint(uint64(v), 10)
 }
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/vip"
+	"SneakerFlash/internal/repository"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// growthRules 成长值滚动窗口与降级宽限期，来自配置文件 growth 段。
+type growthRules struct {
+	windowMonths int
+	grace        time.Duration
+}
+
+func loadGrowthRules() growthRules {
+	cfg := config.Conf.Growth
+	rules := growthRules{
+		windowMonths: cfg.WindowMonths,
+		grace:        time.Duration(cfg.GraceDays) * 24 * time.Hour,
+	}
+	if rules.windowMonths <= 0 {
+		rules.windowMonths = 12
+	}
+	if rules.grace <= 0 {
+		rules.grace = 30 * 24 * time.Hour
+	}
+	return rules
+}
+
+func (r growthRules) windowStart(now time.Time) time.Time {
+	return now.AddDate(0, -r.windowMonths, 0)
+}
+
+// GrowthReviewResult 一轮成长等级复核的统计。
+type GrowthReviewResult struct {
+	Checked    int `json:"checked"`
+	Upgraded   int `json:"upgraded"`
+	Warned     int `json:"warned"`
+	Restored   int `json:"restored"`
+	Downgraded int `json:"downgraded"`
+}
+
+// GrowthService 成长等级复核：按滚动窗口重算，低于门槛先预警，宽限期满再降级。
+type GrowthService struct {
+	db           *gorm.DB
+	userRepo     *repository.UserRepo
+	vipConfigSvc *VIPConfigService
+}
+
+func NewGrowthService(db *gorm.DB) *GrowthService {
+	return &GrowthService{
+		db:           db,
+		userRepo:     repository.NewUserRepo(db),
+		vipConfigSvc: NewVIPConfigService(db),
+	}
+}
+
+// Review 复核所有高于 L1 或处于宽限期的用户，单个用户失败不影响其他用户。
+func (s *GrowthService) Review(ctx context.Context, batchSize int) (*GrowthReviewResult, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if batchSize <= 0 {
+		batchSize = 200
+	}
+	vipCfg, err := s.vipConfigSvc.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	thresholds := vipCfg.Thresholds()
+
+	result := &GrowthReviewResult{}
+	var afterID uint
+	for {
+		ids, err := s.userRepo.ListGrowthReviewIDs(ctx, afterID, batchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(ids) == 0 {
+			return result, nil
+		}
+		for _, id := range ids {
+			afterID = id
+			var change *model.GrowthLevelChange
+			err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				user, err := repository.NewUserRepo(tx).GetByIDForUpdate(ctx, id)
+				if err != nil {
+					return err
+				}
+				change, err = syncGrowthLevel(ctx, tx, user, thresholds, 0, time.Now())
+				return err
+			})
+			if err != nil {
+				slog.Warn("成长等级复核失败", slog.Uint64("user_id", uint64(id)), slog.Any("err", err))
+				continue
+			}
+			result.Checked++
+			if change == nil {
+				continue
+			}
+			switch change.Reason {
+			case model.GrowthChangeUpgrade:
+				result.Upgraded++
+			case model.GrowthChangeDowngradeWarning:
+				result.Warned++
+			case model.GrowthChangeDowngradeCancelled:
+				result.Restored++
+			case model.GrowthChangeDowngrade:
+				result.Downgraded++
+			}
+			if change.FromLevel != change.ToLevel && change.Reason != model.GrowthChangeDowngradeWarning {
+				invalidateVIPLevelCache(ctx, id)
+			}
+		}
+	}
+}
+
+// syncGrowthLevel 在事务内按滚动窗口实付重算成长等级并记录变更，user 须已加锁且累计实付已更新。
+// 达到更高门槛立即升级；低于门槛先进入宽限期，期满仍未达标再降级，期间恢复达标则取消。
+// refundedCents 非 0 表示因退款重算：当前等级正是由该笔消费支撑时立即回退，不给宽限期。
+func syncGrowthLevel(ctx context.Context, tx *gorm.DB, user *model.User, thresholds []vip.Threshold, refundedCents int64, now time.Time) (*model.GrowthLevelChange, error) {
+	rules := loadGrowthRules()
+	window, err := repository.NewOrderRepo(tx).SumPaidSince(ctx, user.ID, rules.windowStart(now))
+	if err != nil {
+		return nil, err
+	}
+	user.WindowSpentCents = window
+	from := user.GrowthLevel
+	target := vip.CalcGrowthLevel(window, thresholds)
+
+	change := &model.GrowthLevelChange{UserID: user.ID, FromLevel: from, ToLevel: target, WindowSpentCents: window}
+	switch {
+	case target > from:
+		change.Reason = model.GrowthChangeUpgrade
+		user.GrowthLevel = target
+		user.GrowthDowngradeAt = nil
+	case target == from:
+		if user.GrowthDowngradeAt == nil {
+			change = nil
+			break
+		}
+		change.Reason = model.GrowthChangeDowngradeCancelled
+		user.GrowthDowngradeAt = nil
+	case refundedCents > 0 && vip.CalcGrowthLevel(window+refundedCents, thresholds) >= from:
+		change.Reason = model.GrowthChangeRefund
+		user.GrowthLevel = target
+		user.GrowthDowngradeAt = nil
+	case user.GrowthDowngradeAt == nil:
+		deadline := now.Add(rules.grace)
+		change.Reason = model.GrowthChangeDowngradeWarning
+		change.DowngradeAt = &deadline
+		user.GrowthDowngradeAt = &deadline
+	case !now.Before(*user.GrowthDowngradeAt):
+		change.Reason = model.GrowthChangeDowngrade
+		user.GrowthLevel = target
+		user.GrowthDowngradeAt = nil
+	default:
+		// 宽限期内，等待到期
+		change = nil
+	}
+
+	if err := repository.NewUserRepo(tx).UpdateGrowth(ctx, user); err != nil {
+		return nil, err
+	}
+	if change != nil {
+		if err := repository.NewGrowthRepo(tx).Create(ctx, change); err != nil {
+			return nil, err
+		}
+		notify(ctx, tx, growthNotice(change))
+	}
+	return change, nil
+}
+
+// growthNotice 等级变更对应的用户通知。
+func growthNotice(change *model.GrowthLevelChange) Notice {
+	kind := model.NotifyGrowthDowngrade
+	switch change.Reason {
+	case model.GrowthChangeUpgrade:
+		kind = model.NotifyGrowthUpgrade
+	case model.GrowthChangeDowngradeWarning:
+		kind = model.NotifyGrowthDowngradeWarning
+	case model.GrowthChangeDowngradeCancelled:
+		kind = model.NotifyGrowthDowngradeCanceled
+	}
+	data := map[string]any{"from": change.FromLevel, "to": change.ToLevel}
+	if change.DowngradeAt != nil {
+		data["downgrade_at"] = notifyTime(*change.DowngradeAt)
+	}
+	return Notice{UserID: change.UserID, Kind: kind, Data: data}
+}
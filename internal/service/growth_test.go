@@ -0,0 +1,104 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGrowthService_RollingWindowAndDowngrade(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	growthSvc := NewGrowthService(db.DB)
+	ctx := context.Background()
+	userID := fixtures.user.ID
+
+	loadUser := func() model.User {
+		t.Helper()
+		var u model.User
+		if err := db.DB.First(&u, userID).Error; err != nil {
+			t.Fatalf("load user: %v", err)
+		}
+		return u
+	}
+	lastChange := func() model.GrowthLevelChange {
+		t.Helper()
+		var c model.GrowthLevelChange
+		if err := db.DB.Where("user_id = ?", userID).Order("id desc").First(&c).Error; err != nil {
+			t.Fatalf("load change: %v", err)
+		}
+		return c
+	}
+
+	// 13 个月前的 L3 消费已滑出窗口
+	paidAt := time.Now().AddDate(0, -13, 0)
+	old := &model.Order{UserID: userID, ProductID: fixtures.product.ID, OrderNum: "ORD-OLD", Status: model.OrderStatusPaid, Type: model.OrderTypeProduct, PaidAt: &paidAt}
+	if err := db.DB.Create(old).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	if err := db.DB.Create(&model.Payment{OrderID: old.ID, PaymentID: "PAY-OLD", AmountCents: 600_000, Status: model.PaymentStatusPaid}).Error; err != nil {
+		t.Fatalf("create payment: %v", err)
+	}
+	if err := db.DB.Model(&model.User{}).Where("id = ?", userID).
+		Updates(map[string]any{"growth_level": 3, "total_spent_cents": 600_000}).Error; err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+
+	// 低于门槛：进入宽限期，不立即降级
+	res, err := growthSvc.Review(ctx, 10)
+	if err != nil || res.Warned != 1 {
+		t.Fatalf("Review() = %+v, %v, want 1 warned", res, err)
+	}
+	u := loadUser()
+	if u.GrowthLevel != 3 || u.GrowthDowngradeAt == nil || time.Until(*u.GrowthDowngradeAt) < 29*24*time.Hour {
+		t.Fatalf("user after warning = level %d, downgrade_at %v", u.GrowthLevel, u.GrowthDowngradeAt)
+	}
+	if c := lastChange(); c.Reason != model.GrowthChangeDowngradeWarning || c.FromLevel != 3 || c.ToLevel != 1 {
+		t.Fatalf("warning change = %+v", c)
+	}
+	// 宽限期内重复复核不再记录
+	if res, err := growthSvc.Review(ctx, 10); err != nil || res.Warned+res.Downgraded != 0 {
+		t.Fatalf("Review() within grace = %+v, %v", res, err)
+	}
+
+	// 宽限期内消费恢复到 L2 仍低于 L3，保持待降级；支付时按窗口升级规则不变
+	if _, err := orderSvc.HandlePaymentResult(ctx, fixtures.payment.PaymentID, model.PaymentStatusPaid, "ok"); err != nil {
+		t.Fatalf("HandlePaymentResult(paid) error = %v", err)
+	}
+	u = loadUser()
+	if u.GrowthLevel != 3 || u.WindowSpentCents != 129_900 || u.TotalSpentCents != 729_900 || u.GrowthDowngradeAt == nil {
+		t.Fatalf("user after payment = %+v", u)
+	}
+
+	// 宽限期满：降至窗口等级 L2
+	past := time.Now().Add(-time.Minute)
+	if err := db.DB.Model(&model.User{}).Where("id = ?", userID).Update("growth_downgrade_at", past).Error; err != nil {
+		t.Fatalf("backdate downgrade: %v", err)
+	}
+	if res, err := growthSvc.Review(ctx, 10); err != nil || res.Downgraded != 1 {
+		t.Fatalf("Review() after grace = %+v, %v, want 1 downgraded", res, err)
+	}
+	u = loadUser()
+	if u.GrowthLevel != 2 || u.GrowthDowngradeAt != nil {
+		t.Fatalf("user after downgrade = level %d, downgrade_at %v", u.GrowthLevel, u.GrowthDowngradeAt)
+	}
+
+	// 退款：L2 正由该笔消费支撑，立即回退
+	if _, err := orderSvc.HandlePaymentResult(ctx, fixtures.payment.PaymentID, model.PaymentStatusRefunded, "refund"); err != nil {
+		t.Fatalf("HandlePaymentResult(refunded) error = %v", err)
+	}
+	u = loadUser()
+	if u.GrowthLevel != 1 || u.WindowSpentCents != 0 || u.TotalSpentCents != 600_000 {
+		t.Fatalf("user after refund = %+v", u)
+	}
+	if c := lastChange(); c.Reason != model.GrowthChangeRefund || c.FromLevel != 2 || c.ToLevel != 1 {
+		t.Fatalf("refund change = %+v", c)
+	}
+
+	vipSvc := NewVIPService(db.DB, nil, nil)
+	history, total, err := vipSvc.GrowthHistory(ctx, userID, 1, 20)
+	if err != nil || total != 3 || len(history) != 3 {
+		t.Fatalf("GrowthHistory() = %d items, total %d, err %v", len(history), total, err)
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrMemberPriceInvalid         = errors.New("会员价配置无效")
+	ErrCouponMemberPriceExclusive = errors.New("该商品会员价不可与优惠券叠加，且优惠券优惠不及会员价")
+)
+
+// validateMemberPrices 校验会员价：等级唯一且不小于 1，折扣与固定价二选一，结果须低于原价。
+func validateMemberPrices(prices model.MemberPrices, price float64) error {
+	base := int64(math.Round(price * 100))
+	seen := make(map[int]struct{}, len(prices))
+	for _, mp := range prices {
+		if mp.Level < 1 {
+			return fmt.Errorf("%w: 等级须不小于 1", ErrMemberPriceInvalid)
+		}
+		if _, ok := seen[mp.Level]; ok {
+			return fmt.Errorf("%w: 等级 %d 重复", ErrMemberPriceInvalid, mp.Level)
+		}
+		seen[mp.Level] = struct{}{}
+		switch {
+		case mp.DiscountRate != 0 && mp.PriceCents != 0:
+			return fmt.Errorf("%w: 折扣与固定价只能设置一项", ErrMemberPriceInvalid)
+		case mp.DiscountRate != 0:
+			if mp.DiscountRate <= 0 || mp.DiscountRate >= 100 {
+				return fmt.Errorf("%w: 折扣须在 1-99 之间", ErrMemberPriceInvalid)
+			}
+		case mp.PriceCents != 0:
+			if mp.PriceCents <= 0 || mp.PriceCents >= base {
+				return fmt.Errorf("%w: 固定价须低于原价", ErrMemberPriceInvalid)
+			}
+		default:
+			return fmt.Errorf("%w: 须设置折扣或固定价", ErrMemberPriceInvalid)
+		}
+	}
+	return nil
+}
+
+// memberPriceFor 返回该等级可享的会员价（分）与命中的规则等级；取不高于用户等级的最高一档，未命中返回原价与 0。
+func memberPriceFor(p *model.Product, level int) (int64, int) {
+	base := int64(math.Round(p.Price * 100))
+	cents, matched := base, 0
+	for _, mp := range p.MemberPrices {
+		if mp.Level > level || mp.Level <= matched {
+			continue
+		}
+		v := mp.PriceCents
+		if mp.DiscountRate > 0 {
+			v = base * int64(mp.DiscountRate) / 100
+		}
+		if v > 0 && v < base {
+			cents, matched = v, mp.Level
+		}
+	}
+	return cents, matched
+}
+
+// OrderPrice 订单计价基础：原价、会员价与叠加规则。
+type OrderPrice struct {
+	OriginalCents int64 // 原价
+	MemberCents   int64 // 会员价，0 表示未享受
+	Stackable     bool  // 会员价是否可与优惠券叠加
+}
+
+// Payable 不使用优惠券时的应付金额。
+func (p OrderPrice) Payable() int64 {
+	if p.MemberCents > 0 {
+		return p.MemberCents
+	}
+	return p.OriginalCents
+}
+
+// applyCoupon 按叠加规则计算用券后金额：
+// 可叠加时在会员价基础上计算（门槛也按会员价判断）；不可叠加时按原价计算，且结果须低于会员价，否则不可用。
+func (p OrderPrice) applyCoupon(c *model.Coupon) (int64, error) {
+	if p.MemberCents <= 0 || p.Stackable {
+		return calcCouponAmount(c, p.Payable())
+	}
+	amount, err := calcCouponAmount(c, p.OriginalCents)
+	if err != nil {
+		return 0, err
+	}
+	if amount >= p.MemberCents {
+		return 0, ErrCouponMemberPriceExclusive
+	}
+	return amount, nil
+}
+
+// orderPrice 取订单计价快照；早期订单无快照时以商品当前价格为原价。
+func orderPrice(ctx context.Context, productRepo *repository.ProductRepo, order *model.Order) (OrderPrice, error) {
+	product, err := productRepo.GetByID(ctx, order.ProductID)
+	if err != nil {
+		return OrderPrice{}, err
+	}
+	original := order.OriginalPriceCents
+	if original <= 0 {
+		original = int64(math.Round(product.Price * 100))
+	}
+	if original <= 0 {
+		return OrderPrice{}, fmt.Errorf("invalid product price: %v", product.Price)
+	}
+	return OrderPrice{
+		OriginalCents: original,
+		MemberCents:   order.MemberPriceCents,
+		Stackable:     product.MemberCouponStackable,
+	}, nil
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotificationNotFound      = errors.New("通知不存在")
+	ErrNotificationRecipientMiss = errors.New("开启邮件/短信通知需填写邮箱/手机号")
+)
+
+// notificationTemplates 各类通知的标题与正文模板，正文使用 text/template 渲染。
+var notificationTemplates = map[model.NotificationKind]struct {
+	title   string
+	content *template.Template
+}{
+	model.NotifySeckillSuccess:          {"抢购成功", tpl("订单 {{.order_num}} 已创建，请尽快完成支付，超时将自动取消。")},
+	model.NotifySeckillFailed:           {"抢购失败", tpl("订单 {{.order_num}} 未能创建：{{.reason}}。")},
+	model.NotifyOrderPaid:               {"支付成功", tpl("订单 {{.order_num}} 已支付 {{.amount}} 元。")},
+	model.NotifyOrderCancelled:          {"订单已取消", tpl("订单 {{.order_num}} 超时未支付，已自动取消。")},
+	model.NotifyOrderRefunded:           {"退款成功", tpl("订单 {{.order_num}} 已退款 {{.amount}} 元。")},
+	model.NotifyCouponIssued:            {"优惠券到账", tpl("{{.count}} 张「{{.title}}」已发放到账户，有效期至 {{.valid_to}}。")},
+	model.NotifyCouponExpiring:          {"优惠券即将过期", tpl("您有 {{.count}} 张优惠券将于 {{.valid_to}} 前过期，请尽快使用。")},
+	model.NotifyVIPActivated:            {"会员已开通", tpl("「{{.plan}}」已生效，当前会员等级 L{{.level}}，有效期至 {{.expired_at}}。")},
+	model.NotifyGrowthUpgrade:           {"等级提升", tpl("恭喜，您的成长等级已从 L{{.from}} 提升至 L{{.to}}。")},
+	model.NotifyGrowthDowngradeWarning:  {"等级即将下调", tpl("近期消费未达 L{{.from}} 门槛，若 {{.downgrade_at}} 前仍未达标，等级将调整为 L{{.to}}。")},
+	model.NotifyGrowthDowngradeCanceled: {"保级成功", tpl("消费已恢复达标，L{{.to}} 等级继续保留。")},
+	model.NotifyGrowthDowngrade:         {"等级已调整", tpl("您的成长等级已从 L{{.from}} 调整为 L{{.to}}。")},
+	model.NotifyDropReminder:            {"开售提醒", tpl("您预约的「{{.product}}」将于 {{.start_time}} 开售，记得准时来抢。")},
+	model.NotifyWaitlistOffer:           {"候补名额到了", tpl("您候补的「{{.product}}」有回流名额，请在 {{.expires_at}} 前完成认领，超时将顺延给下一位。")},
+	model.NotifyPenaltyApplied:          {"抢购受限", tpl("近 {{.days}} 天内您有 {{.count}} 笔抢购订单超时未支付，{{if .banned_until}}{{.banned_until}} 前暂停抢购，{{end}}{{if .graylisted}}账号已被限制访问，{{end}}如有异议可提交申诉。")},
+	model.NotifyPenaltyLifted:           {"限制已解除", tpl("您的抢购限制已解除，未支付记录已清零，请按时完成支付。")},
+	model.NotifyPenaltyAppealRejected:   {"申诉未通过", tpl("您的抢购限制申诉未通过{{if .reply}}：{{.reply}}{{end}}。")},
+	model.NotifySellerApproved:          {"入驻审核通过", tpl("店铺「{{.store}}」已通过审核，现在可以发布商品了。")},
+	model.NotifySellerRejected:          {"入驻审核未通过", tpl("店铺「{{.store}}」的入驻申请未通过：{{.reason}}。修改资料后可重新提交。")},
+	model.NotifyProductApproved:         {"商品已上架", tpl("您的商品「{{.product}}」已通过审核并上架。")},
+	model.NotifyProductRejected:         {"商品审核未通过", tpl("您的商品「{{.product}}」未通过审核：{{.reason}}。修改后可重新提交。")},
+	model.NotifyProductOffline:          {"商品已下架", tpl("您的商品「{{.product}}」已被平台下架：{{.reason}}。")},
+	model.NotifySettlementPaid:          {"结算款已打款", tpl("{{.period}} 的结算款 {{.net}} 元已打款，流水号 {{.ref}}。")},
+}
+
+// tpl 缺少变量时渲染报错，由 renderNotice 回退为标题，避免正文出现 "<no value>"。
+func tpl(text string) *template.Template {
+	return template.Must(template.New("").Option("missingkey=error").Parse(text))
+}
+
+// Notice 待发送的通知。
+type Notice struct {
+	UserID uint
+	Kind   model.NotificationKind
+	Data   map[string]any
+}
+
+// notify 在业务事务内写入站内信与各渠道投递记录，提交后由投递任务发送。
+// 写入放在保存点中：通知失败只回滚通知本身并记录日志，不影响订单、支付等业务操作。
+// 站内信始终落库，用户偏好只决定推送渠道。
+func notify(ctx context.Context, tx *gorm.DB, notices ...Notice) {
+	if len(notices) == 0 {
+		return
+	}
+	err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return writeNotices(ctx, tx, notices)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "写入通知失败", slog.Int("count", len(notices)), slog.String("kind", string(notices[0].Kind)), slog.Any("err", err))
+	}
+}
+
+func writeNotices(ctx context.Context, tx *gorm.DB, notices []Notice) error {
+	repo := repository.NewNotificationRepo(tx)
+	userIDs := make([]uint, 0, len(notices))
+	for _, n := range notices {
+		userIDs = append(userIDs, n.UserID)
+	}
+	prefs, err := repo.ListPreferences(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	prefByUser := make(map[uint]model.NotificationPreference, len(prefs))
+	for _, p := range prefs {
+		prefByUser[p.UserID] = p
+	}
+
+	notifications := make([]*model.Notification, 0, len(notices))
+	for _, n := range notices {
+		t, ok := notificationTemplates[n.Kind]
+		if !ok {
+			slog.WarnContext(ctx, "未知通知类型", slog.String("kind", string(n.Kind)))
+			continue
+		}
+		notifications = append(notifications, &model.Notification{UserID: n.UserID, Kind: n.Kind, Title: t.title, Content: renderNotice(ctx, n)})
+	}
+	if err := repo.BatchCreate(ctx, notifications); err != nil {
+		return err
+	}
+
+	deliveries := make([]*model.NotificationDelivery, 0, len(notifications))
+	for _, n := range notifications {
+		pref, ok := prefByUser[n.UserID]
+		if !ok {
+			pref = defaultNotificationPreference(n.UserID)
+		}
+		targets := []struct {
+			enabled   bool
+			channel   model.NotificationChannel
+			recipient string
+		}{
+			{pref.InApp, model.NotificationChannelInApp, ""},
+			{pref.Email && pref.EmailAddr != "", model.NotificationChannelEmail, pref.EmailAddr},
+			{pref.SMS && pref.Phone != "", model.NotificationChannelSMS, pref.Phone},
+		}
+		for _, t := range targets {
+			if !t.enabled {
+				continue
+			}
+			deliveries = append(deliveries, &model.NotificationDelivery{
+				NotificationID: n.ID,
+				UserID:         n.UserID,
+				Channel:        t.channel,
+				Recipient:      t.recipient,
+				Status:         model.NotificationDeliveryPending,
+			})
+		}
+	}
+	return repo.BatchCreateDeliveries(ctx, deliveries)
+}
+
+// renderNotice 渲染通知正文，模板变量缺失时以标题作为正文。
+func renderNotice(ctx context.Context, n Notice) string {
+	t := notificationTemplates[n.Kind]
+	var buf bytes.Buffer
+	if err := t.content.Execute(&buf, n.Data); err != nil {
+		slog.WarnContext(ctx, "通知模板渲染失败，使用默认正文", slog.String("kind", string(n.Kind)), slog.Any("err", err))
+		return t.title + "。"
+	}
+	return buf.String()
+}
+
+func defaultNotificationPreference(userID uint) model.NotificationPreference {
+	return model.NotificationPreference{UserID: userID, InApp: true}
+}
+
+// NotificationPreferenceView 通知偏好。
+type NotificationPreferenceView struct {
+	InApp     bool   `json:"in_app"`
+	Email     bool   `json:"email"`
+	SMS       bool   `json:"sms"`
+	EmailAddr string `json:"email_addr"`
+	Phone     string `json:"phone"`
+}
+
+// NotificationService 通知服务：收件箱、渠道偏好与投递。
+type NotificationService struct {
+	db        *gorm.DB
+	repo      *repository.NotificationRepo
+	notifiers map[model.NotificationChannel]Notifier
+}
+
+// NewNotificationService 未传入 notifiers 时使用站内推送与按配置写文件的邮件/短信桩。
+func NewNotificationService(db *gorm.DB, notifiers ...Notifier) *NotificationService {
+	if len(notifiers) == 0 {
+		cfg := config.Conf.Notification
+		notifiers = []Notifier{
+			InAppNotifier{},
+			NewFileNotifier(model.NotificationChannelEmail, cfg.EmailOutbox),
+			NewFileNotifier(model.NotificationChannelSMS, cfg.SMSOutbox),
+		}
+	}
+	byChannel := make(map[model.NotificationChannel]Notifier, len(notifiers))
+	for _, n := range notifiers {
+		byChannel[n.Channel()] = n
+	}
+	return &NotificationService{
+		db:        db,
+		repo:      repository.NewNotificationRepo(db),
+		notifiers: byChannel,
+	}
+}
+
+// List 分页查询站内信。
+func (s *NotificationService) List(ctx context.Context, userID uint, unreadOnly bool, page, pageSize int) ([]model.Notification, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.repo.ListByUser(ctx, userID, unreadOnly, page, pageSize)
+}
+
+// UnreadCount 未读站内信数量。
+func (s *NotificationService) UnreadCount(ctx context.Context, userID uint) (int64, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	return s.repo.CountUnread(ctx, userID)
+}
+
+// MarkRead 标记单条已读，重复标记视为成功。
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id uint) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	rows, err := s.repo.MarkRead(ctx, userID, id, time.Now())
+	if err != nil || rows > 0 {
+		return err
+	}
+	exists, err := s.repo.Exists(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead 全部标记已读，返回本次标记条数。
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	return s.repo.MarkAllRead(ctx, userID, time.Now())
+}
+
+// GetPreference 查询通知偏好，未设置时返回默认值。
+func (s *NotificationService) GetPreference(ctx context.Context, userID uint) (*NotificationPreferenceView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	prefs, err := s.repo.ListPreferences(ctx, []uint{userID})
+	if err != nil {
+		return nil, err
+	}
+	pref := defaultNotificationPreference(userID)
+	if len(prefs) > 0 {
+		pref = prefs[0]
+	}
+	return toNotificationPreferenceView(pref), nil
+}
+
+// UpdatePreference 覆盖通知偏好；开启邮件/短信须提供对应地址。
+func (s *NotificationService) UpdatePreference(ctx context.Context, userID uint, input NotificationPreferenceView) (*NotificationPreferenceView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	pref := model.NotificationPreference{
+		UserID:    userID,
+		InApp:     input.InApp,
+		Email:     input.Email,
+		SMS:       input.SMS,
+		EmailAddr: strings.TrimSpace(input.EmailAddr),
+		Phone:     strings.TrimSpace(input.Phone),
+		UpdatedAt: time.Now(),
+	}
+	if (pref.Email && pref.EmailAddr == "") || (pref.SMS && pref.Phone == "") {
+		return nil, ErrNotificationRecipientMiss
+	}
+	if err := s.repo.SavePreference(ctx, &pref); err != nil {
+		return nil, err
+	}
+	return toNotificationPreferenceView(pref), nil
+}
+
+// Dispatch 投递待发送记录，单条失败累计重试，达到上限置为失败；返回成功条数。
+func (s *NotificationService) Dispatch(ctx context.Context, limit int) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+	deliveries, err := s.repo.ListPendingDeliveries(ctx, limit)
+	if err != nil || len(deliveries) == 0 {
+		return 0, err
+	}
+	ids := make([]uint, 0, len(deliveries))
+	for _, d := range deliveries {
+		ids = append(ids, d.NotificationID)
+	}
+	notifications, err := s.repo.ListByIDs(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	byID := make(map[uint]*model.Notification, len(notifications))
+	for i := range notifications {
+		byID[notifications[i].ID] = &notifications[i]
+	}
+
+	maxRetries := config.Conf.Notification.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 5
+	}
+	sent := 0
+	for _, d := range deliveries {
+		sendErr := s.send(ctx, &d, byID[d.NotificationID])
+		if sendErr == nil {
+			if err := s.repo.MarkDeliverySent(ctx, d.ID, time.Now()); err != nil {
+				return sent, err
+			}
+			sent++
+			continue
+		}
+		retry := d.RetryCount + 1
+		status := model.NotificationDeliveryPending
+		if retry >= maxRetries {
+			status = model.NotificationDeliveryFailed
+		}
+		slog.WarnContext(ctx, "通知投递失败",
+			slog.Uint64("delivery_id", uint64(d.ID)),
+			slog.String("channel", string(d.Channel)),
+			slog.Int("retry", retry),
+			slog.Any("err", sendErr))
+		if err := s.repo.MarkDeliveryRetry(ctx, d.ID, retry, status, truncateError(sendErr)); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+func (s *NotificationService) send(ctx context.Context, d *model.NotificationDelivery, n *model.Notification) error {
+	if n == nil {
+		return fmt.Errorf("notification %d not found", d.NotificationID)
+	}
+	notifier, ok := s.notifiers[d.Channel]
+	if !ok {
+		return fmt.Errorf("no notifier for channel %s", d.Channel)
+	}
+	return notifier.Send(ctx, &NotificationMessage{
+		NotificationID: n.ID,
+		UserID:         n.UserID,
+		Kind:           n.Kind,
+		Recipient:      d.Recipient,
+		Title:          n.Title,
+		Content:        n.Content,
+		CreatedAt:      n.CreatedAt,
+	})
+}
+
+func toNotificationPreferenceView(p model.NotificationPreference) *NotificationPreferenceView {
+	return &NotificationPreferenceView{
+		InApp:     p.InApp,
+		Email:     p.Email,
+		SMS:       p.SMS,
+		EmailAddr: p.EmailAddr,
+		Phone:     p.Phone,
+	}
+}
+
+// yuan 分转元，用于通知文案。
+func yuan(cents int64) string {
+	return fmt.Sprintf("%.2f", float64(cents)/100)
+}
+
+// notifyTime 通知文案中的时间格式。
+func notifyTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04")
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeNotifier struct {
+	channel model.NotificationChannel
+	fails   int
+	sent    []*NotificationMessage
+}
+
+func (n *fakeNotifier) Channel() model.NotificationChannel {
+	return n.channel
+}
+
+func (n *fakeNotifier) Send(_ context.Context, msg *NotificationMessage) error {
+	if n.fails > 0 {
+		n.fails--
+		return errors.New("smtp unavailable")
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestNotificationService_InboxPreferenceAndDispatch(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	ctx := context.Background()
+	userID := fixtures.user.ID
+
+	inApp := &fakeNotifier{channel: model.NotificationChannelInApp}
+	email := &fakeNotifier{channel: model.NotificationChannelEmail, fails: 1}
+	svc := NewNotificationService(db.DB, inApp, email)
+
+	if _, err := svc.UpdatePreference(ctx, userID, NotificationPreferenceView{InApp: true, Email: true}); !errors.Is(err, ErrNotificationRecipientMiss) {
+		t.Fatalf("UpdatePreference(no addr) error = %v, want %v", err, ErrNotificationRecipientMiss)
+	}
+	if _, err := svc.UpdatePreference(ctx, userID, NotificationPreferenceView{InApp: true, Email: true, EmailAddr: " alice@example.com "}); err != nil {
+		t.Fatalf("UpdatePreference() error = %v", err)
+	}
+
+	// 支付成功：写入站内信，按偏好生成站内与邮件投递
+	if _, err := orderSvc.HandlePaymentResult(ctx, fixtures.payment.PaymentID, model.PaymentStatusPaid, "ok"); err != nil {
+		t.Fatalf("HandlePaymentResult(paid) error = %v", err)
+	}
+	var paid model.Notification
+	if err := db.DB.Where("user_id = ? AND kind = ?", userID, model.NotifyOrderPaid).First(&paid).Error; err != nil {
+		t.Fatalf("load order_paid notification: %v", err)
+	}
+	if paid.Title != "支付成功" || paid.Content != "订单 ORD-001 已支付 1299.00 元。" {
+		t.Fatalf("order_paid notification = %+v", paid)
+	}
+
+	unread, err := svc.UnreadCount(ctx, userID)
+	if err != nil || unread == 0 {
+		t.Fatalf("UnreadCount() = %d, %v", unread, err)
+	}
+
+	// 第一封邮件投递失败，进入重试，下一轮成功
+	if _, err := svc.Dispatch(ctx, 100); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if len(inApp.sent) != int(unread) || len(email.sent) != int(unread)-1 {
+		t.Fatalf("first dispatch in_app=%d email=%d, want %d/%d", len(inApp.sent), len(email.sent), unread, unread-1)
+	}
+	var retried model.NotificationDelivery
+	if err := db.DB.Where("channel = ? AND retry_count > 0", model.NotificationChannelEmail).First(&retried).Error; err != nil {
+		t.Fatalf("load email delivery: %v", err)
+	}
+	if retried.Status != model.NotificationDeliveryPending || retried.RetryCount != 1 || retried.LastError == "" {
+		t.Fatalf("email delivery after failure = %+v", retried)
+	}
+	if _, err := svc.Dispatch(ctx, 100); err != nil {
+		t.Fatalf("Dispatch() retry error = %v", err)
+	}
+	if len(email.sent) != int(unread) || email.sent[0].Recipient != "alice@example.com" {
+		t.Fatalf("email sent = %d, want %d", len(email.sent), unread)
+	}
+	if sent, err := svc.Dispatch(ctx, 100); err != nil || sent != 0 {
+		t.Fatalf("Dispatch() drained = %d, %v", sent, err)
+	}
+
+	// 已读
+	if err := svc.MarkRead(ctx, userID, paid.ID); err != nil {
+		t.Fatalf("MarkRead() error = %v", err)
+	}
+	if err := svc.MarkRead(ctx, userID+1, paid.ID); !errors.Is(err, ErrNotificationNotFound) {
+		t.Fatalf("MarkRead(other user) error = %v, want %v", err, ErrNotificationNotFound)
+	}
+	list, total, err := svc.List(ctx, userID, true, 1, 20)
+	if err != nil || total != unread-1 || len(list) != int(total) {
+		t.Fatalf("List(unread) = %d items, total %d, err %v", len(list), total, err)
+	}
+	if updated, err := svc.MarkAllRead(ctx, userID); err != nil || updated != unread-1 {
+		t.Fatalf("MarkAllRead() = %d, %v", updated, err)
+	}
+
+	// 优惠券过期提醒：同一用户合并，只提醒一次
+	coupon := &model.Coupon{Type: model.CouponTypeFullCut, Title: "满减券", AmountCents: 500, ValidFrom: time.Now().Add(-time.Hour), ValidTo: time.Now().Add(48 * time.Hour), Status: model.CouponTemplateStatusActive}
+	if err := db.DB.Create(coupon).Error; err != nil {
+		t.Fatalf("create coupon: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		uc := &model.UserCoupon{UserID: userID, CouponID: coupon.ID, Status: model.CouponStatusAvailable, ObtainedFrom: "purchase", ValidFrom: coupon.ValidFrom, ValidTo: coupon.ValidTo, IssuedAt: time.Now()}
+		if err := db.DB.Create(uc).Error; err != nil {
+			t.Fatalf("create user coupon: %v", err)
+		}
+	}
+	couponSvc := NewCouponService(db.DB)
+	if reminded, err := couponSvc.RemindExpiring(ctx, 72*time.Hour, 100); err != nil || reminded != 2 {
+		t.Fatalf("RemindExpiring() = %d, %v, want 2", reminded, err)
+	}
+	if reminded, err := couponSvc.RemindExpiring(ctx, 72*time.Hour, 100); err != nil || reminded != 0 {
+		t.Fatalf("RemindExpiring() again = %d, %v, want 0", reminded, err)
+	}
+	var expiring int64
+	db.DB.Model(&model.Notification{}).Where("user_id = ? AND kind = ?", userID, model.NotifyCouponExpiring).Count(&expiring)
+	if expiring != 1 {
+		t.Fatalf("coupon_expiring notifications = %d, want 1", expiring)
+	}
+}
+
+func TestNotify_FailureDoesNotFailBusiness(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	ctx := context.Background()
+
+	// 模板变量缺失时以标题作为正文
+	if got := renderNotice(ctx, Notice{Kind: model.NotifyOrderPaid, Data: map[string]any{"order_num": "ORD-001"}}); got != "支付成功。" {
+		t.Fatalf("renderNotice(missing key) = %q", got)
+	}
+
+	// 通知表不可写时支付回调仍然成功
+	if err := db.DB.Migrator().DropTable(&model.NotificationDelivery{}); err != nil {
+		t.Fatalf("drop deliveries: %v", err)
+	}
+	result, err := orderSvc.HandlePaymentResult(ctx, fixtures.payment.PaymentID, model.PaymentStatusPaid, "ok")
+	if err != nil || result.Order.Status != model.OrderStatusPaid {
+		t.Fatalf("HandlePaymentResult(paid) = %+v, %v", result, err)
+	}
+	var notifications int64
+	db.DB.Model(&model.Notification{}).Where("user_id = ?", fixtures.user.ID).Count(&notifications)
+	if notifications != 0 {
+		t.Fatalf("notifications = %d, want rolled back to savepoint", notifications)
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"SneakerFlash/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// NotificationMessage 一次投递的内容。
+type NotificationMessage struct {
+	NotificationID uint                   `json:"notification_id"`
+	UserID         uint                   `json:"user_id"`
+	Kind           model.NotificationKind `json:"kind"`
+	Recipient      string                 `json:"recipient,omitempty"`
+	Title          string                 `json:"title"`
+	Content        string                 `json:"content"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// Notifier 通知投递渠道，返回错误时由投递任务重试。
+type Notifier interface {
+	Channel() model.NotificationChannel
+	Send(ctx context.Context, msg *NotificationMessage) error
+}
+
+// InAppNotifier 站内实时推送：通过 SSE 通道推给在线用户，离线用户在收件箱查看。
+type InAppNotifier struct{}
+
+func (InAppNotifier) Channel() model.NotificationChannel {
+	return model.NotificationChannelInApp
+}
+
+func (InAppNotifier) Send(_ context.Context, msg *NotificationMessage) error {
+	publishStreamEvent(notificationStreamTopic(msg.UserID), StreamEvent{Event: "notification", Data: msg})
+	return nil
+}
+
+// FileNotifier 邮件/短信桩实现：按 JSON 行追加写入文件，未配置路径时只写日志。
+// 接入真实服务商时实现 Notifier 并在 NewNotificationService 中替换即可。
+type FileNotifier struct {
+	channel model.NotificationChannel
+	path    string
+	mu      sync.Mutex
+}
+
+func NewFileNotifier(channel model.NotificationChannel, path string) *FileNotifier {
+	return &FileNotifier{channel: channel, path: path}
+}
+
+func (n *FileNotifier) Channel() model.NotificationChannel {
+	return n.channel
+}
+
+func (n *FileNotifier) Send(ctx context.Context, msg *NotificationMessage) error {
+	if msg.Recipient == "" {
+		return fmt.Errorf("%s recipient is empty", n.channel)
+	}
+	if n.path == "" {
+		slog.InfoContext(ctx, "通知投递（桩）",
+			slog.String("channel", string(n.channel)),
+			slog.String("recipient", msg.Recipient),
+			slog.String("title", msg.Title))
+		return nil
+	}
+	line, err := json.Marshal(struct {
+		Channel model.NotificationChannel `json:"channel"`
+		*NotificationMessage
+	}{n.channel, msg})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(n.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+func notificationStreamTopic(userID uint) string {
+	return "notification:" + itoa(userID)
+}
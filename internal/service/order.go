@@ -4,11 +4,11 @@ import (
 	"SneakerFlash/internal/infra/redis"
 	"SneakerFlash/internal/model"
 	"SneakerFlash/internal/pkg/utils"
-	"SneakerFlash/internal/pkg/vip"
 	"SneakerFlash/internal/repository"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
 	"time"
 
@@ -25,12 +25,13 @@ var (
 
 // OrderService 订单服务，处理订单查询、优惠券核销、支付回调。
 type OrderService struct {
-	db          *gorm.DB
-	orderRepo   *repository.OrderRepo
-	paymentRepo *repository.PaymentRepo
-	productRepo *repository.ProductRepo
-	userRepo    *repository.UserRepo
-	couponSvc   *CouponService
+	db           *gorm.DB
+	orderRepo    *repository.OrderRepo
+	paymentRepo  *repository.PaymentRepo
+	productRepo  *repository.ProductRepo
+	userRepo     *repository.UserRepo
+	couponSvc    *CouponService
+	vipConfigSvc *VIPConfigService
 }
 
 // OrderWithPayment 订单聚合视图，含支付单和已用优惠券。
@@ -52,12 +53,13 @@ type OrderPollResult struct {
 // NewOrderService 构建订单服务，聚合订单/支付/商品仓储用于事务处理。
 func NewOrderService(db *gorm.DB, productRepo *repository.ProductRepo, userRepo *repository.UserRepo) *OrderService {
 	return &OrderService{
-		db:          db,
-		orderRepo:   repository.NewOrderRepo(db),
-		paymentRepo: repository.NewPaymentRepo(db),
-		productRepo: productRepo,
-		userRepo:    userRepo,
-		couponSvc:   NewCouponService(db),
+		db:           db,
+		orderRepo:    repository.NewOrderRepo(db),
+		paymentRepo:  repository.NewPaymentRepo(db),
+		productRepo:  productRepo,
+		userRepo:     userRepo,
+		couponSvc:    NewCouponService(db),
+		vipConfigSvc: NewVIPConfigService(db),
 	}
 }
 
@@ -110,25 +112,24 @@ func (s *OrderService) ApplyCoupon(ctx context.Context, userID, orderID uint, co
 		if order.Status != model.OrderStatusUnpaid {
 			return ErrOrderNotPayable
 		}
+		if order.IsVIP() {
+			return ErrVIPOrderNoCoupon
+		}
 
-		product, err := txProductRepo.GetByID(ctx, order.ProductID)
+		price, err := orderPrice(ctx, txProductRepo, order)
 		if err != nil {
 			return err
 		}
-		baseAmount := int64(math.Round(product.Price * 100))
-		if baseAmount <= 0 {
-			return fmt.Errorf("invalid product price: %v", product.Price)
-		}
 
 		if _, existingErr := txUserCouponRepo.GetByOrderID(ctx, order.ID); existingErr == nil {
 			_ = txUserCouponRepo.ReleaseByOrder(ctx, order.ID)
 		}
 
-		finalAmount := baseAmount
+		finalAmount := price.Payable()
 		var appliedUC *model.UserCoupon
 		var appliedTpl *model.Coupon
 		if couponID != nil {
-			uc, tpl, discounted, cErr := txCouponSvc.ApplyCoupon(ctx, userID, *couponID, baseAmount)
+			uc, tpl, discounted, cErr := txCouponSvc.ApplyCoupon(ctx, userID, *couponID, price)
 			if cErr != nil {
 				return cErr
 			}
@@ -136,6 +137,16 @@ func (s *OrderService) ApplyCoupon(ctx context.Context, userID, orderID uint, co
 			appliedUC = uc
 			appliedTpl = tpl
 		}
+		// 更换优惠券会退回已抵扣积分，需重新抵扣
+		if order.PointsUsed > 0 {
+			if err := releaseOrderPoints(ctx, tx, order, time.Now()); err != nil {
+				return err
+			}
+			if err := txOrderRepo.UpdatePointsUsed(ctx, order.ID, 0); err != nil {
+				return err
+			}
+			order.PointsUsed = 0
+		}
 
 		payment, err := txPaymentRepo.GetByOrderID(ctx, order.ID)
 		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -189,6 +200,148 @@ func (s *OrderService) ApplyCoupon(ctx context.Context, userID, orderID uint, co
 	return &result, nil
 }
 
+// ApplyPoints 在待支付订单上使用积分抵扣，points 为 0 时取消抵扣；重复调用以最后一次为准。
+func (s *OrderService) ApplyPoints(ctx context.Context, userID, orderID uint, points int64) (*OrderWithPayment, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if points < 0 {
+		return nil, ErrPointsInvalid
+	}
+	rules := loadPointsRules()
+
+	var result OrderWithPayment
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txOrderRepo := repository.NewOrderRepo(tx)
+		txPaymentRepo := repository.NewPaymentRepo(tx)
+
+		order, err := txOrderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+		if order.UserID != userID {
+			return ErrOrderNotFound
+		}
+		if order.Status != model.OrderStatusUnpaid {
+			return ErrOrderNotPayable
+		}
+		if order.IsVIP() {
+			return ErrVIPOrderNoPoints
+		}
+		payment, err := txPaymentRepo.GetByOrderIDForUpdate(ctx, order.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+		if payment.Status != model.PaymentStatusPending {
+			return ErrOrderNotPayable
+		}
+
+		// 抵扣前金额 = 当前应付 + 已抵扣金额（已含优惠券）
+		now := time.Now()
+		beforeAmount := payment.AmountCents + order.PointsUsed*rules.pointValueCents
+		deductCents := points * rules.pointValueCents
+		if deductCents > beforeAmount*rules.maxDeductPercent/100 {
+			return ErrPointsExceedLimit
+		}
+		if err := releaseOrderPoints(ctx, tx, order, now); err != nil {
+			return err
+		}
+		if _, err := spendPoints(ctx, tx, userID, points, model.PointKindOrderDeduct, order.ID, order.OrderNum, now, true); err != nil {
+			return err
+		}
+		if err := txOrderRepo.UpdatePointsUsed(ctx, order.ID, points); err != nil {
+			return err
+		}
+		if _, err := txPaymentRepo.UpdateAmountIfPending(ctx, order.ID, beforeAmount-deductCents); err != nil {
+			return err
+		}
+		order.PointsUsed = points
+		payment, err = txPaymentRepo.GetByOrderID(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+
+		result = OrderWithPayment{Order: order, Payment: payment}
+		if uc, ucErr := repository.NewUserCouponRepo(tx).GetByOrderID(ctx, order.ID); ucErr == nil {
+			if tpl, tplErr := repository.NewCouponRepo(tx).GetByID(ctx, uc.CouponID); tplErr == nil {
+				result.Coupon = toMyCoupon(uc, tpl)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// OrderCouponOptions 订单可用券试算结果。
+type OrderCouponOptions struct {
+	OrderID       uint           `json:"order_id"`
+	AmountCents   int64          `json:"amount_cents"`   // 不用券时应付金额（分），享受会员价时为会员价
+	OriginalCents int64          `json:"original_cents"` // 订单原价（分）
+	Options       []CouponOption `json:"options"`
+	BestCouponID  *uint          `json:"best_coupon_id,omitempty"`
+}
+
+// CouponOptions 试算用户所有可用券在该待支付订单上的优惠，按最优排序。
+func (s *OrderService) CouponOptions(ctx context.Context, userID, orderID uint) (*OrderCouponOptions, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrOrderNotFound
+	}
+	if order.Status != model.OrderStatusUnpaid {
+		return nil, ErrOrderNotPayable
+	}
+	if order.IsVIP() {
+		return nil, ErrVIPOrderNoCoupon
+	}
+
+	price, err := orderPrice(ctx, s.productRepo, order)
+	if err != nil {
+		return nil, err
+	}
+	options, err := s.couponSvc.EvaluateCoupons(ctx, userID, order.ID, price)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OrderCouponOptions{OrderID: order.ID, AmountCents: price.Payable(), OriginalCents: price.OriginalCents, Options: options}
+	if len(options) > 0 && options[0].Usable && options[0].SavedCents > 0 {
+		best := options[0].Coupon.ID
+		result.BestCouponID = &best
+	}
+	return result, nil
+}
+
+// AutoApplyBestCoupon 为待支付订单自动使用最优券；无可用券时返回 nil。
+func (s *OrderService) AutoApplyBestCoupon(ctx context.Context, userID, orderID uint) (*OrderWithPayment, error) {
+	opts, err := s.CouponOptions(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if opts.BestCouponID == nil {
+		return nil, nil
+	}
+	return s.ApplyCoupon(ctx, userID, orderID, opts.BestCouponID)
+}
+
 // ListOrders 查询订单列表，可选状态过滤。
 func (s *OrderService) ListOrders(ctx context.Context, userID uint, status *model.OrderStatus, page, pageSize int) ([]model.Order, int64, error) {
 	if ctx == nil {
@@ -222,7 +375,7 @@ func (s *OrderService) GetOrderWithPayment(ctx context.Context, userID, orderID
 		return nil, err
 	}
 
-	if payment == nil && errors.Is(err, gorm.ErrRecordNotFound) {
+	if payment == nil && errors.Is(err, gorm.ErrRecordNotFound) && !order.IsVIP() {
 		// 补偿创建支付单，避免页面缺少 payment_id
 		product, pErr := s.productRepo.GetByID(ctx, order.ProductID)
 		if pErr != nil {
@@ -320,7 +473,8 @@ func (s *OrderService) PollOrder(ctx context.Context, userID uint, orderNum stri
 	return &OrderPollResult{Status: PendingStatusReady, OrderNum: orderNum, PaymentID: pid, Order: order}, nil
 }
 
-// HandlePaymentResult 幂等处理支付回调：乐观锁更新支付单，条件更新订单状态，并在支付成功时刷新缓存库存。
+// HandlePaymentResult 幂等处理支付回调：乐观锁更新支付单，条件更新订单状态，并在支付成功时刷新缓存库存、发放积分；
+// VIP 订单在支付成功时开通会员。已支付订单再回调退款时扣回积分与成长值，VIP 订单缩短或撤销会员。
 func (s *OrderService) HandlePaymentResult(ctx context.Context, paymentID string, targetStatus model.PaymentStatus, notifyData string) (*OrderWithPayment, error) {
 	if targetStatus != model.PaymentStatusPaid && targetStatus != model.PaymentStatusFailed && targetStatus != model.PaymentStatusRefunded {
 		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPayStatus, targetStatus)
@@ -333,6 +487,8 @@ func (s *OrderService) HandlePaymentResult(ctx context.Context, paymentID string
 	if ctx == nil {
 		return nil, fmt.Errorf("context is nil")
 	}
+	// 事务外读取 VIP 配置（走缓存），避免在事务内回源；读取失败时使用默认配置，不拒绝支付回调
+	vipCfg := s.vipConfigSvc.CurrentOrDefault(ctx)
 
 	// 事务防脏写
 	// 事务的四大特性
@@ -356,6 +512,11 @@ func (s *OrderService) HandlePaymentResult(ctx context.Context, paymentID string
 		if err != nil {
 			return err
 		}
+		if rows == 0 && targetStatus == model.PaymentStatusRefunded && payment.Status == model.PaymentStatusPaid {
+			if err := refundPaidOrder(ctx, tx, payment, notifyData, vipCfg); err != nil {
+				return err
+			}
+		}
 		if rows == 0 {
 			// 幂等命中或已处理，返回当前状态
 			updated, getErr := txPaymentRepo.GetByPaymentID(ctx, paymentID)
@@ -371,11 +532,11 @@ func (s *OrderService) HandlePaymentResult(ctx context.Context, paymentID string
 		}
 
 		// 支付状态变更成功后，尝试更新订单状态
-		orderStatus := model.OrderStatusFailed
 		if targetStatus == model.PaymentStatusPaid {
-			orderStatus = model.OrderStatusPaid
-		}
-		if _, err := txOrderRepo.UpdateStatusIfMatch(ctx, payment.OrderID, model.OrderStatusUnpaid, orderStatus); err != nil {
+			if _, err := txOrderRepo.MarkPaid(ctx, payment.OrderID, time.Now()); err != nil {
+				return err
+			}
+		} else if _, err := txOrderRepo.UpdateStatusIfMatch(ctx, payment.OrderID, model.OrderStatusUnpaid, model.OrderStatusFailed); err != nil {
 			return err
 		}
 		order, err := txOrderRepo.GetByID(ctx, payment.OrderID)
@@ -386,31 +547,65 @@ func (s *OrderService) HandlePaymentResult(ctx context.Context, paymentID string
 		if err != nil {
 			return err
 		}
-		if targetStatus == model.PaymentStatusPaid {
+		if targetStatus == model.PaymentStatusPaid && order.IsVIP() {
+			// VIP 订单：开通/续费会员并发放对应等级月度券，不计入成长值
+			level, vErr := activatePaidVIP(ctx, tx, order, time.Now())
+			if vErr != nil {
+				return vErr
+			}
+			// 月度券发放失败只记录日志，不影响会员开通
+			if cErr := NewCouponService(tx).IssueVIPMonthly(ctx, order.UserID, level); cErr != nil {
+				slog.ErrorContext(ctx, "开通会员后发放月度券失败", slog.Uint64("order_id", uint64(order.ID)), slog.Uint64("user_id", uint64(order.UserID)), slog.Any("err", cErr))
+			}
+		} else if targetStatus == model.PaymentStatusPaid {
 			if product, pErr := txProductRepo.GetByID(ctx, order.ProductID); pErr == nil {
 				// 异步刷新缓存库存（worker pool）
 				refreshStockCacheAsync(product.ID, product.Stock)
 				invalidateProductInfoCache(product.ID)
 			}
-			// 成长值累积：按支付金额计算成长等级
-			txUserRepo := repository.NewUserRepo(tx)
-			user, uErr := txUserRepo.GetByIDForUpdate(ctx, order.UserID)
+			// 成长值累积：按滚动窗口实付重算成长等级
+			now := time.Now()
+			user, uErr := repository.NewUserRepo(tx).GetByIDForUpdate(ctx, order.UserID)
 			if uErr != nil {
 				return uErr
 			}
-			newTotal := user.TotalSpentCents + payment.AmountCents
-			newLevel := vip.CalcGrowthLevel(newTotal)
-			if uErr := txUserRepo.UpdateGrowth(ctx, order.UserID, newTotal, newLevel); uErr != nil {
+			// 积分按下单前的生效等级（成长与付费取高）计算倍率
+			level := user.GrowthLevel
+			user.TotalSpentCents += payment.AmountCents
+			if _, uErr := syncGrowthLevel(ctx, tx, user, vipCfg.Thresholds(), 0, now); uErr != nil {
 				return uErr
 			}
 			// 成长等级提升后发放月度优惠券
 			couponSvc := NewCouponService(tx)
-			_ = couponSvc.IssueVIPMonthly(ctx, order.UserID, newLevel)
+			if cErr := couponSvc.IssueVIPMonthly(ctx, order.UserID, user.GrowthLevel); cErr != nil {
+				slog.ErrorContext(ctx, "成长等级月度券发放失败", slog.Uint64("order_id", uint64(order.ID)), slog.Uint64("user_id", uint64(order.UserID)), slog.Any("err", cErr))
+			}
+			if pv, pvErr := repository.NewPaidVIPRepo(tx).GetByUser(ctx, order.UserID); pvErr == nil && pv.ExpiredAt.After(now) {
+				level = max(level, pv.Level)
+			}
+			if pErr := awardOrderPoints(ctx, tx, order, payment.AmountCents, level, now); pErr != nil {
+				return pErr
+			}
+			// 受邀用户首单：为邀请双方发放奖励
+			if rErr := rewardReferral(ctx, tx, order, now); rErr != nil {
+				return rErr
+			}
 		} else {
-			// 支付失败/退款则释放已占用的优惠券，避免用户券被锁死
+			// 支付失败/退款则释放已占用的优惠券与抵扣积分，避免被锁死
 			if releaseErr := txUserCouponRepo.ReleaseByOrder(ctx, order.ID); releaseErr != nil {
 				return releaseErr
 			}
+			if releaseErr := releaseOrderPoints(ctx, tx, order, time.Now()); releaseErr != nil {
+				return releaseErr
+			}
+		}
+		if targetStatus == model.PaymentStatusPaid {
+			notify(ctx, tx, Notice{UserID: order.UserID, Kind: model.NotifyOrderPaid, Data: map[string]any{
+				"order_num": order.OrderNum, "amount": yuan(payment.AmountCents),
+			}})
+			if wErr := enqueueOrderWebhook(ctx, tx, model.WebhookOrderPaid, order, payment.AmountCents); wErr != nil {
+				return wErr
+			}
 		}
 		result = OrderWithPayment{
 			Order:   order,
@@ -423,11 +618,57 @@ func (s *OrderService) HandlePaymentResult(ctx context.Context, paymentID string
 		return nil, err
 	}
 	if result.Order != nil && result.Payment != nil {
+		if targetStatus != model.PaymentStatusFailed {
+			invalidateVIPLevelCache(ctx, result.Order.UserID)
+		}
 		publishOrderEvent(result.Order.UserID, result.Order.ID, result.Order.Status, result.Payment.Status)
 	}
 	return &result, nil
 }
 
+// refundPaidOrder 已支付订单退款：扣回积分与成长值，收回邀请首单奖励，VIP 订单缩短或撤销会员。商品库存不回补。
+func refundPaidOrder(ctx context.Context, tx *gorm.DB, payment *model.Payment, notifyData string, vipCfg *VIPConfig) error {
+	txOrderRepo := repository.NewOrderRepo(tx)
+	order, err := txOrderRepo.GetByIDForUpdate(ctx, payment.OrderID)
+	if err != nil {
+		return err
+	}
+	refunded, err := repository.NewPaymentRepo(tx).UpdateStatusByPaymentIDIfMatch(ctx, payment.PaymentID, model.PaymentStatusPaid, model.PaymentStatusRefunded, notifyData)
+	if err != nil || refunded == 0 {
+		return err
+	}
+	if _, err := txOrderRepo.UpdateStatusIfMatch(ctx, order.ID, model.OrderStatusPaid, model.OrderStatusRefunded); err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := reverseOrderPoints(ctx, tx, order, now); err != nil {
+		return err
+	}
+	notify(ctx, tx, Notice{UserID: order.UserID, Kind: model.NotifyOrderRefunded, Data: map[string]any{
+		"order_num": order.OrderNum, "amount": yuan(payment.AmountCents),
+	}})
+	if err := enqueueOrderWebhook(ctx, tx, model.WebhookOrderRefunded, order, payment.AmountCents); err != nil {
+		return err
+	}
+	if order.IsVIP() {
+		return refundPaidVIP(ctx, tx, order, now)
+	}
+	if err := revokeReferral(ctx, tx, order, now); err != nil {
+		return err
+	}
+
+	user, err := repository.NewUserRepo(tx).GetByIDForUpdate(ctx, order.UserID)
+	if err != nil {
+		return err
+	}
+	user.TotalSpentCents -= payment.AmountCents
+	if user.TotalSpentCents < 0 {
+		user.TotalSpentCents = 0
+	}
+	_, err = syncGrowthLevel(ctx, tx, user, vipCfg.Thresholds(), payment.AmountCents, now)
+	return err
+}
+
 func (s *OrderService) CancelExpiredOrders(ctx context.Context, ttl time.Duration, batchSize int) (int, error) {
 	if ctx == nil {
 		return 0, fmt.Errorf("context is nil")
@@ -446,7 +687,7 @@ func (s *OrderService) CancelExpiredOrders(ctx context.Context, ttl time.Duratio
 
 	cancelled := 0
 	for _, order := range staleOrders {
-		ok, cancelErr := s.cancelOrder(ctx, order.OrderNum, "auto_cancel_timeout")
+		ok, cancelErr := s.cancelOrder(ctx, order.OrderNum, cancelReasonTimeout)
 		if cancelErr != nil {
 			return cancelled, cancelErr
 		}
@@ -464,6 +705,8 @@ func (s *OrderService) cancelOrder(ctx context.Context, orderNum, notifyData str
 		productID     uint
 		productStock  int
 		paymentStatus model.PaymentStatus
+		offered       *model.WaitlistEntry // 回流名额分配给的候补用户
+		penalty       *penaltyOutcome      // 超时未支付新触发的处罚
 	}
 
 	var snapshot cancelSnapshot
@@ -513,6 +756,38 @@ func (s *OrderService) cancelOrder(ctx context.Context, orderNum, notifyData str
 		if err := txUserCouponRepo.ReleaseByOrder(ctx, order.ID); err != nil {
 			return err
 		}
+		if err := releaseOrderPoints(ctx, tx, order, time.Now()); err != nil {
+			return err
+		}
+		notify(ctx, tx, Notice{UserID: order.UserID, Kind: model.NotifyOrderCancelled, Data: map[string]any{"order_num": order.OrderNum}})
+		snapshot.orderID = order.ID
+		snapshot.userID = order.UserID
+		var amountCents int64
+		if payment != nil {
+			snapshot.paymentStatus = payment.Status
+			amountCents = payment.AmountCents
+		}
+		if err := enqueueOrderWebhook(ctx, tx, model.WebhookOrderCancelled, order, amountCents); err != nil {
+			return err
+		}
+		if order.IsVIP() {
+			return nil
+		}
+		if notifyData == cancelReasonTimeout {
+			if snapshot.penalty, err = recordUnpaidTimeout(ctx, tx, order, time.Now()); err != nil {
+				return err
+			}
+		}
+		snapshot.productID = order.ProductID
+		// 有候补用户时名额直接预留给队首，不回到公开库存
+		offered, err := offerWaitlist(ctx, tx, order.ProductID, time.Now())
+		if err != nil {
+			return err
+		}
+		if offered != nil {
+			snapshot.offered = offered
+			return nil
+		}
 		if _, err := txProductRepo.IncreaseStockDB(ctx, order.ProductID, 1); err != nil {
 			return err
 		}
@@ -520,14 +795,7 @@ func (s *OrderService) cancelOrder(ctx context.Context, orderNum, notifyData str
 		if err != nil {
 			return err
 		}
-
-		snapshot.orderID = order.ID
-		snapshot.userID = order.UserID
-		snapshot.productID = order.ProductID
 		snapshot.productStock = product.Stock
-		if payment != nil {
-			snapshot.paymentStatus = payment.Status
-		}
 		return nil
 	})
 	if errors.Is(err, errOrderAlreadySettled) {
@@ -540,18 +808,25 @@ func (s *OrderService) cancelOrder(ctx context.Context, orderNum, notifyData str
 		return false, nil
 	}
 
-	stockKey := fmt.Sprintf("product:stock:%d", snapshot.productID)
-	userSetKey := fmt.Sprintf("product:users:%d", snapshot.productID)
-	_ = redis.RDB.Incr(ctx, stockKey).Err()
-	_ = redis.RDB.SRem(ctx, userSetKey, snapshot.userID).Err()
+	applyPenaltyOutcome(ctx, snapshot.penalty)
+	if snapshot.productID > 0 {
+		stockKey := fmt.Sprintf("product:stock:%d", snapshot.productID)
+		userSetKey := fmt.Sprintf("product:users:%d", snapshot.productID)
+		_ = redis.RDB.SRem(ctx, userSetKey, snapshot.userID).Err()
+		if snapshot.offered != nil {
+			publishWaitlistEvent(snapshot.offered.UserID, snapshot.productID, snapshot.offered.Status, snapshot.offered.OfferExpiresAt)
+		} else {
+			_ = redis.RDB.Incr(ctx, stockKey).Err()
+			refreshStockCacheAsync(snapshot.productID, snapshot.productStock)
+		}
+		invalidateProductInfoCache(snapshot.productID)
+	}
 	_ = setPendingOrder(ctx, PendingOrderCache{
 		OrderNum: orderNum,
 		OrderID:  snapshot.orderID,
 		Status:   PendingStatusFailed,
 		Message:  "订单已超时取消",
 	})
-	refreshStockCacheAsync(snapshot.productID, snapshot.productStock)
-	invalidateProductInfoCache(snapshot.productID)
 	publishOrderEvent(snapshot.userID, snapshot.orderID, model.OrderStatusCancelled, snapshot.paymentStatus)
 	return true, nil
 }
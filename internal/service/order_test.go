@@ -7,6 +7,7 @@ import (
 	"SneakerFlash/internal/repository"
 	"SneakerFlash/internal/testutil"
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -249,3 +250,165 @@ func TestOrderService_CancelExpiredOrdersSkipsPaidPayment(t *testing.T) {
 		t.Fatalf("product stock = %d, want %d", product.Stock, fixtures.product.Stock)
 	}
 }
+
+func TestOrderService_CouponOptionsAndAutoApply(t *testing.T) {
+	svc, fixtures := newOrderServiceForTest(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	templates := []*model.Coupon{
+		{Type: model.CouponTypeFullCut, Title: "满1000减100", AmountCents: 10000, MinSpendCents: 100000},
+		{Type: model.CouponTypeDiscount, Title: "八五折", DiscountRate: 85},
+		{Type: model.CouponTypeFullCut, Title: "满2000减500", AmountCents: 50000, MinSpendCents: 200000},
+	}
+	ucs := make([]*model.UserCoupon, 0, len(templates))
+	for _, tpl := range templates {
+		tpl.ValidFrom = now.Add(-time.Hour)
+		tpl.ValidTo = now.Add(time.Hour)
+		tpl.Status = model.CouponTemplateStatusActive
+		if err := db.DB.Create(tpl).Error; err != nil {
+			t.Fatalf("create coupon: %v", err)
+		}
+		uc := &model.UserCoupon{
+			UserID:    fixtures.user.ID,
+			CouponID:  tpl.ID,
+			Status:    model.CouponStatusAvailable,
+			ValidFrom: tpl.ValidFrom,
+			ValidTo:   tpl.ValidTo,
+			IssuedAt:  now,
+		}
+		if err := db.DB.Create(uc).Error; err != nil {
+			t.Fatalf("create user coupon: %v", err)
+		}
+		ucs = append(ucs, uc)
+	}
+
+	opts, err := svc.CouponOptions(ctx, fixtures.user.ID, fixtures.order.ID)
+	if err != nil {
+		t.Fatalf("CouponOptions() error = %v", err)
+	}
+	if opts.AmountCents != 129900 || len(opts.Options) != 3 {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+	// 八五折省 19485 > 满减 10000 > 未达门槛
+	if opts.Options[0].Coupon.ID != ucs[1].ID || opts.Options[0].DiscountedCents != 110415 {
+		t.Fatalf("best option = %+v", opts.Options[0])
+	}
+	if opts.Options[1].Coupon.ID != ucs[0].ID || !opts.Options[1].Usable {
+		t.Fatalf("second option = %+v", opts.Options[1])
+	}
+	if opts.Options[2].Usable || opts.Options[2].Reason != ErrCouponBelowThreshold.Error() {
+		t.Fatalf("unusable option = %+v", opts.Options[2])
+	}
+
+	result, err := svc.AutoApplyBestCoupon(ctx, fixtures.user.ID, fixtures.order.ID)
+	if err != nil {
+		t.Fatalf("AutoApplyBestCoupon() error = %v", err)
+	}
+	if result == nil || result.Coupon == nil || result.Coupon.ID != ucs[1].ID || result.Payment.AmountCents != 110415 {
+		t.Fatalf("unexpected auto apply result: %+v", result)
+	}
+
+	// 已绑定在订单上的券仍出现在选项中，便于换券
+	opts, err = svc.CouponOptions(ctx, fixtures.user.ID, fixtures.order.ID)
+	if err != nil {
+		t.Fatalf("CouponOptions() after apply error = %v", err)
+	}
+	if opts.BestCouponID == nil || *opts.BestCouponID != ucs[1].ID {
+		t.Fatalf("best coupon after apply = %v", opts.BestCouponID)
+	}
+}
+
+func TestOrderService_VIPOrderLifecycle(t *testing.T) {
+	svc, fixtures := newOrderServiceForTest(t)
+	ctx := context.Background()
+	vipSvc := NewVIPService(db.DB, repository.NewUserRepo(db.DB), NewCouponService(db.DB))
+	paidVIPRepo := repository.NewPaidVIPRepo(db.DB)
+	userID := fixtures.user.ID
+
+	first, err := vipSvc.PurchasePaidVIP(ctx, userID, 1)
+	if err != nil {
+		t.Fatalf("PurchasePaidVIP() error = %v", err)
+	}
+	if !first.Order.IsVIP() || first.Order.Status != model.OrderStatusUnpaid || first.Payment.AmountCents != 3000 {
+		t.Fatalf("vip order = %+v, payment = %+v", first.Order, first.Payment)
+	}
+	// 未支付前不开通
+	if _, err := paidVIPRepo.GetByUser(ctx, userID); err == nil {
+		t.Fatalf("paid vip should not exist before payment")
+	}
+	if _, err := svc.ApplyCoupon(ctx, userID, first.Order.ID, nil); !errors.Is(err, ErrVIPOrderNoCoupon) {
+		t.Fatalf("ApplyCoupon() error = %v, want %v", err, ErrVIPOrderNoCoupon)
+	}
+
+	if _, err := svc.HandlePaymentResult(ctx, first.Payment.PaymentID, model.PaymentStatusPaid, "ok"); err != nil {
+		t.Fatalf("HandlePaymentResult(first) error = %v", err)
+	}
+	pv, err := paidVIPRepo.GetByUser(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByUser() error = %v", err)
+	}
+	firstExpiry := pv.ExpiredAt
+	if pv.Level != 3 || time.Until(firstExpiry) < 29*24*time.Hour {
+		t.Fatalf("paid vip after first payment = %+v", pv)
+	}
+
+	// 有效期内续费，时长叠加
+	second, err := vipSvc.PurchasePaidVIP(ctx, userID, 1)
+	if err != nil {
+		t.Fatalf("PurchasePaidVIP(second) error = %v", err)
+	}
+	if _, err := svc.HandlePaymentResult(ctx, second.Payment.PaymentID, model.PaymentStatusPaid, "ok"); err != nil {
+		t.Fatalf("HandlePaymentResult(second) error = %v", err)
+	}
+	pv, _ = paidVIPRepo.GetByUser(ctx, userID)
+	if got := pv.ExpiredAt.Sub(firstExpiry); got != 30*24*time.Hour {
+		t.Fatalf("stacked duration = %v, want 720h", got)
+	}
+
+	// 退款首单：扣回剩余时长，续费时段前移
+	refunded, err := svc.HandlePaymentResult(ctx, first.Payment.PaymentID, model.PaymentStatusRefunded, "refund")
+	if err != nil {
+		t.Fatalf("HandlePaymentResult(refund first) error = %v", err)
+	}
+	if refunded.Order.Status != model.OrderStatusRefunded || refunded.Payment.Status != model.PaymentStatusRefunded {
+		t.Fatalf("refunded order = %+v, payment = %+v", refunded.Order, refunded.Payment)
+	}
+	pv, _ = paidVIPRepo.GetByUser(ctx, userID)
+	if left := time.Until(pv.ExpiredAt); left > 30*24*time.Hour || left < 29*24*time.Hour {
+		t.Fatalf("expiry after first refund = %v", pv.ExpiredAt)
+	}
+
+	// 重复退款回调幂等
+	if _, err := svc.HandlePaymentResult(ctx, first.Payment.PaymentID, model.PaymentStatusRefunded, "refund"); err != nil {
+		t.Fatalf("HandlePaymentResult(refund again) error = %v", err)
+	}
+
+	// 全部退款后撤销
+	if _, err := svc.HandlePaymentResult(ctx, second.Payment.PaymentID, model.PaymentStatusRefunded, "refund"); err != nil {
+		t.Fatalf("HandlePaymentResult(refund second) error = %v", err)
+	}
+	pv, _ = paidVIPRepo.GetByUser(ctx, userID)
+	if pv.ExpiredAt.After(time.Now()) {
+		t.Fatalf("paid vip should be revoked, expired_at = %v", pv.ExpiredAt)
+	}
+
+	// 超时取消的 VIP 订单不回补商品库存
+	third, err := vipSvc.PurchasePaidVIP(ctx, userID, 1)
+	if err != nil {
+		t.Fatalf("PurchasePaidVIP(third) error = %v", err)
+	}
+	if err := db.DB.Model(&model.Order{}).Where("id = ?", third.Order.ID).Update("created_at", time.Now().Add(-20*time.Minute)).Error; err != nil {
+		t.Fatalf("backdate order: %v", err)
+	}
+	if _, err := svc.CancelExpiredOrders(ctx, 15*time.Minute, 10); err != nil {
+		t.Fatalf("CancelExpiredOrders() error = %v", err)
+	}
+	var product model.Product
+	if err := db.DB.First(&product, fixtures.product.ID).Error; err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+	if product.Stock != fixtures.product.Stock {
+		t.Fatalf("product stock = %d, want %d", product.Stock, fixtures.product.Stock)
+	}
+}
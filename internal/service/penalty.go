@@ -0,0 +1,433 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSeckillPenalized           = errors.New("近期多次抢购未支付，暂停抢购")
+	ErrPenaltyNotFound            = errors.New("当前没有生效中的处罚")
+	ErrPenaltyAppealPending       = errors.New("申诉正在处理中")
+	ErrPenaltyAppealNotPending    = errors.New("没有待处理的申诉")
+	ErrPenaltyAppealStatusInvalid = errors.New("申诉状态无效")
+)
+
+// cancelReasonTimeout 超时自动取消的原因，只有该原因计入未支付处罚。
+const cancelReasonTimeout = "auto_cancel_timeout"
+
+// penaltyRules 未支付处罚规则，来自配置文件 penalty 段。
+type penaltyRules struct {
+	window          time.Duration
+	windowDays      int
+	demoteThreshold int
+	banThreshold    int
+	banDuration     time.Duration
+	grayThreshold   int
+}
+
+func loadPenaltyRules() penaltyRules {
+	cfg := config.Conf.Penalty
+	rules := penaltyRules{
+		windowDays:      cfg.WindowDays,
+		demoteThreshold: cfg.DemoteThreshold,
+		banThreshold:    cfg.BanThreshold,
+		grayThreshold:   cfg.GrayThreshold,
+	}
+	if rules.windowDays <= 0 {
+		rules.windowDays = 30
+	}
+	if rules.demoteThreshold <= 0 {
+		rules.demoteThreshold = 2
+	}
+	if rules.banThreshold <= 0 {
+		rules.banThreshold = 3
+	}
+	if rules.grayThreshold <= 0 {
+		rules.grayThreshold = 5
+	}
+	banDays := cfg.BanDays
+	if banDays <= 0 {
+		banDays = 7
+	}
+	rules.window = time.Duration(rules.windowDays) * 24 * time.Hour
+	rules.banDuration = time.Duration(banDays) * 24 * time.Hour
+	return rules
+}
+
+// windowStart 计数起点：窗口起点与最近一次重置取较晚者。
+func (r penaltyRules) windowStart(penalty *model.UserPenalty, now time.Time) time.Time {
+	since := now.Add(-r.window)
+	if penalty != nil && penalty.ResetAt != nil && penalty.ResetAt.After(since) {
+		since = *penalty.ResetAt
+	}
+	return since
+}
+
+func penaltyBanKey(userID uint) string {
+	return fmt.Sprintf("penalty:ban:%d", userID)
+}
+
+// penaltyOutcome 本次超时新触发的处罚，事务提交后同步到 Redis。
+type penaltyOutcome struct {
+	userID      uint
+	bannedUntil *time.Time
+	graylist    bool
+}
+
+// recordUnpaidTimeout 在取消事务内记录超时，并按窗口内次数升级处罚；未触发新处罚时返回 nil。
+func recordUnpaidTimeout(ctx context.Context, tx *gorm.DB, order *model.Order, now time.Time) (*penaltyOutcome, error) {
+	repo := repository.NewPenaltyRepo(tx)
+	created, err := repo.RecordTimeout(ctx, &model.UnpaidTimeout{
+		UserID:    order.UserID,
+		OrderID:   order.ID,
+		OrderNum:  order.OrderNum,
+		ProductID: order.ProductID,
+	})
+	if err != nil || !created {
+		return nil, err
+	}
+	penalty, err := repo.GetOrCreateForUpdate(ctx, order.UserID)
+	if err != nil {
+		return nil, err
+	}
+	rules := loadPenaltyRules()
+	count, err := repo.CountTimeoutsSince(ctx, order.UserID, rules.windowStart(penalty, now))
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{"timeout_count": int(count)}
+	outcome := &penaltyOutcome{userID: order.UserID}
+	if int(count) >= rules.banThreshold {
+		until := now.Add(rules.banDuration)
+		updates["banned_until"] = until
+		outcome.bannedUntil = &until
+	}
+	if int(count) >= rules.grayThreshold && !penalty.Graylisted {
+		updates["graylisted"] = true
+		outcome.graylist = true
+	}
+	if err := repo.Update(ctx, order.UserID, updates); err != nil {
+		return nil, err
+	}
+	if outcome.bannedUntil == nil && !outcome.graylist {
+		return nil, nil
+	}
+
+	data := map[string]any{"days": rules.windowDays, "count": count, "graylisted": outcome.graylist, "banned_until": ""}
+	if outcome.bannedUntil != nil {
+		data["banned_until"] = notifyTime(*outcome.bannedUntil)
+	}
+	notify(ctx, tx, Notice{UserID: order.UserID, Kind: model.NotifyPenaltyApplied, Data: data})
+	return outcome, nil
+}
+
+// applyPenaltyOutcome 同步暂停抢购标记与灰名单，失败只记录日志，以数据库状态为准。
+func applyPenaltyOutcome(ctx context.Context, outcome *penaltyOutcome) {
+	if outcome == nil {
+		return
+	}
+	if outcome.bannedUntil != nil {
+		if ttl := time.Until(*outcome.bannedUntil); ttl > 0 {
+			if err := redis.RDB.Set(ctx, penaltyBanKey(outcome.userID), 1, ttl).Err(); err != nil {
+				slog.WarnContext(ctx, "写入暂停抢购标记失败", slog.Uint64("user_id", uint64(outcome.userID)), slog.Any("err", err))
+			}
+		}
+	}
+	if outcome.graylist {
+		if err := NewRiskService(redis.RDB).AddPenaltyGraylist(ctx, outcome.userID); err != nil {
+			slog.WarnContext(ctx, "自动加入灰名单失败", slog.Uint64("user_id", uint64(outcome.userID)), slog.Any("err", err))
+		}
+	}
+}
+
+// checkSeckillBan 抢购前检查暂停标记，走 Redis 避免热点路径查库。
+func checkSeckillBan(ctx context.Context, userID uint) error {
+	banned, err := redis.RDB.Exists(ctx, penaltyBanKey(userID)).Result()
+	if err != nil {
+		return ErrSeckillBusy
+	}
+	if banned > 0 {
+		return ErrSeckillPenalized
+	}
+	return nil
+}
+
+// penaltyDemoted 窗口内超时次数达到降级门槛时，候补分配排在未受罚用户之后。
+func penaltyDemoted(ctx context.Context, db *gorm.DB, userID uint, now time.Time) (bool, error) {
+	repo := repository.NewPenaltyRepo(db)
+	penalty, err := repo.Get(ctx, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	rules := loadPenaltyRules()
+	count, err := repo.CountTimeoutsSince(ctx, userID, rules.windowStart(penalty, now))
+	if err != nil {
+		return false, err
+	}
+	return int(count) >= rules.demoteThreshold, nil
+}
+
+// PenaltyView 用户当前的未支付处罚状态。
+type PenaltyView struct {
+	UserID         uint                      `json:"user_id"`
+	WindowDays     int                       `json:"window_days"`
+	TimeoutCount   int64                     `json:"timeout_count"` // 窗口内超时次数
+	Demoted        bool                      `json:"demoted"`       // 候补排队降级
+	BannedUntil    *time.Time                `json:"banned_until,omitempty"`
+	Graylisted     bool                      `json:"graylisted"`
+	AppealStatus   model.PenaltyAppealStatus `json:"appeal_status,omitempty"`
+	AppealReason   string                    `json:"appeal_reason,omitempty"`
+	AppealReply    string                    `json:"appeal_reply,omitempty"`
+	AppealedAt     *time.Time                `json:"appealed_at,omitempty"`
+	RecentTimeouts []model.UnpaidTimeout     `json:"recent_timeouts,omitempty"` // 仅管理台返回
+}
+
+// Active 是否存在生效中的处罚。
+func (v *PenaltyView) Active() bool {
+	return v.Demoted || v.BannedUntil != nil || v.Graylisted
+}
+
+// PenaltyService 未支付处罚：超时计数、暂停抢购、灰名单、候补降级与申诉。
+type PenaltyService struct {
+	db      *gorm.DB
+	repo    *repository.PenaltyRepo
+	riskSvc *RiskService
+}
+
+func NewPenaltyService(db *gorm.DB, riskSvc *RiskService) *PenaltyService {
+	return &PenaltyService{
+		db:      db,
+		repo:    repository.NewPenaltyRepo(db),
+		riskSvc: riskSvc,
+	}
+}
+
+// Status 查询用户当前处罚状态。
+func (s *PenaltyService) Status(ctx context.Context, userID uint) (*PenaltyView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	penalty, err := s.repo.Get(ctx, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return s.view(ctx, s.repo, userID, penalty, time.Now())
+}
+
+// Detail 管理台查看处罚详情，附带最近的超时订单。
+func (s *PenaltyService) Detail(ctx context.Context, userID uint) (*PenaltyView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	view, err := s.Status(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if view.RecentTimeouts, err = s.repo.ListTimeouts(ctx, userID, 20); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// List 管理台分页查询处罚记录，activeOnly 只返回暂停期内或在灰名单中的用户。
+func (s *PenaltyService) List(ctx context.Context, appealStatus string, activeOnly bool, page, pageSize int) ([]model.UserPenalty, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	appealStatus = strings.TrimSpace(appealStatus)
+	switch model.PenaltyAppealStatus(appealStatus) {
+	case model.PenaltyAppealNone, model.PenaltyAppealPending, model.PenaltyAppealApproved, model.PenaltyAppealRejected:
+	default:
+		return nil, 0, ErrPenaltyAppealStatusInvalid
+	}
+	filter := repository.PenaltyFilter{AppealStatus: appealStatus, Page: page, PageSize: pageSize}
+	if activeOnly {
+		now := time.Now()
+		filter.ActiveAt = &now
+	}
+	return s.repo.List(ctx, filter)
+}
+
+// Appeal 用户对生效中的处罚提交申诉，同一时间只能有一条待处理申诉。
+func (s *PenaltyService) Appeal(ctx context.Context, userID uint, reason string) (*PenaltyView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("申诉理由不能为空")
+	}
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewPenaltyRepo(tx)
+		penalty, err := txRepo.GetForUpdate(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPenaltyNotFound
+			}
+			return err
+		}
+		view, err := s.view(ctx, txRepo, userID, penalty, now)
+		if err != nil {
+			return err
+		}
+		if !view.Active() {
+			return ErrPenaltyNotFound
+		}
+		if penalty.AppealStatus == model.PenaltyAppealPending {
+			return ErrPenaltyAppealPending
+		}
+		return txRepo.Update(ctx, userID, map[string]any{
+			"appeal_status": model.PenaltyAppealPending,
+			"appeal_reason": reason,
+			"appeal_reply":  "",
+			"appealed_at":   now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Status(ctx, userID)
+}
+
+// ResolveAppeal 处理待处理申诉；通过时解除全部处罚，此前的超时不再计数。
+func (s *PenaltyService) ResolveAppeal(ctx context.Context, userID uint, approve bool, reply string) (*PenaltyView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	reply = strings.TrimSpace(reply)
+	now := time.Now()
+	var lifted *model.UserPenalty
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewPenaltyRepo(tx)
+		penalty, err := txRepo.GetForUpdate(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPenaltyAppealNotPending
+			}
+			return err
+		}
+		if penalty.AppealStatus != model.PenaltyAppealPending {
+			return ErrPenaltyAppealNotPending
+		}
+		if !approve {
+			if err := txRepo.Update(ctx, userID, map[string]any{
+				"appeal_status": model.PenaltyAppealRejected,
+				"appeal_reply":  reply,
+			}); err != nil {
+				return err
+			}
+			notify(ctx, tx, Notice{UserID: userID, Kind: model.NotifyPenaltyAppealRejected, Data: map[string]any{"reply": reply}})
+			return nil
+		}
+		lifted = penalty
+		return s.reset(ctx, tx, userID, now, map[string]any{
+			"appeal_status": model.PenaltyAppealApproved,
+			"appeal_reply":  reply,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.lift(ctx, lifted)
+	return s.Status(ctx, userID)
+}
+
+// Reset 管理员直接解除处罚并清零计数，待处理申诉一并视为通过。
+func (s *PenaltyService) Reset(ctx context.Context, userID uint, reason string) (*PenaltyView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	reason = strings.TrimSpace(reason)
+	now := time.Now()
+	var lifted *model.UserPenalty
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		penalty, err := repository.NewPenaltyRepo(tx).GetForUpdate(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPenaltyNotFound
+			}
+			return err
+		}
+		updates := map[string]any{}
+		if penalty.AppealStatus == model.PenaltyAppealPending {
+			updates["appeal_status"] = model.PenaltyAppealApproved
+			updates["appeal_reply"] = reason
+		}
+		lifted = penalty
+		return s.reset(ctx, tx, userID, now, updates)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.lift(ctx, lifted)
+	return s.Status(ctx, userID)
+}
+
+// reset 清零计数并解除暂停与灰名单标记，extra 为附带更新的申诉字段。
+func (s *PenaltyService) reset(ctx context.Context, tx *gorm.DB, userID uint, now time.Time, extra map[string]any) error {
+	updates := map[string]any{
+		"timeout_count": 0,
+		"banned_until":  nil,
+		"graylisted":    false,
+		"reset_at":      now,
+	}
+	for k, v := range extra {
+		updates[k] = v
+	}
+	if err := repository.NewPenaltyRepo(tx).Update(ctx, userID, updates); err != nil {
+		return err
+	}
+	notify(ctx, tx, Notice{UserID: userID, Kind: model.NotifyPenaltyLifted})
+	return nil
+}
+
+// lift 事务提交后清除暂停标记，并移出由处罚加入的灰名单。
+func (s *PenaltyService) lift(ctx context.Context, penalty *model.UserPenalty) {
+	if penalty == nil {
+		return
+	}
+	if err := redis.RDB.Del(ctx, penaltyBanKey(penalty.UserID)).Err(); err != nil {
+		slog.WarnContext(ctx, "清除暂停抢购标记失败", slog.Uint64("user_id", uint64(penalty.UserID)), slog.Any("err", err))
+	}
+	if penalty.Graylisted && s.riskSvc != nil {
+		if err := s.riskSvc.RemovePenaltyGraylist(ctx, penalty.UserID); err != nil {
+			slog.WarnContext(ctx, "移出灰名单失败", slog.Uint64("user_id", uint64(penalty.UserID)), slog.Any("err", err))
+		}
+	}
+}
+
+func (s *PenaltyService) view(ctx context.Context, repo *repository.PenaltyRepo, userID uint, penalty *model.UserPenalty, now time.Time) (*PenaltyView, error) {
+	rules := loadPenaltyRules()
+	view := &PenaltyView{UserID: userID, WindowDays: rules.windowDays}
+	if penalty == nil {
+		return view, nil
+	}
+	count, err := repo.CountTimeoutsSince(ctx, userID, rules.windowStart(penalty, now))
+	if err != nil {
+		return nil, err
+	}
+	view.TimeoutCount = count
+	view.Demoted = int(count) >= rules.demoteThreshold
+	if penalty.Banned(now) {
+		view.BannedUntil = penalty.BannedUntil
+	}
+	view.Graylisted = penalty.Graylisted
+	view.AppealStatus = penalty.AppealStatus
+	view.AppealReason = penalty.AppealReason
+	view.AppealReply = penalty.AppealReply
+	view.AppealedAt = penalty.AppealedAt
+	return view, nil
+}
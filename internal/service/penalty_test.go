@@ -0,0 +1,158 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/db"
+	redisinfra "SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPenaltyService_EscalateAndAppeal(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	config.Conf.Penalty = config.PenaltyConfig{WindowDays: 30, DemoteThreshold: 1, BanThreshold: 2, BanDays: 7, GrayThreshold: 3}
+	riskSvc := NewRiskService(redisinfra.RDB)
+	penaltySvc := NewPenaltyService(db.DB, riskSvc)
+	seckillSvc := NewSeckillService(db.DB, repository.NewProductRepo(db.DB))
+	ctx := context.Background()
+	userID := fixtures.user.ID
+
+	// 首单沿用夹具订单，之后每轮新建一笔待支付订单
+	seq := 1
+	timeout := func() {
+		t.Helper()
+		if seq > 1 {
+			order := &model.Order{UserID: userID, ProductID: fixtures.product.ID, OrderNum: fmt.Sprintf("ORD-00%d", seq), Status: model.OrderStatusUnpaid}
+			if err := db.DB.Create(order).Error; err != nil {
+				t.Fatalf("create order: %v", err)
+			}
+			payment := &model.Payment{OrderID: order.ID, PaymentID: fmt.Sprintf("PAY-00%d", seq), AmountCents: 129900, Status: model.PaymentStatusPending}
+			if err := db.DB.Create(payment).Error; err != nil {
+				t.Fatalf("create payment: %v", err)
+			}
+		}
+		seq++
+		if err := db.DB.Model(&model.Order{}).Where("status = ?", model.OrderStatusUnpaid).Update("created_at", time.Now().Add(-20*time.Minute)).Error; err != nil {
+			t.Fatalf("age orders: %v", err)
+		}
+		if cancelled, err := orderSvc.CancelExpiredOrders(ctx, 15*time.Minute, 10); err != nil || cancelled != 1 {
+			t.Fatalf("CancelExpiredOrders() = %d, %v, want 1", cancelled, err)
+		}
+	}
+	status := func() *PenaltyView {
+		t.Helper()
+		view, err := penaltySvc.Status(ctx, userID)
+		if err != nil {
+			t.Fatalf("Status() error = %v", err)
+		}
+		return view
+	}
+	grayUsers := func() []string {
+		t.Helper()
+		_, users, err := riskSvc.ListGraylist(ctx)
+		if err != nil {
+			t.Fatalf("ListGraylist() error = %v", err)
+		}
+		return users
+	}
+
+	if _, err := penaltySvc.Appeal(ctx, userID, "误判"); !errors.Is(err, ErrPenaltyNotFound) {
+		t.Fatalf("Appeal() without penalty error = %v, want ErrPenaltyNotFound", err)
+	}
+
+	timeout()
+	if view := status(); view.TimeoutCount != 1 || !view.Demoted || view.BannedUntil != nil {
+		t.Fatalf("after 1 timeout = %+v, want demoted only", view)
+	}
+
+	timeout()
+	if view := status(); view.BannedUntil == nil || view.Graylisted {
+		t.Fatalf("after 2 timeouts = %+v, want banned", view)
+	}
+	if _, err := seckillSvc.Seckill(ctx, userID, fixtures.product.ID, false); !errors.Is(err, ErrSeckillPenalized) {
+		t.Fatalf("Seckill() while banned error = %v, want ErrSeckillPenalized", err)
+	}
+
+	timeout()
+	if view := status(); !view.Graylisted || view.TimeoutCount != 3 {
+		t.Fatalf("after 3 timeouts = %+v, want graylisted", view)
+	}
+	if users := grayUsers(); len(users) != 1 || users[0] != fmt.Sprint(userID) {
+		t.Fatalf("graylist users = %v, want [%d]", users, userID)
+	}
+	var applied int64
+	db.DB.Model(&model.Notification{}).Where("user_id = ? AND kind = ?", userID, model.NotifyPenaltyApplied).Count(&applied)
+	if applied != 2 {
+		t.Fatalf("penalty notices = %d, want 2", applied)
+	}
+
+	if _, err := penaltySvc.Appeal(ctx, userID, "网络故障导致未能支付"); err != nil {
+		t.Fatalf("Appeal() error = %v", err)
+	}
+	if _, err := penaltySvc.Appeal(ctx, userID, "再次申诉"); !errors.Is(err, ErrPenaltyAppealPending) {
+		t.Fatalf("second Appeal() error = %v, want ErrPenaltyAppealPending", err)
+	}
+	pending, total, err := penaltySvc.List(ctx, string(model.PenaltyAppealPending), true, 1, 20)
+	if err != nil || total != 1 || pending[0].UserID != userID {
+		t.Fatalf("List(pending) = %+v, %d, %v", pending, total, err)
+	}
+
+	view, err := penaltySvc.ResolveAppeal(ctx, userID, true, "已核实")
+	if err != nil {
+		t.Fatalf("ResolveAppeal() error = %v", err)
+	}
+	if view.Active() || view.TimeoutCount != 0 || view.AppealStatus != model.PenaltyAppealApproved {
+		t.Fatalf("after approve = %+v, want cleared", view)
+	}
+	if n, _ := redisinfra.RDB.Exists(ctx, penaltyBanKey(userID)).Result(); n != 0 {
+		t.Fatalf("ban key still exists after approve")
+	}
+	if users := grayUsers(); len(users) != 0 {
+		t.Fatalf("graylist users after approve = %v, want empty", users)
+	}
+	if _, err := penaltySvc.ResolveAppeal(ctx, userID, false, ""); !errors.Is(err, ErrPenaltyAppealNotPending) {
+		t.Fatalf("ResolveAppeal() twice error = %v, want ErrPenaltyAppealNotPending", err)
+	}
+
+	// 重置前的超时不再计数
+	timeout()
+	if view := status(); view.TimeoutCount != 1 || view.BannedUntil != nil {
+		t.Fatalf("after reset and 1 timeout = %+v, want count restarted", view)
+	}
+}
+
+func TestPenaltyService_ResetKeepsAdminGraylist(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	config.Conf.Penalty = config.PenaltyConfig{WindowDays: 30, DemoteThreshold: 1, BanThreshold: 1, BanDays: 7, GrayThreshold: 1}
+	riskSvc := NewRiskService(redisinfra.RDB)
+	penaltySvc := NewPenaltyService(db.DB, riskSvc)
+	ctx := context.Background()
+	userID := fixtures.user.ID
+
+	// 管理员先手工加入灰名单，处罚再次加入不改变归属
+	if err := riskSvc.AddGraylist(ctx, "user", fmt.Sprint(userID)); err != nil {
+		t.Fatalf("AddGraylist() error = %v", err)
+	}
+	if err := db.DB.Model(&model.Order{}).Where("id = ?", fixtures.order.ID).Update("created_at", time.Now().Add(-20*time.Minute)).Error; err != nil {
+		t.Fatalf("age order: %v", err)
+	}
+	if cancelled, err := orderSvc.CancelExpiredOrders(ctx, 15*time.Minute, 10); err != nil || cancelled != 1 {
+		t.Fatalf("CancelExpiredOrders() = %d, %v, want 1", cancelled, err)
+	}
+	if view, err := penaltySvc.Status(ctx, userID); err != nil || !view.Graylisted {
+		t.Fatalf("Status() = %+v, %v, want graylisted", view, err)
+	}
+
+	if _, err := penaltySvc.Reset(ctx, userID, "人工解除"); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	_, users, err := riskSvc.ListGraylist(ctx)
+	if err != nil || len(users) != 1 || users[0] != fmt.Sprint(userID) {
+		t.Fatalf("graylist users after reset = %v, %v, want admin entry kept", users, err)
+	}
+}
@@ -0,0 +1,430 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPointsInvalid       = errors.New("积分数量无效")
+	ErrPointsInsufficient  = errors.New("积分不足")
+	ErrPointsExceedLimit   = errors.New("积分抵扣超出订单上限")
+	ErrCouponNotRedeemable = errors.New("该优惠券不支持积分兑换")
+	ErrVIPOrderNoPoints    = errors.New("VIP 订单不支持积分抵扣")
+)
+
+// pointsExpiringWindow 积分概览中“即将过期”的统计窗口。
+const pointsExpiringWindow = 30 * 24 * time.Hour
+
+// pointsRules 积分规则，来自配置文件 points 段，未配置项使用默认值。
+type pointsRules struct {
+	expire           time.Duration
+	earnPerYuan      int64
+	multipliers      []int
+	firstOrderBonus  int64
+	referralBonus    int64
+	pointValueCents  int64
+	maxDeductPercent int64
+}
+
+func loadPointsRules() pointsRules {
+	cfg := config.Conf.Points
+	rules := pointsRules{
+		expire:           time.Duration(cfg.ExpireDays) * 24 * time.Hour,
+		earnPerYuan:      cfg.EarnPerYuan,
+		multipliers:      cfg.LevelMultipliers,
+		firstOrderBonus:  cfg.FirstOrderBonus,
+		referralBonus:    cfg.ReferralBonus,
+		pointValueCents:  cfg.PointValueCents,
+		maxDeductPercent: int64(cfg.MaxDeductPercent),
+	}
+	if rules.expire <= 0 {
+		rules.expire = 365 * 24 * time.Hour
+	}
+	if rules.earnPerYuan <= 0 {
+		rules.earnPerYuan = 1
+	}
+	if len(rules.multipliers) == 0 {
+		rules.multipliers = []int{100, 120, 150, 200}
+	}
+	if rules.firstOrderBonus <= 0 {
+		rules.firstOrderBonus = 100
+	}
+	if rules.referralBonus <= 0 {
+		rules.referralBonus = 200
+	}
+	if rules.pointValueCents <= 0 {
+		rules.pointValueCents = 1
+	}
+	if rules.maxDeductPercent <= 0 || rules.maxDeductPercent > 100 {
+		rules.maxDeductPercent = 50
+	}
+	return rules
+}
+
+// orderPoints 按实付金额（满 1 元计）与等级倍率计算订单积分。
+func (r pointsRules) orderPoints(amountCents int64, level int) int64 {
+	idx := min(max(level-1, 0), len(r.multipliers)-1)
+	return amountCents / 100 * r.earnPerYuan * int64(r.multipliers[idx]) / 100
+}
+
+// PointsSummary 积分概览。
+type PointsSummary struct {
+	Balance          int64 `json:"balance"`            // 可用积分
+	ExpiringSoon     int64 `json:"expiring_soon"`      // 30 天内过期的积分
+	PointValueCents  int64 `json:"point_value_cents"`  // 抵扣时 1 积分价值（分）
+	MaxDeductPercent int64 `json:"max_deduct_percent"` // 单笔订单最多抵扣比例
+}
+
+// PointsService 积分服务：余额、流水、兑换与过期处理。
+type PointsService struct {
+	db         *gorm.DB
+	pointRepo  *repository.PointRepo
+	couponRepo *repository.CouponRepo
+}
+
+func NewPointsService(db *gorm.DB) *PointsService {
+	return &PointsService{
+		db:         db,
+		pointRepo:  repository.NewPointRepo(db),
+		couponRepo: repository.NewCouponRepo(db),
+	}
+}
+
+// Summary 查询可用积分与即将过期积分。
+func (s *PointsService) Summary(ctx context.Context, userID uint) (*PointsSummary, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	now := time.Now()
+	balance, err := s.pointRepo.SumSpendable(ctx, userID, now, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	expiring, err := s.pointRepo.SumSpendable(ctx, userID, now, now.Add(pointsExpiringWindow))
+	if err != nil {
+		return nil, err
+	}
+	rules := loadPointsRules()
+	return &PointsSummary{
+		Balance:          balance,
+		ExpiringSoon:     expiring,
+		PointValueCents:  rules.pointValueCents,
+		MaxDeductPercent: rules.maxDeductPercent,
+	}, nil
+}
+
+// History 分页查询积分流水。
+func (s *PointsService) History(ctx context.Context, userID uint, page, pageSize int) ([]model.PointEntry, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.pointRepo.ListByUser(ctx, userID, page, pageSize)
+}
+
+// RedeemCoupon 消耗积分兑换优惠券。
+func (s *PointsService) RedeemCoupon(ctx context.Context, userID, couponID uint) (*MyCoupon, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	var result *MyCoupon
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := repository.NewCouponRepo(tx).GetByID(ctx, couponID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCouponNotFound
+			}
+			return err
+		}
+		now := time.Now()
+		if c.PointsCost <= 0 || c.Status != model.CouponTemplateStatusActive || !c.ValidTo.After(now) {
+			return ErrCouponNotRedeemable
+		}
+		if _, err := spendPoints(ctx, tx, userID, c.PointsCost, model.PointKindRedeemCoupon, 0, c.Title, now, true); err != nil {
+			return err
+		}
+		uc := &model.UserCoupon{
+			UserID:       userID,
+			CouponID:     c.ID,
+			Status:       model.CouponStatusAvailable,
+			ObtainedFrom: "points",
+			ValidFrom:    c.ValidFrom,
+			ValidTo:      c.ValidTo,
+			IssuedAt:     now,
+		}
+		if err := repository.NewUserCouponRepo(tx).Create(ctx, uc); err != nil {
+			return err
+		}
+		result = toMyCoupon(uc, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AwardReferral 发放邀请奖励积分，供邀请活动调用。
+func (s *PointsService) AwardReferral(ctx context.Context, userID uint, remark string) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	rules := loadPointsRules()
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return earnPoints(ctx, tx, userID, rules.referralBonus, model.PointKindReferral, 0, remark, time.Now())
+	})
+}
+
+// ExpirePoints 处理已过期的积分，按收入流水逐条扣减余额，返回处理条数。
+func (s *PointsService) ExpirePoints(ctx context.Context, limit int) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+	now := time.Now()
+	entries, err := s.pointRepo.ListExpired(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	processed := 0
+	for _, e := range entries {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			txUserRepo := repository.NewUserRepo(tx)
+			txPointRepo := repository.NewPointRepo(tx)
+			// 先锁用户，与扣减路径保持相同加锁顺序
+			user, err := txUserRepo.GetByIDForUpdate(ctx, e.UserID)
+			if err != nil {
+				return err
+			}
+			entry, err := txPointRepo.GetByID(ctx, e.ID)
+			if err != nil {
+				return err
+			}
+			if entry.Remaining <= 0 {
+				return nil
+			}
+			balance := user.Points - entry.Remaining
+			if err := txPointRepo.UpdateRemaining(ctx, entry.ID, 0); err != nil {
+				return err
+			}
+			if err := txPointRepo.Create(ctx, &model.PointEntry{
+				UserID:       entry.UserID,
+				Delta:        -entry.Remaining,
+				Kind:         model.PointKindExpire,
+				OrderID:      entry.OrderID,
+				Remark:       fmt.Sprintf("流水 %d 过期", entry.ID),
+				BalanceAfter: balance,
+			}); err != nil {
+				return err
+			}
+			return txUserRepo.UpdatePoints(ctx, entry.UserID, balance)
+		})
+		if err != nil {
+			slog.Warn("积分过期处理失败", slog.Uint64("entry_id", uint64(e.ID)), slog.Any("err", err))
+			continue
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// earnPoints 在事务内入账积分，收入流水按有效期记录剩余可用数。
+func earnPoints(ctx context.Context, tx *gorm.DB, userID uint, points int64, kind model.PointKind, orderID uint, remark string, now time.Time) error {
+	if points <= 0 {
+		return nil
+	}
+	userRepo := repository.NewUserRepo(tx)
+	user, err := userRepo.GetByIDForUpdate(ctx, userID)
+	if err != nil {
+		return err
+	}
+	expiresAt := now.Add(loadPointsRules().expire)
+	balance := user.Points + points
+	if err := repository.NewPointRepo(tx).Create(ctx, &model.PointEntry{
+		UserID:       userID,
+		Delta:        points,
+		Kind:         kind,
+		OrderID:      orderID,
+		Remark:       remark,
+		Remaining:    points,
+		ExpiresAt:    &expiresAt,
+		BalanceAfter: balance,
+	}); err != nil {
+		return err
+	}
+	return userRepo.UpdatePoints(ctx, userID, balance)
+}
+
+// spendPoints 在事务内按先过期先扣的顺序扣减积分，返回实际扣减数。
+// strict 为 true 时余额不足直接报错，否则尽量扣减（用于退款扣回，已消耗部分不再追回）。
+func spendPoints(ctx context.Context, tx *gorm.DB, userID uint, points int64, kind model.PointKind, orderID uint, remark string, now time.Time, strict bool) (int64, error) {
+	if points <= 0 {
+		return 0, nil
+	}
+	userRepo := repository.NewUserRepo(tx)
+	pointRepo := repository.NewPointRepo(tx)
+	user, err := userRepo.GetByIDForUpdate(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := pointRepo.ListSpendableForUpdate(ctx, userID, now)
+	if err != nil {
+		return 0, err
+	}
+	var available int64
+	for _, e := range entries {
+		available += e.Remaining
+	}
+	if available < points {
+		if strict {
+			return 0, ErrPointsInsufficient
+		}
+		points = available
+	}
+	if points == 0 {
+		return 0, nil
+	}
+
+	need := points
+	var allocations []model.PointAllocation
+	for _, e := range entries {
+		if need == 0 {
+			break
+		}
+		take := min(need, e.Remaining)
+		if err := pointRepo.UpdateRemaining(ctx, e.ID, e.Remaining-take); err != nil {
+			return 0, err
+		}
+		allocations = append(allocations, model.PointAllocation{SourceEntryID: e.ID, OrderID: orderID, Amount: take})
+		need -= take
+	}
+	balance := user.Points - points
+	spend := &model.PointEntry{
+		UserID:       userID,
+		Delta:        -points,
+		Kind:         kind,
+		OrderID:      orderID,
+		Remark:       remark,
+		BalanceAfter: balance,
+	}
+	if err := pointRepo.Create(ctx, spend); err != nil {
+		return 0, err
+	}
+	for i := range allocations {
+		allocations[i].SpendEntryID = spend.ID
+	}
+	if err := pointRepo.CreateAllocations(ctx, allocations); err != nil {
+		return 0, err
+	}
+	return points, userRepo.UpdatePoints(ctx, userID, balance)
+}
+
+// awardOrderPoints 支付成功后按实付金额与生效等级倍率发放积分，首单额外奖励。
+func awardOrderPoints(ctx context.Context, tx *gorm.DB, order *model.Order, amountCents int64, level int, now time.Time) error {
+	rules := loadPointsRules()
+	if err := earnPoints(ctx, tx, order.UserID, rules.orderPoints(amountCents, level), model.PointKindOrderEarn, order.ID, order.OrderNum, now); err != nil {
+		return err
+	}
+	exists, err := repository.NewPointRepo(tx).ExistsByUserKind(ctx, order.UserID, model.PointKindFirstOrder)
+	if err != nil || exists {
+		return err
+	}
+	return earnPoints(ctx, tx, order.UserID, rules.firstOrderBonus, model.PointKindFirstOrder, order.ID, order.OrderNum, now)
+}
+
+// releaseOrderPoints 退回订单已抵扣且尚未退回的积分，可重复调用。
+// 积分按抵扣时的占用明细退回原收入流水并保留原过期时间，已过期的部分不再退回。
+func releaseOrderPoints(ctx context.Context, tx *gorm.DB, order *model.Order, now time.Time) error {
+	userRepo := repository.NewUserRepo(tx)
+	pointRepo := repository.NewPointRepo(tx)
+	// 先锁用户，与扣减路径保持相同加锁顺序
+	user, err := userRepo.GetByIDForUpdate(ctx, order.UserID)
+	if err != nil {
+		return err
+	}
+	allocations, err := pointRepo.ListOpenAllocationsForUpdate(ctx, order.ID, model.PointKindOrderDeduct)
+	if err != nil || len(allocations) == 0 {
+		return err
+	}
+	ids := make([]uint, 0, len(allocations))
+	sourceIDs := make([]uint, 0, len(allocations))
+	for _, a := range allocations {
+		ids = append(ids, a.ID)
+		sourceIDs = append(sourceIDs, a.SourceEntryID)
+	}
+	sources, err := pointRepo.ListByIDsForUpdate(ctx, sourceIDs)
+	if err != nil {
+		return err
+	}
+	expiresAt := make(map[uint]*time.Time, len(sources))
+	for _, e := range sources {
+		expiresAt[e.ID] = e.ExpiresAt
+	}
+
+	var restored, expired int64
+	for _, a := range allocations {
+		if exp := expiresAt[a.SourceEntryID]; exp == nil || !exp.After(now) {
+			expired += a.Amount
+			continue
+		}
+		if err := pointRepo.RestoreRemaining(ctx, a.SourceEntryID, a.Amount); err != nil {
+			return err
+		}
+		restored += a.Amount
+	}
+	if err := pointRepo.MarkAllocationsReleased(ctx, ids); err != nil {
+		return err
+	}
+	if restored == 0 {
+		return nil
+	}
+	remark := order.OrderNum
+	if expired > 0 {
+		remark = fmt.Sprintf("%s（%d 积分已过期未退回）", order.OrderNum, expired)
+	}
+	balance := user.Points + restored
+	if err := pointRepo.Create(ctx, &model.PointEntry{
+		UserID:       order.UserID,
+		Delta:        restored,
+		Kind:         model.PointKindOrderRelease,
+		OrderID:      order.ID,
+		Remark:       remark,
+		BalanceAfter: balance,
+	}); err != nil {
+		return err
+	}
+	return userRepo.UpdatePoints(ctx, order.UserID, balance)
+}
+
+// reverseOrderPoints 退款时扣回订单获得的积分（含首单奖励），并退回订单抵扣的积分。
+func reverseOrderPoints(ctx context.Context, tx *gorm.DB, order *model.Order, now time.Time) error {
+	pointRepo := repository.NewPointRepo(tx)
+	earned, err := pointRepo.SumByOrder(ctx, order.ID, model.PointKindOrderEarn, model.PointKindFirstOrder)
+	if err != nil {
+		return err
+	}
+	reversed, err := pointRepo.SumByOrder(ctx, order.ID, model.PointKindOrderReversal)
+	if err != nil {
+		return err
+	}
+	if _, err := spendPoints(ctx, tx, order.UserID, earned+reversed, model.PointKindOrderReversal, order.ID, order.OrderNum, now, false); err != nil {
+		return err
+	}
+	return releaseOrderPoints(ctx, tx, order, now)
+}
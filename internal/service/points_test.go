@@ -0,0 +1,216 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPointsService_Lifecycle(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	pointsSvc := NewPointsService(db.DB)
+	ctx := context.Background()
+	userID := fixtures.user.ID
+
+	balanceOf := func() int64 {
+		t.Helper()
+		summary, err := pointsSvc.Summary(ctx, userID)
+		if err != nil {
+			t.Fatalf("Summary() error = %v", err)
+		}
+		var user model.User
+		if err := db.DB.First(&user, userID).Error; err != nil {
+			t.Fatalf("load user: %v", err)
+		}
+		if user.Points != summary.Balance {
+			t.Fatalf("user.Points = %d, ledger balance = %d", user.Points, summary.Balance)
+		}
+		return summary.Balance
+	}
+
+	// 支付首单：1299 元 * 1 倍 + 首单奖励 100
+	if _, err := orderSvc.HandlePaymentResult(ctx, fixtures.payment.PaymentID, model.PaymentStatusPaid, "ok"); err != nil {
+		t.Fatalf("HandlePaymentResult(paid) error = %v", err)
+	}
+	if got := balanceOf(); got != 1399 {
+		t.Fatalf("balance after first order = %d, want 1399", got)
+	}
+
+	order := &model.Order{UserID: userID, ProductID: fixtures.product.ID, OrderNum: "ORD-002", Status: model.OrderStatusUnpaid}
+	if err := db.DB.Create(order).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	payment := &model.Payment{OrderID: order.ID, PaymentID: "PAY-002", AmountCents: 2000, Status: model.PaymentStatusPending}
+	if err := db.DB.Create(payment).Error; err != nil {
+		t.Fatalf("create payment: %v", err)
+	}
+
+	// 最多抵扣 50%
+	if _, err := orderSvc.ApplyPoints(ctx, userID, order.ID, 1500); !errors.Is(err, ErrPointsExceedLimit) {
+		t.Fatalf("ApplyPoints(1500) error = %v, want %v", err, ErrPointsExceedLimit)
+	}
+	got, err := orderSvc.ApplyPoints(ctx, userID, order.ID, 800)
+	if err != nil {
+		t.Fatalf("ApplyPoints(800) error = %v", err)
+	}
+	if got.Payment.AmountCents != 1200 || got.Order.PointsUsed != 800 {
+		t.Fatalf("after ApplyPoints(800) order = %+v, payment = %+v", got.Order, got.Payment)
+	}
+	// 重新抵扣以最后一次为准
+	got, err = orderSvc.ApplyPoints(ctx, userID, order.ID, 300)
+	if err != nil {
+		t.Fatalf("ApplyPoints(300) error = %v", err)
+	}
+	if got.Payment.AmountCents != 1700 || balanceOf() != 1099 {
+		t.Fatalf("after ApplyPoints(300) payment = %+v, balance = %d", got.Payment, balanceOf())
+	}
+
+	// 支付失败退回抵扣积分
+	if _, err := orderSvc.HandlePaymentResult(ctx, payment.PaymentID, model.PaymentStatusFailed, "fail"); err != nil {
+		t.Fatalf("HandlePaymentResult(failed) error = %v", err)
+	}
+	if got := balanceOf(); got != 1399 {
+		t.Fatalf("balance after failed payment = %d, want 1399", got)
+	}
+
+	// 积分兑换优惠券
+	coupon := &model.Coupon{
+		Type:        model.CouponTypeFullCut,
+		Title:       "积分券",
+		AmountCents: 500,
+		ValidFrom:   time.Now().Add(-time.Hour),
+		ValidTo:     time.Now().Add(time.Hour),
+		PointsCost:  500,
+		Status:      model.CouponTemplateStatusActive,
+	}
+	if err := db.DB.Create(coupon).Error; err != nil {
+		t.Fatalf("create coupon: %v", err)
+	}
+	if _, err := pointsSvc.RedeemCoupon(ctx, userID, coupon.ID); err != nil {
+		t.Fatalf("RedeemCoupon() error = %v", err)
+	}
+	if got := balanceOf(); got != 899 {
+		t.Fatalf("balance after redeem = %d, want 899", got)
+	}
+
+	// 退款扣回首单积分，已消耗部分不再追回
+	if _, err := orderSvc.HandlePaymentResult(ctx, fixtures.payment.PaymentID, model.PaymentStatusRefunded, "refund"); err != nil {
+		t.Fatalf("HandlePaymentResult(refunded) error = %v", err)
+	}
+	if got := balanceOf(); got != 0 {
+		t.Fatalf("balance after refund = %d, want 0", got)
+	}
+	if _, err := pointsSvc.RedeemCoupon(ctx, userID, coupon.ID); !errors.Is(err, ErrPointsInsufficient) {
+		t.Fatalf("RedeemCoupon() error = %v, want %v", err, ErrPointsInsufficient)
+	}
+
+	// 过期处理
+	if err := pointsSvc.AwardReferral(ctx, userID, "invite"); err != nil {
+		t.Fatalf("AwardReferral() error = %v", err)
+	}
+	if got := balanceOf(); got != 200 {
+		t.Fatalf("balance after referral = %d, want 200", got)
+	}
+	if err := db.DB.Model(&model.PointEntry{}).Where("kind = ?", model.PointKindReferral).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("backdate entry: %v", err)
+	}
+	n, err := pointsSvc.ExpirePoints(ctx, 10)
+	if err != nil || n != 1 {
+		t.Fatalf("ExpirePoints() = %d, %v, want 1", n, err)
+	}
+	if got := balanceOf(); got != 0 {
+		t.Fatalf("balance after expire = %d, want 0", got)
+	}
+}
+
+func TestPointsService_ReleaseKeepsOriginalExpiry(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	pointsSvc := NewPointsService(db.DB)
+	ctx := context.Background()
+	userID := fixtures.user.ID
+
+	if err := pointsSvc.AwardReferral(ctx, userID, "invite"); err != nil {
+		t.Fatalf("AwardReferral() error = %v", err)
+	}
+	soon := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := db.DB.Model(&model.PointEntry{}).Where("kind = ?", model.PointKindReferral).
+		Update("expires_at", soon).Error; err != nil {
+		t.Fatalf("set expiry: %v", err)
+	}
+	loadSource := func() model.PointEntry {
+		t.Helper()
+		var entry model.PointEntry
+		if err := db.DB.Where("kind = ?", model.PointKindReferral).First(&entry).Error; err != nil {
+			t.Fatalf("load entry: %v", err)
+		}
+		return entry
+	}
+	loadUser := func() model.User {
+		t.Helper()
+		var user model.User
+		if err := db.DB.First(&user, userID).Error; err != nil {
+			t.Fatalf("load user: %v", err)
+		}
+		return user
+	}
+
+	// 取消订单退回原流水，保留原过期时间
+	if _, err := orderSvc.ApplyPoints(ctx, userID, fixtures.order.ID, 150); err != nil {
+		t.Fatalf("ApplyPoints(150) error = %v", err)
+	}
+	if src := loadSource(); src.Remaining != 50 {
+		t.Fatalf("source remaining after deduct = %d, want 50", src.Remaining)
+	}
+	if _, err := orderSvc.cancelOrder(ctx, fixtures.order.OrderNum, "timeout"); err != nil {
+		t.Fatalf("cancelOrder() error = %v", err)
+	}
+	src := loadSource()
+	if src.Remaining != 200 || src.ExpiresAt == nil || !src.ExpiresAt.Equal(soon) {
+		t.Fatalf("source after release = remaining %d expires %v, want 200 at %v", src.Remaining, src.ExpiresAt, soon)
+	}
+	var release model.PointEntry
+	if err := db.DB.Where("order_id = ? AND kind = ?", fixtures.order.ID, model.PointKindOrderRelease).First(&release).Error; err != nil {
+		t.Fatalf("load release entry: %v", err)
+	}
+	if release.Delta != 150 || release.Remaining != 0 || release.ExpiresAt != nil {
+		t.Fatalf("release entry = %+v", release)
+	}
+	if user := loadUser(); user.Points != 200 {
+		t.Fatalf("user.Points after release = %d, want 200", user.Points)
+	}
+
+	// 原流水已过期的部分不再退回
+	order := &model.Order{UserID: userID, ProductID: fixtures.product.ID, OrderNum: "ORD-002", Status: model.OrderStatusUnpaid}
+	if err := db.DB.Create(order).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	if err := db.DB.Create(&model.Payment{OrderID: order.ID, PaymentID: "PAY-002", AmountCents: 20000, Status: model.PaymentStatusPending}).Error; err != nil {
+		t.Fatalf("create payment: %v", err)
+	}
+	if _, err := orderSvc.ApplyPoints(ctx, userID, order.ID, 100); err != nil {
+		t.Fatalf("ApplyPoints(100) error = %v", err)
+	}
+	if err := db.DB.Model(&model.PointEntry{}).Where("kind = ?", model.PointKindReferral).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("backdate entry: %v", err)
+	}
+	if _, err := orderSvc.cancelOrder(ctx, order.OrderNum, "timeout"); err != nil {
+		t.Fatalf("cancelOrder(expired) error = %v", err)
+	}
+	if src := loadSource(); src.Remaining != 100 {
+		t.Fatalf("source remaining after expired release = %d, want 100", src.Remaining)
+	}
+	if user := loadUser(); user.Points != 100 {
+		t.Fatalf("user.Points after expired release = %d, want 100", user.Points)
+	}
+	if n, err := pointsSvc.ExpirePoints(ctx, 10); err != nil || n != 1 {
+		t.Fatalf("ExpirePoints() = %d, %v, want 1", n, err)
+	}
+	if user := loadUser(); user.Points != 0 {
+		t.Fatalf("user.Points after expire = %d, want 0", user.Points)
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPriceInvalid          = errors.New("价格须大于 0")
+	ErrPriceScheduleNotFound = errors.New("调价计划不存在")
+	ErrPriceScheduleTime     = errors.New("生效时间须至少晚于当前 1 分钟")
+	ErrPriceScheduleLimit    = errors.New("每个商品最多 10 个待执行的调价计划")
+	ErrPriceScheduleConflict = errors.New("该生效时间已有调价计划")
+	ErrPriceScheduleFinished = errors.New("调价计划已执行或已取消")
+)
+
+const (
+	priceScheduleDeletedReason = "商品已删除"
+	priceScheduleMaxPending    = 10
+	priceScheduleMinLead       = time.Minute
+	priceScheduleBatchSize     = 100
+)
+
+// PriceService 调价计划与价格历史：计划到点由定时任务改价，每次改价都记录历史。
+type PriceService struct {
+	db          *gorm.DB
+	productRepo *repository.ProductRepo
+	sellerRepo  *repository.SellerRepo
+	priceRepo   *repository.PriceRepo
+	now         func() time.Time
+}
+
+func NewPriceService(db *gorm.DB) *PriceService {
+	return &PriceService{
+		db:          db,
+		productRepo: repository.NewProductRepo(db),
+		sellerRepo:  repository.NewSellerRepo(db),
+		priceRepo:   repository.NewPriceRepo(db),
+		now:         time.Now,
+	}
+}
+
+// CreateSchedule 卖家为自己的商品预设调价。新价格在创建时按会员价规则校验，商品当前状态与价格不变，
+// 已上架的商品继续售卖，计划到点执行时再次校验。
+func (s *PriceService) CreateSchedule(ctx context.Context, userID, productID uint, price float64, effectiveAt time.Time) (*model.PriceSchedule, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if err := requireApprovedSeller(ctx, s.sellerRepo, userID); err != nil {
+		return nil, err
+	}
+	if price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		return nil, ErrPriceInvalid
+	}
+	now := s.now()
+	if effectiveAt.Before(now.Add(priceScheduleMinLead)) {
+		return nil, ErrPriceScheduleTime
+	}
+
+	schedule := &model.PriceSchedule{
+		ProductID:   productID,
+		SellerID:    userID,
+		Price:       math.Round(price*100) / 100,
+		EffectiveAt: effectiveAt,
+		Status:      model.PriceSchedulePending,
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		productRepo := repository.NewProductRepo(tx)
+		priceRepo := repository.NewPriceRepo(tx)
+		// 锁住商品，串行化同一商品的计划数量与时间冲突校验
+		product, err := productRepo.GetByIDForUpdate(ctx, productID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+		if product.UserID != userID {
+			return ErrProductNotFound
+		}
+		if err := validateMemberPrices(product.MemberPrices, schedule.Price); err != nil {
+			return err
+		}
+		pending, err := priceRepo.PendingSchedules(ctx, productID)
+		if err != nil {
+			return err
+		}
+		if len(pending) >= priceScheduleMaxPending {
+			return ErrPriceScheduleLimit
+		}
+		for _, p := range pending {
+			if p.EffectiveAt.Equal(effectiveAt) {
+				return ErrPriceScheduleConflict
+			}
+		}
+		return priceRepo.CreateSchedule(ctx, schedule)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// ListSchedules 卖家查看商品的调价计划，按生效时间倒序。
+func (s *PriceService) ListSchedules(ctx context.Context, userID, productID uint, page, pageSize int) ([]model.PriceSchedule, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	if _, err := s.ownedProduct(ctx, userID, productID); err != nil {
+		return nil, 0, err
+	}
+	page, pageSize = normalizePage(page, pageSize)
+	return s.priceRepo.ListSchedules(ctx, productID, page, pageSize)
+}
+
+// CancelSchedule 取消尚未执行的调价计划。
+func (s *PriceService) CancelSchedule(ctx context.Context, userID, productID, scheduleID uint) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	if err := requireApprovedSeller(ctx, s.sellerRepo, userID); err != nil {
+		return err
+	}
+	if _, err := s.ownedProduct(ctx, userID, productID); err != nil {
+		return err
+	}
+	if _, err := s.priceRepo.GetSchedule(ctx, scheduleID, productID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPriceScheduleNotFound
+		}
+		return err
+	}
+	rows, err := s.priceRepo.FinishSchedule(ctx, scheduleID, map[string]any{"status": model.PriceScheduleCancelled})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrPriceScheduleFinished
+	}
+	return nil
+}
+
+// PriceHistory 已上架商品的价格变动记录，最新的在前。
+func (s *PriceService) PriceHistory(ctx context.Context, productID uint, page, pageSize int) ([]model.PriceHistory, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrProductNotFound
+		}
+		return nil, 0, err
+	}
+	if !product.Listed() {
+		return nil, 0, ErrProductNotFound
+	}
+	page, pageSize = normalizePage(page, pageSize)
+	return s.priceRepo.ListHistory(ctx, productID, page, pageSize)
+}
+
+// ApplyDueSchedules 执行已到生效时间的调价计划，返回实际改价的商品数。
+// 商品已删除的计划取消；会员价不再低于新价格的计划标记失败，商品价格不变。
+func (s *PriceService) ApplyDueSchedules(ctx context.Context) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	now := s.now()
+	changed := 0
+	for {
+		due, err := s.priceRepo.ListDue(ctx, now, priceScheduleBatchSize)
+		if err != nil {
+			return changed, err
+		}
+		for _, schedule := range due {
+			ok, err := s.apply(ctx, schedule, now)
+			if err != nil {
+				return changed, err
+			}
+			if ok {
+				changed++
+			}
+		}
+		if len(due) < priceScheduleBatchSize {
+			return changed, nil
+		}
+	}
+}
+
+func (s *PriceService) apply(ctx context.Context, schedule model.PriceSchedule, now time.Time) (bool, error) {
+	changed := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		productRepo := repository.NewProductRepo(tx)
+		priceRepo := repository.NewPriceRepo(tx)
+		product, err := productRepo.GetByIDForUpdate(ctx, schedule.ProductID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				_, err = priceRepo.FinishSchedule(ctx, schedule.ID, map[string]any{
+					"status":      model.PriceScheduleCancelled,
+					"fail_reason": priceScheduleDeletedReason,
+				})
+			}
+			return err
+		}
+		if err := validateMemberPrices(product.MemberPrices, schedule.Price); err != nil {
+			_, err = priceRepo.FinishSchedule(ctx, schedule.ID, map[string]any{
+				"status":      model.PriceScheduleFailed,
+				"fail_reason": err.Error(),
+			})
+			return err
+		}
+		// 条件流转，多个 worker 同时扫描时只有一个执行
+		rows, err := priceRepo.FinishSchedule(ctx, schedule.ID, map[string]any{
+			"status":     model.PriceScheduleApplied,
+			"applied_at": now,
+		})
+		if err != nil || rows == 0 || samePrice(product.Price, schedule.Price) {
+			return err
+		}
+		if err := productRepo.Update(ctx, product.ID, map[string]any{"price": schedule.Price}); err != nil {
+			return err
+		}
+		changed = true
+		return priceRepo.CreateHistory(ctx, &model.PriceHistory{
+			ProductID:  product.ID,
+			OldPrice:   product.Price,
+			NewPrice:   schedule.Price,
+			Source:     model.PriceChangeSchedule,
+			ScheduleID: schedule.ID,
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+	if changed {
+		invalidateProductCatalogCache(schedule.ProductID)
+	}
+	return changed, nil
+}
+
+func (s *PriceService) ownedProduct(ctx context.Context, userID, productID uint) (*model.Product, error) {
+	product, err := s.productRepo.GetByIDAndUser(ctx, productID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return product, nil
+}
+
+// samePrice 按分比较价格，避免浮点误差。
+func samePrice(a, b float64) bool {
+	return math.Round(a*100) == math.Round(b*100)
+}
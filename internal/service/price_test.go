@@ -0,0 +1,169 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPriceService_ScheduleAndHistory(t *testing.T) {
+	_, fixtures := newOrderServiceForTest(t)
+	svc := NewPriceService(db.DB)
+	productRepo := repository.NewProductRepo(db.DB)
+	productSvc := NewProductService(db.DB, productRepo, repository.NewSellerRepo(db.DB), repository.NewCatalogRepo(db.DB))
+	ctx := context.Background()
+	sellerID := fixtures.user.ID
+	productID := fixtures.product.ID
+	now := time.Now().Truncate(time.Second)
+	svc.now = func() time.Time { return now }
+
+	if _, err := svc.CreateSchedule(ctx, sellerID, productID, 999, now.Add(time.Hour)); !errors.Is(err, ErrSellerNotApproved) {
+		t.Fatalf("CreateSchedule(not seller) error = %v, want %v", err, ErrSellerNotApproved)
+	}
+	if err := db.DB.Create(&model.SellerProfile{UserID: sellerID, StoreName: "鞋仓", Status: model.SellerStatusApproved}).Error; err != nil {
+		t.Fatalf("create seller: %v", err)
+	}
+	if _, err := svc.CreateSchedule(ctx, sellerID, productID, 999, now.Add(30*time.Second)); !errors.Is(err, ErrPriceScheduleTime) {
+		t.Fatalf("CreateSchedule(too soon) error = %v, want %v", err, ErrPriceScheduleTime)
+	}
+	if _, err := svc.CreateSchedule(ctx, sellerID+1, productID, 999, now.Add(time.Hour)); !errors.Is(err, ErrSellerNotApproved) {
+		t.Fatalf("CreateSchedule(other user) error = %v, want %v", err, ErrSellerNotApproved)
+	}
+
+	// 新增计划不影响已上架商品
+	drop, err := svc.CreateSchedule(ctx, sellerID, productID, 999, now.Add(time.Hour))
+	if err != nil || drop.Status != model.PriceSchedulePending {
+		t.Fatalf("CreateSchedule() = %+v, %v", drop, err)
+	}
+	product, _ := productRepo.GetByID(ctx, productID)
+	if product.Status != model.ProductStatusApproved || product.Price != 1299 {
+		t.Fatalf("product after schedule = status %q price %v", product.Status, product.Price)
+	}
+	if _, err := svc.CreateSchedule(ctx, sellerID, productID, 1099, now.Add(time.Hour)); !errors.Is(err, ErrPriceScheduleConflict) {
+		t.Fatalf("CreateSchedule(same time) error = %v, want %v", err, ErrPriceScheduleConflict)
+	}
+	retail, err := svc.CreateSchedule(ctx, sellerID, productID, 1299, now.Add(25*time.Hour))
+	if err != nil {
+		t.Fatalf("CreateSchedule(retail) error = %v", err)
+	}
+	for i := 2; i < priceScheduleMaxPending; i++ {
+		if _, err := svc.CreateSchedule(ctx, sellerID, productID, 1199, now.Add(time.Duration(48+i)*time.Hour)); err != nil {
+			t.Fatalf("CreateSchedule(#%d) error = %v", i, err)
+		}
+	}
+	if _, err := svc.CreateSchedule(ctx, sellerID, productID, 1199, now.Add(100*time.Hour)); !errors.Is(err, ErrPriceScheduleLimit) {
+		t.Fatalf("CreateSchedule(over limit) error = %v, want %v", err, ErrPriceScheduleLimit)
+	}
+	schedules, total, err := svc.ListSchedules(ctx, sellerID, productID, 1, 20)
+	if err != nil || total != priceScheduleMaxPending || len(schedules) != priceScheduleMaxPending {
+		t.Fatalf("ListSchedules() = %d, %d, %v", len(schedules), total, err)
+	}
+	last := schedules[0]
+	if err := svc.CancelSchedule(ctx, sellerID, productID, last.ID); err != nil {
+		t.Fatalf("CancelSchedule() error = %v", err)
+	}
+	if err := svc.CancelSchedule(ctx, sellerID, productID, last.ID); !errors.Is(err, ErrPriceScheduleFinished) {
+		t.Fatalf("CancelSchedule(again) error = %v, want %v", err, ErrPriceScheduleFinished)
+	}
+	if err := svc.CancelSchedule(ctx, sellerID, productID, 9999); !errors.Is(err, ErrPriceScheduleNotFound) {
+		t.Fatalf("CancelSchedule(missing) error = %v, want %v", err, ErrPriceScheduleNotFound)
+	}
+
+	// 未到生效时间不执行
+	if changed, err := svc.ApplyDueSchedules(ctx); err != nil || changed != 0 {
+		t.Fatalf("ApplyDueSchedules(early) = %d, %v", changed, err)
+	}
+	svc.now = func() time.Time { return now.Add(time.Hour) }
+	if changed, err := svc.ApplyDueSchedules(ctx); err != nil || changed != 1 {
+		t.Fatalf("ApplyDueSchedules(drop) = %d, %v", changed, err)
+	}
+	product, _ = productRepo.GetByID(ctx, productID)
+	if product.Price != 999 || product.Status != model.ProductStatusApproved {
+		t.Fatalf("product after drop = price %v status %q", product.Price, product.Status)
+	}
+	if changed, err := svc.ApplyDueSchedules(ctx); err != nil || changed != 0 {
+		t.Fatalf("ApplyDueSchedules(again) = %d, %v", changed, err)
+	}
+
+	// 会员价不再低于新价格时计划失败，价格不变
+	if err := productSvc.UpdateProduct(ctx, sellerID, productID, map[string]any{
+		"member_prices": model.MemberPrices{{Level: 1, PriceCents: 95000}},
+	}); err != nil {
+		t.Fatalf("UpdateProduct(member prices) error = %v", err)
+	}
+	if err := db.DB.Model(&model.Product{}).Where("id = ?", productID).Update("member_prices", model.MemberPrices{{Level: 1, PriceCents: 130000}}).Error; err != nil {
+		t.Fatalf("raise member price: %v", err)
+	}
+	svc.now = func() time.Time { return now.Add(25 * time.Hour) }
+	if changed, err := svc.ApplyDueSchedules(ctx); err != nil || changed != 0 {
+		t.Fatalf("ApplyDueSchedules(invalid member price) = %d, %v", changed, err)
+	}
+	failed, _ := repository.NewPriceRepo(db.DB).GetSchedule(ctx, retail.ID, productID)
+	if failed.Status != model.PriceScheduleFailed || failed.FailReason == "" {
+		t.Fatalf("retail schedule = %+v, want failed", failed)
+	}
+
+	// 卖家直接改价同样记录历史（修改会员价后商品待审核，先恢复上架）
+	if err := db.DB.Model(&model.Product{}).Where("id = ?", productID).Updates(map[string]any{"member_prices": model.MemberPrices{}, "status": model.ProductStatusApproved}).Error; err != nil {
+		t.Fatalf("clear member price: %v", err)
+	}
+	if err := productSvc.UpdateProduct(ctx, sellerID, productID, map[string]any{"price": 1099.0}); err != nil {
+		t.Fatalf("UpdateProduct(price) error = %v", err)
+	}
+	// 手动改价与计划改价一致，不需要重新审核
+	product, _ = productRepo.GetByID(ctx, productID)
+	if product.Price != 1099 || product.Status != model.ProductStatusApproved {
+		t.Fatalf("product after manual price = price %v status %q", product.Price, product.Status)
+	}
+	history, total, err := svc.PriceHistory(ctx, productID, 1, 20)
+	if err != nil || total != 2 {
+		t.Fatalf("PriceHistory() = %+v, %d, %v", history, total, err)
+	}
+	if h := history[0]; h.Source != model.PriceChangeManual || h.OldPrice != 999 || h.NewPrice != 1099 {
+		t.Fatalf("latest history = %+v", h)
+	}
+	if h := history[1]; h.Source != model.PriceChangeSchedule || h.ScheduleID != drop.ID || h.OldPrice != 1299 || h.NewPrice != 999 {
+		t.Fatalf("scheduled history = %+v", h)
+	}
+
+	// 商品删除后到期的计划自动取消
+	pending, _ := repository.NewPriceRepo(db.DB).PendingSchedules(ctx, productID)
+	if err := db.DB.Delete(&model.Product{}, productID).Error; err != nil {
+		t.Fatalf("delete product: %v", err)
+	}
+	svc.now = func() time.Time { return now.Add(200 * time.Hour) }
+	if changed, err := svc.ApplyDueSchedules(ctx); err != nil || changed != 0 {
+		t.Fatalf("ApplyDueSchedules(deleted) = %d, %v", changed, err)
+	}
+	if len(pending) == 0 {
+		t.Fatal("expected pending schedules before delete")
+	}
+	cancelled, _ := repository.NewPriceRepo(db.DB).GetSchedule(ctx, pending[0].ID, productID)
+	if cancelled.Status != model.PriceScheduleCancelled || cancelled.FailReason != priceScheduleDeletedReason {
+		t.Fatalf("schedule after delete = %+v", cancelled)
+	}
+}
+
+func TestProductService_CreateRecordsInitialPrice(t *testing.T) {
+	_, fixtures := newOrderServiceForTest(t)
+	productSvc := NewProductService(db.DB, repository.NewProductRepo(db.DB), repository.NewSellerRepo(db.DB), repository.NewCatalogRepo(db.DB))
+	ctx := context.Background()
+	if err := db.DB.Create(&model.SellerProfile{UserID: fixtures.user.ID, StoreName: "鞋仓", Status: model.SellerStatusApproved}).Error; err != nil {
+		t.Fatalf("create seller: %v", err)
+	}
+	product := &model.Product{UserID: fixtures.user.ID, Name: "Dunk Low", Price: 899, Stock: 5, StartTime: time.Now()}
+	if err := productSvc.CreateProduct(ctx, product); err != nil {
+		t.Fatalf("CreateProduct() error = %v", err)
+	}
+	history, total, err := repository.NewPriceRepo(db.DB).ListHistory(ctx, product.ID, 1, 20)
+	if err != nil || total != 1 {
+		t.Fatalf("ListHistory() = %+v, %d, %v", history, total, err)
+	}
+	if h := history[0]; h.Source != model.PriceChangeCreate || h.OldPrice != 0 || h.NewPrice != 899 {
+		t.Fatalf("initial history = %+v", h)
+	}
+}
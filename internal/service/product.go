@@ -5,42 +5,111 @@ import (
 	"SneakerFlash/internal/model"
 	"SneakerFlash/internal/repository"
 	"context"
+	"crypto/sha1"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
 	"strconv"
+	"strings"
 	"time"
 
+	_redis "github.com/redis/go-redis/v9"
 	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
 )
 
 type ProductService struct {
-	repo *repository.ProductRepo
+	db          *gorm.DB
+	repo        *repository.ProductRepo
+	sellerRepo  *repository.SellerRepo
+	catalogRepo *repository.CatalogRepo
 	// 归并重复请求, 防止缓存击穿
 	sf *singleflight.Group
 }
 
 var (
-	ErrProductNotFound  = errors.New("找不到商品信息")
-	ErrProductDuplicate = errors.New("商品已存在")
+	ErrProductNotFound       = errors.New("找不到商品信息")
+	ErrProductDuplicate      = errors.New("商品已存在")
+	ErrProductStatusConflict = errors.New("当前商品状态不允许该操作")
+	ErrProductStatusInvalid  = errors.New("商品状态无效")
+	ErrProductReasonEmpty    = errors.New("驳回或下架原因不能为空")
+	ErrProductImagesInvalid  = errors.New("商品图片最多 9 张，地址不能为空且不超过 255 个字符")
 )
 
-func NewProductService(repo *repository.ProductRepo) *ProductService {
+// productReviewedFields 修改这些字段后，已上架商品需重新审核。
+// 价格不在其中：手动改价与调价计划同样直接生效，均记录价格历史，会员价规则在写入时校验。
+var productReviewedFields = []string{"name", "image", "images", "member_prices", "description", "brand_id", "category_id", "tags", "sku", "release_date", "retail_price"}
+
+const (
+	productImageMaxCount  = 9
+	productImageMaxLength = 255
+)
+
+// 目录缓存只覆盖前几页；开售、结束等按时间变化的状态靠短 TTL 收敛。
+const (
+	productListCachePages = 3
+	productListCacheTTL   = 30 * time.Second
+)
+
+func NewProductService(db *gorm.DB, repo *repository.ProductRepo, sellerRepo *repository.SellerRepo, catalogRepo *repository.CatalogRepo) *ProductService {
 	return &ProductService{
-		repo: repo,
-		sf:   &singleflight.Group{},
+		db:          db,
+		repo:        repo,
+		sellerRepo:  sellerRepo,
+		catalogRepo: catalogRepo,
+		sf:          &singleflight.Group{},
 	}
 }
 
-// CreateProduct 创建商品；若 Redis 预热失败会回滚数据库记录以保持库存一致性。
+// CreateProduct 审核通过的卖家创建商品，保存为草稿或直接提交审核，审核通过后才对外上架；
+// 若 Redis 预热失败会回滚数据库记录以保持库存一致性。
 func (s *ProductService) CreateProduct(ctx context.Context, product *model.Product) error {
 	if ctx == nil {
 		return fmt.Errorf("context is nil")
 	}
+	if err := requireApprovedSeller(ctx, s.sellerRepo, product.UserID); err != nil {
+		return err
+	}
+	if err := validateMemberPrices(product.MemberPrices, product.Price); err != nil {
+		return err
+	}
+	if err := validateProductCatalog(ctx, s.catalogRepo, product.BrandID, product.CategoryID); err != nil {
+		return err
+	}
+	tags, err := normalizeProductTags(product.Tags)
+	if err != nil {
+		return err
+	}
+	product.Tags = tags
+	// 只传封面时作为单图图集
+	if len(product.Images) == 0 && product.Image != "" {
+		product.Images = model.ProductImages{{URL: product.Image}}
+	}
+	images, err := normalizeProductImages(product.Images)
+	if err != nil {
+		return err
+	}
+	product.Images = images
+	product.Image = productCover(images)
+	if product.Status != model.ProductStatusDraft {
+		now := time.Now()
+		product.Status = model.ProductStatusPendingReview
+		product.SubmittedAt = &now
+	}
 
-	if err := s.repo.Create(ctx, product); err != nil {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := repository.NewProductRepo(tx).Create(ctx, product); err != nil {
+			return err
+		}
+		return repository.NewPriceRepo(tx).CreateHistory(ctx, &model.PriceHistory{
+			ProductID:  product.ID,
+			NewPrice:   product.Price,
+			Source:     model.PriceChangeCreate,
+			OperatorID: product.UserID,
+		})
+	})
+	if err != nil {
 		if errors.Is(err, gorm.ErrDuplicatedKey) || isMySQLDuplicate(err) {
 			return ErrProductDuplicate
 		}
@@ -63,7 +132,7 @@ func (s *ProductService) SyncStockToRedis(ctx context.Context, id uint, stock in
 	return setStockCache(ctx, id, stock)
 }
 
-// ListProducts 分页查询商品列表。
+// ListProducts 分页查询已上架商品列表。
 func (s *ProductService) ListProducts(ctx context.Context, page, pageSize int) ([]model.Product, int64, error) {
 	if ctx == nil {
 		return nil, 0, fmt.Errorf("context is nil")
@@ -71,7 +140,98 @@ func (s *ProductService) ListProducts(ctx context.Context, page, pageSize int) (
 	return s.repo.List(ctx, page, pageSize)
 }
 
-// GetProductByID 优先读缓存，singleflight 防击穿，null 哨兵防穿透，随机 TTL 防雪崩；库存以 Redis 为准。
+// SearchProducts 按条件检索已上架商品；前几页结果按条件缓存到 Redis，商品变更时随详情缓存一并失效。
+func (s *ProductService) SearchProducts(ctx context.Context, filter repository.ProductFilter) ([]model.Product, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	filter.Keyword = strings.TrimSpace(filter.Keyword)
+	filter.Tag = normalizeProductTag(filter.Tag)
+	filter.CategoryIDs = nil
+	filter.Page, filter.PageSize = normalizePage(filter.Page, filter.PageSize)
+	if filter.Page > productListCachePages {
+		return s.search(ctx, filter)
+	}
+
+	cacheKey, err := productListCacheKey(ctx, filter)
+	if err != nil {
+		// Redis 不可用时直接查库
+		return s.search(ctx, filter)
+	}
+	if page, ok := getProductListCache(ctx, cacheKey); ok {
+		return page.List, page.Total, nil
+	}
+	raw, err, _ := s.sf.Do(cacheKey, func() (interface{}, error) {
+		if page, ok := getProductListCache(ctx, cacheKey); ok {
+			return page, nil
+		}
+		list, total, err := s.search(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		page := &productListPage{List: list, Total: total}
+		if data, err := json.Marshal(page); err == nil {
+			redis.RDB.Set(ctx, cacheKey, data, productListCacheTTL)
+		}
+		return page, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	page, ok := raw.(*productListPage)
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid product list data type")
+	}
+	return page.List, page.Total, nil
+}
+
+// search 展开分类筛选到全部下级分类后查库。
+func (s *ProductService) search(ctx context.Context, filter repository.ProductFilter) ([]model.Product, int64, error) {
+	if filter.CategoryID > 0 {
+		ids, err := categorySubtreeIDs(ctx, s.catalogRepo, filter.CategoryID)
+		if err != nil {
+			return nil, 0, err
+		}
+		filter.CategoryIDs = ids
+	}
+	return s.repo.Search(ctx, filter, time.Now())
+}
+
+// productListPage 目录缓存内容。
+type productListPage struct {
+	List  []model.Product `json:"list"`
+	Total int64           `json:"total"`
+}
+
+// productListCacheKey 由当前缓存版本与检索条件摘要组成。
+func productListCacheKey(ctx context.Context, filter repository.ProductFilter) (string, error) {
+	version, err := redis.RDB.Get(ctx, productListVersionKey).Result()
+	if errors.Is(err, _redis.Nil) {
+		version = "0"
+	} else if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("product:list:%s:%x", version, sha1.Sum(data)), nil
+}
+
+func getProductListCache(ctx context.Context, key string) (*productListPage, bool) {
+	val, err := redis.RDB.Get(ctx, key).Result()
+	if err != nil {
+		return nil, false
+	}
+	var page productListPage
+	if err := json.Unmarshal([]byte(val), &page); err != nil {
+		return nil, false
+	}
+	return &page, true
+}
+
+// GetProductByID 查询已上架商品详情，未上架视为不存在。
+// 优先读缓存，singleflight 防击穿，null 哨兵防穿透，随机 TTL 防雪崩；库存以 Redis 为准。
 func (s *ProductService) GetProductByID(ctx context.Context, id uint) (*model.Product, error) {
 	if ctx == nil {
 		return nil, fmt.Errorf("context is nil")
@@ -137,6 +297,9 @@ func (s *ProductService) GetProductByID(ctx context.Context, id uint) (*model.Pr
 	if !ok {
 		return nil, fmt.Errorf("invalid product data type")
 	}
+	if !product.Listed() {
+		return nil, ErrProductNotFound
+	}
 	// 以 redis 实时库存为准，避免详情页显示旧库存
 	if stockStr, err := redis.RDB.Get(ctx, fmt.Sprintf("product:stock:%d", product.ID)).Result(); err == nil {
 		if v, convErr := strconv.Atoi(stockStr); convErr == nil {
@@ -146,34 +309,185 @@ func (s *ProductService) GetProductByID(ctx context.Context, id uint) (*model.Pr
 	return product, nil
 }
 
-// UpdateProduct 仅允许创建者更新，未命中则视为不存在。
+// UpdateProduct 仅允许创建者且为审核通过的卖家更新，未命中则视为不存在；调整价格或会员价时按更新后的组合校验。
+// 已上架商品修改名称、价格、图片、会员价、描述、品牌或分类后转为待审核，审核通过前暂停展示。
 func (s *ProductService) UpdateProduct(ctx context.Context, userID, id uint, data map[string]any) error {
 	if ctx == nil {
 		return fmt.Errorf("context is nil")
 	}
+	if err := requireApprovedSeller(ctx, s.sellerRepo, userID); err != nil {
+		return err
+	}
 	if len(data) == 0 {
 		return nil
 	}
-	rows, err := s.repo.UpdateByUser(ctx, id, userID, data)
+	p, err := s.repo.GetByIDAndUser(ctx, id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	memberPrices, hasMemberPrices := data["member_prices"].(model.MemberPrices)
+	price, hasPrice := data["price"].(float64)
+	if hasMemberPrices || hasPrice {
+		if !hasMemberPrices {
+			memberPrices = p.MemberPrices
+		}
+		if !hasPrice {
+			price = p.Price
+		}
+		if err := validateMemberPrices(memberPrices, price); err != nil {
+			return err
+		}
+	}
+	brandID, hasBrand := data["brand_id"].(uint)
+	categoryID, hasCategory := data["category_id"].(uint)
+	if hasBrand || hasCategory {
+		if err := validateProductCatalog(ctx, s.catalogRepo, brandID, categoryID); err != nil {
+			return err
+		}
+	}
+	if raw, ok := data["tags"].(model.ProductTags); ok {
+		tags, err := normalizeProductTags(raw)
+		if err != nil {
+			return err
+		}
+		data["tags"] = tags
+	}
+	if raw, ok := data["images"].(model.ProductImages); ok {
+		images, err := normalizeProductImages(raw)
+		if err != nil {
+			return err
+		}
+		data["images"] = images
+		data["image"] = productCover(images)
+	} else if cover, ok := data["image"].(string); ok {
+		// 只传 image 时替换封面，其余图片保持原顺序
+		raw := model.ProductImages{}
+		if cover != "" {
+			raw = append(raw, model.ProductImage{URL: cover})
+		}
+		if len(p.Images) > 1 {
+			raw = append(raw, p.Images[1:]...)
+		}
+		images, err := normalizeProductImages(raw)
+		if err != nil {
+			return err
+		}
+		data["images"] = images
+		data["image"] = productCover(images)
+	}
+	if p.Status == model.ProductStatusApproved {
+		for _, field := range productReviewedFields {
+			if _, ok := data[field]; ok {
+				data["status"] = model.ProductStatusPendingReview
+				data["submitted_at"] = time.Now()
+				break
+			}
+		}
+	}
+	var rows int64
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewProductRepo(tx)
+		var (
+			old *model.Product
+			err error
+		)
+		if hasPrice {
+			// 加锁读取改价前的价格，与调价计划的执行串行
+			if old, err = txRepo.GetByIDForUpdate(ctx, id); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrProductNotFound
+				}
+				return err
+			}
+		}
+		if rows, err = txRepo.UpdateByUser(ctx, id, userID, data); err != nil || rows == 0 {
+			return err
+		}
+		if old == nil || samePrice(old.Price, price) {
+			return nil
+		}
+		return repository.NewPriceRepo(tx).CreateHistory(ctx, &model.PriceHistory{
+			ProductID:  id,
+			OldPrice:   old.Price,
+			NewPrice:   price,
+			Source:     model.PriceChangeManual,
+			OperatorID: userID,
+		})
+	})
 	if err != nil {
 		return err
 	}
 	if rows == 0 {
 		return ErrProductNotFound
 	}
+	invalidateProductCatalogCache(id)
 	return nil
 }
 
-// DeleteProduct 删除指定用户的商品。
+// SubmitProduct 卖家将草稿、被驳回或已下架的商品提交审核。
+func (s *ProductService) SubmitProduct(ctx context.Context, userID, id uint) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	if err := requireApprovedSeller(ctx, s.sellerRepo, userID); err != nil {
+		return err
+	}
+	return s.transit(ctx, userID, id,
+		[]model.ProductStatus{model.ProductStatusDraft, model.ProductStatusRejected, model.ProductStatusOffline},
+		map[string]any{"status": model.ProductStatusPendingReview, "review_reason": "", "submitted_at": time.Now()})
+}
+
+// TakeOffline 卖家主动下架已上架的商品，重新上架需再次提交审核。
+func (s *ProductService) TakeOffline(ctx context.Context, userID, id uint) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	if err := requireApprovedSeller(ctx, s.sellerRepo, userID); err != nil {
+		return err
+	}
+	return s.transit(ctx, userID, id,
+		[]model.ProductStatus{model.ProductStatusApproved},
+		map[string]any{"status": model.ProductStatusOffline, "review_reason": ""})
+}
+
+func (s *ProductService) transit(ctx context.Context, userID, id uint, from []model.ProductStatus, data map[string]any) error {
+	rows, err := s.repo.UpdateStatus(ctx, id, userID, from, data)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		if _, err := s.repo.GetByIDAndUser(ctx, id, userID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+		return ErrProductStatusConflict
+	}
+	invalidateProductCatalogCache(id)
+	return nil
+}
+
+// DeleteProduct 删除指定卖家的商品。
 func (s *ProductService) DeleteProduct(ctx context.Context, userID, id uint) error {
 	if ctx == nil {
 		return fmt.Errorf("context is nil")
 	}
+	if err := requireApprovedSeller(ctx, s.sellerRepo, userID); err != nil {
+		return err
+	}
 	p, err := s.repo.GetByIDAndUser(ctx, id, userID)
 	if err != nil {
 		return err
 	}
-	return s.repo.Delete(ctx, p.ID)
+	if err := s.repo.Delete(ctx, p.ID); err != nil {
+		return err
+	}
+	invalidateProductCatalogCache(p.ID)
+	return nil
 }
 
 // ListUserProducts 查询用户发布的商品列表。
@@ -183,3 +497,31 @@ func (s *ProductService) ListUserProducts(ctx context.Context, userID uint, page
 	}
 	return s.repo.ListByUserID(ctx, userID, page, size)
 }
+
+// normalizeProductImages 去除首尾空白并按地址去重，保持传入顺序，补全各尺寸变体地址。
+func normalizeProductImages(raw model.ProductImages) (model.ProductImages, error) {
+	images := make(model.ProductImages, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for _, img := range raw {
+		url := strings.TrimSpace(img.URL)
+		if url == "" || len(url) > productImageMaxLength {
+			return nil, ErrProductImagesInvalid
+		}
+		if _, ok := seen[url]; ok {
+			continue
+		}
+		seen[url] = struct{}{}
+		images = append(images, productImageFor(url))
+	}
+	if len(images) > productImageMaxCount {
+		return nil, ErrProductImagesInvalid
+	}
+	return images, nil
+}
+
+func productCover(images model.ProductImages) string {
+	if len(images) == 0 {
+		return ""
+	}
+	return images[0].URL
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApproveProduct 审核通过待审核商品，商品随即上架。
+func (s *AdminService) ApproveProduct(ctx context.Context, id uint) (*model.Product, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	return s.reviewProduct(ctx, id, []model.ProductStatus{model.ProductStatusPendingReview}, model.ProductStatusApproved, "", model.NotifyProductApproved)
+}
+
+// RejectProduct 驳回待审核商品，卖家修改后可重新提交。
+func (s *AdminService) RejectProduct(ctx context.Context, id uint, reason string) (*model.Product, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrProductReasonEmpty
+	}
+	return s.reviewProduct(ctx, id, []model.ProductStatus{model.ProductStatusPendingReview}, model.ProductStatusRejected, reason, model.NotifyProductRejected)
+}
+
+// OfflineProduct 强制下架已上架或待审核的商品。
+func (s *AdminService) OfflineProduct(ctx context.Context, id uint, reason string) (*model.Product, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrProductReasonEmpty
+	}
+	return s.reviewProduct(ctx, id, []model.ProductStatus{model.ProductStatusApproved, model.ProductStatusPendingReview}, model.ProductStatusOffline, reason, model.NotifyProductOffline)
+}
+
+// reviewProduct 条件流转商品状态并在同一事务内通知卖家。
+func (s *AdminService) reviewProduct(ctx context.Context, id uint, from []model.ProductStatus, to model.ProductStatus, reason string, kind model.NotificationKind) (*model.Product, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txProductRepo := repository.NewProductRepo(tx)
+		product, err := txProductRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return err
+		}
+		rows, err := txProductRepo.UpdateStatus(ctx, id, 0, from, map[string]any{
+			"status":        to,
+			"review_reason": reason,
+			"reviewed_at":   time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrProductStatusConflict
+		}
+		notify(ctx, tx, Notice{UserID: product.UserID, Kind: kind, Data: map[string]any{"product": product.Name, "reason": reason}})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateProductCatalogCache(id)
+	return s.productRepo.GetByID(ctx, id)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProductReview_ListingFollowsStatus(t *testing.T) {
+	_, fixtures := newOrderServiceForTest(t)
+	productRepo := repository.NewProductRepo(db.DB)
+	productSvc := NewProductService(db.DB, productRepo, repository.NewSellerRepo(db.DB), repository.NewCatalogRepo(db.DB))
+	adminSvc := NewAdminService(db.DB, repository.NewUserRepo(db.DB), productRepo)
+	seckillSvc := NewSeckillService(db.DB, productRepo)
+	ctx := context.Background()
+	sellerID := fixtures.user.ID
+	if err := db.DB.Create(&model.SellerProfile{UserID: sellerID, StoreName: "鞋仓", Status: model.SellerStatusApproved}).Error; err != nil {
+		t.Fatalf("create seller: %v", err)
+	}
+
+	product := &model.Product{UserID: sellerID, Name: "Dunk Low", Price: 899, Stock: 5, StartTime: time.Now().Add(-time.Minute)}
+	if err := productSvc.CreateProduct(ctx, product); err != nil {
+		t.Fatalf("CreateProduct() error = %v", err)
+	}
+	if product.Status != model.ProductStatusPendingReview || product.SubmittedAt == nil {
+		t.Fatalf("created status = %q, submitted_at = %v", product.Status, product.SubmittedAt)
+	}
+	listed := func() int64 {
+		t.Helper()
+		_, total, err := productSvc.ListProducts(ctx, 1, 10)
+		if err != nil {
+			t.Fatalf("ListProducts() error = %v", err)
+		}
+		return total
+	}
+	// 夹具商品为存量数据，迁移后默认已上架
+	if got := listed(); got != 1 {
+		t.Fatalf("listed = %d, want 1", got)
+	}
+	if _, err := seckillSvc.Seckill(ctx, sellerID, product.ID, false); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("Seckill(pending) error = %v, want %v", err, ErrProductNotFound)
+	}
+	queue, total, err := adminSvc.ListAllProducts(ctx, model.ProductStatusPendingReview, 1, 20)
+	if err != nil || total != 1 || queue[0].ID != product.ID {
+		t.Fatalf("review queue = %+v, %d, %v", queue, total, err)
+	}
+
+	if _, err := adminSvc.RejectProduct(ctx, product.ID, " "); !errors.Is(err, ErrProductReasonEmpty) {
+		t.Fatalf("RejectProduct(empty) error = %v, want %v", err, ErrProductReasonEmpty)
+	}
+	rejected, err := adminSvc.RejectProduct(ctx, product.ID, "图片与描述不符")
+	if err != nil || rejected.Status != model.ProductStatusRejected || rejected.ReviewReason != "图片与描述不符" {
+		t.Fatalf("RejectProduct() = %+v, %v", rejected, err)
+	}
+	if _, err := adminSvc.ApproveProduct(ctx, product.ID); !errors.Is(err, ErrProductStatusConflict) {
+		t.Fatalf("ApproveProduct(rejected) error = %v, want %v", err, ErrProductStatusConflict)
+	}
+	if err := productSvc.SubmitProduct(ctx, sellerID, product.ID); err != nil {
+		t.Fatalf("SubmitProduct() error = %v", err)
+	}
+	approved, err := adminSvc.ApproveProduct(ctx, product.ID)
+	if err != nil || approved.Status != model.ProductStatusApproved || approved.ReviewReason != "" {
+		t.Fatalf("ApproveProduct() = %+v, %v", approved, err)
+	}
+	if got := listed(); got != 2 {
+		t.Fatalf("listed after approve = %d, want 2", got)
+	}
+	if p, err := productSvc.GetProductByID(ctx, product.ID); err != nil || p.ID != product.ID {
+		t.Fatalf("GetProductByID() = %+v, %v", p, err)
+	}
+
+	// 库存与价格调整不影响上架，修改名称需重新审核
+	if err := productSvc.UpdateProduct(ctx, sellerID, product.ID, map[string]any{"stock": 8}); err != nil {
+		t.Fatalf("UpdateProduct(stock) error = %v", err)
+	}
+	if got := listed(); got != 2 {
+		t.Fatalf("listed after stock update = %d, want 2", got)
+	}
+	if err := productSvc.UpdateProduct(ctx, sellerID, product.ID, map[string]any{"price": 799.0}); err != nil {
+		t.Fatalf("UpdateProduct(price) error = %v", err)
+	}
+	if got := listed(); got != 2 {
+		t.Fatalf("listed after price update = %d, want 2", got)
+	}
+	if err := productSvc.UpdateProduct(ctx, sellerID, product.ID, map[string]any{"name": "Dunk Low Retro"}); err != nil {
+		t.Fatalf("UpdateProduct(name) error = %v", err)
+	}
+	if got := listed(); got != 1 {
+		t.Fatalf("listed after name update = %d, want 1", got)
+	}
+	if err := productSvc.TakeOffline(ctx, sellerID, product.ID); !errors.Is(err, ErrProductStatusConflict) {
+		t.Fatalf("TakeOffline(pending) error = %v, want %v", err, ErrProductStatusConflict)
+	}
+	if _, err := adminSvc.OfflineProduct(ctx, product.ID, "涉嫌售假"); err != nil {
+		t.Fatalf("OfflineProduct() error = %v", err)
+	}
+	if err := productSvc.SubmitProduct(ctx, sellerID+1, product.ID); !errors.Is(err, ErrSellerNotApproved) {
+		t.Fatalf("SubmitProduct(other user) error = %v, want %v", err, ErrSellerNotApproved)
+	}
+
+	var kinds []model.NotificationKind
+	db.DB.Model(&model.Notification{}).Where("user_id = ?", sellerID).Order("id asc").Pluck("kind", &kinds)
+	want := []model.NotificationKind{model.NotifyProductRejected, model.NotifyProductApproved, model.NotifyProductOffline}
+	if len(kinds) != len(want) {
+		t.Fatalf("notifications = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("notifications = %v, want %v", kinds, want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestProductService_SearchFiltersSortAndCache(t *testing.T) {
+	_, fixtures := newOrderServiceForTest(t)
+	svc := NewProductService(db.DB, repository.NewProductRepo(db.DB), repository.NewSellerRepo(db.DB), repository.NewCatalogRepo(db.DB))
+	ctx := context.Background()
+
+	now := time.Now()
+	ended := now.Add(-time.Hour)
+	other := &model.User{Username: "bob", Password: "hashed"}
+	if err := db.DB.Create(other).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	catalog := NewCatalogService(db.DB)
+	nike, _ := catalog.CreateBrand(ctx, BrandInput{Name: "Nike"})
+	adidas, _ := catalog.CreateBrand(ctx, BrandInput{Name: "Adidas"})
+	shoes, _ := catalog.CreateCategory(ctx, CategoryInput{Name: "鞋类"})
+	skate, _ := catalog.CreateCategory(ctx, CategoryInput{ParentID: shoes.ID, Name: "板鞋"})
+	running, _ := catalog.CreateCategory(ctx, CategoryInput{ParentID: shoes.ID, Name: "跑鞋"})
+	products := []*model.Product{
+		{UserID: other.ID, Name: "Dunk Low Panda", Description: "经典黑白配色", BrandID: nike.ID, CategoryID: skate.ID, Tags: model.ProductTags{"熊猫", "dunk"}, Price: 799, Stock: 3, StartTime: now.Add(-time.Hour)},
+		{UserID: other.ID, Name: "Yeezy 350", Description: "椰子 100%_限定", BrandID: adidas.ID, CategoryID: running.ID, Price: 1899, Stock: 0, StartTime: now.Add(-time.Hour)},
+		{UserID: other.ID, Name: "Air Max 1", Description: "复刻", SKU: "FD9082-100", BrandID: nike.ID, CategoryID: running.ID, Tags: model.ProductTags{"复刻"}, Price: 1099, Stock: 8, StartTime: now.Add(time.Hour)},
+		{UserID: other.ID, Name: "Samba OG", BrandID: adidas.ID, CategoryID: skate.ID, Tags: model.ProductTags{"复刻版"}, Price: 699, Stock: 5, StartTime: now.Add(-2 * time.Hour), EndTime: &ended},
+		{UserID: other.ID, Name: "Dunk High", BrandID: nike.ID, CategoryID: skate.ID, Price: 899, Stock: 5, StartTime: now.Add(-time.Hour), Status: model.ProductStatusPendingReview},
+	}
+	for _, p := range products {
+		if err := db.DB.Create(p).Error; err != nil {
+			t.Fatalf("create product: %v", err)
+		}
+	}
+	// 夹具商品 ORD-001 未支付，加上两笔订单后 Dunk Low 最热门
+	for i, status := range []model.OrderStatus{model.OrderStatusPaid, model.OrderStatusUnpaid, model.OrderStatusCancelled} {
+		order := &model.Order{UserID: fixtures.user.ID, ProductID: products[0].ID, OrderNum: fmt.Sprintf("SEARCH-%d", i), Status: status}
+		if err := db.DB.Create(order).Error; err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+	}
+
+	price := func(v float64) *float64 { return &v }
+	cases := []struct {
+		name   string
+		filter repository.ProductFilter
+		want   []uint
+	}{
+		{"keyword name", repository.ProductFilter{Keyword: "dunk"}, []uint{products[0].ID}},
+		{"keyword description wildcard", repository.ProductFilter{Keyword: "100%_"}, []uint{products[1].ID}},
+		{"keyword sku", repository.ProductFilter{Keyword: "fd9082"}, []uint{products[2].ID}},
+		{"brand and category", repository.ProductFilter{BrandID: nike.ID, CategoryID: running.ID}, []uint{products[2].ID}},
+		{"parent category", repository.ProductFilter{CategoryID: shoes.ID}, []uint{products[3].ID, products[2].ID, products[1].ID, products[0].ID}},
+		{"tag exact", repository.ProductFilter{Tag: " 复刻 "}, []uint{products[2].ID}},
+		{"price range", repository.ProductFilter{MinPrice: price(700), MaxPrice: price(1100), Sort: repository.ProductSortPriceAsc}, []uint{products[0].ID, products[2].ID}},
+		{"upcoming", repository.ProductFilter{SaleStatus: model.ProductSaleUpcoming}, []uint{products[2].ID}},
+		{"live", repository.ProductFilter{SaleStatus: model.ProductSaleLive, Sort: repository.ProductSortPriceDesc}, []uint{fixtures.product.ID, products[0].ID}},
+		{"sold out", repository.ProductFilter{SaleStatus: model.ProductSaleSoldOut}, []uint{products[1].ID}},
+		{"ended", repository.ProductFilter{SaleStatus: model.ProductSaleEnded}, []uint{products[3].ID}},
+		{"seller start time", repository.ProductFilter{SellerID: other.ID, Sort: repository.ProductSortStartTimeDesc}, []uint{products[2].ID, products[1].ID, products[0].ID, products[3].ID}},
+		{"popular", repository.ProductFilter{Sort: repository.ProductSortPopular, PageSize: 2}, []uint{products[0].ID, fixtures.product.ID}},
+	}
+	for _, tc := range cases {
+		list, _, err := svc.SearchProducts(ctx, tc.filter)
+		if err != nil {
+			t.Fatalf("%s: SearchProducts() error = %v", tc.name, err)
+		}
+		var got []uint
+		for _, p := range list {
+			got = append(got, p.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Fatalf("%s: ids = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	// 命中缓存时看不到直接写库的变更，失效后重新查询
+	filter := repository.ProductFilter{BrandID: nike.ID}
+	if _, total, _ := svc.SearchProducts(ctx, filter); total != 2 {
+		t.Fatalf("nike total = %d, want 2", total)
+	}
+	if err := db.DB.Model(&model.Product{}).Where("id = ?", products[4].ID).Update("status", model.ProductStatusApproved).Error; err != nil {
+		t.Fatalf("approve product: %v", err)
+	}
+	if _, total, _ := svc.SearchProducts(ctx, filter); total != 2 {
+		t.Fatalf("cached nike total = %d, want 2", total)
+	}
+	// 库存类失效只清详情缓存，不影响目录缓存
+	invalidateProductInfoCache(products[4].ID)
+	time.Sleep(50 * time.Millisecond)
+	if _, total, _ := svc.SearchProducts(ctx, filter); total != 2 {
+		t.Fatalf("nike total after info invalidation = %d, want 2", total)
+	}
+	invalidateProductCatalogCache(products[4].ID)
+	if _, total, err := svc.SearchProducts(ctx, filter); err != nil || total != 3 {
+		t.Fatalf("nike total after catalog invalidation = %d, %v, want 3", total, err)
+	}
+}
@@ -0,0 +1,478 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInviteCodeInvalid     = errors.New("邀请码无效")
+	ErrReferralStatusInvalid = errors.New("邀请状态无效")
+)
+
+// inviteCodeAlphabet 邀请码字符集，去掉易混淆的 0/O/1/I。
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const inviteCodeLength = 8
+
+// referralRules 邀请奖励与反作弊规则，来自配置文件 referral 段。
+type referralRules struct {
+	inviterPoints   int64
+	inviteePoints   int64
+	inviterCouponID uint
+	inviteeCouponID uint
+	maxPerSource    int64
+	footprintTTL    time.Duration
+}
+
+func loadReferralRules() referralRules {
+	cfg := config.Conf.Referral
+	rules := referralRules{
+		inviterPoints:   cfg.InviterPoints,
+		inviteePoints:   cfg.InviteePoints,
+		inviterCouponID: cfg.InviterCouponID,
+		inviteeCouponID: cfg.InviteeCouponID,
+		maxPerSource:    int64(cfg.MaxPerSource),
+		footprintTTL:    time.Duration(cfg.FootprintDays) * 24 * time.Hour,
+	}
+	if rules.inviterPoints <= 0 {
+		rules.inviterPoints = loadPointsRules().referralBonus
+	}
+	if rules.maxPerSource <= 0 {
+		rules.maxPerSource = 3
+	}
+	if rules.footprintTTL <= 0 {
+		rules.footprintTTL = 90 * 24 * time.Hour
+	}
+	return rules
+}
+
+// ClientMeta 请求来源，用于邀请反作弊。
+type ClientMeta struct {
+	IP       string
+	DeviceID string
+}
+
+// ReferralRewards 邀请双方可获得的奖励。
+type ReferralRewards struct {
+	InviterPoints   int64 `json:"inviter_points"`
+	InviteePoints   int64 `json:"invitee_points"`
+	InviterCouponID uint  `json:"inviter_coupon_id,omitempty"`
+	InviteeCouponID uint  `json:"invitee_coupon_id,omitempty"`
+}
+
+// ReferralCounts 按状态统计的邀请数。
+type ReferralCounts struct {
+	Invited  int64 `json:"invited"`
+	Pending  int64 `json:"pending"`
+	Rewarded int64 `json:"rewarded"`
+	Rejected int64 `json:"rejected"`
+	Revoked  int64 `json:"revoked"`
+}
+
+// MyReferral 我的邀请码与邀请统计。
+type MyReferral struct {
+	InviteCode string          `json:"invite_code"`
+	Counts     ReferralCounts  `json:"counts"`
+	Rewards    ReferralRewards `json:"rewards"`
+}
+
+// ReferralRecord 管理台邀请记录，附带双方用户名。
+type ReferralRecord struct {
+	model.Referral
+	InviterName string `json:"inviter_name"`
+	InviteeName string `json:"invitee_name"`
+}
+
+// ReferralInviterStat 邀请人转化排行。
+type ReferralInviterStat struct {
+	repository.InviterConversion
+	InviterName string `json:"inviter_name"`
+}
+
+// ReferralReport 邀请转化报表。
+type ReferralReport struct {
+	ReferralCounts
+	ConversionRate float64               `json:"conversion_rate"` // 首单转化率：已奖励 / 有效邀请（不含被拒）
+	TopInviters    []ReferralInviterStat `json:"top_inviters"`
+}
+
+// ReferralService 邀请服务：邀请码、注册绑定与反作弊、转化报表。奖励在首单支付事务内发放。
+type ReferralService struct {
+	db           *gorm.DB
+	referralRepo *repository.ReferralRepo
+	userRepo     *repository.UserRepo
+	riskSvc      *RiskService
+}
+
+func NewReferralService(db *gorm.DB, riskSvc *RiskService) *ReferralService {
+	return &ReferralService{
+		db:           db,
+		referralRepo: repository.NewReferralRepo(db),
+		userRepo:     repository.NewUserRepo(db),
+		riskSvc:      riskSvc,
+	}
+}
+
+// Mine 查询我的邀请码（首次查询时生成）与邀请统计。
+func (s *ReferralService) Mine(ctx context.Context, userID uint) (*MyReferral, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	code, err := s.ensureInviteCode(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.counts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	rules := loadReferralRules()
+	return &MyReferral{
+		InviteCode: code,
+		Counts:     counts,
+		Rewards: ReferralRewards{
+			InviterPoints:   rules.inviterPoints,
+			InviteePoints:   rules.inviteePoints,
+			InviterCouponID: rules.inviterCouponID,
+			InviteeCouponID: rules.inviteeCouponID,
+		},
+	}, nil
+}
+
+// List 管理台分页查询邀请记录。
+func (s *ReferralService) List(ctx context.Context, inviterID uint, status string, page, pageSize int) ([]ReferralRecord, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	referralStatus, err := parseReferralStatus(status)
+	if err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	referrals, total, err := s.referralRepo.List(ctx, inviterID, referralStatus, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]uint, 0, len(referrals)*2)
+	for _, r := range referrals {
+		ids = append(ids, r.InviterID, r.InviteeID)
+	}
+	names, err := s.userRepo.UsernamesByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	records := make([]ReferralRecord, 0, len(referrals))
+	for _, r := range referrals {
+		records = append(records, ReferralRecord{Referral: r, InviterName: names[r.InviterID], InviteeName: names[r.InviteeID]})
+	}
+	return records, total, nil
+}
+
+// Report 邀请转化报表：各状态数量、首单转化率与转化排行。
+func (s *ReferralService) Report(ctx context.Context, top int) (*ReferralReport, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if top <= 0 || top > 100 {
+		top = 10
+	}
+	counts, err := s.counts(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	report := &ReferralReport{ReferralCounts: counts}
+	if valid := counts.Invited - counts.Rejected; valid > 0 {
+		report.ConversionRate = float64(counts.Rewarded) / float64(valid)
+	}
+	inviters, err := s.referralRepo.TopInviters(ctx, top)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(inviters))
+	for _, i := range inviters {
+		ids = append(ids, i.InviterID)
+	}
+	names, err := s.userRepo.UsernamesByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	report.TopInviters = make([]ReferralInviterStat, 0, len(inviters))
+	for _, i := range inviters {
+		report.TopInviters = append(report.TopInviters, ReferralInviterStat{InviterConversion: i, InviterName: names[i.InviterID]})
+	}
+	return report, nil
+}
+
+// RecordFootprint 记录用户本次登录/注册的 IP 与设备，失败仅记录日志。
+func (s *ReferralService) RecordFootprint(ctx context.Context, userID uint, client ClientMeta) {
+	if s.riskSvc == nil {
+		return
+	}
+	if err := s.riskSvc.RecordFootprint(ctx, userID, client.IP, client.DeviceID, loadReferralRules().footprintTTL); err != nil {
+		slog.Warn("记录登录足迹失败", slog.Uint64("user_id", uint64(userID)), slog.Any("err", err))
+	}
+}
+
+// resolveInviter 按邀请码查找邀请人。
+func (s *ReferralService) resolveInviter(ctx context.Context, code string) (uint, error) {
+	ic, err := s.referralRepo.GetInviteCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrInviteCodeInvalid
+		}
+		return 0, err
+	}
+	return ic.UserID, nil
+}
+
+// bind 记录邀请关系；命中反作弊规则的直接标记为被拒，不影响注册本身。
+func (s *ReferralService) bind(ctx context.Context, inviterID, inviteeID uint, client ClientMeta) (*model.Referral, error) {
+	ic, err := s.referralRepo.GetInviteCodeByUser(ctx, inviterID)
+	if err != nil {
+		return nil, err
+	}
+	referral := &model.Referral{
+		InviterID:  inviterID,
+		InviteeID:  inviteeID,
+		Code:       ic.Code,
+		Status:     model.ReferralStatusPending,
+		RegisterIP: client.IP,
+		DeviceID:   client.DeviceID,
+	}
+	if reason := s.screen(ctx, inviterID, inviteeID, client); reason != "" {
+		referral.Status = model.ReferralStatusRejected
+		referral.RejectReason = reason
+	}
+	if err := s.referralRepo.Create(ctx, referral); err != nil {
+		return nil, err
+	}
+	return referral, nil
+}
+
+// screen 注册时的反作弊判定，返回被拒原因；风控存储不可用时跳过对应检查。
+func (s *ReferralService) screen(ctx context.Context, inviterID, inviteeID uint, client ClientMeta) string {
+	if inviterID == inviteeID {
+		return model.ReferralRejectSelf
+	}
+	if s.riskSvc != nil {
+		listed, err := s.riskSvc.IsListed(ctx, []uint{inviterID}, []string{client.IP})
+		if err != nil {
+			slog.Warn("邀请风控名单检查失败", slog.Uint64("inviter_id", uint64(inviterID)), slog.Any("err", err))
+		} else if listed {
+			return model.ReferralRejectRiskListed
+		}
+		sameIP, sameDevice, err := s.riskSvc.MatchFootprint(ctx, inviterID, client.IP, client.DeviceID)
+		if err != nil {
+			slog.Warn("邀请足迹比对失败", slog.Uint64("inviter_id", uint64(inviterID)), slog.Any("err", err))
+		}
+		if sameDevice {
+			return model.ReferralRejectSelf
+		}
+		if sameIP {
+			return model.ReferralRejectSameIP
+		}
+	}
+	n, err := s.referralRepo.CountBySource(ctx, inviterID, client.IP, client.DeviceID)
+	if err != nil {
+		slog.Warn("邀请来源统计失败", slog.Uint64("inviter_id", uint64(inviterID)), slog.Any("err", err))
+	} else if n >= loadReferralRules().maxPerSource {
+		return model.ReferralRejectSourceLimit
+	}
+	return ""
+}
+
+// ensureInviteCode 返回用户邀请码，不存在时生成；随机码冲突时重试。
+func (s *ReferralService) ensureInviteCode(ctx context.Context, userID uint) (string, error) {
+	ic, err := s.referralRepo.GetInviteCodeByUser(ctx, userID)
+	if err == nil {
+		return ic.Code, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	for i := 0; i < 5; i++ {
+		code, err := newInviteCode()
+		if err != nil {
+			return "", err
+		}
+		err = s.referralRepo.CreateInviteCode(ctx, &model.InviteCode{UserID: userID, Code: code})
+		if err == nil {
+			return code, nil
+		}
+		if !errors.Is(err, gorm.ErrDuplicatedKey) && !isMySQLDuplicate(err) {
+			return "", err
+		}
+		// 并发生成时以已落库的为准
+		if ic, getErr := s.referralRepo.GetInviteCodeByUser(ctx, userID); getErr == nil {
+			return ic.Code, nil
+		}
+	}
+	return "", fmt.Errorf("generate invite code: too many collisions")
+}
+
+func (s *ReferralService) counts(ctx context.Context, inviterID uint) (ReferralCounts, error) {
+	rows, err := s.referralRepo.CountByStatus(ctx, inviterID)
+	if err != nil {
+		return ReferralCounts{}, err
+	}
+	var counts ReferralCounts
+	for _, row := range rows {
+		counts.Invited += row.Total
+		switch row.Status {
+		case model.ReferralStatusPending:
+			counts.Pending = row.Total
+		case model.ReferralStatusRewarded:
+			counts.Rewarded = row.Total
+		case model.ReferralStatusRejected:
+			counts.Rejected = row.Total
+		case model.ReferralStatusRevoked:
+			counts.Revoked = row.Total
+		}
+	}
+	return counts, nil
+}
+
+func newInviteCode() (string, error) {
+	buf := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = inviteCodeAlphabet[int(b)%len(inviteCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+func parseReferralStatus(status string) (model.ReferralStatus, error) {
+	switch s := model.ReferralStatus(strings.TrimSpace(status)); s {
+	case "", model.ReferralStatusPending, model.ReferralStatusRewarded, model.ReferralStatusRejected, model.ReferralStatusRevoked:
+		return s, nil
+	default:
+		return "", ErrReferralStatusInvalid
+	}
+}
+
+// referralSource 邀请奖励券的来源标记，撤销时按此作废。
+func referralSource(referralID uint) string {
+	return fmt.Sprintf("referral:%d", referralID)
+}
+
+// rewardReferral 被邀请人首单支付后在同一事务内为双方发放奖励；发放前复查风控名单。
+func rewardReferral(ctx context.Context, tx *gorm.DB, order *model.Order, now time.Time) error {
+	referralRepo := repository.NewReferralRepo(tx)
+	referral, err := referralRepo.GetByInviteeForUpdate(ctx, order.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if referral.Status != model.ReferralStatusPending {
+		return nil
+	}
+	if redis.RDB != nil {
+		listed, err := NewRiskService(redis.RDB).IsListed(ctx, []uint{referral.InviterID, referral.InviteeID}, nil)
+		if err != nil {
+			slog.Warn("邀请奖励风控复查失败", slog.Uint64("referral_id", uint64(referral.ID)), slog.Any("err", err))
+		} else if listed {
+			return referralRepo.Update(ctx, referral.ID, map[string]any{
+				"status":        model.ReferralStatusRejected,
+				"reject_reason": model.ReferralRejectRiskListed,
+			})
+		}
+	}
+
+	rules := loadReferralRules()
+	source := referralSource(referral.ID)
+	if err := earnPoints(ctx, tx, referral.InviterID, rules.inviterPoints, model.PointKindReferral, order.ID, "邀请好友完成首单", now); err != nil {
+		return err
+	}
+	if err := earnPoints(ctx, tx, referral.InviteeID, rules.inviteePoints, model.PointKindReferral, order.ID, "受邀完成首单", now); err != nil {
+		return err
+	}
+	if err := issueReferralCoupon(ctx, tx, referral.InviterID, rules.inviterCouponID, source, now); err != nil {
+		return err
+	}
+	if err := issueReferralCoupon(ctx, tx, referral.InviteeID, rules.inviteeCouponID, source, now); err != nil {
+		return err
+	}
+	return referralRepo.Update(ctx, referral.ID, map[string]any{
+		"status":      model.ReferralStatusRewarded,
+		"order_id":    order.ID,
+		"rewarded_at": now,
+	})
+}
+
+// issueReferralCoupon 按模板发放邀请奖励券；模板不存在或已下线时跳过，不阻断支付。
+func issueReferralCoupon(ctx context.Context, tx *gorm.DB, userID, couponID uint, source string, now time.Time) error {
+	if couponID == 0 {
+		return nil
+	}
+	c, err := repository.NewCouponRepo(tx).GetByID(ctx, couponID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			slog.Warn("邀请奖励券模板不存在", slog.Uint64("coupon_id", uint64(couponID)))
+			return nil
+		}
+		return err
+	}
+	if c.Status != model.CouponTemplateStatusActive || !c.ValidTo.After(now) {
+		slog.Warn("邀请奖励券模板不可用", slog.Uint64("coupon_id", uint64(couponID)))
+		return nil
+	}
+	return repository.NewUserCouponRepo(tx).Create(ctx, &model.UserCoupon{
+		UserID:       userID,
+		CouponID:     c.ID,
+		Status:       model.CouponStatusAvailable,
+		ObtainedFrom: source,
+		ValidFrom:    c.ValidFrom,
+		ValidTo:      c.ValidTo,
+		IssuedAt:     now,
+	})
+}
+
+// revokeReferral 触发奖励的首单退款时收回双方奖励：扣回积分（已消耗部分不再追回），作废未使用的奖励券。
+func revokeReferral(ctx context.Context, tx *gorm.DB, order *model.Order, now time.Time) error {
+	referralRepo := repository.NewReferralRepo(tx)
+	referral, err := referralRepo.GetByInviteeForUpdate(ctx, order.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if referral.Status != model.ReferralStatusRewarded || referral.OrderID != order.ID {
+		return nil
+	}
+	pointRepo := repository.NewPointRepo(tx)
+	for _, userID := range []uint{referral.InviterID, referral.InviteeID} {
+		earned, err := pointRepo.SumByUserOrder(ctx, userID, order.ID, model.PointKindReferral)
+		if err != nil {
+			return err
+		}
+		if _, err := spendPoints(ctx, tx, userID, earned, model.PointKindReferralRevoke, order.ID, order.OrderNum, now, false); err != nil {
+			return err
+		}
+	}
+	if _, err := repository.NewUserCouponRepo(tx).ExpireAvailableByObtainedFrom(ctx, referralSource(referral.ID)); err != nil {
+		return err
+	}
+	return referralRepo.Update(ctx, referral.ID, map[string]any{"status": model.ReferralStatusRevoked})
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReferralService_RegisterRewardAndRevoke(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	riskSvc := NewRiskService(redis.RDB)
+	referralSvc := NewReferralService(db.DB, riskSvc)
+	userSvc := NewUserService(repository.NewUserRepo(db.DB), referralSvc)
+	ctx := context.Background()
+	inviterID := fixtures.user.ID
+
+	coupon := &model.Coupon{
+		Type:        model.CouponTypeFullCut,
+		Title:       "邀请券",
+		AmountCents: 1000,
+		ValidFrom:   time.Now().Add(-time.Hour),
+		ValidTo:     time.Now().Add(24 * time.Hour),
+		Status:      model.CouponTemplateStatusActive,
+	}
+	if err := db.DB.Create(coupon).Error; err != nil {
+		t.Fatalf("create coupon: %v", err)
+	}
+	prev := config.Conf.Referral
+	t.Cleanup(func() { config.Conf.Referral = prev })
+	config.Conf.Referral = config.ReferralConfig{InviterPoints: 300, InviteePoints: 100, InviterCouponID: coupon.ID, InviteeCouponID: coupon.ID}
+
+	mine, err := referralSvc.Mine(ctx, inviterID)
+	if err != nil || len(mine.InviteCode) != inviteCodeLength {
+		t.Fatalf("Mine() = %+v, %v", mine, err)
+	}
+	referralSvc.RecordFootprint(ctx, inviterID, ClientMeta{IP: "10.0.0.1", DeviceID: "dev-alice"})
+
+	if err := userSvc.Register(ctx, "nobody", "password-123", "XXXXXXXX", ClientMeta{}); !errors.Is(err, ErrInviteCodeInvalid) {
+		t.Fatalf("Register(bad code) error = %v, want %v", err, ErrInviteCodeInvalid)
+	}
+	// 同设备：视为自邀
+	if err := userSvc.Register(ctx, "alt", "password-123", mine.InviteCode, ClientMeta{IP: "10.0.0.9", DeviceID: "dev-alice"}); err != nil {
+		t.Fatalf("Register(alt) error = %v", err)
+	}
+	// 正常邀请，邀请码大小写不敏感
+	if err := userSvc.Register(ctx, "bob", "password-123", " "+strings.ToLower(mine.InviteCode)+" ", ClientMeta{IP: "10.0.0.2", DeviceID: "dev-bob"}); err != nil {
+		t.Fatalf("Register(bob) error = %v", err)
+	}
+
+	referralOf := func(username string) model.Referral {
+		t.Helper()
+		var u model.User
+		if err := db.DB.Where("username = ?", username).First(&u).Error; err != nil {
+			t.Fatalf("load user: %v", err)
+		}
+		var r model.Referral
+		if err := db.DB.Where("invitee_id = ?", u.ID).First(&r).Error; err != nil {
+			t.Fatalf("load referral: %v", err)
+		}
+		return r
+	}
+	if r := referralOf("alt"); r.Status != model.ReferralStatusRejected || r.RejectReason != model.ReferralRejectSelf {
+		t.Fatalf("alt referral = %+v", r)
+	}
+	bobReferral := referralOf("bob")
+	if bobReferral.Status != model.ReferralStatusPending || bobReferral.InviterID != inviterID {
+		t.Fatalf("bob referral = %+v", bobReferral)
+	}
+
+	// bob 首单支付：双方获得积分与券
+	order := &model.Order{UserID: bobReferral.InviteeID, ProductID: fixtures.product.ID, OrderNum: "ORD-BOB", Status: model.OrderStatusUnpaid, Type: model.OrderTypeProduct}
+	if err := db.DB.Create(order).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	payment := &model.Payment{OrderID: order.ID, PaymentID: "PAY-BOB", AmountCents: 10_000, Status: model.PaymentStatusPending}
+	if err := db.DB.Create(payment).Error; err != nil {
+		t.Fatalf("create payment: %v", err)
+	}
+	if _, err := orderSvc.HandlePaymentResult(ctx, payment.PaymentID, model.PaymentStatusPaid, "ok"); err != nil {
+		t.Fatalf("HandlePaymentResult(paid) error = %v", err)
+	}
+	pointRepo := repository.NewPointRepo(db.DB)
+	if got, _ := pointRepo.SumByUserOrder(ctx, inviterID, order.ID, model.PointKindReferral); got != 300 {
+		t.Fatalf("inviter referral points = %d, want 300", got)
+	}
+	if got, _ := pointRepo.SumByUserOrder(ctx, bobReferral.InviteeID, order.ID, model.PointKindReferral); got != 100 {
+		t.Fatalf("invitee referral points = %d, want 100", got)
+	}
+	var issued int64
+	db.DB.Model(&model.UserCoupon{}).Where("obtained_from = ? AND status = ?", referralSource(bobReferral.ID), model.CouponStatusAvailable).Count(&issued)
+	if issued != 2 {
+		t.Fatalf("referral coupons issued = %d, want 2", issued)
+	}
+	if r := referralOf("bob"); r.Status != model.ReferralStatusRewarded || r.OrderID != order.ID {
+		t.Fatalf("bob referral after pay = %+v", r)
+	}
+
+	report, err := referralSvc.Report(ctx, 10)
+	if err != nil || report.Invited != 2 || report.Rewarded != 1 || report.Rejected != 1 || report.ConversionRate != 1 {
+		t.Fatalf("Report() = %+v, %v", report, err)
+	}
+	if len(report.TopInviters) != 1 || report.TopInviters[0].InviterName != "alice" {
+		t.Fatalf("TopInviters = %+v", report.TopInviters)
+	}
+
+	// 首单退款：收回奖励
+	if _, err := orderSvc.HandlePaymentResult(ctx, payment.PaymentID, model.PaymentStatusRefunded, "refund"); err != nil {
+		t.Fatalf("HandlePaymentResult(refunded) error = %v", err)
+	}
+	if got, _ := pointRepo.SumByUserOrder(ctx, inviterID, order.ID, model.PointKindReferralRevoke); got != -300 {
+		t.Fatalf("inviter revoked points = %d, want -300", got)
+	}
+	db.DB.Model(&model.UserCoupon{}).Where("obtained_from = ? AND status = ?", referralSource(bobReferral.ID), model.CouponStatusAvailable).Count(&issued)
+	if issued != 0 {
+		t.Fatalf("referral coupons left = %d, want 0", issued)
+	}
+	if r := referralOf("bob"); r.Status != model.ReferralStatusRevoked {
+		t.Fatalf("bob referral after refund = %+v", r)
+	}
+
+	// 邀请人进入黑名单后，新邀请直接被拒
+	if err := riskSvc.AddBlacklist(ctx, "user", strconv.FormatUint(uint64(inviterID), 10)); err != nil {
+		t.Fatalf("AddBlacklist() error = %v", err)
+	}
+	if err := userSvc.Register(ctx, "carol", "password-123", mine.InviteCode, ClientMeta{IP: "10.0.0.3"}); err != nil {
+		t.Fatalf("Register(carol) error = %v", err)
+	}
+	if r := referralOf("carol"); r.Status != model.ReferralStatusRejected || r.RejectReason != model.ReferralRejectRiskListed {
+		t.Fatalf("carol referral = %+v", r)
+	}
+}
@@ -5,13 +5,34 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 var ErrRiskEntryTypeInvalid = errors.New("风控名单类型无效")
 
+// penaltyGrayKey 由未支付处罚加入灰名单的用户，解除处罚时只移出这些用户。
+const penaltyGrayKey = "risk:user:gray:penalty"
+
+// addPenaltyGrayScript 用户不在灰名单时加入并记为处罚所加。
+var addPenaltyGrayScript = redis.NewScript(`
+if redis.call('SADD', KEYS[1], ARGV[1]) == 1 then
+  redis.call('SADD', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// removePenaltyGrayScript 只移出由处罚加入的灰名单用户。
+var removePenaltyGrayScript = redis.NewScript(`
+if redis.call('SREM', KEYS[2], ARGV[1]) == 1 then
+  redis.call('SREM', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
 type RiskService struct {
 	rdb *redis.Client
 }
@@ -36,12 +57,110 @@ func (s *RiskService) ListGraylist(ctx context.Context) (ips, users []string, er
 	return s.list(ctx, "gray")
 }
 
+// AddGraylist 管理员加入灰名单，已由处罚加入的用户改为手工维护，解除处罚时不再移出。
 func (s *RiskService) AddGraylist(ctx context.Context, entryType, value string) error {
-	return s.add(ctx, "gray", entryType, value)
+	if err := s.add(ctx, "gray", entryType, value); err != nil {
+		return err
+	}
+	return s.releasePenaltyGray(ctx, entryType, value)
 }
 
 func (s *RiskService) RemoveGraylist(ctx context.Context, entryType, value string) error {
-	return s.remove(ctx, "gray", entryType, value)
+	if err := s.remove(ctx, "gray", entryType, value); err != nil {
+		return err
+	}
+	return s.releasePenaltyGray(ctx, entryType, value)
+}
+
+// AddPenaltyGraylist 处罚将用户加入灰名单，用户已在名单中时保持原状。
+func (s *RiskService) AddPenaltyGraylist(ctx context.Context, userID uint) error {
+	if s.rdb == nil {
+		return fmt.Errorf("redis is nil")
+	}
+	_, userKey, _ := riskKeys("gray")
+	return addPenaltyGrayScript.Run(ctx, s.rdb, []string{userKey, penaltyGrayKey}, strconv.FormatUint(uint64(userID), 10)).Err()
+}
+
+// RemovePenaltyGraylist 解除处罚时移出灰名单，管理员手工加入的不受影响。
+func (s *RiskService) RemovePenaltyGraylist(ctx context.Context, userID uint) error {
+	if s.rdb == nil {
+		return fmt.Errorf("redis is nil")
+	}
+	_, userKey, _ := riskKeys("gray")
+	return removePenaltyGrayScript.Run(ctx, s.rdb, []string{userKey, penaltyGrayKey}, strconv.FormatUint(uint64(userID), 10)).Err()
+}
+
+// releasePenaltyGray 管理员操作过的用户不再由处罚维护。
+func (s *RiskService) releasePenaltyGray(ctx context.Context, entryType, value string) error {
+	if strings.TrimSpace(entryType) != "user" {
+		return nil
+	}
+	return s.rdb.SRem(ctx, penaltyGrayKey, strings.TrimSpace(value)).Err()
+}
+
+// RecordFootprint 记录用户近期使用的 IP 与设备，供邀请反作弊比对。
+func (s *RiskService) RecordFootprint(ctx context.Context, userID uint, ip, deviceID string, ttl time.Duration) error {
+	if s.rdb == nil {
+		return fmt.Errorf("redis is nil")
+	}
+	members := footprintMembers(ip, deviceID)
+	if len(members) == 0 {
+		return nil
+	}
+	key := footprintKey(userID)
+	pipe := s.rdb.TxPipeline()
+	pipe.SAdd(ctx, key, members...)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// MatchFootprint 判断 IP/设备是否出现在用户近期足迹中。
+func (s *RiskService) MatchFootprint(ctx context.Context, userID uint, ip, deviceID string) (sameIP, sameDevice bool, err error) {
+	if s.rdb == nil {
+		return false, false, fmt.Errorf("redis is nil")
+	}
+	key := footprintKey(userID)
+	if ip = strings.TrimSpace(ip); ip != "" {
+		if sameIP, err = s.rdb.SIsMember(ctx, key, "ip:"+ip).Result(); err != nil {
+			return false, false, err
+		}
+	}
+	if deviceID = strings.TrimSpace(deviceID); deviceID != "" {
+		if sameDevice, err = s.rdb.SIsMember(ctx, key, "dev:"+deviceID).Result(); err != nil {
+			return false, false, err
+		}
+	}
+	return sameIP, sameDevice, nil
+}
+
+// IsListed 判断用户或 IP 是否在黑名单或灰名单中。
+func (s *RiskService) IsListed(ctx context.Context, userIDs []uint, ips []string) (bool, error) {
+	if s.rdb == nil {
+		return false, fmt.Errorf("redis is nil")
+	}
+	for _, listType := range []string{"black", "gray"} {
+		ipKey, userKey, err := riskKeys(listType)
+		if err != nil {
+			return false, err
+		}
+		for _, id := range userIDs {
+			in, err := s.rdb.SIsMember(ctx, userKey, strconv.FormatUint(uint64(id), 10)).Result()
+			if err != nil || in {
+				return in, err
+			}
+		}
+		for _, ip := range ips {
+			if ip == "" {
+				continue
+			}
+			in, err := s.rdb.SIsMember(ctx, ipKey, ip).Result()
+			if err != nil || in {
+				return in, err
+			}
+		}
+	}
+	return false, nil
 }
 
 func (s *RiskService) list(ctx context.Context, listType string) (ips, users []string, err error) {
@@ -122,3 +241,18 @@ func riskEntryKey(listType, entryType string) (string, error) {
 		return "", ErrRiskEntryTypeInvalid
 	}
 }
+
+func footprintKey(userID uint) string {
+	return fmt.Sprintf("risk:footprint:%d", userID)
+}
+
+func footprintMembers(ip, deviceID string) []any {
+	var members []any
+	if ip = strings.TrimSpace(ip); ip != "" {
+		members = append(members, "ip:"+ip)
+	}
+	if deviceID = strings.TrimSpace(deviceID); deviceID != "" {
+		members = append(members, "dev:"+deviceID)
+	}
+	return members
+}
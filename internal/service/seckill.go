@@ -23,8 +23,13 @@ import (
 // lua 脚本: 原子检查库存, 扣减, 记录用户
 // key1 商品库存
 // key2 商品购买用户
+// key3 商品抢购请求计数，供卖家统计转化率
 // argv1 用户 id
+// argv2 请求计数保留秒数，每次请求顺延
 var seckillScript = _redis.NewScript(`
+	redis.call("INCR", KEYS[3])
+	redis.call("EXPIRE", KEYS[3], ARGV[2])
+
 	-- 1. 检查用户是否已经抢购过
 	if redis.call("SISMEMBER", KEYS[2], ARGV[1]) == 1 then
 		return -1 -- 重复抢购
@@ -46,16 +51,20 @@ var seckillScript = _redis.NewScript(`
 
 // SeckillService 秒杀服务，负责 Redis 原子扣减 + Outbox 投递。
 type SeckillService struct {
-	db          *gorm.DB
-	productRepo *repository.ProductRepo
-	outboxRepo  *repository.OutboxRepo
+	db           *gorm.DB
+	productRepo  *repository.ProductRepo
+	outboxRepo   *repository.OutboxRepo
+	vipSvc       *VIPService
+	vipConfigSvc *VIPConfigService
 }
 
 func NewSeckillService(db *gorm.DB, productRepo *repository.ProductRepo) *SeckillService {
 	return &SeckillService{
-		db:          db,
-		productRepo: productRepo,
-		outboxRepo:  repository.NewOutboxRepo(db),
+		db:           db,
+		productRepo:  productRepo,
+		outboxRepo:   repository.NewOutboxRepo(db),
+		vipSvc:       NewVIPService(db, repository.NewUserRepo(db), nil),
+		vipConfigSvc: NewVIPConfigService(db),
 	}
 }
 
@@ -65,6 +74,7 @@ var (
 	ErrSeckillBusy     = errors.New("系统繁忙, 请稍后重试")
 	ErrSeckillNotStart = errors.New("活动尚未开始")
 	ErrSeckillEnded    = errors.New("活动已结束")
+	ErrSeckillVIPOnly  = errors.New("该商品仅限更高 VIP 等级抢购")
 )
 
 var (
@@ -82,12 +92,13 @@ type SeckillResult struct {
 
 // Seckill 秒杀扣减库存并投递消息，由 worker 落库；Redis 原子扣减保护库存。
 // 使用 Outbox 模式：先写本地消息表，再异步发送 Kafka，保证消息最终一致性。
-func (s *SeckillService) Seckill(ctx context.Context, userID, productID uint) (*SeckillResult, error) {
+// autoCoupon 为 true 时 worker 创建订单后自动使用最优券。
+func (s *SeckillService) Seckill(ctx context.Context, userID, productID uint, autoCoupon bool) (*SeckillResult, error) {
 	if ctx == nil {
 		return nil, fmt.Errorf("context is nil")
 	}
 
-	// 0. 校验商品存在与开始时间
+	// 0. 校验商品已上架、等级门槛与个人开抢时间
 	product, err := s.productRepo.GetByID(ctx, productID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -95,22 +106,53 @@ func (s *SeckillService) Seckill(ctx context.Context, userID, productID uint) (*
 		}
 		return nil, err
 	}
-	if time.Now().Before(product.StartTime) {
-		return nil, ErrSeckillNotStart
+	if !product.Listed() {
+		return nil, ErrProductNotFound
 	}
-	if product.EndTime != nil && time.Now().After(*product.EndTime) {
+	now := time.Now()
+	if product.EndTime != nil && now.After(*product.EndTime) {
 		return nil, ErrSeckillEnded
 	}
+	if now.Before(product.StartTime) {
+		// 早于任何等级的提前入场时间，无需查询用户等级
+		cfg, err := s.vipConfigSvc.Current(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if now.Before(product.StartTime.Add(-cfg.MaxEarlyAccess())) {
+			return nil, ErrSeckillNotStart
+		}
+	}
+	// 有等级门槛、会员价或处于提前入场窗口时才查询用户等级（走缓存）
+	var access *DropAccess
+	if product.MinVIPLevel > 0 || len(product.MemberPrices) > 0 || now.Before(product.StartTime) {
+		access, err = s.vipSvc.DropAccess(ctx, userID, product)
+		if err != nil {
+			return nil, err
+		}
+		if !access.Eligible {
+			return nil, ErrSeckillVIPOnly
+		}
+		if now.Before(access.OpenAt) {
+			return nil, ErrSeckillNotStart
+		}
+	}
+
+	// 多次未支付的用户处于暂停期内不能抢购
+	if err := checkSeckillBan(ctx, userID); err != nil {
+		return nil, err
+	}
 
 	// 1. 准备 redis key
 	stockKey := fmt.Sprintf("product:stock:%d", productID)
 	userSetKey := fmt.Sprintf("product:users:%d", productID)
+	attemptsKey := productAttemptsKey(productID)
 
 	// 2. 执行 lua 脚本
 	if !breaker.Default.Allow("redis") {
 		return nil, ErrSeckillBusy
 	}
-	res, err := seckillScript.Run(ctx, redis.RDB, []string{stockKey, userSetKey}, userID).Int()
+	res, err := seckillScript.Run(ctx, redis.RDB, []string{stockKey, userSetKey, attemptsKey}, userID, int(productAttemptsTTL.Seconds())).Int()
 	if err != nil {
 		breaker.Default.ReportFailure("redis")
 		return nil, ErrSeckillBusy
@@ -126,44 +168,16 @@ func (s *SeckillService) Seckill(ctx context.Context, userID, productID uint) (*
 	}
 
 	// 4. 抢到了, 生成订单号/支付号，准备消息
-	orderNum, err := genSeckillID()
+	msg, err := newSeckillMessage(userID, product, access, autoCoupon)
 	if err != nil {
 		// 回滚 Redis
 		redis.RDB.Incr(ctx, stockKey)
 		redis.RDB.SRem(ctx, userSetKey, userID)
 		return nil, ErrSeckillBusy
 	}
-	paymentID, err := genSeckillID()
-	if err != nil {
-		redis.RDB.Incr(ctx, stockKey)
-		redis.RDB.SRem(ctx, userSetKey, userID)
-		return nil, ErrSeckillBusy
-	}
-	priceCents := int64(math.Round(product.Price * 100))
-	if priceCents <= 0 {
-		redis.RDB.Incr(ctx, stockKey)
-		redis.RDB.SRem(ctx, userSetKey, userID)
-		return nil, ErrSeckillBusy
-	}
-
-	msg := SeckillMessage{
-		UserID:     userID,
-		ProductID:  productID,
-		OrderNum:   orderNum,
-		PaymentID:  paymentID,
-		PriceCents: priceCents,
-		Time:       time.Now(),
-	}
-
-	msgBytes, _ := json.Marshal(msg)
-	topic := config.Conf.Data.Kafka.Topic
 
 	// 5. 写入本地消息表（Outbox Pattern）
-	outboxMsg := &model.OutboxMessage{
-		Topic:   topic,
-		Payload: string(msgBytes),
-		Status:  model.OutboxStatusPending,
-	}
+	outboxMsg := newSeckillOutbox(msg)
 	if err := s.outboxRepo.Create(ctx, outboxMsg); err != nil {
 		slog.ErrorContext(ctx, "写入 Outbox 失败", slog.Any("error", err))
 		// 回滚 Redis 库存/用户标记
@@ -177,11 +191,11 @@ func (s *SeckillService) Seckill(ctx context.Context, userID, productID uint) (*
 
 	// 7. 预写 pending 状态，便于前端轮询
 	_ = setPendingOrder(ctx, PendingOrderCache{
-		OrderNum:   orderNum,
-		PaymentID:  paymentID,
+		OrderNum:   msg.OrderNum,
+		PaymentID:  msg.PaymentID,
 		ProductID:  productID,
 		UserID:     userID,
-		PriceCents: priceCents,
+		PriceCents: msg.PriceCents,
 		Status:     PendingStatusPending,
 	})
 
@@ -189,12 +203,53 @@ func (s *SeckillService) Seckill(ctx context.Context, userID, productID uint) (*
 	invalidateProductInfoCache(productID)
 
 	return &SeckillResult{
-		OrderNum:  orderNum,
-		PaymentID: paymentID,
+		OrderNum:  msg.OrderNum,
+		PaymentID: msg.PaymentID,
 		Status:    string(PendingStatusPending),
 	}, nil
 }
 
+// newSeckillMessage 生成订单号/支付号，按会员价快照组装落库消息。
+func newSeckillMessage(userID uint, product *model.Product, access *DropAccess, autoCoupon bool) (*SeckillMessage, error) {
+	orderNum, err := genSeckillID()
+	if err != nil {
+		return nil, err
+	}
+	paymentID, err := genSeckillID()
+	if err != nil {
+		return nil, err
+	}
+	originalCents := int64(math.Round(product.Price * 100))
+	priceCents, memberLevel := originalCents, 0
+	if access != nil {
+		priceCents, memberLevel = access.MemberPriceCents, access.MemberLevel
+	}
+	if priceCents <= 0 {
+		return nil, fmt.Errorf("invalid price for product %d", product.ID)
+	}
+	return &SeckillMessage{
+		UserID:             userID,
+		ProductID:          product.ID,
+		OrderNum:           orderNum,
+		PaymentID:          paymentID,
+		PriceCents:         priceCents,
+		Time:               time.Now(),
+		AutoCoupon:         autoCoupon,
+		OriginalPriceCents: originalCents,
+		MemberLevel:        memberLevel,
+	}, nil
+}
+
+// newSeckillOutbox 将落库消息包装为待发送的 Outbox 记录。
+func newSeckillOutbox(msg *SeckillMessage) *model.OutboxMessage {
+	msgBytes, _ := json.Marshal(msg)
+	return &model.OutboxMessage{
+		Topic:   config.Conf.Data.Kafka.Topic,
+		Payload: string(msgBytes),
+		Status:  model.OutboxStatusPending,
+	}
+}
+
 // sendOutboxMessage 异步发送 Outbox 消息到 Kafka
 func (s *SeckillService) sendOutboxMessage(msg *model.OutboxMessage) {
 	ctx := context.Background()
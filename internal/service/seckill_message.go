@@ -8,6 +8,12 @@ type SeckillMessage struct {
 	ProductID  uint      `json:"product_id"`
 	OrderNum   string    `json:"order_num"`
 	PaymentID  string    `json:"payment_id"`
-	PriceCents int64     `json:"price_cents"`
+	PriceCents int64     `json:"price_cents"` // 应付金额，享受会员价时为会员价
 	Time       time.Time `json:"time"`
+	AutoCoupon bool      `json:"auto_coupon,omitempty"` // 落库后自动使用最优券
+	// 会员价快照：MemberLevel 为 0 表示按原价购买
+	OriginalPriceCents int64 `json:"original_price_cents,omitempty"`
+	MemberLevel        int   `json:"member_level,omitempty"`
+	// 候补认领：库存已在取消订单时预留，落库时不再扣减
+	FromWaitlist bool `json:"from_waitlist,omitempty"`
 }
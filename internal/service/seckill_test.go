@@ -7,6 +7,7 @@ import (
 	"SneakerFlash/internal/repository"
 	"SneakerFlash/internal/testutil"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
@@ -69,7 +70,7 @@ func TestSeckillService_Seckill(t *testing.T) {
 			t.Fatalf("create future product: %v", err)
 		}
 
-		if _, err := svc.Seckill(ctx, 1, futureProduct.ID); !errors.Is(err, ErrSeckillNotStart) {
+		if _, err := svc.Seckill(ctx, 1, futureProduct.ID, false); !errors.Is(err, ErrSeckillNotStart) {
 			t.Fatalf("Seckill(not started) error = %v, want %v", err, ErrSeckillNotStart)
 		}
 	})
@@ -79,7 +80,7 @@ func TestSeckillService_Seckill(t *testing.T) {
 			t.Fatalf("set stock cache: %v", err)
 		}
 
-		if _, err := svc.Seckill(ctx, 2, product.ID); !errors.Is(err, ErrSeckillFull) {
+		if _, err := svc.Seckill(ctx, 2, product.ID, false); !errors.Is(err, ErrSeckillFull) {
 			t.Fatalf("Seckill(sold out) error = %v, want %v", err, ErrSeckillFull)
 		}
 	})
@@ -94,7 +95,7 @@ func TestSeckillService_Seckill(t *testing.T) {
 			t.Fatalf("set user cache: %v", err)
 		}
 
-		if _, err := svc.Seckill(ctx, 3, product.ID); !errors.Is(err, ErrSeckillRepeat) {
+		if _, err := svc.Seckill(ctx, 3, product.ID, false); !errors.Is(err, ErrSeckillRepeat) {
 			t.Fatalf("Seckill(repeat) error = %v, want %v", err, ErrSeckillRepeat)
 		}
 	})
@@ -109,7 +110,7 @@ func TestSeckillService_Seckill(t *testing.T) {
 			t.Fatalf("clear user cache: %v", err)
 		}
 
-		got, err := svc.Seckill(ctx, 9, product.ID)
+		got, err := svc.Seckill(ctx, 9, product.ID, false)
 		if err != nil {
 			t.Fatalf("Seckill() error = %v", err)
 		}
@@ -132,5 +133,130 @@ func TestSeckillService_Seckill(t *testing.T) {
 		if remaining != 4 {
 			t.Fatalf("remaining stock = %d, want 4", remaining)
 		}
+		// 售罄、重复与成功的请求都计入卖家统计的抢购次数
+		attempts, err := redisinfra.RDB.Get(ctx, productAttemptsKey(product.ID)).Int()
+		if err != nil || attempts != 3 {
+			t.Fatalf("attempts = %d, %v, want 3", attempts, err)
+		}
+		if ttl := redisinfra.RDB.TTL(ctx, productAttemptsKey(product.ID)).Val(); ttl <= 0 || ttl > productAttemptsTTL {
+			t.Fatalf("attempts ttl = %v, want (0, %v]", ttl, productAttemptsTTL)
+		}
 	})
 }
+
+func TestSeckillService_VIPEarlyAccessAndExclusive(t *testing.T) {
+	svc, _ := newSeckillServiceForTest(t)
+	ctx := context.Background()
+
+	originalSend := sendKafkaMessage
+	t.Cleanup(func() { sendKafkaMessage = originalSend })
+	sendKafkaMessage = func(topic, message string) error { return errors.New("skip send in unit test") }
+
+	regular := &model.User{Username: "regular", Password: "x", GrowthLevel: 1}
+	vipUser := &model.User{Username: "vip", Password: "x", GrowthLevel: 4, TotalSpentCents: 2_000_000}
+	for _, u := range []*model.User{regular, vipUser} {
+		if err := db.DB.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	// 默认配置 L4 提前 10 分钟
+	drop := &model.Product{UserID: 1, Name: "Early Drop", Price: 1599, Stock: 5, StartTime: time.Now().Add(5 * time.Minute)}
+	exclusive := &model.Product{UserID: 1, Name: "VIP Only", Price: 1999, Stock: 5, StartTime: time.Now().Add(-time.Minute), MinVIPLevel: 3}
+	for _, p := range []*model.Product{drop, exclusive} {
+		if err := db.DB.Create(p).Error; err != nil {
+			t.Fatalf("create product: %v", err)
+		}
+		if err := setStockCache(ctx, p.ID, p.Stock); err != nil {
+			t.Fatalf("set stock cache: %v", err)
+		}
+	}
+
+	if _, err := svc.Seckill(ctx, regular.ID, drop.ID, false); !errors.Is(err, ErrSeckillNotStart) {
+		t.Fatalf("Seckill(regular early) error = %v, want %v", err, ErrSeckillNotStart)
+	}
+	if _, err := svc.Seckill(ctx, vipUser.ID, drop.ID, false); err != nil {
+		t.Fatalf("Seckill(vip early) error = %v", err)
+	}
+
+	if _, err := svc.Seckill(ctx, regular.ID, exclusive.ID, false); !errors.Is(err, ErrSeckillVIPOnly) {
+		t.Fatalf("Seckill(regular exclusive) error = %v, want %v", err, ErrSeckillVIPOnly)
+	}
+	if _, err := svc.Seckill(ctx, vipUser.ID, exclusive.ID, false); err != nil {
+		t.Fatalf("Seckill(vip exclusive) error = %v", err)
+	}
+
+	access, err := svc.vipSvc.DropAccess(ctx, vipUser.ID, drop)
+	if err != nil {
+		t.Fatalf("DropAccess() error = %v", err)
+	}
+	if access.Level != 4 || access.EarlyAccessMinutes != 10 || !access.OpenAt.Equal(drop.StartTime.Add(-10*time.Minute)) {
+		t.Fatalf("DropAccess() = %+v", access)
+	}
+
+	// 生效等级写入 Redis 缓存
+	if cached, err := redisinfra.RDB.Get(ctx, vipLevelCacheKey(regular.ID)).Int(); err != nil || cached != 1 {
+		t.Fatalf("cached level = %d, err = %v", cached, err)
+	}
+}
+
+func TestSeckillService_MemberPrice(t *testing.T) {
+	svc, _ := newSeckillServiceForTest(t)
+	ctx := context.Background()
+
+	originalSend := sendKafkaMessage
+	t.Cleanup(func() { sendKafkaMessage = originalSend })
+	sendKafkaMessage = func(topic, message string) error { return errors.New("skip send in unit test") }
+
+	regular := &model.User{Username: "regular", Password: "x", GrowthLevel: 1}
+	silver := &model.User{Username: "silver", Password: "x", GrowthLevel: 3, TotalSpentCents: 600_000}
+	gold := &model.User{Username: "gold", Password: "x", GrowthLevel: 4, TotalSpentCents: 2_000_000}
+	for _, u := range []*model.User{regular, silver, gold} {
+		if err := db.DB.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	product := &model.Product{
+		UserID:    1,
+		Name:      "Member Drop",
+		Price:     1000,
+		Stock:     5,
+		StartTime: time.Now().Add(-time.Minute),
+		MemberPrices: model.MemberPrices{
+			{Level: 2, DiscountRate: 90},
+			{Level: 4, PriceCents: 80000},
+		},
+	}
+	if err := db.DB.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	if err := setStockCache(ctx, product.ID, product.Stock); err != nil {
+		t.Fatalf("set stock cache: %v", err)
+	}
+
+	cases := []struct {
+		user      *model.User
+		wantCents int64
+		wantLevel int
+	}{
+		{regular, 100000, 0},
+		{silver, 90000, 2},
+		{gold, 80000, 4},
+	}
+	for _, tc := range cases {
+		if _, err := svc.Seckill(ctx, tc.user.ID, product.ID, false); err != nil {
+			t.Fatalf("Seckill(%s) error = %v", tc.user.Username, err)
+		}
+		var outbox model.OutboxMessage
+		if err := db.DB.Order("id desc").First(&outbox).Error; err != nil {
+			t.Fatalf("load outbox: %v", err)
+		}
+		var msg SeckillMessage
+		if err := json.Unmarshal([]byte(outbox.Payload), &msg); err != nil {
+			t.Fatalf("unmarshal message: %v", err)
+		}
+		if msg.PriceCents != tc.wantCents || msg.MemberLevel != tc.wantLevel || msg.OriginalPriceCents != 100000 {
+			t.Fatalf("%s message = %+v, want price %d level %d", tc.user.Username, msg, tc.wantCents, tc.wantLevel)
+		}
+	}
+}
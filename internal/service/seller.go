@@ -0,0 +1,304 @@
+package service
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSellerNotApproved       = errors.New("仅审核通过的卖家可以管理商品")
+	ErrSellerNotFound          = errors.New("卖家不存在")
+	ErrSellerApplied           = errors.New("入驻申请已提交或已通过")
+	ErrSellerStoreNameTaken    = errors.New("店铺名称已被使用")
+	ErrSellerNotPending        = errors.New("入驻申请不是待审核状态")
+	ErrSellerStatusInvalid     = errors.New("入驻申请状态无效")
+	ErrSellerRejectReasonEmpty = errors.New("驳回原因不能为空")
+	ErrSellerApplicationEmpty  = errors.New("店铺名称与联系人信息不能为空")
+)
+
+// SellerApplication 入驻申请资料。
+type SellerApplication struct {
+	StoreName    string
+	Description  string
+	Logo         string
+	ContactName  string
+	ContactPhone string
+	LicenseNo    string
+}
+
+// SellerStoreUpdate 已入驻卖家可直接修改的展示资料，nil 表示不修改。
+type SellerStoreUpdate struct {
+	Description *string
+	Logo        *string
+}
+
+// SellerPublicProfile 卖家主页公开资料。
+type SellerPublicProfile struct {
+	UserID      uint       `json:"user_id"`
+	StoreName   string     `json:"store_name"`
+	Description string     `json:"description"`
+	Logo        string     `json:"logo"`
+	ApprovedAt  *time.Time `json:"approved_at,omitempty"`
+	Drops       int64      `json:"drops"` // 已上架商品数
+}
+
+// SellerService 卖家入驻：提交资料、管理员审核、卖家主页。
+type SellerService struct {
+	db          *gorm.DB
+	repo        *repository.SellerRepo
+	productRepo *repository.ProductRepo
+}
+
+func NewSellerService(db *gorm.DB) *SellerService {
+	return &SellerService{
+		db:          db,
+		repo:        repository.NewSellerRepo(db),
+		productRepo: repository.NewProductRepo(db),
+	}
+}
+
+// Apply 提交入驻申请；被驳回后可修改资料重新提交。
+func (s *SellerService) Apply(ctx context.Context, userID uint, app SellerApplication) (*model.SellerProfile, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	applied := &model.SellerProfile{
+		UserID:       userID,
+		StoreName:    strings.TrimSpace(app.StoreName),
+		Description:  strings.TrimSpace(app.Description),
+		Logo:         strings.TrimSpace(app.Logo),
+		ContactName:  strings.TrimSpace(app.ContactName),
+		ContactPhone: strings.TrimSpace(app.ContactPhone),
+		LicenseNo:    strings.TrimSpace(app.LicenseNo),
+		Status:       model.SellerStatusPending,
+	}
+	if applied.StoreName == "" || applied.ContactName == "" || applied.ContactPhone == "" {
+		return nil, ErrSellerApplicationEmpty
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewSellerRepo(tx)
+		profile, err := txRepo.GetForUpdate(ctx, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return txRepo.Create(ctx, applied)
+			}
+			return err
+		}
+		if profile.Status != model.SellerStatusRejected {
+			return ErrSellerApplied
+		}
+		return txRepo.Update(ctx, userID, map[string]any{
+			"store_name":    applied.StoreName,
+			"description":   applied.Description,
+			"logo":          applied.Logo,
+			"contact_name":  applied.ContactName,
+			"contact_phone": applied.ContactPhone,
+			"license_no":    applied.LicenseNo,
+			"status":        model.SellerStatusPending,
+			"reject_reason": "",
+		})
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || isMySQLDuplicate(err) {
+			return nil, ErrSellerStoreNameTaken
+		}
+		return nil, err
+	}
+	return s.repo.Get(ctx, userID)
+}
+
+// Mine 查询自己的入驻资料与审核状态。
+func (s *SellerService) Mine(ctx context.Context, userID uint) (*model.SellerProfile, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	profile, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSellerNotFound
+		}
+		return nil, err
+	}
+	return profile, nil
+}
+
+// UpdateStore 已入驻卖家修改店铺简介与 Logo，无需重新审核。
+func (s *SellerService) UpdateStore(ctx context.Context, userID uint, update SellerStoreUpdate) (*model.SellerProfile, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if err := requireApprovedSeller(ctx, s.repo, userID); err != nil {
+		return nil, err
+	}
+	values := map[string]any{}
+	if update.Description != nil {
+		values["description"] = strings.TrimSpace(*update.Description)
+	}
+	if update.Logo != nil {
+		values["logo"] = strings.TrimSpace(*update.Logo)
+	}
+	if len(values) > 0 {
+		if err := s.repo.Update(ctx, userID, values); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.Get(ctx, userID)
+}
+
+// List 管理台按状态分页查询入驻申请。
+func (s *SellerService) List(ctx context.Context, status string, page, pageSize int) ([]model.SellerProfile, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	status = strings.TrimSpace(status)
+	switch model.SellerStatus(status) {
+	case "", model.SellerStatusPending, model.SellerStatusApproved, model.SellerStatusRejected:
+	default:
+		return nil, 0, ErrSellerStatusInvalid
+	}
+	return s.repo.List(ctx, status, page, pageSize)
+}
+
+// Approve 审核通过：普通用户角色升级为卖家，管理员角色保持不变。
+func (s *SellerService) Approve(ctx context.Context, reviewerID, userID uint) (*model.SellerProfile, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		profile, err := s.pendingForUpdate(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		updates := map[string]any{
+			"status":        model.SellerStatusApproved,
+			"reject_reason": "",
+			"reviewed_by":   reviewerID,
+			"reviewed_at":   now,
+		}
+		if profile.ApprovedAt == nil {
+			updates["approved_at"] = now
+		}
+		if err := repository.NewSellerRepo(tx).Update(ctx, userID, updates); err != nil {
+			return err
+		}
+		notify(ctx, tx, Notice{UserID: userID, Kind: model.NotifySellerApproved, Data: map[string]any{"store": profile.StoreName}})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, userID)
+}
+
+// Reject 驳回入驻申请并记录原因。
+func (s *SellerService) Reject(ctx context.Context, reviewerID, userID uint, reason string) (*model.SellerProfile, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrSellerRejectReasonEmpty
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		profile, err := s.pendingForUpdate(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := repository.NewSellerRepo(tx).Update(ctx, userID, map[string]any{
+			"status":        model.SellerStatusRejected,
+			"reject_reason": reason,
+			"reviewed_by":   reviewerID,
+			"reviewed_at":   time.Now(),
+		}); err != nil {
+			return err
+		}
+		notify(ctx, tx, Notice{UserID: userID, Kind: model.NotifySellerRejected, Data: map[string]any{"store": profile.StoreName, "reason": reason}})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Get(ctx, userID)
+}
+
+// Profile 卖家主页资料，仅对审核通过的卖家公开。
+func (s *SellerService) Profile(ctx context.Context, userID uint) (*SellerPublicProfile, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	profile, err := s.approved(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	_, drops, err := s.productRepo.ListListedByUserID(ctx, userID, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &SellerPublicProfile{
+		UserID:      profile.UserID,
+		StoreName:   profile.StoreName,
+		Description: profile.Description,
+		Logo:        profile.Logo,
+		ApprovedAt:  profile.ApprovedAt,
+		Drops:       drops,
+	}, nil
+}
+
+// ListDrops 卖家主页已上架商品列表，新发布的在前。
+func (s *SellerService) ListDrops(ctx context.Context, userID uint, page, pageSize int) ([]model.Product, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	if _, err := s.approved(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+	return s.productRepo.ListListedByUserID(ctx, userID, page, pageSize)
+}
+
+func (s *SellerService) approved(ctx context.Context, userID uint) (*model.SellerProfile, error) {
+	profile, err := s.repo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSellerNotFound
+		}
+		return nil, err
+	}
+	if profile.Status != model.SellerStatusApproved {
+		return nil, ErrSellerNotFound
+	}
+	return profile, nil
+}
+
+func (s *SellerService) pendingForUpdate(ctx context.Context, tx *gorm.DB, userID uint) (*model.SellerProfile, error) {
+	profile, err := repository.NewSellerRepo(tx).GetForUpdate(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSellerNotFound
+		}
+		return nil, err
+	}
+	if profile.Status != model.SellerStatusPending {
+		return nil, ErrSellerNotPending
+	}
+	return profile, nil
+}
+
+// requireApprovedSeller 商品写操作前校验卖家身份，以入驻记录为准，不依赖 token 中的角色。
+func requireApprovedSeller(ctx context.Context, repo *repository.SellerRepo, userID uint) error {
+	ok, err := repo.IsApproved(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSellerNotApproved
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	sellerStatsCacheTTL    = 10 * time.Minute
+	sellerStatsRevenueDays = 90 // 缓存的营收趋势天数
+	sellerStatsBatchSize   = 200
+	// sellerStatsOverlap 增量刷新的回看时长，覆盖上次刷新时尚未提交的事务
+	sellerStatsOverlap = time.Minute
+	// productAttemptsTTL 秒杀请求计数在最后一次请求后的保留时长
+	productAttemptsTTL = 30 * 24 * time.Hour
+
+	sellerStatsLeaseKey  = "seller:stats:refresh:lease"
+	sellerStatsCursorKey = "seller:stats:refresh:since"
+)
+
+var ErrSellerStatsDaysInvalid = errors.New("统计天数需在 1-90 之间")
+
+// ProductSalesStats 单个商品的销售统计。
+type ProductSalesStats struct {
+	ProductID      uint                `json:"product_id"`
+	Name           string              `json:"name"`
+	Status         model.ProductStatus `json:"status"`
+	StartTime      time.Time           `json:"start_time"`
+	EndTime        *time.Time          `json:"end_time,omitempty"`
+	Stock          int                 `json:"stock"`     // 剩余库存
+	Sold           int64               `json:"sold"`      // 已支付件数
+	Unpaid         int64               `json:"unpaid"`    // 待支付
+	Cancelled      int64               `json:"cancelled"` // 超时取消
+	Refunded       int64               `json:"refunded"`
+	Failed         int64               `json:"failed"`
+	Attempts       int64               `json:"attempts"` // 秒杀请求次数
+	RevenueCents   int64               `json:"revenue_cents"`
+	ConversionRate float64             `json:"conversion_rate"` // 已支付 / 秒杀请求
+	SellThrough    float64             `json:"sell_through"`    // 已支付 / (已支付 + 待支付 + 剩余库存)
+}
+
+// SellerStatsSummary 卖家全部商品的汇总。
+type SellerStatsSummary struct {
+	Products       int     `json:"products"`
+	Stock          int64   `json:"stock"`
+	Sold           int64   `json:"sold"`
+	Unpaid         int64   `json:"unpaid"`
+	Cancelled      int64   `json:"cancelled"`
+	Refunded       int64   `json:"refunded"`
+	Attempts       int64   `json:"attempts"`
+	RevenueCents   int64   `json:"revenue_cents"`
+	ConversionRate float64 `json:"conversion_rate"`
+	SellThrough    float64 `json:"sell_through"`
+}
+
+// SellerStats 卖家销售看板，由 worker 定时写入缓存。
+type SellerStats struct {
+	Summary   SellerStatsSummary  `json:"summary"`
+	Products  []ProductSalesStats `json:"products"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// DailyRevenue 按天汇总的已支付订单与营收。
+type DailyRevenue struct {
+	Date         string `json:"date"`
+	Orders       int64  `json:"orders"`
+	RevenueCents int64  `json:"revenue_cents"`
+}
+
+// ProductLiveStats 开售中商品的实时售卖进度，直接读取 Redis 秒杀计数。
+type ProductLiveStats struct {
+	ProductID      uint      `json:"product_id"`
+	Name           string    `json:"name"`
+	Selling        bool      `json:"selling"`   // 当前是否在售卖时间内
+	Remaining      int64     `json:"remaining"` // 秒杀库存余量
+	Grabbed        int64     `json:"grabbed"`   // 已抢到名额（含待支付）
+	Attempts       int64     `json:"attempts"`
+	Paid           int64     `json:"paid"`
+	Unpaid         int64     `json:"unpaid"`
+	Cancelled      int64     `json:"cancelled"`
+	SellThrough    float64   `json:"sell_through"`    // 已抢到 / (已抢到 + 余量)
+	ConversionRate float64   `json:"conversion_rate"` // 已支付 / 秒杀请求
+	At             time.Time `json:"at"`
+}
+
+// sellerStatsSnapshot 缓存内容：看板与营收趋势一起计算、一起失效。
+type sellerStatsSnapshot struct {
+	Stats   SellerStats    `json:"stats"`
+	Revenue []DailyRevenue `json:"revenue"`
+}
+
+// SellerStatsService 卖家销售看板：订单聚合由 worker 定时刷新到 Redis，实时进度直接读秒杀计数。
+type SellerStatsService struct {
+	repo        *repository.SellerStatsRepo
+	productRepo *repository.ProductRepo
+}
+
+func NewSellerStatsService(db *gorm.DB) *SellerStatsService {
+	return &SellerStatsService{
+		repo:        repository.NewSellerStatsRepo(db),
+		productRepo: repository.NewProductRepo(db),
+	}
+}
+
+func sellerStatsCacheKey(sellerID uint) string {
+	return fmt.Sprintf("seller:stats:%d", sellerID)
+}
+
+// Overview 卖家看板：优先读缓存，未命中时现算并回写。
+func (s *SellerStatsService) Overview(ctx context.Context, sellerID uint) (*SellerStats, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	snapshot, err := s.snapshot(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot.Stats, nil
+}
+
+// Revenue 最近 days 天（含今天）的每日营收，无订单的日期补 0。
+func (s *SellerStatsService) Revenue(ctx context.Context, sellerID uint, days int) ([]DailyRevenue, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if days < 1 || days > sellerStatsRevenueDays {
+		return nil, ErrSellerStatsDaysInvalid
+	}
+	snapshot, err := s.snapshot(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	series := snapshot.Revenue
+	if len(series) > days {
+		series = series[len(series)-days:]
+	}
+	return series, nil
+}
+
+// Live 商品实时售卖进度，只能查看自己的商品。
+func (s *SellerStatsService) Live(ctx context.Context, sellerID, productID uint) (*ProductLiveStats, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	product, err := s.productRepo.GetByIDAndUser(ctx, productID, sellerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	now := time.Now()
+	live := &ProductLiveStats{
+		ProductID: product.ID,
+		Name:      product.Name,
+		Selling:   product.Listed() && !now.Before(product.StartTime) && (product.EndTime == nil || now.Before(*product.EndTime)),
+		Remaining: int64(product.Stock),
+		At:        now,
+	}
+	if stock, err := redis.RDB.Get(ctx, fmt.Sprintf("product:stock:%d", productID)).Int64(); err == nil {
+		live.Remaining = stock
+	}
+	live.Grabbed, _ = redis.RDB.SCard(ctx, fmt.Sprintf("product:users:%d", productID)).Result()
+	live.Attempts, _ = redis.RDB.Get(ctx, productAttemptsKey(productID)).Int64()
+
+	rows, err := s.repo.ProductOrderStats(ctx, sellerID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 {
+		live.Paid, live.Unpaid, live.Cancelled = rows[0].Paid, rows[0].Unpaid, rows[0].Cancelled
+	}
+	live.SellThrough = ratio(live.Grabbed, live.Grabbed+live.Remaining)
+	live.ConversionRate = ratio(live.Paid, live.Attempts)
+	return live, nil
+}
+
+// refresh 重新计算卖家看板并写入缓存。
+func (s *SellerStatsService) refresh(ctx context.Context, sellerID uint) (*sellerStatsSnapshot, error) {
+	snapshot, err := s.compute(ctx, sellerID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(snapshot)
+	redis.RDB.Set(ctx, sellerStatsCacheKey(sellerID), data, sellerStatsCacheTTL)
+	return snapshot, nil
+}
+
+// RefreshChanged 刷新上次刷新以来有商品或订单变动的卖家看板，首次运行刷新全部卖家。
+// 多个 worker 通过 Redis 租约保证每个 lease 周期只有一个实例执行；未抢到租约时返回 0。
+// 未变动卖家的缓存过期后由读请求现算。单个卖家失败只记录日志。
+func (s *SellerStatsService) RefreshChanged(ctx context.Context, lease time.Duration) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	ok, err := redis.RDB.SetNX(ctx, sellerStatsLeaseKey, 1, lease).Result()
+	if err != nil || !ok {
+		return 0, err
+	}
+	now := time.Now()
+	var ids []uint
+	if since, err := redis.RDB.Get(ctx, sellerStatsCursorKey).Int64(); err == nil {
+		ids, err = s.repo.ListChangedSellerIDs(ctx, time.Unix(0, since).Add(-sellerStatsOverlap))
+		if err != nil {
+			return 0, err
+		}
+	} else if ids, err = s.allSellerIDs(ctx); err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	for _, id := range ids {
+		if _, err := s.refresh(ctx, id); err != nil {
+			slog.WarnContext(ctx, "刷新卖家看板失败", slog.Uint64("seller_id", uint64(id)), slog.Any("err", err))
+			continue
+		}
+		refreshed++
+	}
+	if err := redis.RDB.Set(ctx, sellerStatsCursorKey, now.UnixNano(), 0).Err(); err != nil {
+		return refreshed, err
+	}
+	return refreshed, nil
+}
+
+// allSellerIDs 按用户 ID 游标分批查询全部发布过商品的卖家。
+func (s *SellerStatsService) allSellerIDs(ctx context.Context) ([]uint, error) {
+	var (
+		all     []uint
+		afterID uint
+	)
+	for {
+		ids, err := s.repo.ListSellerIDs(ctx, afterID, sellerStatsBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, ids...)
+		if len(ids) < sellerStatsBatchSize {
+			return all, nil
+		}
+		afterID = ids[len(ids)-1]
+	}
+}
+
+func (s *SellerStatsService) snapshot(ctx context.Context, sellerID uint) (*sellerStatsSnapshot, error) {
+	if val, err := redis.RDB.Get(ctx, sellerStatsCacheKey(sellerID)).Result(); err == nil {
+		var snapshot sellerStatsSnapshot
+		if err := json.Unmarshal([]byte(val), &snapshot); err == nil {
+			return &snapshot, nil
+		}
+	}
+	return s.refresh(ctx, sellerID)
+}
+
+func (s *SellerStatsService) compute(ctx context.Context, sellerID uint, now time.Time) (*sellerStatsSnapshot, error) {
+	products, err := s.repo.ListProducts(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.ProductOrderStats(ctx, sellerID, 0)
+	if err != nil {
+		return nil, err
+	}
+	byProduct := make(map[uint]repository.ProductOrderStat, len(rows))
+	for _, row := range rows {
+		byProduct[row.ProductID] = row
+	}
+	attempts := productAttempts(ctx, products)
+
+	stats := SellerStats{Products: make([]ProductSalesStats, 0, len(products)), UpdatedAt: now}
+	sum := &stats.Summary
+	for _, p := range products {
+		row := byProduct[p.ID]
+		item := ProductSalesStats{
+			ProductID:    p.ID,
+			Name:         p.Name,
+			Status:       p.Status,
+			StartTime:    p.StartTime,
+			EndTime:      p.EndTime,
+			Stock:        p.Stock,
+			Sold:         row.Paid,
+			Unpaid:       row.Unpaid,
+			Cancelled:    row.Cancelled,
+			Refunded:     row.Refunded,
+			Failed:       row.Failed,
+			Attempts:     attempts[p.ID],
+			RevenueCents: row.RevenueCents,
+		}
+		item.ConversionRate = ratio(item.Sold, item.Attempts)
+		item.SellThrough = ratio(item.Sold, item.Sold+item.Unpaid+int64(item.Stock))
+		stats.Products = append(stats.Products, item)
+
+		sum.Products++
+		sum.Stock += int64(item.Stock)
+		sum.Sold += item.Sold
+		sum.Unpaid += item.Unpaid
+		sum.Cancelled += item.Cancelled
+		sum.Refunded += item.Refunded
+		sum.Attempts += item.Attempts
+		sum.RevenueCents += item.RevenueCents
+	}
+	sum.ConversionRate = ratio(sum.Sold, sum.Attempts)
+	sum.SellThrough = ratio(sum.Sold, sum.Sold+sum.Unpaid+sum.Stock)
+
+	revenue, err := s.dailyRevenue(ctx, sellerID, now)
+	if err != nil {
+		return nil, err
+	}
+	return &sellerStatsSnapshot{Stats: stats, Revenue: revenue}, nil
+}
+
+// dailyRevenue 按本地时区的自然日汇总，避免依赖数据库的时区与日期函数。
+func (s *SellerStatsService) dailyRevenue(ctx context.Context, sellerID uint, now time.Time) ([]DailyRevenue, error) {
+	y, m, d := now.Date()
+	first := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1-sellerStatsRevenueDays)
+	rows, err := s.repo.ListPaidAmounts(ctx, sellerID, first)
+	if err != nil {
+		return nil, err
+	}
+	series := make([]DailyRevenue, sellerStatsRevenueDays)
+	index := make(map[string]int, sellerStatsRevenueDays)
+	for i := range series {
+		date := first.AddDate(0, 0, i).Format(time.DateOnly)
+		series[i].Date = date
+		index[date] = i
+	}
+	for _, row := range rows {
+		i, ok := index[row.PaidAt.In(now.Location()).Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+		series[i].Orders++
+		series[i].RevenueCents += row.AmountCents
+	}
+	return series, nil
+}
+
+func productAttemptsKey(productID uint) string {
+	return fmt.Sprintf("product:attempts:%d", productID)
+}
+
+// productAttempts 批量读取秒杀请求计数，Redis 不可用时按 0 处理。
+func productAttempts(ctx context.Context, products []model.Product) map[uint]int64 {
+	counts := make(map[uint]int64, len(products))
+	if len(products) == 0 {
+		return counts
+	}
+	keys := make([]string, 0, len(products))
+	for _, p := range products {
+		keys = append(keys, productAttemptsKey(p.ID))
+	}
+	vals, err := redis.RDB.MGet(ctx, keys...).Result()
+	if err != nil {
+		return counts
+	}
+	for i, val := range vals {
+		if str, ok := val.(string); ok {
+			n, _ := strconv.ParseInt(str, 10, 64)
+			counts[products[i].ID] = n
+		}
+	}
+	return counts
+}
+
+func ratio(part, whole int64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	redisinfra "SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/testutil"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSellerStatsService_OverviewRevenueAndLive(t *testing.T) {
+	testutil.SetupTestConfig()
+	db.DB = testutil.NewSQLiteDB(t)
+	testutil.SetupTestRedis(t)
+	svc := NewSellerStatsService(db.DB)
+	ctx := context.Background()
+
+	buyer := &model.User{Username: "alice", Password: "hashed"}
+	seller := &model.User{Username: "seller", Password: "hashed"}
+	for _, u := range []*model.User{buyer, seller} {
+		if err := db.DB.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	drop := &model.Product{UserID: seller.ID, Name: "Dunk Low", Price: 1000, Stock: 7, StartTime: time.Now().Add(-time.Hour)}
+	idle := &model.Product{UserID: seller.ID, Name: "Air Force 1", Price: 800, Stock: 5, StartTime: time.Now().Add(time.Hour)}
+	for _, p := range []*model.Product{drop, idle} {
+		if err := db.DB.Create(p).Error; err != nil {
+			t.Fatalf("create product: %v", err)
+		}
+	}
+	now := time.Now()
+	seq := 0
+	newOrder := func(status model.OrderStatus, original, member int64, paidAt *time.Time) {
+		seq++
+		order := &model.Order{UserID: buyer.ID, ProductID: drop.ID, OrderNum: fmt.Sprintf("STAT-%03d", seq), Status: status,
+			OriginalPriceCents: original, MemberPriceCents: member, PaidAt: paidAt}
+		if err := db.DB.Create(order).Error; err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+	}
+	yesterday := now.AddDate(0, 0, -1)
+	newOrder(model.OrderStatusPaid, 100000, 80000, &yesterday)
+	newOrder(model.OrderStatusPaid, 100000, 0, &now)
+	newOrder(model.OrderStatusUnpaid, 100000, 0, nil)
+	newOrder(model.OrderStatusCancelled, 100000, 0, nil)
+	newOrder(model.OrderStatusRefunded, 100000, 0, &yesterday)
+	if err := redisinfra.RDB.Set(ctx, productAttemptsKey(drop.ID), 20, 0).Err(); err != nil {
+		t.Fatalf("set attempts: %v", err)
+	}
+
+	stats, err := svc.Overview(ctx, seller.ID)
+	if err != nil {
+		t.Fatalf("Overview() error = %v", err)
+	}
+	sum := stats.Summary
+	if sum.Products != 2 || sum.Sold != 2 || sum.Unpaid != 1 || sum.Cancelled != 1 || sum.Refunded != 1 ||
+		sum.Stock != 12 || sum.Attempts != 20 || sum.RevenueCents != 180000 || sum.ConversionRate != 0.1 {
+		t.Fatalf("summary = %+v", sum)
+	}
+	var dropStats *ProductSalesStats
+	for i := range stats.Products {
+		if stats.Products[i].ProductID == drop.ID {
+			dropStats = &stats.Products[i]
+		}
+	}
+	if dropStats == nil || dropStats.SellThrough != 0.2 || dropStats.RevenueCents != 180000 {
+		t.Fatalf("drop stats = %+v", dropStats)
+	}
+
+	// 看板读缓存，worker 刷新后才反映新订单
+	newOrder(model.OrderStatusPaid, 100000, 0, &now)
+	if cached, _ := svc.Overview(ctx, seller.ID); cached.Summary.Sold != 2 {
+		t.Fatalf("cached sold = %d, want 2", cached.Summary.Sold)
+	}
+	// 首次运行刷新全部卖家，租约期内其他实例跳过
+	if refreshed, err := svc.RefreshChanged(ctx, time.Minute); err != nil || refreshed != 1 {
+		t.Fatalf("RefreshChanged() = %d, %v, want 1", refreshed, err)
+	}
+	if fresh, _ := svc.Overview(ctx, seller.ID); fresh.Summary.Sold != 3 {
+		t.Fatalf("refreshed sold = %d, want 3", fresh.Summary.Sold)
+	}
+	if refreshed, err := svc.RefreshChanged(ctx, time.Minute); err != nil || refreshed != 0 {
+		t.Fatalf("RefreshChanged(lease held) = %d, %v, want 0", refreshed, err)
+	}
+
+	// 之后只刷新有变动的卖家
+	other := &model.User{Username: "other", Password: "hashed"}
+	if err := db.DB.Create(other).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := db.DB.Create(&model.Product{UserID: other.ID, Name: "Samba", Price: 700, Stock: 3, StartTime: now}).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	redisinfra.RDB.Del(ctx, sellerStatsLeaseKey)
+	redisinfra.RDB.Set(ctx, sellerStatsCursorKey, time.Now().Add(sellerStatsOverlap).UnixNano(), 0)
+	newOrder(model.OrderStatusUnpaid, 100000, 0, nil)
+	if refreshed, err := svc.RefreshChanged(ctx, time.Minute); err != nil || refreshed != 1 {
+		t.Fatalf("RefreshChanged(incremental) = %d, %v, want 1", refreshed, err)
+	}
+	if exists, _ := redisinfra.RDB.Exists(ctx, sellerStatsCacheKey(other.ID)).Result(); exists != 0 {
+		t.Fatal("unchanged seller should not be refreshed")
+	}
+	if fresh, _ := svc.Overview(ctx, seller.ID); fresh.Summary.Unpaid != 2 {
+		t.Fatalf("refreshed unpaid = %d, want 2", fresh.Summary.Unpaid)
+	}
+
+	if _, err := svc.Revenue(ctx, seller.ID, 0); !errors.Is(err, ErrSellerStatsDaysInvalid) {
+		t.Fatalf("Revenue(0) error = %v, want %v", err, ErrSellerStatsDaysInvalid)
+	}
+	series, err := svc.Revenue(ctx, seller.ID, 2)
+	if err != nil || len(series) != 2 {
+		t.Fatalf("Revenue(2) = %v, %v", series, err)
+	}
+	if series[0].Date != yesterday.Format(time.DateOnly) || series[0].Orders != 1 || series[0].RevenueCents != 80000 {
+		t.Fatalf("yesterday revenue = %+v", series[0])
+	}
+	if series[1].Orders != 2 || series[1].RevenueCents != 200000 {
+		t.Fatalf("today revenue = %+v", series[1])
+	}
+
+	if err := redisinfra.RDB.Set(ctx, fmt.Sprintf("product:stock:%d", drop.ID), 7, 0).Err(); err != nil {
+		t.Fatalf("set stock: %v", err)
+	}
+	if err := redisinfra.RDB.SAdd(ctx, fmt.Sprintf("product:users:%d", drop.ID), 11, 12, 13).Err(); err != nil {
+		t.Fatalf("set users: %v", err)
+	}
+	live, err := svc.Live(ctx, seller.ID, drop.ID)
+	if err != nil || !live.Selling || live.Grabbed != 3 || live.Remaining != 7 || live.SellThrough != 0.3 || live.Paid != 3 {
+		t.Fatalf("Live() = %+v, %v", live, err)
+	}
+	if _, err := svc.Live(ctx, seller.ID+1, drop.ID); !errors.Is(err, ErrProductNotFound) {
+		t.Fatalf("Live(other seller) error = %v, want %v", err, ErrProductNotFound)
+	}
+}
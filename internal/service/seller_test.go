@@ -0,0 +1,111 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSellerService_ApplyReviewAndPublish(t *testing.T) {
+	_, fixtures := newOrderServiceForTest(t)
+	sellerSvc := NewSellerService(db.DB)
+	productSvc := NewProductService(db.DB, repository.NewProductRepo(db.DB), repository.NewSellerRepo(db.DB), repository.NewCatalogRepo(db.DB))
+	ctx := context.Background()
+	userID := fixtures.user.ID
+	const reviewer = 99
+
+	newProduct := func() *model.Product {
+		return &model.Product{UserID: userID, Name: "Air Max 1", Price: 999, Stock: 3, StartTime: time.Now().Add(time.Hour)}
+	}
+	if err := productSvc.CreateProduct(ctx, newProduct()); !errors.Is(err, ErrSellerNotApproved) {
+		t.Fatalf("CreateProduct(no profile) error = %v, want %v", err, ErrSellerNotApproved)
+	}
+
+	app := SellerApplication{StoreName: "鞋仓", ContactName: "张三", ContactPhone: "13800000000"}
+	if _, err := sellerSvc.Apply(ctx, userID, app); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if _, err := sellerSvc.Apply(ctx, userID, app); !errors.Is(err, ErrSellerApplied) {
+		t.Fatalf("Apply(again) error = %v, want %v", err, ErrSellerApplied)
+	}
+	bob := &model.User{Username: "bob", Password: "hashed"}
+	if err := db.DB.Create(bob).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := sellerSvc.Apply(ctx, bob.ID, app); !errors.Is(err, ErrSellerStoreNameTaken) {
+		t.Fatalf("Apply(duplicate store) error = %v, want %v", err, ErrSellerStoreNameTaken)
+	}
+	if err := productSvc.CreateProduct(ctx, newProduct()); !errors.Is(err, ErrSellerNotApproved) {
+		t.Fatalf("CreateProduct(pending) error = %v, want %v", err, ErrSellerNotApproved)
+	}
+	if _, err := sellerSvc.Profile(ctx, userID); !errors.Is(err, ErrSellerNotFound) {
+		t.Fatalf("Profile(pending) error = %v, want %v", err, ErrSellerNotFound)
+	}
+
+	// 驳回后修改资料重新提交
+	if _, err := sellerSvc.Reject(ctx, reviewer, userID, ""); !errors.Is(err, ErrSellerRejectReasonEmpty) {
+		t.Fatalf("Reject(empty reason) error = %v, want %v", err, ErrSellerRejectReasonEmpty)
+	}
+	rejected, err := sellerSvc.Reject(ctx, reviewer, userID, "资质材料不全")
+	if err != nil || rejected.Status != model.SellerStatusRejected || rejected.RejectReason != "资质材料不全" {
+		t.Fatalf("Reject() = %+v, %v", rejected, err)
+	}
+	if _, err := sellerSvc.Approve(ctx, reviewer, userID); !errors.Is(err, ErrSellerNotPending) {
+		t.Fatalf("Approve(rejected) error = %v, want %v", err, ErrSellerNotPending)
+	}
+	app.LicenseNo = "91310000MA1FL0000X"
+	reapplied, err := sellerSvc.Apply(ctx, userID, app)
+	if err != nil || reapplied.Status != model.SellerStatusPending || reapplied.RejectReason != "" {
+		t.Fatalf("Apply(reapply) = %+v, %v", reapplied, err)
+	}
+
+	approved, err := sellerSvc.Approve(ctx, reviewer, userID)
+	if err != nil || approved.Status != model.SellerStatusApproved || approved.ApprovedAt == nil || approved.ReviewedBy != reviewer {
+		t.Fatalf("Approve() = %+v, %v", approved, err)
+	}
+	var kinds []model.NotificationKind
+	db.DB.Model(&model.Notification{}).Where("user_id = ?", userID).Order("id asc").Pluck("kind", &kinds)
+	if len(kinds) != 2 || kinds[0] != model.NotifySellerRejected || kinds[1] != model.NotifySellerApproved {
+		t.Fatalf("notifications = %v", kinds)
+	}
+
+	if err := productSvc.CreateProduct(ctx, newProduct()); err != nil {
+		t.Fatalf("CreateProduct(approved) error = %v", err)
+	}
+	profile, err := sellerSvc.Profile(ctx, userID)
+	if err != nil || profile.StoreName != "鞋仓" || profile.Drops != 1 {
+		t.Fatalf("Profile() = %+v, %v", profile, err)
+	}
+}
+
+func TestSellerService_BackfillExistingOwners(t *testing.T) {
+	_, fixtures := newOrderServiceForTest(t)
+	productSvc := NewProductService(db.DB, repository.NewProductRepo(db.DB), repository.NewSellerRepo(db.DB), repository.NewCatalogRepo(db.DB))
+	ctx := context.Background()
+	ownerID := fixtures.user.ID
+
+	// 入驻上线前发布过商品的用户没有入驻记录
+	if err := productSvc.UpdateProduct(ctx, ownerID, fixtures.product.ID, map[string]any{"stock": 8}); !errors.Is(err, ErrSellerNotApproved) {
+		t.Fatalf("UpdateProduct(before backfill) error = %v, want %v", err, ErrSellerNotApproved)
+	}
+	if err := db.BackfillSellerProfiles(db.DB); err != nil {
+		t.Fatalf("BackfillSellerProfiles() error = %v", err)
+	}
+	if err := db.BackfillSellerProfiles(db.DB); err != nil {
+		t.Fatalf("BackfillSellerProfiles(again) error = %v", err)
+	}
+	var profiles []model.SellerProfile
+	if err := db.DB.Find(&profiles).Error; err != nil || len(profiles) != 1 {
+		t.Fatalf("profiles = %+v, %v", profiles, err)
+	}
+	if p := profiles[0]; p.UserID != ownerID || p.Status != model.SellerStatusApproved || p.ApprovedAt == nil {
+		t.Fatalf("backfilled profile = %+v", p)
+	}
+	if err := productSvc.UpdateProduct(ctx, ownerID, fixtures.product.ID, map[string]any{"stock": 8}); err != nil {
+		t.Fatalf("UpdateProduct(after backfill) error = %v", err)
+	}
+}
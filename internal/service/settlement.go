@@ -0,0 +1,376 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSettlementNotFound      = errors.New("结算单不存在")
+	ErrSettlementNotPending    = errors.New("结算单不是待打款状态")
+	ErrSettlementStatusInvalid = errors.New("结算单状态无效")
+	ErrSettlementPayoutRef     = errors.New("打款流水号不能为空")
+)
+
+const (
+	settlementPeriod    = 7 * 24 * time.Hour
+	settlementBatchSize = 500
+)
+
+// SettlementRunResult 一次结算生成的汇总。
+type SettlementRunResult struct {
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Statements  int       `json:"statements"` // 本次写入明细的结算单数
+	Orders      int       `json:"orders"`     // 新结算订单数
+	Reversals   int       `json:"reversals"`  // 新增退款冲销数
+	Skipped     int       `json:"skipped"`    // 因本期结算单已打款而顺延到下期的明细数
+}
+
+// SettlementDetail 结算单及明细。
+type SettlementDetail struct {
+	model.SettlementStatement
+	Items []model.SettlementItem `json:"items"`
+}
+
+// SettlementService 卖家结算：退款期满的订单扣除平台佣金后按周汇总为结算单，管理台确认打款。
+type SettlementService struct {
+	db         *gorm.DB
+	repo       *repository.SettlementRepo
+	sellerRepo *repository.SellerRepo
+}
+
+func NewSettlementService(db *gorm.DB) *SettlementService {
+	return &SettlementService{
+		db:         db,
+		repo:       repository.NewSettlementRepo(db),
+		sellerRepo: repository.NewSellerRepo(db),
+	}
+}
+
+func loadSettlementRules() (commissionBps int64, refundWindow time.Duration) {
+	cfg := config.Conf.Settlement
+	commissionBps = int64(cfg.CommissionBps)
+	if commissionBps <= 0 {
+		commissionBps = 500
+	}
+	days := cfg.RefundWindowDays
+	if days <= 0 {
+		days = 7
+	}
+	return commissionBps, time.Duration(days) * 24 * time.Hour
+}
+
+// settlementPeriodOf 返回 now 之前最近一个完整结算周期（周一 00:00 起的自然周）。
+func settlementPeriodOf(now time.Time) (time.Time, time.Time) {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	offset := (int(today.Weekday()) + 6) % 7 // 周一为 0
+	end := today.AddDate(0, 0, -offset)
+	return end.Add(-settlementPeriod), end
+}
+
+// commissionOf 按万分比计算佣金，四舍五入到分。
+func commissionOf(amountCents, bps int64) int64 {
+	return (amountCents*bps + 5000) / 10000
+}
+
+// Generate 生成 now 之前最近一个完整周期的结算单：周期结束时已过退款期的订单计入本期，
+// 已结算订单随后退款的记为冲销；可重复执行，已结算的订单不会重复计入。
+func (s *SettlementService) Generate(ctx context.Context, now time.Time) (*SettlementRunResult, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	bps, window := loadSettlementRules()
+	start, end := settlementPeriodOf(now)
+	result := &SettlementRunResult{PeriodStart: start, PeriodEnd: end}
+
+	bySeller := map[uint][]model.SettlementItem{}
+	var afterID uint
+	for {
+		orders, err := s.repo.ListSettleableOrders(ctx, end.Add(-window), afterID, settlementBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range orders {
+			amount := o.MemberPriceCents
+			if amount <= 0 {
+				amount = o.OriginalPriceCents
+			}
+			if amount <= 0 {
+				amount = o.PaidCents
+			}
+			commission := commissionOf(amount, bps)
+			bySeller[o.SellerID] = append(bySeller[o.SellerID], model.SettlementItem{
+				SellerID:        o.SellerID,
+				OrderID:         o.ID,
+				Kind:            model.SettlementItemSale,
+				OrderNum:        o.OrderNum,
+				ProductID:       o.ProductID,
+				AmountCents:     amount,
+				CommissionCents: commission,
+				NetCents:        amount - commission,
+				PaidAt:          o.PaidAt,
+			})
+			afterID = o.ID
+		}
+		if len(orders) < settlementBatchSize {
+			break
+		}
+	}
+	afterID = 0
+	for {
+		sales, err := s.repo.ListUnreversedRefunds(ctx, afterID, settlementBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, sale := range sales {
+			bySeller[sale.SellerID] = append(bySeller[sale.SellerID], model.SettlementItem{
+				SellerID:        sale.SellerID,
+				OrderID:         sale.OrderID,
+				Kind:            model.SettlementItemRefund,
+				OrderNum:        sale.OrderNum,
+				ProductID:       sale.ProductID,
+				AmountCents:     -sale.AmountCents,
+				CommissionCents: -sale.CommissionCents,
+				NetCents:        -sale.NetCents,
+				PaidAt:          sale.PaidAt,
+			})
+			afterID = sale.ID
+		}
+		if len(sales) < settlementBatchSize {
+			break
+		}
+	}
+
+	sellerIDs := make([]uint, 0, len(bySeller))
+	for id := range bySeller {
+		sellerIDs = append(sellerIDs, id)
+	}
+	sort.Slice(sellerIDs, func(i, j int) bool { return sellerIDs[i] < sellerIDs[j] })
+	for _, sellerID := range sellerIDs {
+		items := bySeller[sellerID]
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			txRepo := repository.NewSettlementRepo(tx)
+			statement, err := txRepo.GetOrCreateStatementForUpdate(ctx, sellerID, start, end)
+			if err != nil {
+				return err
+			}
+			if statement.Status != model.SettlementStatusPending {
+				result.Skipped += len(items)
+				return nil
+			}
+			for i := range items {
+				items[i].StatementID = statement.ID
+			}
+			if err := txRepo.CreateItems(ctx, items); err != nil {
+				return err
+			}
+			if err := txRepo.RecalcStatement(ctx, statement.ID); err != nil {
+				return err
+			}
+			result.Statements++
+			for _, item := range items {
+				if item.Kind == model.SettlementItemSale {
+					result.Orders++
+				} else {
+					result.Reversals++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	if result.Skipped > 0 {
+		slog.WarnContext(ctx, "本期结算单已打款，明细顺延到下期", slog.Time("period_start", start), slog.Int("items", result.Skipped))
+	}
+	return result, nil
+}
+
+// ListForSeller 卖家查看自己的结算单。
+func (s *SettlementService) ListForSeller(ctx context.Context, sellerID uint, page, pageSize int) ([]model.SettlementStatement, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	return s.repo.ListStatements(ctx, repository.SettlementFilter{SellerID: sellerID, Page: page, PageSize: pageSize})
+}
+
+// List 管理台按卖家、状态分页查询结算单。
+func (s *SettlementService) List(ctx context.Context, sellerID uint, status string, page, pageSize int) ([]model.SettlementStatement, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	if err := validSettlementStatus(status); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListStatements(ctx, repository.SettlementFilter{SellerID: sellerID, Status: status, Page: page, PageSize: pageSize})
+}
+
+// Detail 结算单明细；sellerID 非 0 时只能查看自己的结算单。
+func (s *SettlementService) Detail(ctx context.Context, sellerID, id uint) (*SettlementDetail, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	statement, err := s.repo.GetStatement(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSettlementNotFound
+		}
+		return nil, err
+	}
+	if sellerID != 0 && statement.SellerID != sellerID {
+		return nil, ErrSettlementNotFound
+	}
+	items, err := s.repo.ListItems(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &SettlementDetail{SettlementStatement: *statement, Items: items}, nil
+}
+
+// MarkPaid 财务线下打款后登记流水号，并通知卖家。
+func (s *SettlementService) MarkPaid(ctx context.Context, operatorID, id uint, payoutRef string) (*model.SettlementStatement, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	payoutRef = strings.TrimSpace(payoutRef)
+	if payoutRef == "" {
+		return nil, ErrSettlementPayoutRef
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewSettlementRepo(tx)
+		statement, err := txRepo.GetStatementForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSettlementNotFound
+			}
+			return err
+		}
+		rows, err := txRepo.MarkPaid(ctx, id, operatorID, payoutRef, time.Now())
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrSettlementNotPending
+		}
+		notify(ctx, tx, Notice{UserID: statement.SellerID, Kind: model.NotifySettlementPaid, Data: map[string]any{
+			"period": settlementPeriodLabel(statement),
+			"net":    yuan(statement.NetCents),
+			"ref":    payoutRef,
+		}})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetStatement(ctx, id)
+}
+
+// ExportStatements 导出结算单 CSV 供财务打款，periodStart 为空时导出全部周期。
+func (s *SettlementService) ExportStatements(ctx context.Context, status string, periodStart *time.Time, w io.Writer) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	if err := validSettlementStatus(status); err != nil {
+		return err
+	}
+	statements, _, err := s.repo.ListStatements(ctx, repository.SettlementFilter{Status: status, PeriodStart: periodStart})
+	if err != nil {
+		return err
+	}
+	sellerIDs := make([]uint, 0, len(statements))
+	for _, st := range statements {
+		sellerIDs = append(sellerIDs, st.SellerID)
+	}
+	profiles, err := s.sellerRepo.ListByUserIDs(ctx, sellerIDs)
+	if err != nil {
+		return err
+	}
+	stores := make(map[uint]model.SellerProfile, len(profiles))
+	for _, p := range profiles {
+		stores[p.UserID] = p
+	}
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"statement_id", "seller_id", "store_name", "contact_name", "contact_phone", "period_start", "period_end",
+		"order_count", "gross", "refund", "commission", "net", "status", "payout_ref", "paid_at"})
+	for _, st := range statements {
+		store := stores[st.SellerID]
+		paidAt := ""
+		if st.PaidAt != nil {
+			paidAt = st.PaidAt.Format(time.RFC3339)
+		}
+		_ = cw.Write([]string{
+			strconv.FormatUint(uint64(st.ID), 10),
+			strconv.FormatUint(uint64(st.SellerID), 10),
+			store.StoreName,
+			store.ContactName,
+			store.ContactPhone,
+			st.PeriodStart.Format(time.DateOnly),
+			st.PeriodEnd.Format(time.DateOnly),
+			strconv.Itoa(st.OrderCount),
+			yuan(st.GrossCents),
+			yuan(st.RefundCents),
+			yuan(st.CommissionCents),
+			yuan(st.NetCents),
+			string(st.Status),
+			st.PayoutRef,
+			paidAt,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportItems 导出结算单明细 CSV；sellerID 非 0 时只能导出自己的结算单。
+func (s *SettlementService) ExportItems(ctx context.Context, sellerID, id uint, w io.Writer) error {
+	detail, err := s.Detail(ctx, sellerID, id)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"statement_id", "order_id", "order_num", "product_id", "kind", "paid_at", "amount", "commission", "net"})
+	for _, item := range detail.Items {
+		_ = cw.Write([]string{
+			strconv.FormatUint(uint64(item.StatementID), 10),
+			strconv.FormatUint(uint64(item.OrderID), 10),
+			item.OrderNum,
+			strconv.FormatUint(uint64(item.ProductID), 10),
+			string(item.Kind),
+			item.PaidAt.Format(time.RFC3339),
+			yuan(item.AmountCents),
+			yuan(item.CommissionCents),
+			yuan(item.NetCents),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func validSettlementStatus(status string) error {
+	switch model.SettlementStatus(status) {
+	case "", model.SettlementStatusPending, model.SettlementStatusPaid:
+		return nil
+	default:
+		return ErrSettlementStatusInvalid
+	}
+}
+
+func settlementPeriodLabel(statement *model.SettlementStatement) string {
+	return statement.PeriodStart.Format(time.DateOnly) + " ~ " + statement.PeriodEnd.AddDate(0, 0, -1).Format(time.DateOnly)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/testutil"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSettlementService_GenerateReverseAndPay(t *testing.T) {
+	testutil.SetupTestConfig()
+	db.DB = testutil.NewSQLiteDB(t)
+	testutil.SetupTestRedis(t)
+	svc := NewSettlementService(db.DB)
+	ctx := context.Background()
+
+	buyer := &model.User{Username: "alice", Password: "hashed"}
+	seller := &model.User{Username: "seller", Password: "hashed"}
+	for _, u := range []*model.User{buyer, seller} {
+		if err := db.DB.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	if err := db.DB.Create(&model.SellerProfile{UserID: seller.ID, StoreName: "鞋仓", ContactName: "张三", ContactPhone: "13800000000", Status: model.SellerStatusApproved}).Error; err != nil {
+		t.Fatalf("create profile: %v", err)
+	}
+	product := &model.Product{UserID: seller.ID, Name: "Dunk Low", Price: 1000, Stock: 10, StartTime: time.Now()}
+	if err := db.DB.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	newPaidOrder := func(orderNum string, original, member int64, paidAt time.Time) *model.Order {
+		order := &model.Order{UserID: buyer.ID, ProductID: product.ID, OrderNum: orderNum, Status: model.OrderStatusPaid,
+			OriginalPriceCents: original, MemberPriceCents: member, PaidAt: &paidAt}
+		if err := db.DB.Create(order).Error; err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+		return order
+	}
+	// 周三生成，本期为 10-05 ~ 10-11，退款期 7 天，只结算 10-05 前支付的订单
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.Local)
+	refunded := newPaidOrder("SET-001", 100000, 0, time.Date(2026, 10, 1, 10, 0, 0, 0, time.Local))
+	newPaidOrder("SET-002", 50000, 45000, time.Date(2026, 10, 2, 10, 0, 0, 0, time.Local))
+	newPaidOrder("SET-003", 30000, 0, time.Date(2026, 10, 10, 10, 0, 0, 0, time.Local))
+
+	result, err := svc.Generate(ctx, now)
+	if err != nil || result.Statements != 1 || result.Orders != 2 || result.Reversals != 0 {
+		t.Fatalf("Generate() = %+v, %v", result, err)
+	}
+	if !result.PeriodStart.Equal(time.Date(2026, 10, 5, 0, 0, 0, 0, time.Local)) {
+		t.Fatalf("period start = %v", result.PeriodStart)
+	}
+	again, err := svc.Generate(ctx, now)
+	if err != nil || again.Orders != 0 || again.Statements != 0 {
+		t.Fatalf("Generate(again) = %+v, %v", again, err)
+	}
+	list, total, err := svc.ListForSeller(ctx, seller.ID, 1, 20)
+	if err != nil || total != 1 {
+		t.Fatalf("ListForSeller() = %v, %d, %v", list, total, err)
+	}
+	first := list[0]
+	// 5% 佣金：1000.00 + 450.00（会员价）
+	if first.OrderCount != 2 || first.GrossCents != 145000 || first.CommissionCents != 7250 || first.NetCents != 137750 {
+		t.Fatalf("statement = %+v", first)
+	}
+	if _, err := svc.Detail(ctx, seller.ID+1, first.ID); !errors.Is(err, ErrSettlementNotFound) {
+		t.Fatalf("Detail(other seller) error = %v, want %v", err, ErrSettlementNotFound)
+	}
+
+	// 已结算订单退款，下期冲销
+	if err := db.DB.Model(refunded).Update("status", model.OrderStatusRefunded).Error; err != nil {
+		t.Fatalf("refund order: %v", err)
+	}
+	next, err := svc.Generate(ctx, now.AddDate(0, 0, 7))
+	if err != nil || next.Orders != 1 || next.Reversals != 1 {
+		t.Fatalf("Generate(next) = %+v, %v", next, err)
+	}
+	list, _, _ = svc.ListForSeller(ctx, seller.ID, 1, 20)
+	if len(list) != 2 {
+		t.Fatalf("statements = %d, want 2", len(list))
+	}
+	second := list[0]
+	if second.OrderCount != 1 || second.GrossCents != 30000 || second.RefundCents != 100000 || second.NetCents != 28500-95000 {
+		t.Fatalf("statement(next) = %+v", second)
+	}
+
+	if _, err := svc.MarkPaid(ctx, 99, first.ID, " "); !errors.Is(err, ErrSettlementPayoutRef) {
+		t.Fatalf("MarkPaid(empty ref) error = %v, want %v", err, ErrSettlementPayoutRef)
+	}
+	paid, err := svc.MarkPaid(ctx, 99, first.ID, "BANK-20261014")
+	if err != nil || paid.Status != model.SettlementStatusPaid || paid.PaidAt == nil || paid.PaidBy != 99 {
+		t.Fatalf("MarkPaid() = %+v, %v", paid, err)
+	}
+	if _, err := svc.MarkPaid(ctx, 99, first.ID, "BANK-20261014"); !errors.Is(err, ErrSettlementNotPending) {
+		t.Fatalf("MarkPaid(again) error = %v, want %v", err, ErrSettlementNotPending)
+	}
+	var notices int64
+	db.DB.Model(&model.Notification{}).Where("user_id = ? AND kind = ?", seller.ID, model.NotifySettlementPaid).Count(&notices)
+	if notices != 1 {
+		t.Fatalf("settlement notifications = %d, want 1", notices)
+	}
+
+	var buf bytes.Buffer
+	periodStart := first.PeriodStart
+	if err := svc.ExportStatements(ctx, string(model.SettlementStatusPaid), &periodStart, &buf); err != nil {
+		t.Fatalf("ExportStatements() error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("export rows = %v, %v", records, err)
+	}
+	if row := records[1]; row[2] != "鞋仓" || row[11] != "1377.50" || row[13] != "BANK-20261014" {
+		t.Fatalf("export row = %v", row)
+	}
+}
@@ -20,6 +20,16 @@ func (s *StreamService) SubscribeProduct(productID uint) (<-chan []byte, func(),
 	return subscribeTopic(productStreamTopic(productID))
 }
 
+// SubscribeWaitlist 订阅用户在某商品上的候补状态推送。
+func (s *StreamService) SubscribeWaitlist(userID, productID uint) (<-chan []byte, func(), error) {
+	return subscribeTopic(waitlistStreamTopic(userID, productID))
+}
+
+// SubscribeNotifications 订阅用户的站内通知推送。
+func (s *StreamService) SubscribeNotifications(userID uint) (<-chan []byte, func(), error) {
+	return subscribeTopic(notificationStreamTopic(userID))
+}
+
 func subscribeTopic(topic string) (<-chan []byte, func(), error) {
 	if redis.RDB == nil {
 		ch, unsubscribe := broker.Subscribe(topic)
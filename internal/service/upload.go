@@ -1,84 +1,478 @@
 package service
 
 import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/infra/storage"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/imaging"
+	"SneakerFlash/internal/repository"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
 	"io"
+	"log/slog"
+	"mime"
 	"mime/multipart"
 	"net/http"
-	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
+
+	_redis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
 )
 
-// UploadService 负责图片落盘校验
-type UploadService struct {
-	uploadDir string
-	maxSize   int64
+// 上传图片统一生成的尺寸，按最长边等比缩小。
+const (
+	imageThumbnailSize = 200
+	imageMediumSize    = 800
+	imageJPEGQuality   = 90
+	// 新版上传的图片放在该目录下，按 <name>_thumb、<name>_medium 命名变体
+	imageKeyPrefix = "images/"
+	// 清理任务每批检查的文件数
+	uploadCleanupBatchSize = 100
+)
+
+var (
+	ErrUploadTooLarge      = errors.New("文件过大")
+	ErrUploadUnsupported   = errors.New("仅支持 jpg/jpeg/png/gif")
+	ErrUploadTypeMismatch  = errors.New("文件扩展名与内容不符")
+	ErrUploadInvalidImage  = errors.New("图片解析失败")
+	ErrUploadDimensions    = errors.New("图片尺寸超出限制")
+	ErrUploadTooManyPixels = errors.New("图片像素过多")
+	ErrUploadDailyCount    = errors.New("今日上传次数已达上限")
+	ErrUploadDailySize     = errors.New("今日上传容量已达上限")
+)
+
+// uploadImageTypes 允许的扩展名及其对应的内容类型。
+var uploadImageTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
 }
 
-func NewUploadService(uploadDir string) *UploadService {
-	if uploadDir == "" {
-		uploadDir = "uploads"
-	}
-	uploadDir = filepath.Clean(uploadDir)
-	// 默认 5MB
-	return &UploadService{uploadDir: uploadDir, maxSize: 10 << 20}
+// lua 脚本: 原子占用当日上传额度，超限时回滚
+// key1 用户当日上传计数，hash 字段 count、bytes
+// argv1 本次大小 argv2 次数上限 argv3 大小上限 argv4 过期秒数
+var uploadQuotaScript = _redis.NewScript(`
+	local count = redis.call("HINCRBY", KEYS[1], "count", 1)
+	local size = redis.call("HINCRBY", KEYS[1], "bytes", ARGV[1])
+	redis.call("EXPIRE", KEYS[1], ARGV[4])
+	local result = 1
+	if count > tonumber(ARGV[2]) then
+		result = -1 -- 次数超限
+	elseif size > tonumber(ARGV[3]) then
+		result = -2 -- 容量超限
+	end
+	if result < 0 then
+		redis.call("HINCRBY", KEYS[1], "count", -1)
+		redis.call("HINCRBY", KEYS[1], "bytes", -tonumber(ARGV[1]))
+	end
+	return result
+`)
+
+// uploadQuotaTTL 计数 key 跨过当天即失效，多留一天避免时区边界提前过期。
+const uploadQuotaTTL = 48 * time.Hour
+
+// uploadLimits 上传限制，来自配置文件 upload 段。
+type uploadLimits struct {
+	maxSize      int64
+	dailyCount   int64
+	dailySize    int64
+	maxDimension int
+	maxPixels    int
 }
 
-// SaveImage 保存图片并返回可直接访问的相对路径
-func (s *UploadService) SaveImage(file *multipart.FileHeader) (string, error) {
-	if file.Size > s.maxSize {
-		return "", fmt.Errorf("文件过大，最大支持 %.1fMB", float64(s.maxSize)/(1<<20))
+func loadUploadLimits() uploadLimits {
+	cfg := config.Conf.Upload
+	limits := uploadLimits{
+		maxSize:      int64(cfg.MaxSizeMB) << 20,
+		dailyCount:   int64(cfg.DailyCount),
+		dailySize:    int64(cfg.DailySizeMB) << 20,
+		maxDimension: cfg.MaxDimension,
+		maxPixels:    cfg.MaxPixels,
+	}
+	if limits.maxSize <= 0 {
+		limits.maxSize = 10 << 20
+	}
+	if limits.dailyCount <= 0 {
+		limits.dailyCount = 100
 	}
+	if limits.dailySize <= 0 {
+		limits.dailySize = 200 << 20
+	}
+	if limits.maxDimension <= 0 {
+		limits.maxDimension = 8000
+	}
+	if limits.maxPixels <= 0 {
+		limits.maxPixels = 40_000_000
+	}
+	return limits
+}
+
+// UploadService 负责图片校验、去除元数据并生成缩略图，文件按内容哈希命名写入配置的存储后端
+type UploadService struct {
+	repo   *repository.UploadRepo
+	store  storage.Storage
+	limits uploadLimits
+	now    func() time.Time
+}
+
+// UploadedImage 上传结果：原图与各尺寸变体地址；历史 WebP 上传没有变体，变体地址与原图相同。
+type UploadedImage struct {
+	URL       string `json:"url"`
+	Thumbnail string `json:"thumbnail"`
+	Medium    string `json:"medium"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+}
+
+func NewUploadService(db *gorm.DB, store storage.Storage) *UploadService {
+	return &UploadService{repo: repository.NewUploadRepo(db), store: store, limits: loadUploadLimits(), now: time.Now}
+}
 
+func uploadQuotaKey(userID uint, day time.Time) string {
+	return fmt.Sprintf("upload:quota:%d:%s", userID, day.Format("20060102"))
+}
+
+// SaveImage 保存图片并返回公开访问地址；内容相同的图片直接返回已有文件。
+// 扩展名、声明的类型与内容须一致，尺寸与像素数在解码前校验；每次上传占用当日次数与容量额度，失败时归还。
+// JPEG/PNG 按 EXIF 方向摆正后重新编码以去除元数据，GIF 保留动画并剔除注释与应用扩展块；
+// 同时生成缩略图与中图（GIF 只取首帧）。标准库无法解码 WebP，无法去除元数据也无法缩放，直接拒绝。
+func (s *UploadService) SaveImage(ctx context.Context, userID uint, file *multipart.FileHeader) (*UploadedImage, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if file.Size > s.limits.maxSize {
+		return nil, s.tooLarge()
+	}
 	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if ext == "" {
-		return "", fmt.Errorf("缺少文件扩展名")
+	if ext == ".webp" {
+		return nil, fmt.Errorf("%w，WebP 无法在服务端处理，请转换为 JPG/PNG 后上传", ErrUploadUnsupported)
+	}
+	mimeType, ok := uploadImageTypes[ext]
+	if !ok {
+		return nil, ErrUploadUnsupported
 	}
-	allowed := map[string]struct{}{".jpg": {}, ".jpeg": {}, ".png": {}, ".gif": {}, ".webp": {}}
-	if _, ok := allowed[ext]; !ok {
-		return "", fmt.Errorf("仅支持 jpg/jpeg/png/gif/webp")
+	if declared := declaredImageType(file); declared != "" && declared != mimeType {
+		return nil, ErrUploadTypeMismatch
 	}
 
-	// 基础的内容探测
 	f, err := file.Open()
 	if err != nil {
-		return "", fmt.Errorf("文件打开失败")
+		return nil, fmt.Errorf("文件打开失败")
 	}
 	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, s.limits.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("文件读取失败")
+	}
+	if int64(len(data)) > s.limits.maxSize {
+		return nil, s.tooLarge()
+	}
+	if http.DetectContentType(data) != mimeType {
+		return nil, ErrUploadTypeMismatch
+	}
+
+	if err := s.reserveQuota(ctx, userID, int64(len(data))); err != nil {
+		return nil, err
+	}
+	uploaded, err := s.save(ctx, userID, mimeType, data)
+	if err != nil {
+		s.releaseQuota(ctx, userID, int64(len(data)))
+		return nil, err
+	}
+	return uploaded, nil
+}
+
+func (s *UploadService) save(ctx context.Context, userID uint, mimeType string, data []byte) (*UploadedImage, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if existing, err := s.reuse(ctx, hash); err != nil || existing != nil {
+		return existing, err
+	}
+
+	upload := &model.Upload{Hash: hash, OwnerID: userID, Size: int64(len(data)), MimeType: mimeType}
+	// 先读文件头校验尺寸，避免解码超大图片耗尽内存
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUploadInvalidImage
+	}
+	if err := s.checkDimensions(cfg.Width, cfg.Height); err != nil {
+		return nil, err
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUploadInvalidImage
+	}
+	var original []byte
+	switch format {
+	case "jpeg":
+		// 重新编码会丢掉 EXIF，先按方向摆正，否则手机竖拍的照片会横着显示
+		img = imaging.Orient(img, imaging.JPEGOrientation(data))
+		if original, err = encodeImage(format, img); err != nil {
+			return nil, fmt.Errorf("图片处理失败")
+		}
+	case "png":
+		if original, err = encodeImage(format, img); err != nil {
+			return nil, fmt.Errorf("图片处理失败")
+		}
+	case "gif":
+		// 保留动画原样存储，只去掉可能携带隐私信息的扩展块
+		if original, err = imaging.StripGIFMetadata(data); err != nil {
+			return nil, ErrUploadInvalidImage
+		}
+	default:
+		return nil, ErrUploadUnsupported
+	}
 
-	buf := make([]byte, 512)
-	n, _ := f.Read(buf)
-	contentType := http.DetectContentType(buf[:n])
-	if !strings.HasPrefix(contentType, "image/") {
-		return "", fmt.Errorf("仅支持图片上传")
+	upload.Key = imageKeyPrefix + hash + imageFormatExt(format)
+	bounds := img.Bounds()
+	upload.Width, upload.Height = bounds.Dx(), bounds.Dy()
+	keys := imageVariantKeys(upload.Key)
+	files := [][]byte{original}
+	for _, size := range []int{imageThumbnailSize, imageMediumSize} {
+		encoded, err := encodeImage(format, imaging.Fit(img, size))
+		if err != nil {
+			return nil, fmt.Errorf("图片处理失败")
+		}
+		files = append(files, encoded)
+	}
+	for i, data := range files {
+		if err := s.write(ctx, keys[i], data); err != nil {
+			for _, written := range keys[:i] {
+				_ = s.store.Delete(ctx, written)
+			}
+			return nil, err
+		}
 	}
+	return s.record(ctx, upload)
+}
 
-	if err := os.MkdirAll(s.uploadDir, 0o755); err != nil {
-		return "", fmt.Errorf("创建上传目录失败")
+func (s *UploadService) tooLarge() error {
+	return fmt.Errorf("%w，最大支持 %.1fMB", ErrUploadTooLarge, float64(s.limits.maxSize)/(1<<20))
+}
+
+func (s *UploadService) checkDimensions(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return ErrUploadInvalidImage
+	}
+	if width > s.limits.maxDimension || height > s.limits.maxDimension {
+		return fmt.Errorf("%w，最长边不能超过 %d 像素", ErrUploadDimensions, s.limits.maxDimension)
 	}
+	if int64(width)*int64(height) > int64(s.limits.maxPixels) {
+		return fmt.Errorf("%w，最多 %d 万像素", ErrUploadTooManyPixels, s.limits.maxPixels/10000)
+	}
+	return nil
+}
 
-	filename := fmt.Sprintf("%d%s", time.Now().UnixNano(), ext)
-	dst := filepath.Join(s.uploadDir, filename)
+// reserveQuota 占用用户当日的上传次数与容量额度。
+func (s *UploadService) reserveQuota(ctx context.Context, userID uint, size int64) error {
+	result, err := uploadQuotaScript.Run(ctx, redis.RDB, []string{uploadQuotaKey(userID, s.now())},
+		size, s.limits.dailyCount, s.limits.dailySize, int(uploadQuotaTTL.Seconds())).Int()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case -1:
+		return fmt.Errorf("%w（%d 次）", ErrUploadDailyCount, s.limits.dailyCount)
+	case -2:
+		return fmt.Errorf("%w（%dMB）", ErrUploadDailySize, s.limits.dailySize>>20)
+	}
+	return nil
+}
 
-	// 重新打开文件进行落盘
-	src, err := file.Open()
+// releaseQuota 归还上传失败占用的额度。
+func (s *UploadService) releaseQuota(ctx context.Context, userID uint, size int64) {
+	key := uploadQuotaKey(userID, s.now())
+	_, err := redis.RDB.TxPipelined(ctx, func(pipe _redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, "count", -1)
+		pipe.HIncrBy(ctx, key, "bytes", -size)
+		return nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("文件打开失败")
+		slog.WarnContext(ctx, "归还上传额度失败", slog.Uint64("user_id", uint64(userID)), slog.Any("err", err))
+	}
+}
+
+// declaredImageType 客户端声明的文件类型，未声明或为通用二进制类型时返回空。
+func declaredImageType(file *multipart.FileHeader) string {
+	mediaType, _, err := mime.ParseMediaType(file.Header.Get("Content-Type"))
+	if err != nil || mediaType == "application/octet-stream" {
+		return ""
+	}
+	switch mediaType {
+	case "image/jpg", "image/pjpeg":
+		return "image/jpeg"
 	}
-	defer src.Close()
+	return mediaType
+}
 
-	dstFile, err := os.Create(dst)
+// reuse 已上传过相同内容时刷新保留期并返回已有文件，未上传过返回 nil。
+func (s *UploadService) reuse(ctx context.Context, hash string) (*UploadedImage, error) {
+	rows, err := s.repo.Touch(ctx, hash, s.now())
 	if err != nil {
-		return "", fmt.Errorf("创建文件失败")
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, nil
+	}
+	existing, err := s.repo.GetByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return s.uploadedImage(existing), nil
+}
+
+// record 写入上传记录；并发上传相同内容时文件路径一致，以先写入的记录为准。
+func (s *UploadService) record(ctx context.Context, upload *model.Upload) (*UploadedImage, error) {
+	upload.LastUsedAt = s.now()
+	if err := s.repo.Create(ctx, upload); err != nil {
+		return nil, err
+	}
+	if upload.ID == 0 {
+		existing, err := s.repo.GetByHash(ctx, upload.Hash)
+		if err != nil {
+			return nil, err
+		}
+		upload = existing
+	}
+	return s.uploadedImage(upload), nil
+}
+
+func (s *UploadService) uploadedImage(upload *model.Upload) *UploadedImage {
+	url := s.store.URL(upload.Key)
+	img := &UploadedImage{URL: url, Thumbnail: url, Medium: url, Width: upload.Width, Height: upload.Height}
+	// 历史 WebP 上传没有生成变体
+	if upload.MimeType != "image/webp" {
+		keys := imageVariantKeys(upload.Key)
+		img.Thumbnail = s.store.URL(keys[1])
+		img.Medium = s.store.URL(keys[2])
+	}
+	return img
+}
+
+// CleanupOrphans 删除超过保留期仍未被引用的上传文件，返回删除数量。
+// 文件从有引用变为无引用时先记录并重新开始保留期，下一轮检查仍无引用才删除。
+func (s *UploadService) CleanupOrphans(ctx context.Context, grace time.Duration) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	now := s.now()
+	before := now.Add(-grace)
+	deleted := 0
+	var (
+		afterID uint
+		refs    map[string]int
+	)
+	for {
+		uploads, err := s.repo.ListIdle(ctx, before, afterID, uploadCleanupBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+		// 有待检查的文件时才扫描引用表，每轮清理每张表只扫描一次
+		if len(uploads) > 0 && refs == nil {
+			if refs, err = s.repo.ReferencedHashes(ctx); err != nil {
+				return deleted, err
+			}
+		}
+		for _, upload := range uploads {
+			afterID = upload.ID
+			if refs[upload.Hash] > 0 || upload.RefCount > 0 {
+				if err := s.repo.MarkReferences(ctx, upload.ID, refs[upload.Hash], now); err != nil {
+					return deleted, err
+				}
+				continue
+			}
+			// 先删记录：与重新上传相同内容并发时，上传方刷新时间后此处条件删除失败，文件得以保留
+			rows, err := s.repo.DeleteIdle(ctx, upload.ID, before)
+			if err != nil {
+				return deleted, err
+			}
+			if rows == 0 {
+				continue
+			}
+			for _, key := range imageVariantKeys(upload.Key) {
+				if err := s.store.Delete(ctx, key); err != nil {
+					slog.ErrorContext(ctx, "删除未引用文件失败", slog.String("key", key), slog.Any("err", err))
+				}
+			}
+			deleted++
+		}
+		if len(uploads) < uploadCleanupBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+func (s *UploadService) write(ctx context.Context, key string, data []byte) error {
+	if err := s.store.Put(ctx, key, data, http.DetectContentType(data)); err != nil {
+		slog.ErrorContext(ctx, "保存上传文件失败", slog.String("key", key), slog.Any("err", err))
+		return fmt.Errorf("保存文件失败")
 	}
-	defer dstFile.Close()
+	return nil
+}
+
+// imageVariantKeys 原图、缩略图与中图的存储路径。
+func imageVariantKeys(key string) [3]string {
+	ext := path.Ext(key)
+	name := strings.TrimSuffix(key, ext)
+	return [3]string{key, name + "_thumb" + ext, name + "_medium" + ext}
+}
 
-	if _, err := io.Copy(dstFile, src); err != nil {
-		return "", fmt.Errorf("保存文件失败")
+func encodeImage(format string, img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: imageJPEGQuality})
+	case "png":
+		err = png.Encode(&buf, img)
+	case "gif":
+		// 变体只取首帧
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = fmt.Errorf("unsupported image format: %s", format)
 	}
+	return buf.Bytes(), err
+}
 
-	publicPath := filepath.ToSlash(filepath.Join("/uploads", filename))
-	return publicPath, nil
+func imageFormatExt(format string) string {
+	if format == "jpeg" {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// productImageFor 按上传命名规则推导图片各尺寸地址；外链、旧版上传与 WebP 没有变体，均指向原图。
+func productImageFor(url string) model.ProductImage {
+	img := model.ProductImage{URL: url, Thumbnail: url, Medium: url}
+	if storage.Store == nil {
+		return img
+	}
+	prefix := storage.Store.URL(imageKeyPrefix)
+	if !strings.HasPrefix(url, prefix) {
+		return img
+	}
+	ext := path.Ext(url)
+	name := strings.TrimSuffix(url[len(prefix):], ext)
+	if ext == ".webp" || name == "" || strings.ContainsAny(name, "_/?#") {
+		return img
+	}
+	img.Thumbnail = storage.Store.URL(imageKeyPrefix + name + "_thumb" + ext)
+	img.Medium = storage.Store.URL(imageKeyPrefix + name + "_medium" + ext)
+	return img
 }
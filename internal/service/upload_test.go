@@ -0,0 +1,396 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/infra/storage"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"SneakerFlash/internal/testutil"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newUploadFile 构造 multipart 上传文件，与 gin 的 c.FormFile 结果一致。
+func newUploadFile(t *testing.T, filename string, data []byte) *multipart.FileHeader {
+	t.Helper()
+	return newTypedUploadFile(t, filename, "application/octet-stream", data)
+}
+
+func newTypedUploadFile(t *testing.T, filename, contentType string, data []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, filename))
+	header.Set("Content-Type", contentType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	_, _ = part.Write(data)
+	_ = w.Close()
+	form, err := multipart.NewReader(&body, w.Boundary()).ReadForm(int64(len(data)) + 1024)
+	if err != nil {
+		t.Fatalf("read form: %v", err)
+	}
+	t.Cleanup(func() { _ = form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+// jpegWithEXIF 生成带 EXIF（方向与一段模拟 GPS 文本）的 JPEG。
+func jpegWithEXIF(t *testing.T, w, h int, orientation uint16) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	tiff := make([]byte, 26)
+	copy(tiff, "MM")
+	binary.BigEndian.PutUint16(tiff[2:], 42)
+	binary.BigEndian.PutUint32(tiff[4:], 8)
+	binary.BigEndian.PutUint16(tiff[8:], 1)
+	binary.BigEndian.PutUint16(tiff[10:], 0x0112)
+	binary.BigEndian.PutUint16(tiff[12:], 3)
+	binary.BigEndian.PutUint32(tiff[14:], 1)
+	binary.BigEndian.PutUint16(tiff[18:], orientation)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	payload = append(payload, "GPS 31.2304N 121.4737E"...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	data := append([]byte{0xFF, 0xD8}, segment...)
+	data = append(data, payload...)
+	return append(data, buf.Bytes()[2:]...)
+}
+
+func decodeUploaded(t *testing.T, dir, url string) (image.Image, []byte) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, strings.TrimPrefix(url, "/uploads/")))
+	if err != nil {
+		t.Fatalf("read %s: %v", url, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode %s: %v", url, err)
+	}
+	return img, data
+}
+
+func TestUploadService_VariantsAndEXIF(t *testing.T) {
+	dir := t.TempDir()
+	storage.Store = storage.NewLocal(dir, "", []byte("test-secret"))
+	t.Cleanup(func() { storage.Store = nil })
+	testutil.SetupTestRedis(t)
+	svc := NewUploadService(testutil.NewSQLiteDB(t), storage.Store)
+	ctx := context.Background()
+
+	// 竖拍照片：存储为 1200x600，EXIF 要求顺时针旋转 90°
+	uploaded, err := svc.SaveImage(ctx, 1, newUploadFile(t, "shoe.JPEG", jpegWithEXIF(t, 1200, 600, 6)))
+	if err != nil {
+		t.Fatalf("SaveImage(jpeg) error = %v", err)
+	}
+	if !strings.HasPrefix(uploaded.URL, "/uploads/images/") || !strings.HasSuffix(uploaded.URL, ".jpg") ||
+		uploaded.Width != 600 || uploaded.Height != 1200 {
+		t.Fatalf("uploaded = %+v", uploaded)
+	}
+	original, raw := decodeUploaded(t, dir, uploaded.URL)
+	if bytes.Contains(raw, []byte("Exif")) || bytes.Contains(raw, []byte("GPS")) {
+		t.Fatalf("original still contains EXIF")
+	}
+	if b := original.Bounds(); b.Dx() != 600 || b.Dy() != 1200 {
+		t.Fatalf("original bounds = %v, want 600x1200", b)
+	}
+	for _, tc := range []struct {
+		url  string
+		w, h int
+	}{{uploaded.Thumbnail, 100, 200}, {uploaded.Medium, 400, 800}} {
+		img, _ := decodeUploaded(t, dir, tc.url)
+		if b := img.Bounds(); b.Dx() != tc.w || b.Dy() != tc.h {
+			t.Fatalf("%s bounds = %v, want %dx%d", tc.url, b, tc.w, tc.h)
+		}
+	}
+	if got := productImageFor(uploaded.URL); got != (model.ProductImage{URL: uploaded.URL, Thumbnail: uploaded.Thumbnail, Medium: uploaded.Medium}) {
+		t.Fatalf("productImageFor() = %+v, want upload variants", got)
+	}
+
+	// 小图不放大
+	var small bytes.Buffer
+	_ = png.Encode(&small, image.NewNRGBA(image.Rect(0, 0, 120, 80)))
+	uploaded, err = svc.SaveImage(ctx, 1, newUploadFile(t, "logo.png", small.Bytes()))
+	if err != nil {
+		t.Fatalf("SaveImage(png) error = %v", err)
+	}
+	if img, _ := decodeUploaded(t, dir, uploaded.Thumbnail); img.Bounds().Dx() != 120 {
+		t.Fatalf("thumbnail of small png = %v, want unscaled", img.Bounds())
+	}
+
+	if _, err := svc.SaveImage(ctx, 1, newUploadFile(t, "fake.jpg", []byte("\xff\xd8\xff\xe0 not really a jpeg"))); err == nil {
+		t.Fatalf("SaveImage(corrupt) error = nil")
+	}
+}
+
+func TestUploadService_DedupAndCleanup(t *testing.T) {
+	gdb := testutil.NewSQLiteDB(t)
+	storage.Store = storage.NewLocal(t.TempDir(), "", []byte("test-secret"))
+	t.Cleanup(func() { storage.Store = nil })
+	testutil.SetupTestRedis(t)
+	svc := NewUploadService(gdb, storage.Store)
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+	pngOf := func(w int) []byte {
+		var buf bytes.Buffer
+		_ = png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, 10)))
+		return buf.Bytes()
+	}
+	exists := func(url string) bool {
+		ok, err := storage.Store.Exists(ctx, strings.TrimPrefix(url, "/uploads/"))
+		if err != nil {
+			t.Fatalf("Exists(%s) error = %v", url, err)
+		}
+		return ok
+	}
+
+	avatar, err := svc.SaveImage(ctx, 1, newUploadFile(t, "a.png", pngOf(20)))
+	if err != nil {
+		t.Fatalf("SaveImage() error = %v", err)
+	}
+	again, err := svc.SaveImage(ctx, 2, newUploadFile(t, "copy.png", pngOf(20)))
+	if err != nil {
+		t.Fatalf("SaveImage(duplicate) error = %v", err)
+	}
+	if *again != *avatar {
+		t.Fatalf("duplicate upload = %+v, want %+v", again, avatar)
+	}
+	orphan, err := svc.SaveImage(ctx, 1, newUploadFile(t, "b.png", pngOf(30)))
+	if err != nil {
+		t.Fatalf("SaveImage() error = %v", err)
+	}
+	var uploads []model.Upload
+	if err := gdb.Order("id asc").Find(&uploads).Error; err != nil {
+		t.Fatalf("load uploads: %v", err)
+	}
+	if len(uploads) != 2 || uploads[0].OwnerID != 1 || uploads[0].MimeType != "image/png" || uploads[0].Width != 20 ||
+		!strings.HasSuffix(avatar.URL, "/"+uploads[0].Hash+".png") {
+		t.Fatalf("uploads = %+v, url = %s", uploads, avatar.URL)
+	}
+	user := &model.User{Username: "alice", Password: "hashed", Avatar: avatar.URL}
+	if err := gdb.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	// 保留期内不清理
+	if deleted, err := svc.CleanupOrphans(ctx, 24*time.Hour); err != nil || deleted != 0 {
+		t.Fatalf("CleanupOrphans(in grace) = %d, %v", deleted, err)
+	}
+	now = now.Add(25 * time.Hour)
+	if deleted, err := svc.CleanupOrphans(ctx, 24*time.Hour); err != nil || deleted != 1 {
+		t.Fatalf("CleanupOrphans() = %d, %v, want 1", deleted, err)
+	}
+	if exists(orphan.URL) || exists(orphan.Thumbnail) || exists(orphan.Medium) {
+		t.Fatalf("orphan files not removed")
+	}
+	if !exists(avatar.URL) || !exists(avatar.Thumbnail) {
+		t.Fatalf("referenced files removed")
+	}
+
+	// 引用移除后重新开始保留期，之后才删除
+	if err := gdb.Model(user).Update("avatar", "").Error; err != nil {
+		t.Fatalf("clear avatar: %v", err)
+	}
+	now = now.Add(25 * time.Hour)
+	if deleted, err := svc.CleanupOrphans(ctx, 24*time.Hour); err != nil || deleted != 0 {
+		t.Fatalf("CleanupOrphans(just released) = %d, %v", deleted, err)
+	}
+	now = now.Add(25 * time.Hour)
+	if deleted, err := svc.CleanupOrphans(ctx, 24*time.Hour); err != nil || deleted != 1 {
+		t.Fatalf("CleanupOrphans(released) = %d, %v, want 1", deleted, err)
+	}
+	if exists(avatar.URL) || exists(avatar.Medium) {
+		t.Fatalf("released files not removed")
+	}
+
+	// 清理后重新上传相同内容会重新写入文件
+	again, err = svc.SaveImage(ctx, 3, newUploadFile(t, "a.png", pngOf(20)))
+	if err != nil || again.URL != avatar.URL || !exists(again.URL) {
+		t.Fatalf("re-upload = %+v, %v", again, err)
+	}
+}
+
+func TestUploadService_ValidationAndQuota(t *testing.T) {
+	dir := t.TempDir()
+	storage.Store = storage.NewLocal(dir, "", []byte("test-secret"))
+	t.Cleanup(func() { storage.Store = nil })
+	testutil.SetupTestRedis(t)
+	svc := NewUploadService(testutil.NewSQLiteDB(t), storage.Store)
+	svc.limits = uploadLimits{maxSize: 1 << 20, dailyCount: 3, dailySize: 1 << 20, maxDimension: 100, maxPixels: 5000}
+	ctx := context.Background()
+	pngOf := func(w, h int) []byte {
+		var buf bytes.Buffer
+		_ = png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, w, h)))
+		return buf.Bytes()
+	}
+	// 两帧 GIF，结束符前带一段注释扩展
+	gifWithComment := func(w, h int) []byte {
+		palette := color.Palette{color.Black, color.White}
+		anim := &gif.GIF{Image: []*image.Paletted{image.NewPaletted(image.Rect(0, 0, w, h), palette), image.NewPaletted(image.Rect(0, 0, w, h), palette)}, Delay: []int{10, 10}}
+		var buf bytes.Buffer
+		_ = gif.EncodeAll(&buf, anim)
+		data := buf.Bytes()
+		data = append(data[:len(data)-1], 0x21, 0xFE, 7)
+		return append(data, "GPS=1,2\x00\x3B"...)
+	}
+
+	for _, tc := range []struct {
+		name string
+		file *multipart.FileHeader
+		want error
+	}{
+		{"bmp", newUploadFile(t, "shoe.bmp", pngOf(10, 10)), ErrUploadUnsupported},
+		{"png as jpg", newUploadFile(t, "shoe.jpg", pngOf(10, 10)), ErrUploadTypeMismatch},
+		{"declared gif", newTypedUploadFile(t, "shoe.png", "image/gif", pngOf(10, 10)), ErrUploadTypeMismatch},
+		{"too wide", newUploadFile(t, "wide.png", pngOf(120, 10)), ErrUploadDimensions},
+		{"too many pixels", newUploadFile(t, "big.png", pngOf(60, 100)), ErrUploadTooManyPixels},
+		{"webp", newUploadFile(t, "shoe.webp", []byte("RIFF\x1a\x00\x00\x00WEBPVP8 ")), ErrUploadUnsupported},
+		{"too large", newUploadFile(t, "huge.png", append(pngOf(10, 10), make([]byte, 1<<20)...)), ErrUploadTooLarge},
+	} {
+		if _, err := svc.SaveImage(ctx, 1, tc.file); !errors.Is(err, tc.want) {
+			t.Fatalf("SaveImage(%s) error = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// 被拒绝的上传不占额度
+	if _, err := svc.SaveImage(ctx, 1, newTypedUploadFile(t, "ok.jpeg", "image/jpg", jpegWithEXIF(t, 40, 20, 1))); err != nil {
+		t.Fatalf("SaveImage(declared image/jpg) error = %v", err)
+	}
+	uploaded, err := svc.SaveImage(ctx, 1, newUploadFile(t, "ok.gif", gifWithComment(80, 40)))
+	if err != nil || uploaded.Width != 80 || uploaded.Height != 40 {
+		t.Fatalf("SaveImage(gif) = %+v, %v", uploaded, err)
+	}
+	// GIF 保留动画，注释扩展被剔除
+	_, stored := decodeUploaded(t, dir, uploaded.URL)
+	if anim, err := gif.DecodeAll(bytes.NewReader(stored)); err != nil || len(anim.Image) != 2 || bytes.Contains(stored, []byte("GPS=")) {
+		t.Fatalf("stored gif = %d bytes, %v", len(stored), err)
+	}
+	if _, err := svc.SaveImage(ctx, 1, newUploadFile(t, "ok.png", pngOf(20, 20))); err != nil {
+		t.Fatalf("SaveImage(3rd) error = %v", err)
+	}
+	if _, err := svc.SaveImage(ctx, 1, newUploadFile(t, "more.png", pngOf(30, 30))); !errors.Is(err, ErrUploadDailyCount) {
+		t.Fatalf("SaveImage(4th) error = %v, want ErrUploadDailyCount", err)
+	}
+	// 额度按用户计算
+	if _, err := svc.SaveImage(ctx, 2, newUploadFile(t, "more.png", pngOf(30, 30))); err != nil {
+		t.Fatalf("SaveImage(other user) error = %v", err)
+	}
+
+	tiny := pngOf(2, 2)
+	svc.limits.dailySize = int64(len(tiny)) + 16
+	if _, err := svc.SaveImage(ctx, 3, newUploadFile(t, "a.jpg", jpegWithEXIF(t, 40, 20, 1))); !errors.Is(err, ErrUploadDailySize) {
+		t.Fatalf("SaveImage(over size) error = %v, want ErrUploadDailySize", err)
+	}
+	if _, err := svc.SaveImage(ctx, 3, newUploadFile(t, "tiny.png", tiny)); err != nil {
+		t.Fatalf("SaveImage(within size) error = %v", err)
+	}
+}
+
+func TestProductImageForObjectStorage(t *testing.T) {
+	store, err := storage.NewS3(config.S3Config{Endpoint: "http://127.0.0.1:9000", Bucket: "sneakers", AccessKey: "a", SecretKey: "s", PathStyle: true}, "https://cdn.example.com")
+	if err != nil {
+		t.Fatalf("NewS3() error = %v", err)
+	}
+	storage.Store = store
+	t.Cleanup(func() { storage.Store = nil })
+
+	got := productImageFor("https://cdn.example.com/images/42.png")
+	if got.Thumbnail != "https://cdn.example.com/images/42_thumb.png" || got.Medium != "https://cdn.example.com/images/42_medium.png" {
+		t.Fatalf("productImageFor(cdn) = %+v", got)
+	}
+	// 旧版本地上传与其他域名的图片没有变体
+	for _, url := range []string{"/uploads/images/42.png", "https://other.example.com/images/42.png", "https://cdn.example.com/images/42_thumb.png"} {
+		if got := productImageFor(url); got.Thumbnail != url || got.Medium != url {
+			t.Fatalf("productImageFor(%s) = %+v", url, got)
+		}
+	}
+}
+
+func TestProductService_ImageGallery(t *testing.T) {
+	_, fixtures := newOrderServiceForTest(t)
+	svc := NewProductService(db.DB, repository.NewProductRepo(db.DB), repository.NewSellerRepo(db.DB), repository.NewCatalogRepo(db.DB))
+	ctx := context.Background()
+	storage.Store = storage.NewLocal(t.TempDir(), "", []byte("test-secret"))
+	t.Cleanup(func() { storage.Store = nil })
+	sellerID := fixtures.user.ID
+	if err := db.DB.Create(&model.SellerProfile{UserID: sellerID, StoreName: "鞋仓", Status: model.SellerStatusApproved}).Error; err != nil {
+		t.Fatalf("create seller: %v", err)
+	}
+
+	product := &model.Product{UserID: sellerID, Name: "AJ 1 Chicago", Price: 1299, Stock: 5, StartTime: time.Now().Add(time.Hour),
+		Images: model.ProductImages{{URL: "/uploads/images/1.jpg"}, {URL: " https://cdn.example.com/side.png "}, {URL: "/uploads/images/1.jpg"}, {URL: "/uploads/images/2.webp"}}}
+	if err := svc.CreateProduct(ctx, product); err != nil {
+		t.Fatalf("CreateProduct() error = %v", err)
+	}
+	want := model.ProductImages{
+		{URL: "/uploads/images/1.jpg", Thumbnail: "/uploads/images/1_thumb.jpg", Medium: "/uploads/images/1_medium.jpg"},
+		{URL: "https://cdn.example.com/side.png", Thumbnail: "https://cdn.example.com/side.png", Medium: "https://cdn.example.com/side.png"},
+		{URL: "/uploads/images/2.webp", Thumbnail: "/uploads/images/2.webp", Medium: "/uploads/images/2.webp"},
+	}
+	var saved model.Product
+	if err := db.DB.First(&saved, product.ID).Error; err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+	if saved.Image != want[0].URL || len(saved.Images) != len(want) {
+		t.Fatalf("saved image = %q, images = %+v", saved.Image, saved.Images)
+	}
+	for i := range want {
+		if saved.Images[i] != want[i] {
+			t.Fatalf("images[%d] = %+v, want %+v", i, saved.Images[i], want[i])
+		}
+	}
+
+	// 只传 image 时替换封面，其余图片不变
+	if err := svc.UpdateProduct(ctx, sellerID, product.ID, map[string]any{"image": "/uploads/images/3.png"}); err != nil {
+		t.Fatalf("UpdateProduct(image) error = %v", err)
+	}
+	if err := db.DB.First(&saved, product.ID).Error; err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+	if saved.Image != "/uploads/images/3.png" || len(saved.Images) != 3 || saved.Images[0].Thumbnail != "/uploads/images/3_thumb.png" || saved.Images[1] != want[1] {
+		t.Fatalf("after cover update = %q %+v", saved.Image, saved.Images)
+	}
+
+	tooMany := make(model.ProductImages, 10)
+	for i := range tooMany {
+		tooMany[i] = model.ProductImage{URL: "/uploads/images/" + string(rune('a'+i)) + ".jpg"}
+	}
+	if err := svc.UpdateProduct(ctx, sellerID, product.ID, map[string]any{"images": tooMany}); !errors.Is(err, ErrProductImagesInvalid) {
+		t.Fatalf("UpdateProduct(10 images) error = %v, want ErrProductImagesInvalid", err)
+	}
+	if err := svc.UpdateProduct(ctx, sellerID, product.ID, map[string]any{"images": model.ProductImages{}}); err != nil {
+		t.Fatalf("UpdateProduct(clear) error = %v", err)
+	}
+	if err := db.DB.First(&saved, product.ID).Error; err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+	if saved.Image != "" || len(saved.Images) != 0 {
+		t.Fatalf("after clear = %q %+v", saved.Image, saved.Images)
+	}
+}
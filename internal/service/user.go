@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -16,20 +17,23 @@ import (
 
 // UserService 用户服务，处理注册、登录、Token 刷新。
 type UserService struct {
-	repo *repository.UserRepo
+	repo        *repository.UserRepo
+	referralSvc *ReferralService
 }
 
 type UserProfile struct {
-	ID              uint      `json:"id"`
-	Username        string    `json:"username"`
-	Balance         float64   `json:"balance"`
-	Avatar          string    `json:"avatar"`
-	TotalSpentCents int64     `json:"total_spent_cents"`
-	GrowthLevel     int       `json:"growth_level"`
-	Role            string    `json:"role"`
-	Permissions     []string  `json:"permissions,omitempty"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID               uint      `json:"id"`
+	Username         string    `json:"username"`
+	Balance          float64   `json:"balance"`
+	Avatar           string    `json:"avatar"`
+	TotalSpentCents  int64     `json:"total_spent_cents"`
+	WindowSpentCents int64     `json:"window_spent_cents"`
+	GrowthLevel      int       `json:"growth_level"`
+	Points           int64     `json:"points"`
+	Role             string    `json:"role"`
+	Permissions      []string  `json:"permissions,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 var (
@@ -40,17 +44,27 @@ var (
 	ErrTokenExpired  = errors.New("token 过期")
 )
 
-func NewUserService(repo *repository.UserRepo) *UserService {
+func NewUserService(repo *repository.UserRepo, referralSvc *ReferralService) *UserService {
 	return &UserService{
-		repo: repo,
+		repo:        repo,
+		referralSvc: referralSvc,
 	}
 }
 
 // Register 注册用户，直接插入并依赖唯一键防重，密码使用哈希存储。
-func (s *UserService) Register(ctx context.Context, username, password string) error {
+// 携带邀请码时先校验邀请码，注册成功后记录邀请关系，邀请记录失败不影响注册。
+func (s *UserService) Register(ctx context.Context, username, password, inviteCode string, client ClientMeta) error {
 	if ctx == nil {
 		return fmt.Errorf("context is nil")
 	}
+	var inviterID uint
+	if inviteCode != "" && s.referralSvc != nil {
+		id, err := s.referralSvc.resolveInviter(ctx, inviteCode)
+		if err != nil {
+			return err
+		}
+		inviterID = id
+	}
 	// 加密用户密码
 	hashPwd, err := utils.HashPassword(password)
 	if err != nil {
@@ -70,11 +84,20 @@ func (s *UserService) Register(ctx context.Context, username, password string) e
 		}
 		return err
 	}
+	if s.referralSvc == nil {
+		return nil
+	}
+	if inviterID != 0 {
+		if _, err := s.referralSvc.bind(ctx, inviterID, user.ID, client); err != nil {
+			slog.Warn("记录邀请关系失败", slog.Uint64("user_id", uint64(user.ID)), slog.Any("err", err))
+		}
+	}
+	s.referralSvc.RecordFootprint(ctx, user.ID, client)
 	return nil
 }
 
-// Login 校验密码后签发 access/refresh token。
-func (s *UserService) Login(ctx context.Context, username, password string) (string, string, error) {
+// Login 校验密码后签发 access/refresh token，并记录登录足迹用于邀请反作弊。
+func (s *UserService) Login(ctx context.Context, username, password string, client ClientMeta) (string, string, error) {
 	if ctx == nil {
 		return "", "", fmt.Errorf("context is nil")
 	}
@@ -95,6 +118,9 @@ func (s *UserService) Login(ctx context.Context, username, password string) (str
 	if err != nil {
 		return "", "", err
 	}
+	if s.referralSvc != nil {
+		s.referralSvc.RecordFootprint(ctx, user.ID, client)
+	}
 
 	return access, refresh, nil
 }
@@ -110,16 +136,18 @@ func (s *UserService) GetProfile(ctx context.Context, userID uint) (*UserProfile
 	}
 	role := model.NormalizeUserRole(user.Role)
 	return &UserProfile{
-		ID:              user.ID,
-		Username:        user.Username,
-		Balance:         user.Balance,
-		Avatar:          user.Avatar,
-		TotalSpentCents: user.TotalSpentCents,
-		GrowthLevel:     user.GrowthLevel,
-		Role:            role,
-		Permissions:     model.PermissionsForRole(role),
-		CreatedAt:       user.CreatedAt,
-		UpdatedAt:       user.UpdatedAt,
+		ID:               user.ID,
+		Username:         user.Username,
+		Balance:          user.Balance,
+		Avatar:           user.Avatar,
+		TotalSpentCents:  user.TotalSpentCents,
+		WindowSpentCents: user.WindowSpentCents,
+		GrowthLevel:      user.GrowthLevel,
+		Points:           user.Points,
+		Role:             role,
+		Permissions:      model.PermissionsForRole(role),
+		CreatedAt:        user.CreatedAt,
+		UpdatedAt:        user.UpdatedAt,
 	}, nil
 }
 
@@ -18,14 +18,14 @@ func newUserServiceForTest(t *testing.T) (*UserService, *repository.UserRepo, *g
 	testutil.SetupTestConfig()
 	db := testutil.NewSQLiteDB(t)
 	repo := repository.NewUserRepo(db)
-	return NewUserService(repo), repo, db
+	return NewUserService(repo, nil), repo, db
 }
 
 func TestUserService_RegisterAndLogin(t *testing.T) {
 	svc, repo, _ := newUserServiceForTest(t)
 	ctx := context.Background()
 
-	if err := svc.Register(ctx, "alice", "password-123"); err != nil {
+	if err := svc.Register(ctx, "alice", "password-123", "", ClientMeta{}); err != nil {
 		t.Fatalf("Register() error = %v", err)
 	}
 
@@ -37,7 +37,7 @@ func TestUserService_RegisterAndLogin(t *testing.T) {
 		t.Fatal("password stored in plaintext")
 	}
 
-	access, refresh, err := svc.Login(ctx, "alice", "password-123")
+	access, refresh, err := svc.Login(ctx, "alice", "password-123", ClientMeta{})
 	if err != nil {
 		t.Fatalf("Login() error = %v", err)
 	}
@@ -50,11 +50,11 @@ func TestUserService_RegisterDuplicate(t *testing.T) {
 	svc, _, _ := newUserServiceForTest(t)
 	ctx := context.Background()
 
-	if err := svc.Register(ctx, "alice", "password-123"); err != nil {
+	if err := svc.Register(ctx, "alice", "password-123", "", ClientMeta{}); err != nil {
 		t.Fatalf("first Register() error = %v", err)
 	}
 
-	err := svc.Register(ctx, "alice", "password-456")
+	err := svc.Register(ctx, "alice", "password-456", "", ClientMeta{})
 	if !errors.Is(err, ErrUserExited) {
 		t.Fatalf("duplicate Register() error = %v, want %v", err, ErrUserExited)
 	}
@@ -64,11 +64,11 @@ func TestUserService_LoginWrongPassword(t *testing.T) {
 	svc, _, _ := newUserServiceForTest(t)
 	ctx := context.Background()
 
-	if err := svc.Register(ctx, "alice", "password-123"); err != nil {
+	if err := svc.Register(ctx, "alice", "password-123", "", ClientMeta{}); err != nil {
 		t.Fatalf("Register() error = %v", err)
 	}
 
-	_, _, err := svc.Login(ctx, "alice", "wrong-password")
+	_, _, err := svc.Login(ctx, "alice", "wrong-password", ClientMeta{})
 	if !errors.Is(err, ErrPasswordWrong) {
 		t.Fatalf("Login() error = %v, want %v", err, ErrPasswordWrong)
 	}
@@ -78,11 +78,11 @@ func TestUserService_RefreshRejectsAccessToken(t *testing.T) {
 	svc, _, _ := newUserServiceForTest(t)
 	ctx := context.Background()
 
-	if err := svc.Register(ctx, "alice", "password-123"); err != nil {
+	if err := svc.Register(ctx, "alice", "password-123", "", ClientMeta{}); err != nil {
 		t.Fatalf("Register() error = %v", err)
 	}
 
-	access, _, err := svc.Login(ctx, "alice", "password-123")
+	access, _, err := svc.Login(ctx, "alice", "password-123", ClientMeta{})
 	if err != nil {
 		t.Fatalf("Login() error = %v", err)
 	}
@@ -116,7 +116,7 @@ func TestUserService_PromoteToAdmin(t *testing.T) {
 	svc, repo, _ := newUserServiceForTest(t)
 	ctx := context.Background()
 
-	if err := svc.Register(ctx, "alice", "password-123"); err != nil {
+	if err := svc.Register(ctx, "alice", "password-123", "", ClientMeta{}); err != nil {
 		t.Fatalf("Register() error = %v", err)
 	}
 
@@ -141,7 +141,7 @@ func TestUserService_PromoteToAdminKeepsScopedAdminRole(t *testing.T) {
 	svc, repo, _ := newUserServiceForTest(t)
 	ctx := context.Background()
 
-	if err := svc.Register(ctx, "alice", "password-123"); err != nil {
+	if err := svc.Register(ctx, "alice", "password-123", "", ClientMeta{}); err != nil {
 		t.Fatalf("Register() error = %v", err)
 	}
 	if err := repo.UpdateProfile(ctx, 1, map[string]any{"role": model.UserRoleRiskAdmin}); err != nil {
@@ -165,11 +165,11 @@ func TestUserService_RefreshUsesCurrentRole(t *testing.T) {
 	svc, repo, _ := newUserServiceForTest(t)
 	ctx := context.Background()
 
-	if err := svc.Register(ctx, "alice", "password-123"); err != nil {
+	if err := svc.Register(ctx, "alice", "password-123", "", ClientMeta{}); err != nil {
 		t.Fatalf("Register() error = %v", err)
 	}
 
-	_, refresh, err := svc.Login(ctx, "alice", "password-123")
+	_, refresh, err := svc.Login(ctx, "alice", "password-123", ClientMeta{})
 	if err != nil {
 		t.Fatalf("Login() error = %v", err)
 	}
@@ -1,52 +1,64 @@
 package service
 
 import (
+	"SneakerFlash/internal/infra/redis"
 	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/utils"
 	"SneakerFlash/internal/repository"
 	"context"
 	"fmt"
+	"math"
+	"strconv"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// PaidPlan 付费 VIP 套餐配置
-type PaidPlan struct {
-	PlanID       int
-	Level        int   // VIP 等级
-	DurationDays int   // 有效天数
-	PriceCents   int64 // 价格（分）
+// VIPProfile 用户 VIP 状态视图
+type VIPProfile struct {
+	TotalSpentCents   int64      `json:"total_spent_cents"`             // 累计消费（分）
+	WindowSpentCents  int64      `json:"window_spent_cents"`            // 滚动窗口内消费（分），成长等级按此计算
+	GrowthLevel       int        `json:"growth_level"`                  // 成长等级
+	GrowthDowngradeAt *time.Time `json:"growth_downgrade_at,omitempty"` // 降级宽限截止时间，为空表示无待降级
+	PaidLevel         int        `json:"paid_level"`                    // 付费等级
+	PaidExpiredAt     time.Time  `json:"paid_expired_at"`               // 付费到期时间
+	EffectiveLevel    int        `json:"effective_level"`               // 生效等级 = max(成长, 付费)
 }
 
-// 简单预置两个付费 VIP 套餐，可按需扩展。
-var paidPlans = map[int]PaidPlan{
-	1: {PlanID: 1, Level: 3, DurationDays: 30, PriceCents: 3000}, // L3 30 天
-	2: {PlanID: 2, Level: 4, DurationDays: 90, PriceCents: 8000}, // L4 90 天
-}
+// vipLevelCacheTTL 生效等级缓存时长，付费到期更早时以到期时间为准。
+const vipLevelCacheTTL = 5 * time.Minute
 
-// VIPProfile 用户 VIP 状态视图
-type VIPProfile struct {
-	TotalSpentCents int64     `json:"total_spent_cents"` // 累计消费（分）
-	GrowthLevel     int       `json:"growth_level"`      // 成长等级（消费累计）
-	PaidLevel       int       `json:"paid_level"`        // 付费等级
-	PaidExpiredAt   time.Time `json:"paid_expired_at"`   // 付费到期时间
-	EffectiveLevel  int       `json:"effective_level"`   // 生效等级 = max(成长, 付费)
+// DropAccess 用户对某个商品的抢购资格与个人开抢时间。
+type DropAccess struct {
+	ProductID          uint       `json:"product_id"`
+	Level              int        `json:"level"`         // 用户生效等级
+	MinVIPLevel        int        `json:"min_vip_level"` // 商品最低可购等级，0 不限
+	Eligible           bool       `json:"eligible"`      // 等级是否满足
+	EarlyAccessMinutes int        `json:"early_access_minutes"`
+	StartTime          time.Time  `json:"start_time"` // 商品公开开抢时间
+	OpenAt             time.Time  `json:"open_at"`    // 该用户可开抢时间
+	EndTime            *time.Time `json:"end_time,omitempty"`
+	PriceCents         int64      `json:"price_cents"`            // 原价（分）
+	MemberPriceCents   int64      `json:"member_price_cents"`     // 该用户应付价格（分），无会员价时等于原价
+	MemberLevel        int        `json:"member_level,omitempty"` // 命中的会员价等级，0 表示无
 }
 
-// VIPService VIP 服务，处理等级查询和付费开通。
+// VIPService VIP 服务，处理等级查询和付费下单。
 type VIPService struct {
-	db          *gorm.DB
-	userRepo    *repository.UserRepo
-	paidVIPRepo *repository.PaidVIPRepo
-	couponSvc   *CouponService
+	db           *gorm.DB
+	userRepo     *repository.UserRepo
+	paidVIPRepo  *repository.PaidVIPRepo
+	couponSvc    *CouponService
+	vipConfigSvc *VIPConfigService
 }
 
 func NewVIPService(db *gorm.DB, userRepo *repository.UserRepo, couponSvc *CouponService) *VIPService {
 	return &VIPService{
-		db:          db,
-		userRepo:    userRepo,
-		paidVIPRepo: repository.NewPaidVIPRepo(db),
-		couponSvc:   couponSvc,
+		db:           db,
+		userRepo:     userRepo,
+		paidVIPRepo:  repository.NewPaidVIPRepo(db),
+		couponSvc:    couponSvc,
+		vipConfigSvc: NewVIPConfigService(db),
 	}
 }
 
@@ -66,8 +78,10 @@ func (s *VIPService) Profile(ctx context.Context, userID uint) (*VIPProfile, err
 	}
 
 	profile := &VIPProfile{
-		TotalSpentCents: user.TotalSpentCents,
-		GrowthLevel:     user.GrowthLevel,
+		TotalSpentCents:   user.TotalSpentCents,
+		WindowSpentCents:  user.WindowSpentCents,
+		GrowthLevel:       user.GrowthLevel,
+		GrowthDowngradeAt: user.GrowthDowngradeAt,
 	}
 	if paid != nil && paid.ExpiredAt.After(time.Now()) {
 		profile.PaidLevel = paid.Level
@@ -77,25 +91,151 @@ func (s *VIPService) Profile(ctx context.Context, userID uint) (*VIPProfile, err
 	return profile, nil
 }
 
-// PurchasePaidVIP 激活付费 VIP（模拟购买成功），当前直接落库，可结合支付单扩展。
-func (s *VIPService) PurchasePaidVIP(ctx context.Context, userID uint, planID int) (*VIPProfile, error) {
+// EffectiveLevel 读取用户生效等级（成长与付费取高），优先走 Redis 缓存，供秒杀入口快速判断。
+func (s *VIPService) EffectiveLevel(ctx context.Context, userID uint) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	key := vipLevelCacheKey(userID)
+	if val, err := redis.RDB.Get(ctx, key).Result(); err == nil {
+		if level, convErr := strconv.Atoi(val); convErr == nil {
+			return level, nil
+		}
+	}
+
+	profile, err := s.Profile(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	ttl := vipLevelCacheTTL
+	if profile.PaidLevel > profile.GrowthLevel {
+		// 付费等级生效中，缓存不能跨过到期时间
+		if left := time.Until(profile.PaidExpiredAt); left < ttl {
+			ttl = left
+		}
+	}
+	if ttl > 0 {
+		_ = redis.RDB.Set(ctx, key, profile.EffectiveLevel, ttl).Err()
+	}
+	return profile.EffectiveLevel, nil
+}
+
+// DropAccess 计算用户对商品的抢购资格：等级门槛与按等级提前的开抢时间。
+func (s *VIPService) DropAccess(ctx context.Context, userID uint, product *model.Product) (*DropAccess, error) {
 	if ctx == nil {
 		return nil, fmt.Errorf("context is nil")
 	}
-	plan, ok := paidPlans[planID]
+	level, err := s.EffectiveLevel(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := s.vipConfigSvc.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	early := cfg.EarlyAccess(level)
+	memberCents, memberLevel := memberPriceFor(product, level)
+	return &DropAccess{
+		ProductID:          product.ID,
+		Level:              level,
+		MinVIPLevel:        product.MinVIPLevel,
+		Eligible:           level >= product.MinVIPLevel,
+		EarlyAccessMinutes: int(early / time.Minute),
+		StartTime:          product.StartTime,
+		OpenAt:             product.StartTime.Add(-early),
+		EndTime:            product.EndTime,
+		PriceCents:         int64(math.Round(product.Price * 100)),
+		MemberPriceCents:   memberCents,
+		MemberLevel:        memberLevel,
+	}, nil
+}
+
+func vipLevelCacheKey(userID uint) string {
+	return fmt.Sprintf("vip:level:%d", userID)
+}
+
+// invalidateVIPLevelCache 成长值或付费状态变化后删除等级缓存。
+func invalidateVIPLevelCache(ctx context.Context, userID uint) {
+	_ = redis.RDB.Del(ctx, vipLevelCacheKey(userID)).Err()
+}
+
+// GrowthHistory 分页查询成长等级变更与降级预警记录。
+func (s *VIPService) GrowthHistory(ctx context.Context, userID uint, page, pageSize int) ([]model.GrowthLevelChange, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+	return repository.NewGrowthRepo(s.db).ListByUser(ctx, userID, page, pageSize)
+}
+
+// ListPlans 返回当前可购买的付费套餐。
+func (s *VIPService) ListPlans(ctx context.Context) ([]model.VIPPlan, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	return s.vipConfigSvc.ActivePlans(ctx)
+}
+
+// PurchasePaidVIP 按当前套餐版本创建 VIP 待支付订单与支付单，支付成功后才开通。
+func (s *VIPService) PurchasePaidVIP(ctx context.Context, userID uint, planID int) (*OrderWithPayment, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	cfg, err := s.vipConfigSvc.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan, ok := cfg.Plan(planID)
 	if !ok {
-		return nil, fmt.Errorf("未知付费VIP套餐")
+		return nil, ErrVIPPlanNotFound
 	}
-	start := time.Now()
-	end := start.Add(time.Duration(plan.DurationDays) * 24 * time.Hour)
-	if err := s.paidVIPRepo.Upsert(ctx, userID, plan.Level, start, end); err != nil {
+	if plan.Status != model.VIPPlanStatusActive {
+		return nil, ErrVIPPlanInactive
+	}
+
+	orderNum, err := utils.GenSnowflakeID()
+	if err != nil {
 		return nil, err
 	}
-	// 购买成功后立即发放当月 VIP 优惠券
-	if s.couponSvc != nil {
-		_ = s.couponSvc.IssueVIPMonthly(ctx, userID, plan.Level)
+	paymentID, err := utils.GenSnowflakeID()
+	if err != nil {
+		return nil, err
+	}
+
+	var result OrderWithPayment
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order := &model.Order{
+			UserID:         userID,
+			OrderNum:       orderNum,
+			Status:         model.OrderStatusUnpaid,
+			Type:           model.OrderTypeVIP,
+			VIPPlanID:      plan.PlanID,
+			VIPPlanVersion: plan.Version,
+		}
+		if err := repository.NewOrderRepo(tx).Create(ctx, order); err != nil {
+			return err
+		}
+		payment := &model.Payment{
+			OrderID:     order.ID,
+			PaymentID:   paymentID,
+			AmountCents: plan.PriceCents,
+			Status:      model.PaymentStatusPending,
+		}
+		if _, err := repository.NewPaymentRepo(tx).CreateIfAbsent(ctx, payment); err != nil {
+			return err
+		}
+		result = OrderWithPayment{Order: order, Payment: payment}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return s.Profile(ctx, userID)
+	return &result, nil
 }
 
 func max(a, b int) int {
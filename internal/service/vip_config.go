@@ -0,0 +1,455 @@
+package service
+
+import (
+	"SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/vip"
+	"SneakerFlash/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	vipConfigCacheKey = "vip:config"
+	vipConfigCacheTTL = 10 * time.Minute
+	// maxEarlyAccessMinutes 提前入场上限，避免误配成整天
+	maxEarlyAccessMinutes = 24 * 60
+)
+
+var (
+	ErrVIPPlanNotFound    = errors.New("VIP 套餐不存在")
+	ErrVIPPlanInvalid     = errors.New("VIP 套餐参数无效")
+	ErrVIPPlanInactive    = errors.New("VIP 套餐已下架")
+	ErrVIPLevelNotFound   = errors.New("VIP 等级配置不存在")
+	ErrVIPLevelInvalid    = errors.New("VIP 等级配置无效")
+	ErrVIPLevelThreshold  = errors.New("成长门槛必须随等级递增，且 L1 门槛为 0")
+	ErrVIPLevelBaseDelete = errors.New("L1 为基础等级，不能删除")
+)
+
+// VIPConfig 当前生效的 VIP 配置快照，缓存在 Redis。
+type VIPConfig struct {
+	Plans  []model.VIPPlan        `json:"plans"`
+	Levels []model.VIPLevelConfig `json:"levels"`
+}
+
+// Plan 按套餐 ID 查找当前版本。
+func (c *VIPConfig) Plan(planID int) (*model.VIPPlan, bool) {
+	for i := range c.Plans {
+		if c.Plans[i].PlanID == planID {
+			return &c.Plans[i], true
+		}
+	}
+	return nil, false
+}
+
+// Level 查找等级配置。
+func (c *VIPConfig) Level(level int) (*model.VIPLevelConfig, bool) {
+	for i := range c.Levels {
+		if c.Levels[i].Level == level {
+			return &c.Levels[i], true
+		}
+	}
+	return nil, false
+}
+
+// EarlyAccess 等级对应的秒杀提前入场时长，未配置的等级为 0。
+func (c *VIPConfig) EarlyAccess(level int) time.Duration {
+	if l, ok := c.Level(level); ok {
+		return time.Duration(l.EarlyAccessMinutes) * time.Minute
+	}
+	return 0
+}
+
+// MaxEarlyAccess 所有等级中最长的提前入场时长。
+func (c *VIPConfig) MaxEarlyAccess() time.Duration {
+	var longest time.Duration
+	for _, l := range c.Levels {
+		if d := time.Duration(l.EarlyAccessMinutes) * time.Minute; d > longest {
+			longest = d
+		}
+	}
+	return longest
+}
+
+// Thresholds 转换为成长等级计算所需的阈值列表。
+func (c *VIPConfig) Thresholds() []vip.Threshold {
+	out := make([]vip.Threshold, 0, len(c.Levels))
+	for _, l := range c.Levels {
+		out = append(out, vip.Threshold{Level: l.Level, Min: l.MinSpentCents})
+	}
+	return out
+}
+
+// ClampLevel 将等级限制在已配置的等级范围内。
+func (c *VIPConfig) ClampLevel(level int) int {
+	maxLevel := 1
+	for _, l := range c.Levels {
+		if l.Level > maxLevel {
+			maxLevel = l.Level
+		}
+	}
+	if level < 1 {
+		return 1
+	}
+	if level > maxLevel {
+		return maxLevel
+	}
+	return level
+}
+
+// VIPPlanInput 新建或修改套餐的参数，修改时会生成新版本。
+type VIPPlanInput struct {
+	Name         string
+	Level        int
+	DurationDays int
+	PriceCents   int64
+	Status       string
+}
+
+// VIPLevelInput 等级配置参数。
+type VIPLevelInput struct {
+	MinSpentCents       int64
+	MonthlyCouponQuota  int
+	CouponTitle         string
+	CouponType          model.CouponType
+	CouponAmountCents   int64
+	CouponDiscountRate  int
+	CouponMinSpendCents int64
+	EarlyAccessMinutes  int
+}
+
+// VIPConfigService 管理付费套餐、成长门槛与月度券规格，读路径走 Redis 缓存。
+type VIPConfigService struct {
+	db   *gorm.DB
+	repo *repository.VIPConfigRepo
+}
+
+func NewVIPConfigService(db *gorm.DB) *VIPConfigService {
+	return &VIPConfigService{
+		db:   db,
+		repo: repository.NewVIPConfigRepo(db),
+	}
+}
+
+// Current 返回当前生效配置：优先读缓存，未命中时查库并回写。
+func (s *VIPConfigService) Current(ctx context.Context) (*VIPConfig, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if val, err := redis.RDB.Get(ctx, vipConfigCacheKey).Result(); err == nil {
+		var cfg VIPConfig
+		if err := json.Unmarshal([]byte(val), &cfg); err == nil {
+			return &cfg, nil
+		}
+	}
+
+	cfg, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(cfg)
+	redis.RDB.Set(ctx, vipConfigCacheKey, data, vipConfigCacheTTL)
+	return cfg, nil
+}
+
+// CurrentOrDefault 读取当前配置，失败时记录日志并使用默认配置，供支付回调等不能因配置读取失败而中断的流程使用。
+func (s *VIPConfigService) CurrentOrDefault(ctx context.Context) *VIPConfig {
+	cfg, err := s.Current(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "读取 VIP 配置失败，使用默认配置", slog.Any("err", err))
+		return &VIPConfig{Plans: model.DefaultVIPPlans(), Levels: model.DefaultVIPLevels()}
+	}
+	return cfg
+}
+
+// load 从数据库读取当前版本，默认配置在迁移时写入。
+func (s *VIPConfigService) load(ctx context.Context) (*VIPConfig, error) {
+	plans, err := s.repo.ListCurrentPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := s.repo.ListCurrentLevels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &VIPConfig{Plans: plans, Levels: levels}, nil
+}
+
+// invalidate 配置变更后删除缓存，下次读取时重建。
+func (s *VIPConfigService) invalidate(ctx context.Context) {
+	redis.RDB.Del(ctx, vipConfigCacheKey)
+}
+
+// ActivePlans 返回可购买的套餐。
+func (s *VIPConfigService) ActivePlans(ctx context.Context) ([]model.VIPPlan, error) {
+	cfg, err := s.Current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]model.VIPPlan, 0, len(cfg.Plans))
+	for _, p := range cfg.Plans {
+		if p.Status == model.VIPPlanStatusActive {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// GetPlanVersion 查询套餐的指定历史版本，用于按购买时条款处理订单。
+func (s *VIPConfigService) GetPlanVersion(ctx context.Context, planID, version int) (*model.VIPPlan, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	plan, err := s.repo.GetPlanVersion(ctx, planID, version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrVIPPlanNotFound
+		}
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (s *VIPConfigService) ListPlanVersions(ctx context.Context, planID int) ([]model.VIPPlan, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	plans, err := s.repo.ListPlanVersions(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return nil, ErrVIPPlanNotFound
+	}
+	return plans, nil
+}
+
+// CreatePlan 新建套餐，分配新的套餐 ID，版本从 1 开始。
+func (s *VIPConfigService) CreatePlan(ctx context.Context, input VIPPlanInput, operatorID uint) (*model.VIPPlan, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if _, err := s.Current(ctx); err != nil {
+		return nil, err
+	}
+	plan := &model.VIPPlan{
+		Version:      1,
+		Name:         strings.TrimSpace(input.Name),
+		Level:        input.Level,
+		DurationDays: input.DurationDays,
+		PriceCents:   input.PriceCents,
+		Status:       input.Status,
+		CreatedBy:    operatorID,
+	}
+	if plan.Status == "" {
+		plan.Status = model.VIPPlanStatusActive
+	}
+	if err := validateVIPPlan(plan); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewVIPConfigRepo(tx)
+		maxID, err := txRepo.MaxPlanID(ctx)
+		if err != nil {
+			return err
+		}
+		plan.PlanID = maxID + 1
+		return txRepo.ReplaceCurrentPlan(ctx, plan)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx)
+	return plan, nil
+}
+
+// UpdatePlan 以当前版本为基础发布新版本，已购用户保留原版本条款。
+func (s *VIPConfigService) UpdatePlan(ctx context.Context, planID int, input VIPPlanInput, operatorID uint) (*model.VIPPlan, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	var plan *model.VIPPlan
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewVIPConfigRepo(tx)
+		current, err := txRepo.GetCurrentPlanForUpdate(ctx, planID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVIPPlanNotFound
+			}
+			return err
+		}
+		plan = &model.VIPPlan{
+			PlanID:       current.PlanID,
+			Version:      current.Version + 1,
+			Name:         strings.TrimSpace(input.Name),
+			Level:        input.Level,
+			DurationDays: input.DurationDays,
+			PriceCents:   input.PriceCents,
+			Status:       input.Status,
+			CreatedBy:    operatorID,
+		}
+		if plan.Status == "" {
+			plan.Status = current.Status
+		}
+		if err := validateVIPPlan(plan); err != nil {
+			return err
+		}
+		return txRepo.ReplaceCurrentPlan(ctx, plan)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx)
+	return plan, nil
+}
+
+func (s *VIPConfigService) ListLevelVersions(ctx context.Context, level int) ([]model.VIPLevelConfig, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	levels, err := s.repo.ListLevelVersions(ctx, level)
+	if err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		return nil, ErrVIPLevelNotFound
+	}
+	return levels, nil
+}
+
+// SaveLevel 新增或修改等级配置，发布为新版本。
+func (s *VIPConfigService) SaveLevel(ctx context.Context, level int, input VIPLevelInput, operatorID uint) (*model.VIPLevelConfig, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if _, err := s.Current(ctx); err != nil {
+		return nil, err
+	}
+	cfg := &model.VIPLevelConfig{
+		Level:               level,
+		MinSpentCents:       input.MinSpentCents,
+		MonthlyCouponQuota:  input.MonthlyCouponQuota,
+		CouponTitle:         strings.TrimSpace(input.CouponTitle),
+		CouponType:          input.CouponType,
+		CouponAmountCents:   input.CouponAmountCents,
+		CouponDiscountRate:  input.CouponDiscountRate,
+		CouponMinSpendCents: input.CouponMinSpendCents,
+		EarlyAccessMinutes:  input.EarlyAccessMinutes,
+		CreatedBy:           operatorID,
+	}
+	if err := validateVIPLevel(cfg); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewVIPConfigRepo(tx)
+		levels, err := txRepo.ListCurrentLevels(ctx)
+		if err != nil {
+			return err
+		}
+		merged := make([]model.VIPLevelConfig, 0, len(levels)+1)
+		for _, l := range levels {
+			if l.Level != level {
+				merged = append(merged, l)
+			}
+		}
+		if err := validateVIPThresholds(append(merged, *cfg)); err != nil {
+			return err
+		}
+
+		version, err := txRepo.MaxLevelVersion(ctx, level)
+		if err != nil {
+			return err
+		}
+		cfg.Version = version + 1
+		return txRepo.ReplaceCurrentLevel(ctx, cfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx)
+	return cfg, nil
+}
+
+// DeleteLevel 下线等级配置，历史版本保留。
+func (s *VIPConfigService) DeleteLevel(ctx context.Context, level int) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	if level == 1 {
+		return ErrVIPLevelBaseDelete
+	}
+	cfg, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Level(level); !ok {
+		return ErrVIPLevelNotFound
+	}
+	if err := s.repo.ClearCurrentLevel(ctx, level); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+	return nil
+}
+
+func validateVIPPlan(plan *model.VIPPlan) error {
+	if plan.Name == "" || plan.Level < 1 || plan.DurationDays <= 0 || plan.PriceCents < 0 {
+		return ErrVIPPlanInvalid
+	}
+	if plan.Status != model.VIPPlanStatusActive && plan.Status != model.VIPPlanStatusInactive {
+		return ErrVIPPlanInvalid
+	}
+	return nil
+}
+
+func validateVIPLevel(cfg *model.VIPLevelConfig) error {
+	if cfg.Level < 1 || cfg.MinSpentCents < 0 || cfg.MonthlyCouponQuota < 0 || cfg.CouponMinSpendCents < 0 {
+		return ErrVIPLevelInvalid
+	}
+	if cfg.EarlyAccessMinutes < 0 || cfg.EarlyAccessMinutes > maxEarlyAccessMinutes {
+		return ErrVIPLevelInvalid
+	}
+	if cfg.MonthlyCouponQuota == 0 {
+		return nil
+	}
+	if cfg.CouponTitle == "" {
+		return ErrVIPLevelInvalid
+	}
+	switch cfg.CouponType {
+	case model.CouponTypeFullCut:
+		if cfg.CouponAmountCents <= 0 {
+			return ErrVIPLevelInvalid
+		}
+	case model.CouponTypeDiscount:
+		if cfg.CouponDiscountRate <= 0 || cfg.CouponDiscountRate >= 100 {
+			return ErrVIPLevelInvalid
+		}
+	default:
+		return ErrVIPLevelInvalid
+	}
+	return nil
+}
+
+// validateVIPThresholds 校验门槛单调递增，保证成长等级计算无歧义。
+func validateVIPThresholds(levels []model.VIPLevelConfig) error {
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Level < levels[j].Level })
+	for i, l := range levels {
+		if l.Level == 1 && l.MinSpentCents != 0 {
+			return ErrVIPLevelThreshold
+		}
+		if i > 0 && l.MinSpentCents <= levels[i-1].MinSpentCents {
+			return ErrVIPLevelThreshold
+		}
+	}
+	return nil
+}
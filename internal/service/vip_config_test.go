@@ -0,0 +1,159 @@
+package service
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/vip"
+	"SneakerFlash/internal/repository"
+	"SneakerFlash/internal/testutil"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestVIPConfigService_PlanVersioning(t *testing.T) {
+	testutil.SetupTestConfig()
+	gdb := testutil.NewSQLiteDB(t)
+	testutil.SetupTestRedis(t)
+	ctx := context.Background()
+
+	cfgSvc := NewVIPConfigService(gdb)
+	vipSvc := NewVIPService(gdb, repository.NewUserRepo(gdb), NewCouponService(gdb))
+
+	user := &model.User{Username: "vip_user", Password: "x"}
+	if err := gdb.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	// 迁移时已写入默认配置
+	cfg, err := cfgSvc.Current(ctx)
+	if err != nil {
+		t.Fatalf("Current() error = %v", err)
+	}
+	if len(cfg.Plans) != 2 || len(cfg.Levels) != 4 {
+		t.Fatalf("default config = %+v", cfg)
+	}
+
+	purchased, err := vipSvc.PurchasePaidVIP(ctx, user.ID, 1)
+	if err != nil {
+		t.Fatalf("PurchasePaidVIP() error = %v", err)
+	}
+	orderSvc := NewOrderService(gdb, repository.NewProductRepo(gdb), repository.NewUserRepo(gdb))
+	if _, err := orderSvc.HandlePaymentResult(ctx, purchased.Payment.PaymentID, model.PaymentStatusPaid, "ok"); err != nil {
+		t.Fatalf("HandlePaymentResult() error = %v", err)
+	}
+
+	updated, err := cfgSvc.UpdatePlan(ctx, 1, VIPPlanInput{Name: "L3 月卡", Level: 3, DurationDays: 30, PriceCents: 4500}, 99)
+	if err != nil {
+		t.Fatalf("UpdatePlan() error = %v", err)
+	}
+	if updated.Version != 2 || updated.PriceCents != 4500 || updated.Status != model.VIPPlanStatusActive {
+		t.Fatalf("updated plan = %+v", updated)
+	}
+
+	// 缓存已失效，读到新版本
+	cfg, err = cfgSvc.Current(ctx)
+	if err != nil {
+		t.Fatalf("Current() error = %v", err)
+	}
+	if plan, _ := cfg.Plan(1); plan.Version != 2 {
+		t.Fatalf("current plan version = %d, want 2", plan.Version)
+	}
+
+	// 历史购买仍指向旧版本条款
+	pv, err := repository.NewPaidVIPRepo(gdb).GetByUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetByUser() error = %v", err)
+	}
+	old, err := cfgSvc.GetPlanVersion(ctx, pv.PlanID, pv.PlanVersion)
+	if err != nil {
+		t.Fatalf("GetPlanVersion() error = %v", err)
+	}
+	if old.PriceCents != 3000 {
+		t.Fatalf("purchased plan price = %d, want 3000", old.PriceCents)
+	}
+
+	if _, err := cfgSvc.UpdatePlan(ctx, 1, VIPPlanInput{Name: "L3 月卡", Level: 3, DurationDays: 30, PriceCents: 4500, Status: model.VIPPlanStatusInactive}, 99); err != nil {
+		t.Fatalf("UpdatePlan() deactivate error = %v", err)
+	}
+	if _, err := vipSvc.PurchasePaidVIP(ctx, user.ID, 1); !errors.Is(err, ErrVIPPlanInactive) {
+		t.Fatalf("PurchasePaidVIP() error = %v, want %v", err, ErrVIPPlanInactive)
+	}
+}
+
+func TestVIPConfigService_LevelsDriveGrowthAndCoupons(t *testing.T) {
+	testutil.SetupTestConfig()
+	gdb := testutil.NewSQLiteDB(t)
+	testutil.SetupTestRedis(t)
+	ctx := context.Background()
+
+	cfgSvc := NewVIPConfigService(gdb)
+	couponSvc := NewCouponService(gdb)
+
+	// 门槛必须单调递增
+	if _, err := cfgSvc.SaveLevel(ctx, 2, VIPLevelInput{MinSpentCents: 600_000}, 1); !errors.Is(err, ErrVIPLevelThreshold) {
+		t.Fatalf("SaveLevel() error = %v, want %v", err, ErrVIPLevelThreshold)
+	}
+
+	saved, err := cfgSvc.SaveLevel(ctx, 2, VIPLevelInput{
+		MinSpentCents:      50_000,
+		MonthlyCouponQuota: 1,
+		CouponTitle:        "L2 新版月度券",
+		CouponType:         model.CouponTypeFullCut,
+		CouponAmountCents:  800,
+	}, 1)
+	if err != nil {
+		t.Fatalf("SaveLevel() error = %v", err)
+	}
+	if saved.Version != 2 {
+		t.Fatalf("level version = %d, want 2", saved.Version)
+	}
+
+	cfg, err := cfgSvc.Current(ctx)
+	if err != nil {
+		t.Fatalf("Current() error = %v", err)
+	}
+	if got := vip.CalcGrowthLevel(60_000, cfg.Thresholds()); got != 2 {
+		t.Fatalf("CalcGrowthLevel() = %d, want 2", got)
+	}
+
+	if err := couponSvc.IssueVIPMonthly(ctx, 7, 2); err != nil {
+		t.Fatalf("IssueVIPMonthly() error = %v", err)
+	}
+	list, total, err := couponSvc.ListUserCoupons(ctx, 7, "", 1, 10)
+	if err != nil {
+		t.Fatalf("ListUserCoupons() error = %v", err)
+	}
+	if total != 1 || list[0].Title != "L2 新版月度券" || list[0].AmountCents != 800 {
+		t.Fatalf("issued coupons = %+v", list)
+	}
+
+	if err := cfgSvc.DeleteLevel(ctx, 1); !errors.Is(err, ErrVIPLevelBaseDelete) {
+		t.Fatalf("DeleteLevel(1) error = %v, want %v", err, ErrVIPLevelBaseDelete)
+	}
+	if err := cfgSvc.DeleteLevel(ctx, 4); err != nil {
+		t.Fatalf("DeleteLevel(4) error = %v", err)
+	}
+	cfg, _ = cfgSvc.Current(ctx)
+	if cfg.ClampLevel(4) != 3 {
+		t.Fatalf("ClampLevel(4) = %d, want 3", cfg.ClampLevel(4))
+	}
+}
+
+func TestVIPConfigService_CurrentOrDefaultFallsBack(t *testing.T) {
+	testutil.SetupTestConfig()
+	gdb := testutil.NewSQLiteDB(t)
+	testutil.SetupTestRedis(t)
+	ctx := context.Background()
+
+	cfgSvc := NewVIPConfigService(gdb)
+	if err := gdb.Migrator().DropTable(&model.VIPPlan{}); err != nil {
+		t.Fatalf("drop vip plans: %v", err)
+	}
+	if _, err := cfgSvc.Current(ctx); err == nil {
+		t.Fatal("Current() error = nil, want error")
+	}
+	cfg := cfgSvc.CurrentOrDefault(ctx)
+	if len(cfg.Plans) != len(model.DefaultVIPPlans()) || len(cfg.Levels) != len(model.DefaultVIPLevels()) {
+		t.Fatalf("CurrentOrDefault() = %+v", cfg)
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVIPOrderNoCoupon = errors.New("VIP 订单不支持使用优惠券")
+)
+
+// activatePaidVIP 在支付事务内按订单快照的套餐版本开通/续费付费 VIP。
+// 当前仍有效时从原到期时间起叠加时长，等级取两者较高值。
+func activatePaidVIP(ctx context.Context, tx *gorm.DB, order *model.Order, now time.Time) (int, error) {
+	plan, err := repository.NewVIPConfigRepo(tx).GetPlanVersion(ctx, order.VIPPlanID, order.VIPPlanVersion)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrVIPPlanNotFound
+		}
+		return 0, err
+	}
+
+	paidVIPRepo := repository.NewPaidVIPRepo(tx)
+	current, err := paidVIPRepo.GetByUserForUpdate(ctx, order.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	level := plan.Level
+	startedAt := now
+	start := now
+	if current != nil && current.ExpiredAt.After(now) {
+		start = current.ExpiredAt
+		startedAt = current.StartedAt
+		level = max(level, current.Level)
+	}
+	end := start.Add(time.Duration(plan.DurationDays) * 24 * time.Hour)
+
+	if err := paidVIPRepo.CreateGrant(ctx, &model.PaidVIPGrant{
+		UserID:       order.UserID,
+		OrderID:      order.ID,
+		PlanID:       plan.PlanID,
+		PlanVersion:  plan.Version,
+		Level:        plan.Level,
+		DurationDays: plan.DurationDays,
+		StartsAt:     start,
+		EndsAt:       end,
+		Status:       model.PaidVIPGrantActive,
+	}); err != nil {
+		return 0, err
+	}
+	if err := paidVIPRepo.Upsert(ctx, &model.PaidVIP{
+		UserID:      order.UserID,
+		Level:       level,
+		StartedAt:   startedAt,
+		ExpiredAt:   end,
+		PlanID:      plan.PlanID,
+		PlanVersion: plan.Version,
+	}); err != nil {
+		return 0, err
+	}
+	notify(ctx, tx, Notice{UserID: order.UserID, Kind: model.NotifyVIPActivated, Data: map[string]any{
+		"plan": plan.Name, "level": level, "expired_at": notifyTime(end),
+	}})
+	return level, nil
+}
+
+// refundPaidVIP 退款时扣回该订单尚未消耗的时长，后续时段前移；剩余时长为 0 则撤销。
+func refundPaidVIP(ctx context.Context, tx *gorm.DB, order *model.Order, now time.Time) error {
+	paidVIPRepo := repository.NewPaidVIPRepo(tx)
+	current, err := paidVIPRepo.GetByUserForUpdate(ctx, order.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	grant, err := paidVIPRepo.GetGrantByOrderID(ctx, order.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if grant.Status != model.PaidVIPGrantActive {
+		return nil
+	}
+
+	// 未开始的时段全额扣回，进行中的扣回剩余部分，已结束的不再调整
+	var removed time.Duration
+	switch {
+	case grant.StartsAt.After(now):
+		removed = grant.EndsAt.Sub(grant.StartsAt)
+		grant.EndsAt = grant.StartsAt
+	case grant.EndsAt.After(now):
+		removed = grant.EndsAt.Sub(now)
+		grant.EndsAt = now
+	}
+	grant.Status = model.PaidVIPGrantRefunded
+	grant.RefundedAt = &now
+	if err := paidVIPRepo.SaveGrant(ctx, grant); err != nil {
+		return err
+	}
+	if removed <= 0 {
+		return nil
+	}
+
+	grants, err := paidVIPRepo.ListActiveGrants(ctx, order.UserID)
+	if err != nil {
+		return err
+	}
+	level := 0
+	var last *model.PaidVIPGrant
+	for i := range grants {
+		g := &grants[i]
+		if !g.StartsAt.Before(grant.StartsAt) {
+			g.StartsAt = g.StartsAt.Add(-removed)
+			g.EndsAt = g.EndsAt.Add(-removed)
+			if err := paidVIPRepo.SaveGrant(ctx, g); err != nil {
+				return err
+			}
+		}
+		if g.EndsAt.After(now) {
+			level = max(level, g.Level)
+			last = g
+		}
+	}
+
+	current.ExpiredAt = current.ExpiredAt.Add(-removed)
+	if !current.ExpiredAt.After(now) {
+		current.ExpiredAt = now
+	}
+	if level > 0 {
+		current.Level = level
+		current.PlanID = last.PlanID
+		current.PlanVersion = last.PlanVersion
+	}
+	return paidVIPRepo.Upsert(ctx, current)
+}
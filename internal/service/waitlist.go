@@ -0,0 +1,407 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	_redis "github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWaitlistNotSoldOut = errors.New("商品尚未售罄，请直接抢购")
+	ErrWaitlistNotJoined  = errors.New("未加入候补")
+	ErrWaitlistNoOffer    = errors.New("暂无可认领的候补名额")
+)
+
+// loadWaitlistClaimWindow 回流名额认领时限，默认 5 分钟。
+func loadWaitlistClaimWindow() time.Duration {
+	minutes := config.Conf.Waitlist.ClaimMinutes
+	if minutes <= 0 {
+		minutes = 5
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// WaitlistView 用户的候补状态。
+type WaitlistView struct {
+	ProductID      uint                 `json:"product_id"`
+	Status         model.WaitlistStatus `json:"status"`
+	Position       int64                `json:"position,omitempty"` // 排队名次，仅 waiting 时返回
+	OfferExpiresAt *time.Time           `json:"offer_expires_at,omitempty"`
+	OrderNum       string               `json:"order_num,omitempty"`
+}
+
+// WaitlistService 售罄候补：取消订单回流的库存先分配给队首用户，限时认领，超时顺延。
+type WaitlistService struct {
+	db          *gorm.DB
+	repo        *repository.WaitlistRepo
+	productRepo *repository.ProductRepo
+	seckill     *SeckillService
+}
+
+func NewWaitlistService(db *gorm.DB, productRepo *repository.ProductRepo) *WaitlistService {
+	return &WaitlistService{
+		db:          db,
+		repo:        repository.NewWaitlistRepo(db),
+		productRepo: productRepo,
+		seckill:     NewSeckillService(db, productRepo),
+	}
+}
+
+// Join 售罄后加入候补；已在队列中直接返回，超时或退出过的重新排到队尾。
+func (s *WaitlistService) Join(ctx context.Context, userID, productID uint) (*WaitlistView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	product, err := s.openProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if product.MinVIPLevel > 0 {
+		access, err := s.seckill.vipSvc.DropAccess(ctx, userID, product)
+		if err != nil {
+			return nil, err
+		}
+		if !access.Eligible {
+			return nil, ErrSeckillVIPOnly
+		}
+	}
+	bought, err := redis.RDB.SIsMember(ctx, fmt.Sprintf("product:users:%d", productID), userID).Result()
+	if err != nil {
+		return nil, err
+	}
+	if bought {
+		return nil, ErrSeckillRepeat
+	}
+	if err := checkSeckillBan(ctx, userID); err != nil {
+		return nil, err
+	}
+	stock, err := redis.RDB.Get(ctx, fmt.Sprintf("product:stock:%d", productID)).Int()
+	if errors.Is(err, _redis.Nil) {
+		stock, err = product.Stock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stock > 0 {
+		return nil, ErrWaitlistNotSoldOut
+	}
+
+	entry, err := s.repo.Get(ctx, productID, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if entry != nil {
+		switch entry.Status {
+		case model.WaitlistStatusWaiting, model.WaitlistStatusOffered:
+			return s.view(ctx, entry)
+		case model.WaitlistStatusClaimed:
+			return nil, ErrSeckillRepeat
+		}
+		if err := s.repo.Delete(ctx, entry.ID); err != nil {
+			return nil, err
+		}
+	}
+	entry = &model.WaitlistEntry{ProductID: productID, UserID: userID, Status: model.WaitlistStatusWaiting}
+	if err := s.repo.Create(ctx, entry); err != nil {
+		if !errors.Is(err, gorm.ErrDuplicatedKey) && !isMySQLDuplicate(err) {
+			return nil, err
+		}
+		// 并发重复加入，以已写入的记录为准
+		if entry, err = s.repo.Get(ctx, productID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return s.view(ctx, entry)
+}
+
+// Status 查询候补状态与排队名次。
+func (s *WaitlistService) Status(ctx context.Context, userID, productID uint) (*WaitlistView, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	entry, err := s.repo.Get(ctx, productID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWaitlistNotJoined
+		}
+		return nil, err
+	}
+	return s.view(ctx, entry)
+}
+
+// Leave 退出候补；已分配名额的视为放弃，名额顺延给下一位。
+func (s *WaitlistService) Leave(ctx context.Context, userID, productID uint) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	entry, err := s.repo.Get(ctx, productID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	switch entry.Status {
+	case model.WaitlistStatusWaiting:
+		_, err := s.repo.UpdateStatusIfMatch(ctx, entry.ID, model.WaitlistStatusWaiting, map[string]any{"status": model.WaitlistStatusCancelled})
+		return err
+	case model.WaitlistStatusOffered:
+		_, err := s.releaseOffer(ctx, entry, model.WaitlistStatusCancelled)
+		return err
+	}
+	return nil
+}
+
+// Claim 认领回流名额：与 Outbox 消息同事务置为已认领，随后走秒杀落库链路创建订单。
+func (s *WaitlistService) Claim(ctx context.Context, userID, productID uint, autoCoupon bool) (*SeckillResult, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	if !product.Listed() {
+		return nil, ErrProductNotFound
+	}
+	if err := checkSeckillBan(ctx, userID); err != nil {
+		return nil, err
+	}
+	var access *DropAccess
+	if product.MinVIPLevel > 0 || len(product.MemberPrices) > 0 {
+		access, err = s.seckill.vipSvc.DropAccess(ctx, userID, product)
+		if err != nil {
+			return nil, err
+		}
+		if !access.Eligible {
+			return nil, ErrSeckillVIPOnly
+		}
+	}
+	msg, err := newSeckillMessage(userID, product, access, autoCoupon)
+	if err != nil {
+		return nil, ErrSeckillBusy
+	}
+	msg.FromWaitlist = true
+	outboxMsg := newSeckillOutbox(msg)
+
+	now := time.Now()
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := repository.NewWaitlistRepo(tx)
+		entry, err := txRepo.GetForUpdate(ctx, productID, userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrWaitlistNotJoined
+			}
+			return err
+		}
+		if entry.Status != model.WaitlistStatusOffered || entry.OfferExpiresAt == nil || !entry.OfferExpiresAt.After(now) {
+			return ErrWaitlistNoOffer
+		}
+		rows, err := txRepo.UpdateStatusIfMatch(ctx, entry.ID, model.WaitlistStatusOffered, map[string]any{
+			"status":    model.WaitlistStatusClaimed,
+			"order_num": msg.OrderNum,
+		})
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrWaitlistNoOffer
+		}
+		return repository.NewOutboxRepo(tx).Create(ctx, outboxMsg)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 标记已购，防止认领后再参与抢购
+	_ = redis.RDB.SAdd(ctx, fmt.Sprintf("product:users:%d", productID), userID).Err()
+	go s.seckill.sendOutboxMessage(outboxMsg)
+	_ = setPendingOrder(ctx, PendingOrderCache{
+		OrderNum:   msg.OrderNum,
+		PaymentID:  msg.PaymentID,
+		ProductID:  productID,
+		UserID:     userID,
+		PriceCents: msg.PriceCents,
+		Status:     PendingStatusPending,
+	})
+	publishWaitlistEvent(userID, productID, model.WaitlistStatusClaimed, nil)
+	return &SeckillResult{
+		OrderNum:  msg.OrderNum,
+		PaymentID: msg.PaymentID,
+		Status:    string(PendingStatusPending),
+	}, nil
+}
+
+// ExpireOffers 处理认领超时的名额，顺延给下一位；无人候补时回到库存池。返回处理条数。
+func (s *WaitlistService) ExpireOffers(ctx context.Context, now time.Time, limit int) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	entries, err := s.repo.ListExpiredOffers(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	expired := 0
+	for i := range entries {
+		ok, err := s.releaseOffer(ctx, &entries[i], model.WaitlistStatusExpired)
+		if err != nil {
+			return expired, err
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// releaseOffer 收回已分配的名额并顺延；返回 false 表示名额已被认领或处理。
+func (s *WaitlistService) releaseOffer(ctx context.Context, entry *model.WaitlistEntry, to model.WaitlistStatus) (bool, error) {
+	var (
+		released bool
+		next     *model.WaitlistEntry
+		stock    = -1
+	)
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		rows, err := repository.NewWaitlistRepo(tx).UpdateStatusIfMatch(ctx, entry.ID, model.WaitlistStatusOffered, map[string]any{"status": to})
+		if err != nil || rows == 0 {
+			return err
+		}
+		released = true
+		if next, err = offerWaitlist(ctx, tx, entry.ProductID, now); err != nil || next != nil {
+			return err
+		}
+		// 无人候补，名额回到库存
+		txProductRepo := repository.NewProductRepo(tx)
+		if _, err := txProductRepo.IncreaseStockDB(ctx, entry.ProductID, 1); err != nil {
+			return err
+		}
+		product, err := txProductRepo.GetByID(ctx, entry.ProductID)
+		if err != nil {
+			return err
+		}
+		stock = product.Stock
+		return nil
+	})
+	if err != nil || !released {
+		return false, err
+	}
+
+	publishWaitlistEvent(entry.UserID, entry.ProductID, to, nil)
+	if next != nil {
+		publishWaitlistEvent(next.UserID, next.ProductID, next.Status, next.OfferExpiresAt)
+	} else {
+		_ = redis.RDB.Incr(ctx, fmt.Sprintf("product:stock:%d", entry.ProductID)).Err()
+		refreshStockCacheAsync(entry.ProductID, stock)
+		invalidateProductInfoCache(entry.ProductID)
+	}
+	slog.InfoContext(ctx, "候补名额已收回",
+		slog.Uint64("product_id", uint64(entry.ProductID)),
+		slog.Uint64("user_id", uint64(entry.UserID)),
+		slog.String("status", string(to)),
+		slog.Bool("handed_over", next != nil))
+	return true, nil
+}
+
+func (s *WaitlistService) openProduct(ctx context.Context, productID uint) (*model.Product, error) {
+	product, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	if !product.Listed() {
+		return nil, ErrProductNotFound
+	}
+	now := time.Now()
+	if product.EndTime != nil && now.After(*product.EndTime) {
+		return nil, ErrSeckillEnded
+	}
+	if now.Before(product.StartTime) {
+		return nil, ErrSeckillNotStart
+	}
+	return product, nil
+}
+
+func (s *WaitlistService) view(ctx context.Context, entry *model.WaitlistEntry) (*WaitlistView, error) {
+	v := &WaitlistView{ProductID: entry.ProductID, Status: entry.Status, OrderNum: entry.OrderNum}
+	switch entry.Status {
+	case model.WaitlistStatusWaiting:
+		now := time.Now()
+		demoted, err := penaltyDemoted(ctx, s.db, entry.UserID, now)
+		if err != nil {
+			return nil, err
+		}
+		rules := loadPenaltyRules()
+		position, err := s.repo.Position(ctx, entry, demoted, now.Add(-rules.window), rules.demoteThreshold)
+		if err != nil {
+			return nil, err
+		}
+		v.Position = position
+	case model.WaitlistStatusOffered:
+		v.OfferExpiresAt = entry.OfferExpiresAt
+	}
+	return v, nil
+}
+
+// offerWaitlist 在事务内把一个回流名额分配给队首用户；无人候补或活动已结束返回 nil，由调用方放回库存。
+func offerWaitlist(ctx context.Context, tx *gorm.DB, productID uint, now time.Time) (*model.WaitlistEntry, error) {
+	product, err := repository.NewProductRepo(tx).GetByID(ctx, productID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if product.EndTime != nil && now.After(*product.EndTime) {
+		return nil, nil
+	}
+
+	repo := repository.NewWaitlistRepo(tx)
+	expiresAt := now.Add(loadWaitlistClaimWindow())
+	rules := loadPenaltyRules()
+	for {
+		entry, err := repo.NextWaiting(ctx, productID, now.Add(-rules.window), rules.demoteThreshold)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		rows, err := repo.UpdateStatusIfMatch(ctx, entry.ID, model.WaitlistStatusWaiting, map[string]any{
+			"status":           model.WaitlistStatusOffered,
+			"offered_at":       now,
+			"offer_expires_at": expiresAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if rows == 0 {
+			// 已被其他实例分配或用户刚退出，取下一位
+			continue
+		}
+		entry.Status = model.WaitlistStatusOffered
+		entry.OfferedAt = &now
+		entry.OfferExpiresAt = &expiresAt
+		notify(ctx, tx, Notice{UserID: entry.UserID, Kind: model.NotifyWaitlistOffer, Data: map[string]any{
+			"product": product.Name, "expires_at": notifyTime(expiresAt),
+		}})
+		return entry, nil
+	}
+}
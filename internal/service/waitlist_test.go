@@ -0,0 +1,212 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/db"
+	redisinfra "SneakerFlash/internal/infra/redis"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWaitlistService_OfferClaimAndCascade(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	productRepo := repository.NewProductRepo(db.DB)
+	waitlistSvc := NewWaitlistService(db.DB, productRepo)
+	ctx := context.Background()
+	productID := fixtures.product.ID
+	stockKey := fmt.Sprintf("product:stock:%d", productID)
+
+	originalSend := sendKafkaMessage
+	originalGen := genSeckillID
+	t.Cleanup(func() {
+		sendKafkaMessage = originalSend
+		genSeckillID = originalGen
+	})
+	sendKafkaMessage = func(topic, message string) error { return errors.New("skip send in unit test") }
+	seq := 0
+	genSeckillID = func() (string, error) {
+		seq++
+		return fmt.Sprintf("WL-%d", seq), nil
+	}
+
+	newUser := func(name string) uint {
+		t.Helper()
+		u := &model.User{Username: name, Password: "hashed"}
+		if err := db.DB.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		return u.ID
+	}
+	bob, carol := newUser("bob"), newUser("carol")
+	entryOf := func(userID uint) model.WaitlistEntry {
+		t.Helper()
+		var e model.WaitlistEntry
+		if err := db.DB.Where("product_id = ? AND user_id = ?", productID, userID).First(&e).Error; err != nil {
+			t.Fatalf("load waitlist entry: %v", err)
+		}
+		return e
+	}
+	stocks := func() (int, int) {
+		t.Helper()
+		p, err := productRepo.GetByID(ctx, productID)
+		if err != nil {
+			t.Fatalf("load product: %v", err)
+		}
+		cached, _ := redisinfra.RDB.Get(ctx, stockKey).Int()
+		return p.Stock, cached
+	}
+	cancelStale := func(orderNum string) {
+		t.Helper()
+		if err := db.DB.Model(&model.Order{}).Where("order_num = ?", orderNum).Update("created_at", time.Now().Add(-time.Hour)).Error; err != nil {
+			t.Fatalf("backdate order: %v", err)
+		}
+		if n, err := orderSvc.CancelExpiredOrders(ctx, 15*time.Minute, 10); err != nil || n != 1 {
+			t.Fatalf("CancelExpiredOrders() = %d, %v", n, err)
+		}
+	}
+
+	// 有库存时不能候补
+	if err := redisinfra.RDB.Set(ctx, stockKey, 3, 0).Err(); err != nil {
+		t.Fatalf("set stock cache: %v", err)
+	}
+	if _, err := waitlistSvc.Join(ctx, bob, productID); !errors.Is(err, ErrWaitlistNotSoldOut) {
+		t.Fatalf("Join(in stock) error = %v, want %v", err, ErrWaitlistNotSoldOut)
+	}
+
+	// 售罄后排队
+	if err := db.DB.Model(&model.Product{}).Where("id = ?", productID).Update("stock", 0).Error; err != nil {
+		t.Fatalf("sell out: %v", err)
+	}
+	if err := redisinfra.RDB.Set(ctx, stockKey, 0, 0).Err(); err != nil {
+		t.Fatalf("set stock cache: %v", err)
+	}
+	if v, err := waitlistSvc.Join(ctx, bob, productID); err != nil || v.Position != 1 {
+		t.Fatalf("Join(bob) = %+v, %v", v, err)
+	}
+	if v, err := waitlistSvc.Join(ctx, carol, productID); err != nil || v.Position != 2 {
+		t.Fatalf("Join(carol) = %+v, %v", v, err)
+	}
+	if _, err := waitlistSvc.Claim(ctx, bob, productID, false); !errors.Is(err, ErrWaitlistNoOffer) {
+		t.Fatalf("Claim(no offer) error = %v, want %v", err, ErrWaitlistNoOffer)
+	}
+
+	// 超时取消的名额先分配给队首，不回到公开库存
+	cancelStale(fixtures.order.OrderNum)
+	if e := entryOf(bob); e.Status != model.WaitlistStatusOffered || e.OfferExpiresAt == nil {
+		t.Fatalf("bob entry after cancel = %+v", e)
+	}
+	if dbStock, cached := stocks(); dbStock != 0 || cached != 0 {
+		t.Fatalf("stock after offer = db %d, redis %d, want 0/0", dbStock, cached)
+	}
+	if v, err := waitlistSvc.Status(ctx, carol, productID); err != nil || v.Position != 1 {
+		t.Fatalf("Status(carol) = %+v, %v", v, err)
+	}
+
+	// 认领走 Outbox，落库时不重复扣库存
+	res, err := waitlistSvc.Claim(ctx, bob, productID, false)
+	if err != nil {
+		t.Fatalf("Claim(bob) error = %v", err)
+	}
+	var outbox model.OutboxMessage
+	if err := db.DB.Order("id desc").First(&outbox).Error; err != nil {
+		t.Fatalf("load outbox: %v", err)
+	}
+	var msg SeckillMessage
+	if err := json.Unmarshal([]byte(outbox.Payload), &msg); err != nil || !msg.FromWaitlist || msg.OrderNum != res.OrderNum {
+		t.Fatalf("outbox payload = %s, %v", outbox.Payload, err)
+	}
+	worker := NewWorkerService(db.DB, productRepo, repository.NewOrderRepo(db.DB))
+	if failed, err := worker.BatchCreateOrdersFromMessages([][]byte{[]byte(outbox.Payload)}); err != nil || len(failed) != 0 {
+		t.Fatalf("BatchCreateOrdersFromMessages() = %v, %v", failed, err)
+	}
+	if dbStock, _ := stocks(); dbStock != 0 {
+		t.Fatalf("db stock after claim order = %d, want 0", dbStock)
+	}
+	if e := entryOf(bob); e.Status != model.WaitlistStatusClaimed || e.OrderNum != res.OrderNum {
+		t.Fatalf("bob entry after claim = %+v", e)
+	}
+
+	// bob 也未支付：名额顺延给 carol，carol 超时后回到库存
+	cancelStale(res.OrderNum)
+	if e := entryOf(carol); e.Status != model.WaitlistStatusOffered {
+		t.Fatalf("carol entry after cascade = %+v", e)
+	}
+	var offers int64
+	db.DB.Model(&model.Notification{}).Where("user_id = ? AND kind = ?", carol, model.NotifyWaitlistOffer).Count(&offers)
+	if offers != 1 {
+		t.Fatalf("carol offer notifications = %d, want 1", offers)
+	}
+	if n, err := waitlistSvc.ExpireOffers(ctx, time.Now().Add(time.Hour), 10); err != nil || n != 1 {
+		t.Fatalf("ExpireOffers() = %d, %v", n, err)
+	}
+	if e := entryOf(carol); e.Status != model.WaitlistStatusExpired {
+		t.Fatalf("carol entry after expiry = %+v", e)
+	}
+	if dbStock, cached := stocks(); dbStock != 1 || cached != 1 {
+		t.Fatalf("stock after expiry = db %d, redis %d, want 1/1", dbStock, cached)
+	}
+}
+
+func TestWaitlistService_DemotionAtOfferTime(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	config.Conf.Penalty = config.PenaltyConfig{WindowDays: 30, DemoteThreshold: 1, BanThreshold: 5, BanDays: 7, GrayThreshold: 5}
+	waitlistSvc := NewWaitlistService(db.DB, repository.NewProductRepo(db.DB))
+	ctx := context.Background()
+	productID := fixtures.product.ID
+
+	newUser := func(name string) uint {
+		t.Helper()
+		u := &model.User{Username: name, Password: "hashed"}
+		if err := db.DB.Create(u).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		return u.ID
+	}
+	bob, carol := newUser("bob"), newUser("carol")
+	if err := db.DB.Model(&model.Product{}).Where("id = ?", productID).Update("stock", 0).Error; err != nil {
+		t.Fatalf("sell out: %v", err)
+	}
+	if err := redisinfra.RDB.Set(ctx, fmt.Sprintf("product:stock:%d", productID), 0, 0).Err(); err != nil {
+		t.Fatalf("set stock cache: %v", err)
+	}
+	if _, err := waitlistSvc.Join(ctx, bob, productID); err != nil {
+		t.Fatalf("Join(bob) error = %v", err)
+	}
+	if _, err := waitlistSvc.Join(ctx, carol, productID); err != nil {
+		t.Fatalf("Join(carol) error = %v", err)
+	}
+
+	// bob 加入后才超时未支付，排队与分配时都排到 carol 之后
+	if err := db.DB.Create(&model.UnpaidTimeout{UserID: bob, OrderID: 999, OrderNum: "ORD-999", ProductID: productID}).Error; err != nil {
+		t.Fatalf("record timeout: %v", err)
+	}
+	if v, err := waitlistSvc.Status(ctx, carol, productID); err != nil || v.Position != 1 {
+		t.Fatalf("Status(carol) = %+v, %v, want position 1", v, err)
+	}
+	if v, err := waitlistSvc.Status(ctx, bob, productID); err != nil || v.Position != 2 {
+		t.Fatalf("Status(bob) = %+v, %v, want position 2", v, err)
+	}
+	if err := db.DB.Model(&model.Order{}).Where("id = ?", fixtures.order.ID).Update("created_at", time.Now().Add(-time.Hour)).Error; err != nil {
+		t.Fatalf("backdate order: %v", err)
+	}
+	if n, err := orderSvc.CancelExpiredOrders(ctx, 15*time.Minute, 10); err != nil || n != 1 {
+		t.Fatalf("CancelExpiredOrders() = %d, %v", n, err)
+	}
+	if v, err := waitlistSvc.Status(ctx, carol, productID); err != nil || v.Status != model.WaitlistStatusOffered {
+		t.Fatalf("Status(carol) after cancel = %+v, %v, want offered", v, err)
+	}
+
+	// 暂停抢购期间不能认领
+	if err := redisinfra.RDB.Set(ctx, penaltyBanKey(carol), 1, time.Hour).Err(); err != nil {
+		t.Fatalf("set ban: %v", err)
+	}
+	if _, err := waitlistSvc.Claim(ctx, carol, productID, false); !errors.Is(err, ErrSeckillPenalized) {
+		t.Fatalf("Claim(banned) error = %v, want %v", err, ErrSeckillPenalized)
+	}
+}
@@ -0,0 +1,642 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/model"
+	"SneakerFlash/internal/pkg/utils"
+	"SneakerFlash/internal/repository"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("Webhook 地址不存在")
+	ErrWebhookURLInvalid       = errors.New("Webhook 地址需为 http 或 https URL")
+	ErrWebhookURLPrivate       = errors.New("Webhook 地址不能指向内网或本机")
+	ErrWebhookEventInvalid     = errors.New("不支持的 Webhook 事件")
+	ErrWebhookLimit            = errors.New("Webhook 地址数量已达上限")
+	ErrWebhookDisabled         = errors.New("Webhook 地址已停用")
+	ErrWebhookDeliveryNotFound = errors.New("投递记录不存在")
+)
+
+const (
+	webhookRetryBase       = 30 * time.Second
+	webhookRetryMax        = time.Hour
+	webhookDispatchWorkers = 8
+	webhookResponseLimit   = 1024
+	webhookSignatureHeader = "X-SneakerFlash-Signature"
+)
+
+// WebhookPayload 推送请求体，签名覆盖整个 JSON。
+type WebhookPayload struct {
+	ID        string             `json:"id"` // 事件 ID，重试与重新投递时不变
+	Event     model.WebhookEvent `json:"event"`
+	CreatedAt time.Time          `json:"created_at"`
+	Data      any                `json:"data"`
+}
+
+// WebhookOrderData 订单类事件数据。
+type WebhookOrderData struct {
+	OrderNum    string     `json:"order_num"`
+	ProductID   uint       `json:"product_id"`
+	ProductName string     `json:"product_name"`
+	AmountCents int64      `json:"amount_cents"` // 买家实付金额
+	PaidAt      *time.Time `json:"paid_at,omitempty"`
+}
+
+// WebhookSoldOutData 商品售罄事件数据。
+type WebhookSoldOutData struct {
+	ProductID   uint      `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	SoldOutAt   time.Time `json:"sold_out_at"`
+}
+
+// WebhookEndpointInput 注册回调地址，Events 为空时订阅全部事件。
+type WebhookEndpointInput struct {
+	URL         string
+	Events      []model.WebhookEvent
+	Description string
+}
+
+// WebhookEndpointUpdate 修改回调地址，nil 字段不修改。
+type WebhookEndpointUpdate struct {
+	URL         *string
+	Events      []model.WebhookEvent
+	Enabled     *bool
+	Description *string
+}
+
+// WebhookEndpointWithSecret 创建或轮换密钥时返回一次明文密钥。
+type WebhookEndpointWithSecret struct {
+	model.WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+type webhookRules struct {
+	maxAttempts  int
+	timeout      time.Duration
+	maxEndpoints int
+	allowPrivate bool
+}
+
+func loadWebhookRules() webhookRules {
+	cfg := config.Conf.Webhook
+	rules := webhookRules{
+		maxAttempts:  cfg.MaxAttempts,
+		timeout:      time.Duration(cfg.TimeoutSeconds) * time.Second,
+		maxEndpoints: cfg.MaxEndpoints,
+		allowPrivate: cfg.AllowPrivate,
+	}
+	if rules.maxAttempts <= 0 {
+		rules.maxAttempts = 8
+	}
+	if rules.timeout <= 0 {
+		rules.timeout = 5 * time.Second
+	}
+	if rules.maxEndpoints <= 0 {
+		rules.maxEndpoints = 5
+	}
+	return rules
+}
+
+// WebhookService 商家 Webhook：事件与业务同事务写入 Outbox，由 OutboxCron 签名投递并按指数退避重试。
+type WebhookService struct {
+	db         *gorm.DB
+	repo       *repository.WebhookRepo
+	outboxRepo *repository.OutboxRepo
+	sellerRepo *repository.SellerRepo
+	rules      webhookRules
+	client     *http.Client
+}
+
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	rules := loadWebhookRules()
+	return &WebhookService{
+		db:         db,
+		repo:       repository.NewWebhookRepo(db),
+		outboxRepo: repository.NewOutboxRepo(db),
+		sellerRepo: repository.NewSellerRepo(db),
+		rules:      rules,
+		client:     newWebhookClient(rules.timeout, rules.allowPrivate),
+	}
+}
+
+// newWebhookClient 不跟随跳转、不走代理；禁止内网时在建连前校验解析后的 IP，防止 DNS 重绑定。
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return ErrWebhookURLPrivate
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// ListEndpoints 卖家的全部回调地址。
+func (s *WebhookService) ListEndpoints(ctx context.Context, sellerID uint) ([]model.WebhookEndpoint, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	return s.repo.ListEndpoints(ctx, sellerID)
+}
+
+// CreateEndpoint 注册回调地址，仅审核通过的卖家可用；密钥只在创建与轮换时返回。
+func (s *WebhookService) CreateEndpoint(ctx context.Context, sellerID uint, in WebhookEndpointInput) (*WebhookEndpointWithSecret, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	approved, err := s.sellerRepo.IsApproved(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if !approved {
+		return nil, ErrSellerNotApproved
+	}
+	rawURL, err := s.validateURL(in.URL)
+	if err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(in.Events)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.repo.CountEndpoints(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= int64(s.rules.maxEndpoints) {
+		return nil, ErrWebhookLimit
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	endpoint := &model.WebhookEndpoint{
+		SellerID:    sellerID,
+		URL:         rawURL,
+		Secret:      secret,
+		Events:      events,
+		Enabled:     true,
+		Description: strings.TrimSpace(in.Description),
+	}
+	if err := s.repo.CreateEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+	return &WebhookEndpointWithSecret{WebhookEndpoint: *endpoint, Secret: secret}, nil
+}
+
+// UpdateEndpoint 修改地址、订阅事件或启停，停用期间新事件不再投递。
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, sellerID, id uint, in WebhookEndpointUpdate) (*model.WebhookEndpoint, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if _, err := s.endpoint(ctx, sellerID, id); err != nil {
+		return nil, err
+	}
+	updates := map[string]any{}
+	if in.URL != nil {
+		rawURL, err := s.validateURL(*in.URL)
+		if err != nil {
+			return nil, err
+		}
+		updates["url"] = rawURL
+	}
+	if in.Events != nil {
+		events, err := normalizeWebhookEvents(in.Events)
+		if err != nil {
+			return nil, err
+		}
+		updates["events"] = events
+	}
+	if in.Enabled != nil {
+		updates["enabled"] = *in.Enabled
+	}
+	if in.Description != nil {
+		updates["description"] = strings.TrimSpace(*in.Description)
+	}
+	if len(updates) > 0 {
+		if err := s.repo.UpdateEndpoint(ctx, id, updates); err != nil {
+			return nil, err
+		}
+	}
+	return s.endpoint(ctx, sellerID, id)
+}
+
+// DeleteEndpoint 删除回调地址，未完成的投递不再重试。
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, sellerID, id uint) error {
+	if ctx == nil {
+		return fmt.Errorf("context is nil")
+	}
+	rows, err := s.repo.DeleteEndpoint(ctx, sellerID, id)
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// RotateSecret 生成新密钥并立即生效，之后的请求（含重试）都用新密钥签名。
+func (s *WebhookService) RotateSecret(ctx context.Context, sellerID, id uint) (*WebhookEndpointWithSecret, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	if _, err := s.endpoint(ctx, sellerID, id); err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateEndpoint(ctx, id, map[string]any{"secret": secret}); err != nil {
+		return nil, err
+	}
+	endpoint, err := s.endpoint(ctx, sellerID, id)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookEndpointWithSecret{WebhookEndpoint: *endpoint, Secret: secret}, nil
+}
+
+// ListDeliveries 回调地址的投递记录。
+func (s *WebhookService) ListDeliveries(ctx context.Context, sellerID, endpointID uint, status string, page, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	if ctx == nil {
+		return nil, 0, fmt.Errorf("context is nil")
+	}
+	if _, err := s.endpoint(ctx, sellerID, endpointID); err != nil {
+		return nil, 0, err
+	}
+	page, pageSize = normalizePage(page, pageSize)
+	return s.repo.ListDeliveries(ctx, endpointID, status, page, pageSize)
+}
+
+// Redeliver 按原请求体重新投递一次，生成新的投递记录，事件 ID 不变。
+func (s *WebhookService) Redeliver(ctx context.Context, sellerID, endpointID, deliveryID uint) (*model.WebhookDelivery, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context is nil")
+	}
+	endpoint, err := s.endpoint(ctx, sellerID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.Enabled {
+		return nil, ErrWebhookDisabled
+	}
+	original, err := s.repo.GetDelivery(ctx, endpointID, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	delivery := &model.WebhookDelivery{
+		EndpointID:   endpoint.ID,
+		SellerID:     endpoint.SellerID,
+		EventID:      original.EventID,
+		Event:        original.Event,
+		Payload:      original.Payload,
+		Status:       model.WebhookDeliveryPending,
+		RedeliveryOf: original.ID,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createWebhookDelivery(ctx, tx, delivery)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Dispatch 投递已到期的 Webhook 消息，返回本次处理的条数；单条失败按退避重试，不中断批次。
+func (s *WebhookService) Dispatch(ctx context.Context, now time.Time, limit int) (int, error) {
+	if ctx == nil {
+		return 0, fmt.Errorf("context is nil")
+	}
+	msgs, err := s.outboxRepo.GetDueByTopic(ctx, model.OutboxTopicWebhook, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	// 租约覆盖一次请求超时，进程中途退出时到期后可被重新领取
+	leaseUntil := now.Add(s.rules.timeout + webhookRetryBase)
+	sem := make(chan struct{}, webhookDispatchWorkers)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	handled := 0
+	for _, msg := range msgs {
+		claimed, err := s.outboxRepo.Claim(ctx, msg.ID, now, leaseUntil)
+		if err != nil {
+			return handled, err
+		}
+		if claimed == 0 {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(msg *model.OutboxMessage) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			s.deliver(ctx, msg)
+			mu.Lock()
+			handled++
+			mu.Unlock()
+		}(msg)
+	}
+	wg.Wait()
+	return handled, nil
+}
+
+// deliver 执行一次投递并记录结果。
+func (s *WebhookService) deliver(ctx context.Context, msg *model.OutboxMessage) {
+	var ref struct {
+		DeliveryID uint `json:"delivery_id"`
+	}
+	if err := json.Unmarshal([]byte(msg.Payload), &ref); err != nil {
+		s.markOutboxFailed(ctx, msg.ID, "消息格式无效")
+		return
+	}
+	delivery, err := s.repo.GetDelivery(ctx, 0, ref.DeliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.markOutboxFailed(ctx, msg.ID, "投递记录不存在")
+		}
+		return
+	}
+	endpoint, err := s.repo.GetEndpoint(ctx, 0, delivery.EndpointID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return
+	}
+	if endpoint == nil || !endpoint.Enabled {
+		s.finishDelivery(ctx, msg, delivery, map[string]any{
+			"status":     model.WebhookDeliveryFailed,
+			"last_error": "回调地址已删除或停用",
+		}, false)
+		return
+	}
+
+	start := time.Now()
+	status, body, sendErr := s.post(ctx, endpoint, delivery)
+	attempts := delivery.Attempts + 1
+	updates := map[string]any{
+		"attempts":        attempts,
+		"response_status": status,
+		"response_body":   body,
+		"duration_ms":     time.Since(start).Milliseconds(),
+		"last_error":      "",
+		"next_retry_at":   nil,
+	}
+	if sendErr == nil && status >= 200 && status < 300 {
+		updates["status"] = model.WebhookDeliverySucceeded
+		updates["delivered_at"] = time.Now()
+		s.finishDelivery(ctx, msg, delivery, updates, true)
+		return
+	}
+	lastErr := fmt.Sprintf("HTTP %d", status)
+	if sendErr != nil {
+		lastErr = truncateError(sendErr)
+	}
+	updates["last_error"] = lastErr
+	if attempts >= s.rules.maxAttempts {
+		updates["status"] = model.WebhookDeliveryFailed
+		s.finishDelivery(ctx, msg, delivery, updates, false)
+		return
+	}
+	next := time.Now().Add(webhookBackoff(attempts))
+	updates["next_retry_at"] = next
+	if err := s.repo.UpdateDelivery(ctx, delivery.ID, updates); err != nil {
+		slog.ErrorContext(ctx, "更新 Webhook 投递记录失败", slog.Uint64("delivery_id", uint64(delivery.ID)), slog.Any("err", err))
+	}
+	if err := s.outboxRepo.ScheduleRetry(ctx, msg.ID, lastErr, next); err != nil {
+		slog.ErrorContext(ctx, "安排 Webhook 重试失败", slog.Uint64("msg_id", uint64(msg.ID)), slog.Any("err", err))
+	}
+}
+
+// finishDelivery 投递进入终态：更新投递记录并结束 Outbox 消息。
+func (s *WebhookService) finishDelivery(ctx context.Context, msg *model.OutboxMessage, delivery *model.WebhookDelivery, updates map[string]any, succeeded bool) {
+	if err := s.repo.UpdateDelivery(ctx, delivery.ID, updates); err != nil {
+		slog.ErrorContext(ctx, "更新 Webhook 投递记录失败", slog.Uint64("delivery_id", uint64(delivery.ID)), slog.Any("err", err))
+		return
+	}
+	if succeeded {
+		if err := s.outboxRepo.MarkSent(ctx, msg.ID); err != nil {
+			slog.ErrorContext(ctx, "标记 Webhook 消息已发送失败", slog.Uint64("msg_id", uint64(msg.ID)), slog.Any("err", err))
+		}
+		return
+	}
+	lastErr, _ := updates["last_error"].(string)
+	s.markOutboxFailed(ctx, msg.ID, lastErr)
+	slog.WarnContext(ctx, "Webhook 投递失败", slog.Uint64("delivery_id", uint64(delivery.ID)), slog.String("err", lastErr))
+}
+
+func (s *WebhookService) markOutboxFailed(ctx context.Context, id uint, errMsg string) {
+	if err := s.outboxRepo.MarkFailed(ctx, id, errMsg); err != nil {
+		slog.ErrorContext(ctx, "标记 Webhook 消息失败状态失败", slog.Uint64("msg_id", uint64(id)), slog.Any("err", err))
+	}
+}
+
+// post 发送签名请求，返回状态码与截断后的响应体。
+func (s *WebhookService) post(ctx context.Context, endpoint *model.WebhookEndpoint, delivery *model.WebhookDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SneakerFlash-Webhook/1.0")
+	req.Header.Set("X-SneakerFlash-Event", string(delivery.Event))
+	req.Header.Set("X-SneakerFlash-Event-Id", delivery.EventID)
+	req.Header.Set("X-SneakerFlash-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-SneakerFlash-Timestamp", timestamp)
+	req.Header.Set(webhookSignatureHeader, "sha256="+signWebhook(endpoint.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	return resp.StatusCode, strings.ToValidUTF8(string(body), ""), nil
+}
+
+// signWebhook 签名内容为 "<timestamp>.<请求体>"，接收方用同样方式计算并比对。
+func signWebhook(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff 第 n 次失败后的等待时间：30s、1m、2m……最长 1 小时。
+func webhookBackoff(attempts int) time.Duration {
+	wait := webhookRetryBase
+	for i := 1; i < attempts && wait < webhookRetryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, webhookRetryMax)
+}
+
+func (s *WebhookService) endpoint(ctx context.Context, sellerID, id uint) (*model.WebhookEndpoint, error) {
+	endpoint, err := s.repo.GetEndpoint(ctx, sellerID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (s *WebhookService) validateURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || len(raw) > 512 {
+		return "", ErrWebhookURLInvalid
+	}
+	if !s.rules.allowPrivate {
+		host := u.Hostname()
+		if strings.EqualFold(host, "localhost") {
+			return "", ErrWebhookURLPrivate
+		}
+		if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+			return "", ErrWebhookURLPrivate
+		}
+	}
+	return raw, nil
+}
+
+func normalizeWebhookEvents(events []model.WebhookEvent) (model.WebhookEvents, error) {
+	if len(events) == 0 {
+		return append(model.WebhookEvents(nil), model.WebhookEventList...), nil
+	}
+	normalized := make(model.WebhookEvents, 0, len(events))
+	for _, event := range events {
+		if !model.ValidWebhookEvent(event) {
+			return nil, ErrWebhookEventInvalid
+		}
+		if !normalized.Has(event) {
+			normalized = append(normalized, event)
+		}
+	}
+	return normalized, nil
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// enqueueWebhook 在业务事务内为卖家订阅了该事件的回调地址写入投递记录与 Outbox 消息。
+func enqueueWebhook(ctx context.Context, tx *gorm.DB, sellerID uint, event model.WebhookEvent, data any) error {
+	endpoints, err := repository.NewWebhookRepo(tx).ListEnabledEndpoints(ctx, sellerID)
+	if err != nil {
+		return err
+	}
+	var targets []model.WebhookEndpoint
+	for _, endpoint := range endpoints {
+		if endpoint.Events.Has(event) {
+			targets = append(targets, endpoint)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	eventID, err := utils.GenSnowflakeID()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(WebhookPayload{ID: eventID, Event: event, CreatedAt: time.Now(), Data: data})
+	if err != nil {
+		return err
+	}
+	for _, endpoint := range targets {
+		delivery := &model.WebhookDelivery{
+			EndpointID: endpoint.ID,
+			SellerID:   sellerID,
+			EventID:    eventID,
+			Event:      event,
+			Payload:    string(payload),
+			Status:     model.WebhookDeliveryPending,
+		}
+		if err := createWebhookDelivery(ctx, tx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueueOrderWebhook 商品订单状态变更推送给卖家，VIP 订单忽略。
+func enqueueOrderWebhook(ctx context.Context, tx *gorm.DB, event model.WebhookEvent, order *model.Order, amountCents int64) error {
+	if order.IsVIP() || order.ProductID == 0 {
+		return nil
+	}
+	product, err := repository.NewProductRepo(tx).GetByID(ctx, order.ProductID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return enqueueWebhook(ctx, tx, product.UserID, event, WebhookOrderData{
+		OrderNum:    order.OrderNum,
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		AmountCents: amountCents,
+		PaidAt:      order.PaidAt,
+	})
+}
+
+func createWebhookDelivery(ctx context.Context, tx *gorm.DB, delivery *model.WebhookDelivery) error {
+	if err := repository.NewWebhookRepo(tx).CreateDelivery(ctx, delivery); err != nil {
+		return err
+	}
+	return repository.NewOutboxRepo(tx).Create(ctx, &model.OutboxMessage{
+		Topic:   model.OutboxTopicWebhook,
+		Payload: fmt.Sprintf(`{"delivery_id":%d}`, delivery.ID),
+		Status:  model.OutboxStatusPending,
+	})
+}
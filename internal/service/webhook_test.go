@@ -0,0 +1,145 @@
+package service
+
+import (
+	"SneakerFlash/internal/config"
+	"SneakerFlash/internal/db"
+	"SneakerFlash/internal/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookService_SignedDeliveryRetryAndRedeliver(t *testing.T) {
+	orderSvc, fixtures := newOrderServiceForTest(t)
+	config.Conf.Webhook.AllowPrivate = true
+	config.Conf.Webhook.MaxAttempts = 2
+	svc := NewWebhookService(db.DB)
+	ctx := context.Background()
+
+	seller := fixtures.user.ID
+	if _, err := svc.CreateEndpoint(ctx, seller, WebhookEndpointInput{URL: "http://example.com/hook"}); !errors.Is(err, ErrSellerNotApproved) {
+		t.Fatalf("CreateEndpoint() unapproved error = %v, want ErrSellerNotApproved", err)
+	}
+	if err := db.DB.Create(&model.SellerProfile{UserID: seller, StoreName: "鞋仓", ContactName: "张三", ContactPhone: "13800000000", Status: model.SellerStatusApproved}).Error; err != nil {
+		t.Fatalf("create seller profile: %v", err)
+	}
+
+	var mu sync.Mutex
+	var received []WebhookPayload
+	failing := true
+	var secret string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		want := "sha256=" + signWebhook(secret, r.Header.Get("X-SneakerFlash-Timestamp"), string(body))
+		if r.Header.Get(webhookSignatureHeader) != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var payload WebhookPayload
+		_ = json.Unmarshal(body, &payload)
+		received = append(received, payload)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	if _, err := svc.CreateEndpoint(ctx, seller, WebhookEndpointInput{URL: server.URL, Events: []model.WebhookEvent{"order.shipped"}}); !errors.Is(err, ErrWebhookEventInvalid) {
+		t.Fatalf("CreateEndpoint() invalid event error = %v", err)
+	}
+	endpoint, err := svc.CreateEndpoint(ctx, seller, WebhookEndpointInput{URL: server.URL, Events: []model.WebhookEvent{model.WebhookOrderPaid}})
+	if err != nil {
+		t.Fatalf("CreateEndpoint() error = %v", err)
+	}
+	secret = endpoint.Secret
+
+	if _, err := orderSvc.HandlePaymentResult(ctx, fixtures.payment.PaymentID, model.PaymentStatusPaid, "mock"); err != nil {
+		t.Fatalf("HandlePaymentResult() error = %v", err)
+	}
+	deliveries, total, err := svc.ListDeliveries(ctx, seller, endpoint.ID, "", 1, 20)
+	if err != nil || total != 1 {
+		t.Fatalf("ListDeliveries() total = %d, err = %v", total, err)
+	}
+	first := deliveries[0]
+
+	// 首次失败进入退避，未到期前不会重复投递
+	now := time.Now()
+	if n, err := svc.Dispatch(ctx, now, 10); err != nil || n != 1 {
+		t.Fatalf("Dispatch() = %d, %v", n, err)
+	}
+	if n, _ := svc.Dispatch(ctx, now, 10); n != 0 {
+		t.Fatalf("Dispatch() before retry = %d, want 0", n)
+	}
+	pending, _ := svc.repo.GetDelivery(ctx, 0, first.ID)
+	if pending.Status != model.WebhookDeliveryPending || pending.Attempts != 1 || pending.ResponseStatus != http.StatusInternalServerError || pending.NextRetryAt == nil {
+		t.Fatalf("pending delivery = %+v", pending)
+	}
+
+	// 达到最大次数后进入失败终态
+	if n, _ := svc.Dispatch(ctx, now.Add(time.Hour), 10); n != 1 {
+		t.Fatalf("Dispatch() retry = %d, want 1", n)
+	}
+	failed, _ := svc.repo.GetDelivery(ctx, 0, first.ID)
+	if failed.Status != model.WebhookDeliveryFailed || failed.Attempts != 2 {
+		t.Fatalf("failed delivery = %+v", failed)
+	}
+
+	// 手动重新投递沿用事件 ID
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	redelivery, err := svc.Redeliver(ctx, seller, endpoint.ID, first.ID)
+	if err != nil {
+		t.Fatalf("Redeliver() error = %v", err)
+	}
+	if n, _ := svc.Dispatch(ctx, time.Now(), 10); n != 1 {
+		t.Fatalf("Dispatch() redelivery = %d, want 1", n)
+	}
+	done, _ := svc.repo.GetDelivery(ctx, 0, redelivery.ID)
+	if done.Status != model.WebhookDeliverySucceeded || done.RedeliveryOf != first.ID || done.DeliveredAt == nil {
+		t.Fatalf("redelivery = %+v", done)
+	}
+	if len(received) != 1 || received[0].ID != first.EventID || received[0].Event != model.WebhookOrderPaid {
+		t.Fatalf("received = %+v", received)
+	}
+
+	// 未订阅的事件不产生投递
+	if _, err := orderSvc.HandlePaymentResult(ctx, fixtures.payment.PaymentID, model.PaymentStatusRefunded, "refund"); err != nil {
+		t.Fatalf("HandlePaymentResult(refund) error = %v", err)
+	}
+	if _, total, _ := svc.ListDeliveries(ctx, seller, endpoint.ID, "", 1, 20); total != 2 {
+		t.Fatalf("deliveries after refund = %d, want 2", total)
+	}
+
+	if _, err := svc.Redeliver(ctx, seller+1, endpoint.ID, first.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Fatalf("Redeliver() other seller error = %v", err)
+	}
+}
+
+func TestWebhookService_RejectsPrivateURL(t *testing.T) {
+	_, fixtures := newOrderServiceForTest(t)
+	config.Conf.Webhook.AllowPrivate = false
+	svc := NewWebhookService(db.DB)
+	ctx := context.Background()
+	if err := db.DB.Create(&model.SellerProfile{UserID: fixtures.user.ID, StoreName: "鞋仓", ContactName: "张三", ContactPhone: "13800000000", Status: model.SellerStatusApproved}).Error; err != nil {
+		t.Fatalf("create seller profile: %v", err)
+	}
+	for _, raw := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "https://10.0.0.8/hook", "http://[::1]/hook"} {
+		if _, err := svc.CreateEndpoint(ctx, fixtures.user.ID, WebhookEndpointInput{URL: raw}); !errors.Is(err, ErrWebhookURLPrivate) {
+			t.Fatalf("CreateEndpoint(%s) error = %v, want ErrWebhookURLPrivate", raw, err)
+		}
+	}
+	if _, err := svc.CreateEndpoint(ctx, fixtures.user.ID, WebhookEndpointInput{URL: "ftp://example.com"}); !errors.Is(err, ErrWebhookURLInvalid) {
+		t.Fatalf("CreateEndpoint(ftp) error = %v, want ErrWebhookURLInvalid", err)
+	}
+}
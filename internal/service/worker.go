@@ -24,6 +24,7 @@ type WorkerService struct {
 	productRepo *repository.ProductRepo
 	orderRepo   *repository.OrderRepo
 	paymentRepo *repository.PaymentRepo
+	orderSvc    *OrderService
 }
 
 // NewWorkerService 构建异步消费服务，处理秒杀队列落库。
@@ -33,6 +34,7 @@ func NewWorkerService(db *gorm.DB, productRepo *repository.ProductRepo, order *r
 		productRepo: productRepo,
 		orderRepo:   order,
 		paymentRepo: repository.NewPaymentRepo(db),
+		orderSvc:    NewOrderService(db, productRepo, repository.NewUserRepo(db)),
 	}
 }
 
@@ -92,6 +94,7 @@ func (s *WorkerService) BatchCreateOrdersFromMessages(msgBodies [][]byte) (faile
 	}
 
 	partialRollbacks := make([]rollbackItem, 0)
+	autoCouponOrders := make([]*model.Order, 0)
 
 	// 2. 开启数据库事务
 	txErr := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -144,11 +147,16 @@ func (s *WorkerService) BatchCreateOrdersFromMessages(msgBodies [][]byte) (faile
 		// 2.3 按 productID 分组统计扣库存数量
 		stockDeductions := make(map[uint]int64)
 		for _, it := range newItems {
+			// 候补认领的名额已在取消订单时预留
+			if it.msg.FromWaitlist {
+				continue
+			}
 			stockDeductions[it.msg.ProductID]++
 		}
 
 		// 2.4 批量扣减库存
 		productStocks := make(map[uint]int) // 记录扣减后的库存
+		var soldOut []*model.Product
 		for productID, count := range stockDeductions {
 			rowsAffected, err := txProductRepo.ReduceStockDBBatch(ctx, productID, int(count))
 			if err != nil {
@@ -158,7 +166,7 @@ func (s *WorkerService) BatchCreateOrdersFromMessages(msgBodies [][]byte) (faile
 				// 库存不足：标记该商品所有消息失败，并从待处理集合剔除
 				filtered := make([]*msgItem, 0, len(newItems))
 				for _, it := range newItems {
-					if it.msg.ProductID == productID {
+					if it.msg.ProductID == productID && !it.msg.FromWaitlist {
 						partialRollbacks = append(partialRollbacks, rollbackItem{
 							orderNum:   it.msg.OrderNum,
 							productID:  it.msg.ProductID,
@@ -175,17 +183,31 @@ func (s *WorkerService) BatchCreateOrdersFromMessages(msgBodies [][]byte) (faile
 			}
 			if product, err := txProductRepo.GetByID(ctx, productID); err == nil {
 				productStocks[productID] = product.Stock
+				if product.Stock == 0 {
+					soldOut = append(soldOut, product)
+				}
 			}
 		}
 
+		// 抢购失败通知与订单同事务写入
+		failNotices := make([]Notice, 0, len(partialRollbacks))
+		for _, item := range partialRollbacks {
+			failNotices = append(failNotices, Notice{UserID: item.userID, Kind: model.NotifySeckillFailed, Data: map[string]any{"order_num": item.orderNum, "reason": item.failReason}})
+		}
 		if len(newItems) == 0 {
+			notify(ctx, tx, failNotices...)
 			return nil
 		}
 
 		// 2.5 构建订单列表
 		orders := make([]*model.Order, 0, len(newItems))
 		for _, it := range newItems {
-			orders = append(orders, &model.Order{UserID: it.msg.UserID, ProductID: it.msg.ProductID, OrderNum: it.msg.OrderNum, Status: model.OrderStatusUnpaid})
+			order := &model.Order{UserID: it.msg.UserID, ProductID: it.msg.ProductID, OrderNum: it.msg.OrderNum, Status: model.OrderStatusUnpaid, Type: model.OrderTypeProduct, OriginalPriceCents: it.msg.OriginalPriceCents}
+			if it.msg.MemberLevel > 0 {
+				order.MemberPriceCents = it.msg.PriceCents
+				order.MemberLevel = it.msg.MemberLevel
+			}
+			orders = append(orders, order)
 		}
 
 		// 2.6 批量插入订单
@@ -226,9 +248,26 @@ func (s *WorkerService) BatchCreateOrdersFromMessages(msgBodies [][]byte) (faile
 		// 2.9 收集成功结果（按原始索引写回）
 		for i, it := range newItems {
 			resultsByIdx[it.idx] = orderResult{orderNum: orders[i].OrderNum, orderID: orders[i].ID, paymentID: payments[i].PaymentID, success: true}
+			if it.msg.AutoCoupon {
+				autoCouponOrders = append(autoCouponOrders, orders[i])
+			}
+		}
+
+		// 2.10 抢购结果通知：成功待支付、库存不足失败
+		notices := failNotices
+		for _, order := range orders {
+			notices = append(notices, Notice{UserID: order.UserID, Kind: model.NotifySeckillSuccess, Data: map[string]any{"order_num": order.OrderNum}})
+		}
+		notify(ctx, tx, notices...)
+		for _, product := range soldOut {
+			if err := enqueueWebhook(ctx, tx, product.UserID, model.WebhookProductSoldOut, WebhookSoldOutData{
+				ProductID: product.ID, ProductName: product.Name, SoldOutAt: time.Now(),
+			}); err != nil {
+				return fmt.Errorf("写入售罄 Webhook 失败: %w", err)
+			}
 		}
 
-		// 2.10 异步刷新库存缓存
+		// 2.11 异步刷新库存缓存
 		for productID, stock := range productStocks {
 			refreshStockCacheAsync(productID, stock)
 			invalidateProductInfoCache(productID)
@@ -242,7 +281,10 @@ func (s *WorkerService) BatchCreateOrdersFromMessages(msgBodies [][]byte) (faile
 		slog.ErrorContext(ctx, "批量事务失败", slog.Any("error", txErr))
 		// 事务失败，回滚所有 Redis 库存，返回所有消息索引作为失败
 		for _, it := range items {
-			rollbackRedisStock(ctx, it.msg.ProductID, it.msg.UserID)
+			// 候补认领的名额未占用 Redis 库存，重试时仍归该用户
+			if !it.msg.FromWaitlist {
+				rollbackRedisStock(ctx, it.msg.ProductID, it.msg.UserID)
+			}
 			markPendingOrderFailed(ctx, it.msg.OrderNum, txErr.Error())
 		}
 		all := make([]int, len(msgBodies))
@@ -257,6 +299,13 @@ func (s *WorkerService) BatchCreateOrdersFromMessages(msgBodies [][]byte) (faile
 		markPendingOrderFailed(ctx, item.orderNum, item.failReason)
 	}
 
+	// 自动用券失败不影响下单，用户仍可在支付前手动选券
+	for _, order := range autoCouponOrders {
+		if _, err := s.orderSvc.AutoApplyBestCoupon(ctx, order.UserID, order.ID); err != nil {
+			slog.WarnContext(ctx, "自动使用最优券失败", slog.String("order_num", order.OrderNum), slog.Any("err", err))
+		}
+	}
+
 	// 4. 批量更新 Redis pending 状态（跳过没有 orderNum 的结果）
 	results := make([]orderResult, 0, len(resultsByIdx))
 	for _, r := range resultsByIdx {
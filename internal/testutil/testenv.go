@@ -2,6 +2,7 @@ package testutil
 
 import (
 	"SneakerFlash/internal/config"
+	appdb "SneakerFlash/internal/db"
 	"SneakerFlash/internal/infra/redis"
 	"SneakerFlash/internal/model"
 	"SneakerFlash/internal/pkg/utils"
@@ -53,12 +54,42 @@ func NewSQLiteDB(t *testing.T) *gorm.DB {
 		&model.Coupon{},
 		&model.UserCoupon{},
 		&model.PaidVIP{},
+		&model.PaidVIPGrant{},
 		&model.OutboxMessage{},
 		&model.AuditLog{},
+		&model.CouponIssueJob{},
+		&model.VIPPlan{},
+		&model.VIPLevelConfig{},
+		&model.PointEntry{},
+		&model.PointAllocation{},
+		&model.GrowthLevelChange{},
+		&model.InviteCode{},
+		&model.Referral{},
+		&model.Notification{},
+		&model.NotificationDelivery{},
+		&model.NotificationPreference{},
+		&model.DropSubscription{},
+		&model.DropReminder{},
+		&model.WaitlistEntry{},
+		&model.UnpaidTimeout{},
+		&model.UserPenalty{},
+		&model.SellerProfile{},
+		&model.SettlementStatement{},
+		&model.SettlementItem{},
+		&model.WebhookEndpoint{},
+		&model.WebhookDelivery{},
+		&model.Brand{},
+		&model.Category{},
+		&model.Upload{},
+		&model.PriceSchedule{},
+		&model.PriceHistory{},
 	)
 	if err != nil {
 		t.Fatalf("migrate sqlite: %v", err)
 	}
+	if err := appdb.SeedVIPConfig(db); err != nil {
+		t.Fatalf("seed vip config: %v", err)
+	}
 
 	return db
 }